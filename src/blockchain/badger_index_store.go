@@ -0,0 +1,80 @@
+// Badger-backed IndexStore implementation for AddressIndexer
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// ErrIndexKeyNotFound is returned by BadgerIndexStore.Get when key has no value
+var ErrIndexKeyNotFound = errors.New("index key not found")
+
+// BadgerIndexStore persists AddressIndexer's address->txid index in an embedded
+// Badger database, so the indexer survives process restarts without an external store
+type BadgerIndexStore struct {
+	db *badger.DB
+}
+
+// NewBadgerIndexStore opens (creating if necessary) a Badger database at dir
+func NewBadgerIndexStore(dir string) (*BadgerIndexStore, error) {
+	db, err := badger.Open(badger.DefaultOptions(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open badger index store: %w", err)
+	}
+	return &BadgerIndexStore{db: db}, nil
+}
+
+// Put writes key/value, overwriting any existing entry
+func (s *BadgerIndexStore) Put(key, value []byte) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(key, value)
+	})
+}
+
+// Get returns the value stored under key, or ErrIndexKeyNotFound if absent
+func (s *BadgerIndexStore) Get(key []byte) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return ErrIndexKeyNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			value = append([]byte(nil), val...)
+			return nil
+		})
+	})
+	return value, err
+}
+
+// IteratePrefix calls fn for every key/value pair whose key starts with prefix
+func (s *BadgerIndexStore) IteratePrefix(prefix []byte, fn func(key, value []byte) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			item := it.Item()
+			key := append([]byte(nil), item.Key()...)
+			if err := item.Value(func(val []byte) error {
+				return fn(key, val)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close releases the underlying Badger database
+func (s *BadgerIndexStore) Close() error {
+	return s.db.Close()
+}
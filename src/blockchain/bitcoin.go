@@ -3,10 +3,12 @@
 package blockchain
 
 import (
-	"encoding/hex"
+	"crypto/rsa"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
@@ -16,25 +18,40 @@ import (
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btcd/txscript"
-	"github.com/btcsuite/btcd/wire"
 )
 
 // BitcoinClient implements the BlockchainClient interface for Bitcoin
 type BitcoinClient struct {
-	client      *rpcclient.Client
-	chainParams *chaincfg.Params
-	explorerURL string
-	testMode    bool
+	client       *rpcclient.Client
+	chainParams  *chaincfg.Params
+	explorerURL  string
+	testMode     bool
+	hdWallet     *HDWallet       // optional; when set, GenerateAddress derives from it instead of a one-off key
+	feeEstimator *FeeEstimator   // set by NewBitcoinClient; EstimateFee derives its feerate from it
+	indexer      *AddressIndexer // optional; when set, GetTransactionsByAddress reads from it (GetBalance queries the node's unspent outputs)
+	sentTxCache  *sentTransactionCache
+
+	multiSigMutex       sync.Mutex
+	multiSigWalletsByID map[string]*btcMultiSigWallet
+
+	paymentSigningCert *x509.Certificate // optional; when set with paymentSigningKey, CreateInvoice signs its PaymentRequest
+	paymentSigningKey  *rsa.PrivateKey
 }
 
 // BitcoinConfig holds configuration for Bitcoin client
 type BitcoinConfig struct {
-	RPCHost     string
-	RPCPort     int
-	RPCUser     string
-	RPCPassword string
-	ExplorerURL string
-	TestMode    bool
+	RPCHost        string
+	RPCPort        int
+	RPCUser        string
+	RPCPassword    string
+	ExplorerURL    string
+	TestMode       bool
+	HDWallet       *HDWallet
+	FeeFallbackURL string // mempool.space-style endpoint used when estimatesmartfee can't answer
+	Indexer        *AddressIndexer
+
+	PaymentSigningCert *x509.Certificate // optional; signs CreateInvoice's PaymentRequest under PkiType "x509+sha256"
+	PaymentSigningKey  *rsa.PrivateKey
 }
 
 // NewBitcoinClient creates a new Bitcoin client
@@ -77,12 +94,20 @@ func NewBitcoinClient(config BitcoinConfig) (*BitcoinClient, error) {
 		}
 	}
 
-	return &BitcoinClient{
+	btcClient := &BitcoinClient{
 		client:      client,
 		chainParams: chainParams,
 		explorerURL: explorerURL,
 		testMode:    config.TestMode,
-	}, nil
+		hdWallet:    config.HDWallet,
+		indexer:     config.Indexer,
+		sentTxCache: newSentTransactionCache(defaultTransactionIdempotencyTTL),
+
+		paymentSigningCert: config.PaymentSigningCert,
+		paymentSigningKey:  config.PaymentSigningKey,
+	}
+	btcClient.feeEstimator = NewFeeEstimator(btcClient, config.FeeFallbackURL)
+	return btcClient, nil
 }
 
 // GetName returns the name of the blockchain
@@ -95,20 +120,20 @@ func (c *BitcoinClient) GetCurrency() string {
 	return "BTC"
 }
 
-// GenerateAddress generates a new Bitcoin address
+// GenerateAddress generates a new Bitcoin address. When an HDWallet is configured, it
+// derives the next receive address from it; otherwise it falls back to a one-off key
+// whose WIF is never logged (a caller without an HDWallet is responsible for persisting it).
 func (c *BitcoinClient) GenerateAddress() (string, error) {
+	if c.hdWallet != nil {
+		return c.hdWallet.NextReceiveAddress()
+	}
+
 	// Generate a new private key
 	privateKey, err := btcec.NewPrivateKey()
 	if err != nil {
 		return "", fmt.Errorf("failed to generate private key: %w", err)
 	}
 
-	// Convert private key to WIF format
-	wif, err := btcutil.NewWIF(privateKey, c.chainParams, true)
-	if err != nil {
-		return "", fmt.Errorf("failed to create WIF: %w", err)
-	}
-
 	// Generate public key and address
 	pubKey := privateKey.PubKey()
 	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
@@ -117,31 +142,44 @@ func (c *BitcoinClient) GenerateAddress() (string, error) {
 		return "", fmt.Errorf("failed to create address: %w", err)
 	}
 
-	// Store private key securely (in a real implementation)
-	// For now, we'll just log it
-	log.Printf("Generated new Bitcoin address: %s with private key: %s", addr.EncodeAddress(), wif.String())
+	log.Printf("Generated new Bitcoin address: %s (no HDWallet configured; caller must capture and store the private key securely, it cannot be recovered from this address)", addr.EncodeAddress())
 
 	return addr.EncodeAddress(), nil
 }
 
+// DeriveAddress derives the address at m/44'/coinType'/account'/change/index from the
+// configured HDWallet, for merchants who need deterministic addresses rather than the
+// next sequential receive address
+func (c *BitcoinClient) DeriveAddress(account, change, index uint32) (string, error) {
+	if c.hdWallet == nil {
+		return "", errors.New("bitcoin client has no HDWallet configured")
+	}
+	return c.hdWallet.DeriveAddress(account, change, index)
+}
+
+// ExportXpub returns the account-level extended public key from the configured
+// HDWallet, letting a merchant watch every derived invoice address without private keys
+func (c *BitcoinClient) ExportXpub() (string, error) {
+	if c.hdWallet == nil {
+		return "", errors.New("bitcoin client has no HDWallet configured")
+	}
+	return c.hdWallet.ExportXpub()
+}
+
 // ValidateAddress validates if an address is valid for Bitcoin
 func (c *BitcoinClient) ValidateAddress(address string) bool {
 	_, err := btcutil.DecodeAddress(address, c.chainParams)
 	return err == nil
 }
 
-// GetBalance returns the balance of a Bitcoin address
+// GetBalance returns the balance of a Bitcoin address, summed from its unspent outputs
 func (c *BitcoinClient) GetBalance(address string) (float64, error) {
-	// Validate address
-	if !c.ValidateAddress(address) {
+	addr, err := btcutil.DecodeAddress(address, c.chainParams)
+	if err != nil {
 		return 0, ErrInvalidAddress
 	}
 
-	// In a real implementation, we would use the Bitcoin RPC to get the balance
-	// For now, we'll use a simplified approach
-	unspentOutputs, err := c.client.ListUnspentMinMaxAddresses(0, 9999999, []btcutil.Address{
-		btcutil.Address(nil), // This is a placeholder, we would use the actual address
-	})
+	unspentOutputs, err := c.client.ListUnspentMinMaxAddresses(0, 9999999, []btcutil.Address{addr})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get unspent outputs: %w", err)
 	}
@@ -199,7 +237,7 @@ func (c *BitcoinClient) GetTransaction(txID string) (*Transaction, error) {
 		TxID:          txID,
 		BlockHash:     blockHash,
 		BlockNumber:   blockNumber,
-		From:          "multiple inputs", // Simplified
+		From:          "multiple inputs",  // Simplified
 		To:            "multiple outputs", // Simplified
 		Amount:        amount,
 		Fee:           fee,
@@ -213,121 +251,101 @@ func (c *BitcoinClient) GetTransaction(txID string) (*Transaction, error) {
 	return transaction, nil
 }
 
-// SendTransaction sends a Bitcoin transaction
-func (c *BitcoinClient) SendTransaction(fromAddress, toAddress string, amount float64, privateKeyWIF string) (string, error) {
-	// Validate addresses
+// SendTransaction sends a Bitcoin transaction. Inputs are chosen by SelectCoins and the
+// transaction is built and signed through the PSBT workflow in psbt.go, rather than the
+// single-input-script/fixed-fee approach this used to take. A request carrying an
+// IdempotencyKey already seen by this client returns the original call's result again instead
+// of broadcasting a duplicate.
+func (c *BitcoinClient) SendTransaction(request *SendTransactionRequest) (string, error) {
+	if txID, err, found := c.sentTxCache.get(request.IdempotencyKey); found {
+		return txID, err
+	}
+	txID, err := c.sendTransaction(request)
+	c.sentTxCache.put(request.IdempotencyKey, txID, err)
+	return txID, err
+}
+
+func (c *BitcoinClient) sendTransaction(request *SendTransactionRequest) (string, error) {
+	fromAddress, toAddress, amount, privateKeyWIF := request.FromAddress, request.ToAddress, request.Amount, request.PrivateKey
 	if !c.ValidateAddress(fromAddress) || !c.ValidateAddress(toAddress) {
 		return "", ErrInvalidAddress
 	}
-
-	// Parse private key
-	wif, err := btcutil.DecodeWIF(privateKeyWIF)
-	if err != nil {
+	if _, err := btcutil.DecodeWIF(privateKeyWIF); err != nil {
 		return "", fmt.Errorf("invalid private key: %w", err)
 	}
 
-	// Parse destination address
-	destAddr, err := btcutil.DecodeAddress(toAddress, c.chainParams)
+	fromAddr, err := btcutil.DecodeAddress(fromAddress, c.chainParams)
 	if err != nil {
-		return "", fmt.Errorf("invalid destination address: %w", err)
+		return "", fmt.Errorf("invalid source address: %w", err)
 	}
-
-	// Create destination script
-	destScript, err := txscript.PayToAddrScript(destAddr)
+	fromScript, err := txscript.PayToAddrScript(fromAddr)
 	if err != nil {
-		return "", fmt.Errorf("failed to create output script: %w", err)
+		return "", fmt.Errorf("failed to build source script: %w", err)
 	}
 
-	// Get unspent outputs for the source address
-	// In a real implementation, we would use the Bitcoin RPC
-	// For now, we'll use a simplified approach
-	unspentOutputs, err := c.client.ListUnspentMinMaxAddresses(1, 9999999, []btcutil.Address{
-		btcutil.Address(nil), // This is a placeholder
-	})
+	unspentOutputs, err := c.client.ListUnspentMinMaxAddresses(1, 9999999, []btcutil.Address{fromAddr})
 	if err != nil {
 		return "", fmt.Errorf("failed to get unspent outputs: %w", err)
 	}
 
-	// Create transaction
-	tx := wire.NewMsgTx(wire.TxVersion)
-
-	// Add outputs
-	amountSatoshi := int64(amount * 100000000) // Convert BTC to satoshi
-	tx.AddTxOut(wire.NewTxOut(amountSatoshi, destScript))
-
-	// Add inputs (simplified)
-	// In a real implementation, we would select appropriate inputs
-	var totalInput float64
+	utxos := make([]UTXO, 0, len(unspentOutputs))
 	for _, output := range unspentOutputs {
-		if output.Address == fromAddress {
-			totalInput += output.Amount
-			// Create input
-			hash, _ := chainhash.NewHashFromStr(output.TxID)
-			outpoint := wire.NewOutPoint(hash, output.Vout)
-			tx.AddTxIn(wire.NewTxIn(outpoint, nil, nil))
-
-			if totalInput >= amount+0.0001 { // Amount + fee
-				break
-			}
+		if output.Address != fromAddress {
+			continue
 		}
+		utxos = append(utxos, UTXO{
+			TxID:     output.TxID,
+			Vout:     output.Vout,
+			Amount:   int64(output.Amount * 1e8),
+			PkScript: fromScript,
+			Address:  output.Address,
+		})
 	}
 
-	if totalInput < amount+0.0001 {
+	amountSat := int64(amount * 1e8)
+	selected, changeSat, err := SelectCoins(utxos, amountSat, defaultFeeRateSatPerVByte)
+	if err != nil {
 		return "", ErrInsufficientBalance
 	}
 
-	// Add change output if necessary
-	change := totalInput - amount - 0.0001
-	if change > 0 {
-		// Create change script
-		changeAddr, _ := btcutil.DecodeAddress(fromAddress, c.chainParams)
-		changeScript, _ := txscript.PayToAddrScript(changeAddr)
-		changeSatoshi := int64(change * 100000000)
-		tx.AddTxOut(wire.NewTxOut(changeSatoshi, changeScript))
+	packet, err := BuildPSBT(c.chainParams, selected, toAddress, amountSat, fromAddress, changeSat)
+	if err != nil {
+		return "", err
 	}
 
-	// Sign transaction (simplified)
-	// In a real implementation, we would sign each input properly
-	for i := range tx.TxIn {
-		sigScript, err := txscript.SignatureScript(tx, i, destScript, txscript.SigHashAll, wif.PrivKey, true)
-		if err != nil {
-			return "", fmt.Errorf("failed to sign transaction: %w", err)
-		}
-		tx.TxIn[i].SignatureScript = sigScript
+	keys := make(map[int]string, len(selected))
+	for i := range selected {
+		keys[i] = privateKeyWIF
 	}
-
-	// Serialize and broadcast transaction
-	var buf [1000]byte
-	buf2 := buf[0:0] // Create a slice with 0 length but 1000 capacity
-	tx.Serialize(buf2)
-	txHex := hex.EncodeToString(buf2)
-
-	// Send raw transaction
-	txHash, err := c.client.SendRawTransaction(tx, true)
-	if err != nil {
-		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
+	if err := SignPSBT(packet, keys); err != nil {
+		return "", err
 	}
 
-	return txHash.String(), nil
+	return c.BroadcastPSBT(packet)
 }
 
-// EstimateFee estimates the fee for a Bitcoin transaction
+// EstimateFee estimates the fee for a Bitcoin transaction at the Normal fee strategy,
+// using the node's mempool state (or the configured HTTP fallback) rather than a flat rate
 func (c *BitcoinClient) EstimateFee(fromAddress, toAddress string, amount float64) (float64, error) {
-	// In a real implementation, we would use the Bitcoin RPC to estimate the fee
-	// For now, we'll return a fixed fee
-	return 0.0001, nil
+	feeRate, err := c.feeEstimator.EstimateFeeRate(FeeStrategyNormal, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate fee rate: %w", err)
+	}
+	feeSat := feeRate * estimateVSize(1, 2)
+	return float64(feeSat) / 1e8, nil
 }
 
-// GetTransactionsByAddress returns transactions for a specific address
+// GetTransactionsByAddress returns transactions for a specific address. It requires an
+// AddressIndexer to be configured (see AddressIndexer.WatchAddress), since the Bitcoin
+// RPC interface has no way to look up transactions by address on its own.
 func (c *BitcoinClient) GetTransactionsByAddress(address string, limit int) ([]Transaction, error) {
-	// Validate address
 	if !c.ValidateAddress(address) {
 		return nil, ErrInvalidAddress
 	}
-
-	// In a real implementation, we would use a blockchain explorer API or indexer
-	// For now, we'll return an empty slice
-	return []Transaction{}, nil
+	if c.indexer == nil {
+		return nil, errors.New("bitcoin client has no AddressIndexer configured")
+	}
+	return c.indexer.GetAddressHistory(address, limit, 0)
 }
 
 // GetConfirmations returns the number of confirmations for a transaction
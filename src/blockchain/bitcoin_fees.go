@@ -0,0 +1,356 @@
+// Dynamic fee estimation, RBF, and CPFP support for BitcoinClient
+// Replaces EstimateFee's hardcoded 0.0001 BTC with real node/mempool-derived feerates,
+// and lets a stuck payment be accelerated either by replacing it (BIP125 RBF) or by
+// spending its change output at a higher feerate (CPFP)
+
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// FeeStrategy selects how a transaction's feerate is chosen
+type FeeStrategy string
+
+const (
+	FeeStrategyPriority FeeStrategy = "priority" // target next-block confirmation
+	FeeStrategyNormal   FeeStrategy = "normal"   // target ~6 blocks
+	FeeStrategyEconomy  FeeStrategy = "economy"  // target ~24 blocks
+	FeeStrategyCustom   FeeStrategy = "custom"   // caller-supplied sat/vB
+)
+
+// confirmationTargets maps each non-custom FeeStrategy to an estimatesmartfee block target
+var confirmationTargets = map[FeeStrategy]int{
+	FeeStrategyPriority: 1,
+	FeeStrategyNormal:   6,
+	FeeStrategyEconomy:  24,
+}
+
+// rbfSequence is the highest nSequence value that opts a transaction into BIP125
+// replace-by-fee; any value below 0xfffffffe signals it
+const rbfSequence = 0xfffffffd
+
+// FeeEstimator derives a satoshi/vByte feerate from the Bitcoin node's mempool state,
+// falling back to a mempool.space-style percentile HTTP endpoint when the node is
+// pruned and estimatesmartfee can't see far enough back to answer
+type FeeEstimator struct {
+	client      *BitcoinClient
+	fallbackURL string // e.g. https://mempool.space/api/v1/fees/recommended
+	httpClient  *http.Client
+}
+
+// NewFeeEstimator creates a FeeEstimator against client, using fallbackURL (optional)
+// when the node itself can't produce an estimate
+func NewFeeEstimator(client *BitcoinClient, fallbackURL string) *FeeEstimator {
+	return &FeeEstimator{
+		client:      client,
+		fallbackURL: fallbackURL,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// EstimateFeeRate returns a sat/vByte feerate for strategy, querying the node's
+// estimatesmartfee first and falling back to the HTTP percentile estimator
+func (e *FeeEstimator) EstimateFeeRate(strategy FeeStrategy, customSatPerVByte int64) (int64, error) {
+	if strategy == FeeStrategyCustom {
+		if customSatPerVByte <= 0 {
+			return 0, errors.New("custom fee strategy requires a positive sat/vB rate")
+		}
+		return customSatPerVByte, nil
+	}
+
+	target, ok := confirmationTargets[strategy]
+	if !ok {
+		return 0, fmt.Errorf("unknown fee strategy: %s", strategy)
+	}
+
+	if rate, err := e.estimateSmartFee(target); err == nil {
+		return rate, nil
+	}
+	return e.estimateFromFallback(strategy)
+}
+
+// estimateSmartFee calls the node's estimatesmartfee RPC and converts its BTC/kB result
+// to sat/vByte
+func (e *FeeEstimator) estimateSmartFee(confTarget int) (int64, error) {
+	raw, err := json.Marshal(confTarget)
+	if err != nil {
+		return 0, err
+	}
+	result, err := e.client.client.RawRequest("estimatesmartfee", []json.RawMessage{raw})
+	if err != nil {
+		return 0, fmt.Errorf("estimatesmartfee failed: %w", err)
+	}
+
+	var resp struct {
+		FeeRate float64  `json:"feerate"` // BTC per kB
+		Errors  []string `json:"errors"`
+	}
+	if err := json.Unmarshal(result, &resp); err != nil {
+		return 0, err
+	}
+	if len(resp.Errors) > 0 || resp.FeeRate <= 0 {
+		return 0, fmt.Errorf("node could not estimate a fee for target %d", confTarget)
+	}
+
+	satPerVByte := int64(resp.FeeRate * 1e8 / 1000)
+	if satPerVByte < 1 {
+		satPerVByte = 1
+	}
+	return satPerVByte, nil
+}
+
+// estimateFromFallback queries a mempool.space-style REST endpoint for percentile fee
+// estimates, used when the node is pruned and estimatesmartfee can't answer
+func (e *FeeEstimator) estimateFromFallback(strategy FeeStrategy) (int64, error) {
+	if e.fallbackURL == "" {
+		return 0, errors.New("no fallback fee estimator configured")
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, e.fallbackURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("fallback fee estimator request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	var percentiles struct {
+		FastestFee  int64 `json:"fastestFee"`
+		HalfHourFee int64 `json:"halfHourFee"`
+		HourFee     int64 `json:"hourFee"`
+		EconomyFee  int64 `json:"economyFee"`
+	}
+	if err := json.Unmarshal(body, &percentiles); err != nil {
+		return 0, fmt.Errorf("failed to parse fallback fee response: %w", err)
+	}
+
+	switch strategy {
+	case FeeStrategyPriority:
+		return percentiles.FastestFee, nil
+	case FeeStrategyEconomy:
+		return percentiles.EconomyFee, nil
+	default:
+		return percentiles.HalfHourFee, nil
+	}
+}
+
+// SendTransactionWithFeeStrategy behaves like SendTransaction but derives the feerate
+// from feeEstimator/strategy instead of the package's defaultFeeRateSatPerVByte, and
+// opts every input into BIP125 replace-by-fee so the payment can be bumped later
+func (c *BitcoinClient) SendTransactionWithFeeStrategy(fromAddress, toAddress string, amount float64, privateKeyWIF string, feeEstimator *FeeEstimator, strategy FeeStrategy, customSatPerVByte int64) (string, error) {
+	if !c.ValidateAddress(fromAddress) || !c.ValidateAddress(toAddress) {
+		return "", ErrInvalidAddress
+	}
+	if _, err := btcutil.DecodeWIF(privateKeyWIF); err != nil {
+		return "", fmt.Errorf("invalid private key: %w", err)
+	}
+
+	feeRate, err := feeEstimator.EstimateFeeRate(strategy, customSatPerVByte)
+	if err != nil {
+		return "", fmt.Errorf("failed to estimate fee rate: %w", err)
+	}
+
+	fromAddr, err := btcutil.DecodeAddress(fromAddress, c.chainParams)
+	if err != nil {
+		return "", fmt.Errorf("invalid source address: %w", err)
+	}
+	fromScript, err := txscript.PayToAddrScript(fromAddr)
+	if err != nil {
+		return "", fmt.Errorf("failed to build source script: %w", err)
+	}
+
+	unspentOutputs, err := c.client.ListUnspentMinMaxAddresses(1, 9999999, []btcutil.Address{fromAddr})
+	if err != nil {
+		return "", fmt.Errorf("failed to get unspent outputs: %w", err)
+	}
+
+	utxos := make([]UTXO, 0, len(unspentOutputs))
+	for _, output := range unspentOutputs {
+		if output.Address != fromAddress {
+			continue
+		}
+		utxos = append(utxos, UTXO{
+			TxID:     output.TxID,
+			Vout:     output.Vout,
+			Amount:   int64(output.Amount * 1e8),
+			PkScript: fromScript,
+			Address:  output.Address,
+		})
+	}
+
+	amountSat := int64(amount * 1e8)
+	selected, changeSat, err := SelectCoins(utxos, amountSat, feeRate)
+	if err != nil {
+		return "", ErrInsufficientBalance
+	}
+
+	packet, err := BuildPSBT(c.chainParams, selected, toAddress, amountSat, fromAddress, changeSat)
+	if err != nil {
+		return "", err
+	}
+	for i := range packet.UnsignedTx.TxIn {
+		packet.UnsignedTx.TxIn[i].Sequence = rbfSequence
+	}
+
+	keys := make(map[int]string, len(selected))
+	for i := range selected {
+		keys[i] = privateKeyWIF
+	}
+	if err := SignPSBT(packet, keys); err != nil {
+		return "", err
+	}
+
+	return c.BroadcastPSBT(packet)
+}
+
+// BumpFeeRBF replaces an unconfirmed, RBF-opted-in transaction with a new version of
+// itself, reusing the same inputs and destination but shrinking the change output by
+// newFeerateSatPerVByte times the transaction's estimated vsize, which is paid on top of
+// the original fee
+func (c *BitcoinClient) BumpFeeRBF(txID string, newFeerateSatPerVByte int64, privateKeyWIF, fromAddress string) (string, error) {
+	hash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction ID: %w", err)
+	}
+
+	original, err := c.client.GetRawTransaction(hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch original transaction: %w", err)
+	}
+	originalTx := original.MsgTx()
+
+	var notRBF = true
+	for _, in := range originalTx.TxIn {
+		if in.Sequence <= rbfSequence {
+			notRBF = false
+		}
+	}
+	if notRBF {
+		return "", errors.New("original transaction did not opt into replace-by-fee")
+	}
+
+	if len(originalTx.TxOut) == 0 {
+		return "", errors.New("original transaction has no outputs to replace")
+	}
+
+	fromAddr, err := btcutil.DecodeAddress(fromAddress, c.chainParams)
+	if err != nil {
+		return "", fmt.Errorf("invalid source address: %w", err)
+	}
+	fromScript, err := txscript.PayToAddrScript(fromAddr)
+	if err != nil {
+		return "", fmt.Errorf("failed to build source script: %w", err)
+	}
+
+	replacement := wire.NewMsgTx(2)
+	for _, in := range originalTx.TxIn {
+		replacement.AddTxIn(wire.NewTxIn(&in.PreviousOutPoint, nil, nil))
+	}
+	for _, out := range originalTx.TxOut {
+		replacement.AddTxOut(out)
+	}
+	for i := range replacement.TxIn {
+		replacement.TxIn[i].Sequence = rbfSequence
+	}
+
+	additionalFee := newFeerateSatPerVByte * estimateVSize(len(replacement.TxIn), len(replacement.TxOut))
+	changeIdx := len(replacement.TxOut) - 1
+	if replacement.TxOut[changeIdx].Value <= additionalFee {
+		return "", errors.New("change output too small to absorb the higher fee")
+	}
+	replacement.TxOut[changeIdx].Value -= additionalFee
+
+	wif, err := btcutil.DecodeWIF(privateKeyWIF)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %w", err)
+	}
+	for i := range replacement.TxIn {
+		sigScript, err := txscript.SignatureScript(replacement, i, fromScript, txscript.SigHashAll, wif.PrivKey, true)
+		if err != nil {
+			return "", fmt.Errorf("failed to sign replacement input %d: %w", i, err)
+		}
+		replacement.TxIn[i].SignatureScript = sigScript
+	}
+
+	txHash, err := c.client.SendRawTransaction(replacement, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast replacement transaction: %w", err)
+	}
+	return txHash.String(), nil
+}
+
+// ChildPaysForParent spends parentTxID's change output (its last output, by this
+// wallet's convention) back to the same address at feerateSatPerVByte, so the combined
+// parent+child package clears the mempool's minimum feerate even though the parent alone
+// was stuck too low
+func (c *BitcoinClient) ChildPaysForParent(parentTxID string, feerateSatPerVByte int64, privateKeyWIF, toAddress string) (string, error) {
+	hash, err := chainhash.NewHashFromStr(parentTxID)
+	if err != nil {
+		return "", fmt.Errorf("invalid parent transaction ID: %w", err)
+	}
+
+	parent, err := c.client.GetRawTransaction(hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch parent transaction: %w", err)
+	}
+	parentTx := parent.MsgTx()
+	if len(parentTx.TxOut) == 0 {
+		return "", errors.New("parent transaction has no outputs to spend")
+	}
+
+	changeIdx := uint32(len(parentTx.TxOut) - 1)
+	changeOutput := parentTx.TxOut[changeIdx]
+
+	destAddr, err := btcutil.DecodeAddress(toAddress, c.chainParams)
+	if err != nil {
+		return "", fmt.Errorf("invalid destination address: %w", err)
+	}
+	destScript, err := txscript.PayToAddrScript(destAddr)
+	if err != nil {
+		return "", fmt.Errorf("failed to build destination script: %w", err)
+	}
+
+	child := wire.NewMsgTx(2)
+	child.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, changeIdx), nil, nil))
+
+	fee := feerateSatPerVByte * estimateVSize(1, 1)
+	if changeOutput.Value <= fee {
+		return "", errors.New("parent's change output is too small to cover a CPFP fee at this rate")
+	}
+	child.AddTxOut(wire.NewTxOut(changeOutput.Value-fee, destScript))
+
+	wif, err := btcutil.DecodeWIF(privateKeyWIF)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %w", err)
+	}
+	sigScript, err := txscript.SignatureScript(child, 0, changeOutput.PkScript, txscript.SigHashAll, wif.PrivKey, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign child transaction: %w", err)
+	}
+	child.TxIn[0].SignatureScript = sigScript
+
+	txHash, err := c.client.SendRawTransaction(child, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast child transaction: %w", err)
+	}
+	return txHash.String(), nil
+}
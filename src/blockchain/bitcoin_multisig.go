@@ -0,0 +1,258 @@
+// P2WSH multisig wallets for BitcoinClient
+// CreateMultiSigWallet builds a standard OP_CHECKMULTISIG redeem script from the supplied
+// public keys and derives its native SegWit (P2WSH) address, the same "any m of these n keys"
+// shape BitGo-style custody wallets use. BuildTransaction/SignTransaction/BroadcastMultiSig
+// reuse psbt.go's coin selection and PSBT plumbing, just with a witness script attached
+// instead of SignPSBT's single-key paths.
+
+package blockchain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// btcMultiSigWallet is the registered state behind a P2WSH multisig walletID: the redeem
+// script and ordered public keys needed to build and later finalize a spend from it
+type btcMultiSigWallet struct {
+	m            int
+	pubKeys      []*btcec.PublicKey
+	redeemScript []byte
+	address      string
+}
+
+// btcMultiSigTx is the Bitcoin-specific payload of an UnsignedTx built against a P2WSH
+// multisig wallet
+type btcMultiSigTx struct {
+	wallet *btcMultiSigWallet
+	packet *psbt.Packet
+}
+
+// multiSigWallets lazily initializes and returns c's wallet registry, since BitcoinConfig
+// has no constructor-time knowledge of multisig wallets the caller hasn't created yet
+func (c *BitcoinClient) multiSigWallets() map[string]*btcMultiSigWallet {
+	c.multiSigMutex.Lock()
+	defer c.multiSigMutex.Unlock()
+	if c.multiSigWalletsByID == nil {
+		c.multiSigWalletsByID = make(map[string]*btcMultiSigWallet)
+	}
+	return c.multiSigWalletsByID
+}
+
+// multiSigRedeemScript builds a standard m-of-n OP_CHECKMULTISIG script paying to pubKeys, in
+// the order supplied. Order matters: the same order must be used to match each
+// PartialSignature back to its signer when finalizing the spend.
+func multiSigRedeemScript(pubKeys []*btcec.PublicKey, m int) ([]byte, error) {
+	if m < 1 || m > len(pubKeys) {
+		return nil, ErrInvalidMultiSigThreshold
+	}
+	builder := txscript.NewScriptBuilder()
+	builder.AddOp(txscript.OP_1 - 1 + byte(m))
+	for _, pubKey := range pubKeys {
+		builder.AddData(pubKey.SerializeCompressed())
+	}
+	builder.AddOp(txscript.OP_1 - 1 + byte(len(pubKeys)))
+	builder.AddOp(txscript.OP_CHECKMULTISIG)
+	return builder.Script()
+}
+
+// CreateMultiSigWallet registers an m-of-n P2WSH wallet from pubKeys (hex-encoded compressed
+// secp256k1 public keys) and returns its walletID and native SegWit receiving address
+func (c *BitcoinClient) CreateMultiSigWallet(m, n int, pubKeys []string) (string, string, error) {
+	if len(pubKeys) != n {
+		return "", "", fmt.Errorf("expected %d public keys, got %d", n, len(pubKeys))
+	}
+
+	parsed := make([]*btcec.PublicKey, 0, len(pubKeys))
+	for _, pubKeyHex := range pubKeys {
+		raw, err := hex.DecodeString(pubKeyHex)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid public key %q: %w", pubKeyHex, err)
+		}
+		pubKey, err := btcec.ParsePubKey(raw)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid public key %q: %w", pubKeyHex, err)
+		}
+		parsed = append(parsed, pubKey)
+	}
+
+	redeemScript, err := multiSigRedeemScript(parsed, m)
+	if err != nil {
+		return "", "", err
+	}
+
+	witnessProgram := sha256.Sum256(redeemScript)
+	addr, err := btcutil.NewAddressWitnessScriptHash(witnessProgram[:], c.chainParams)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to derive multisig address: %w", err)
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", fmt.Errorf("failed to generate wallet id: %w", err)
+	}
+	walletID := hex.EncodeToString(idBytes)
+
+	c.multiSigWallets()[walletID] = &btcMultiSigWallet{
+		m:            m,
+		pubKeys:      parsed,
+		redeemScript: redeemScript,
+		address:      addr.EncodeAddress(),
+	}
+	return walletID, addr.EncodeAddress(), nil
+}
+
+// BuildTransaction selects UTXOs held at walletID's address and builds an unsigned PSBT
+// spending amount to toAddress, with each input's witness script set so SignTransaction and
+// BroadcastMultiSig can sign and finalize it as a multisig spend
+func (c *BitcoinClient) BuildTransaction(walletID, toAddress string, amount float64) (*UnsignedTx, error) {
+	wallet, ok := c.multiSigWallets()[walletID]
+	if !ok {
+		return nil, ErrMultiSigWalletNotFound
+	}
+
+	fromAddr, err := btcutil.DecodeAddress(wallet.address, c.chainParams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multisig address: %w", err)
+	}
+	fromScript, err := txscript.PayToAddrScript(fromAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build multisig source script: %w", err)
+	}
+	unspentOutputs, err := c.client.ListUnspentMinMaxAddresses(1, 9999999, []btcutil.Address{fromAddr})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unspent outputs: %w", err)
+	}
+
+	utxos := make([]UTXO, 0, len(unspentOutputs))
+	for _, output := range unspentOutputs {
+		if output.Address != wallet.address {
+			continue
+		}
+		utxos = append(utxos, UTXO{
+			TxID:     output.TxID,
+			Vout:     output.Vout,
+			Amount:   int64(output.Amount * 1e8),
+			PkScript: fromScript,
+			Address:  output.Address,
+		})
+	}
+
+	amountSat := int64(amount * 1e8)
+	selected, changeSat, err := SelectCoins(utxos, amountSat, defaultFeeRateSatPerVByte)
+	if err != nil {
+		return nil, ErrInsufficientBalance
+	}
+
+	packet, err := BuildPSBT(c.chainParams, selected, toAddress, amountSat, wallet.address, changeSat)
+	if err != nil {
+		return nil, err
+	}
+	for i := range packet.Inputs {
+		packet.Inputs[i].WitnessScript = wallet.redeemScript
+	}
+
+	return &UnsignedTx{WalletID: walletID, ToAddress: toAddress, Amount: amount, BTC: &btcMultiSigTx{wallet: wallet, packet: packet}}, nil
+}
+
+// SignTransaction signs every input of unsigned.BTC.packet with privateKey, returning one
+// DER signature per input alongside the signer's public key so BroadcastMultiSig can match
+// it to its position in the wallet's redeem script
+func (c *BitcoinClient) SignTransaction(unsigned *UnsignedTx, privateKeyWIF string) (*PartialSignature, error) {
+	if unsigned.BTC == nil {
+		return nil, fmt.Errorf("unsigned transaction has no Bitcoin multisig payload")
+	}
+	wif, err := btcutil.DecodeWIF(privateKeyWIF)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	packet := unsigned.BTC.packet
+	tx := packet.UnsignedTx
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, in := range packet.Inputs {
+		if in.WitnessUtxo != nil {
+			fetcher.AddPrevOut(tx.TxIn[i].PreviousOutPoint, in.WitnessUtxo)
+		}
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+
+	signatures := make([][]byte, len(tx.TxIn))
+	for i, in := range packet.Inputs {
+		sig, err := txscript.RawTxInWitnessSignature(tx, sigHashes, i, in.WitnessUtxo.Value, unsigned.BTC.wallet.redeemScript, txscript.SigHashAll, wif.PrivKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign input %d: %w", i, err)
+		}
+		signatures[i] = sig
+	}
+
+	return &PartialSignature{SignerPubKey: hex.EncodeToString(wif.PrivKey.PubKey().SerializeCompressed()), Signatures: signatures}, nil
+}
+
+// BroadcastMultiSig combines sigs onto unsigned.BTC.packet's witness scripts and broadcasts
+// the resulting transaction. sigs must cover at least the wallet's m threshold with
+// signatures from distinct registered public keys.
+func (c *BitcoinClient) BroadcastMultiSig(unsigned *UnsignedTx, sigs []PartialSignature) (string, error) {
+	if unsigned.BTC == nil {
+		return "", fmt.Errorf("unsigned transaction has no Bitcoin multisig payload")
+	}
+	wallet := unsigned.BTC.wallet
+	packet := unsigned.BTC.packet
+
+	if len(sigs) < wallet.m {
+		return "", ErrInsufficientSignatures
+	}
+
+	pubKeyOrder := make(map[string]int, len(wallet.pubKeys))
+	for i, pubKey := range wallet.pubKeys {
+		pubKeyOrder[hex.EncodeToString(pubKey.SerializeCompressed())] = i
+	}
+
+	for inputIndex := range packet.Inputs {
+		// OP_CHECKMULTISIG requires signatures in the same order as their pubkeys appear
+		// in the redeem script
+		type ordered struct {
+			order int
+			sig   []byte
+		}
+		var orderedSigs []ordered
+		seen := make(map[string]bool, len(sigs))
+		for _, sig := range sigs {
+			order, known := pubKeyOrder[sig.SignerPubKey]
+			if !known || seen[sig.SignerPubKey] || inputIndex >= len(sig.Signatures) {
+				continue
+			}
+			seen[sig.SignerPubKey] = true
+			orderedSigs = append(orderedSigs, ordered{order: order, sig: sig.Signatures[inputIndex]})
+		}
+		if len(orderedSigs) < wallet.m {
+			return "", ErrInsufficientSignatures
+		}
+		for i := range orderedSigs {
+			for j := i + 1; j < len(orderedSigs); j++ {
+				if orderedSigs[j].order < orderedSigs[i].order {
+					orderedSigs[i], orderedSigs[j] = orderedSigs[j], orderedSigs[i]
+				}
+			}
+		}
+
+		// OP_CHECKMULTISIG consumes one extra stack item due to a historical bug; a null
+		// dummy (empty push) is the standard way to satisfy that off-by-one
+		witness := wire.TxWitness{nil}
+		for _, s := range orderedSigs[:wallet.m] {
+			witness = append(witness, s.sig)
+		}
+		witness = append(witness, wallet.redeemScript)
+		packet.Inputs[inputIndex].FinalScriptWitness = serializeWitness(witness)
+	}
+
+	return c.BroadcastPSBT(packet)
+}
@@ -0,0 +1,310 @@
+// BIP21 URI and BIP70-style payment request support for merchant invoicing
+//
+// This repo doesn't vendor a protobuf toolchain anywhere (erc20.go hand-encodes ABI calls
+// rather than pulling in abigen, for the same reason), so PaymentRequest/Payment/PaymentACK
+// below are JSON-encoded rather than the BIP70 spec's binary protobuf wire format. They
+// carry the same fields and are X.509-signed the same way, but a wallet expecting literal BIP70
+// protobuf bytes on the wire will need a translation shim in front of this.
+
+package blockchain
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BIP21Payment is the parsed form of a "bitcoin:<address>?amount=&label=&message=" URI
+type BIP21Payment struct {
+	Address string
+	Amount  float64 // BTC; 0 if unset
+	Label   string
+	Message string
+}
+
+// EncodeBIP21URI builds a BIP21 "bitcoin:" URI for address. amountBTC, label, and message
+// are omitted from the query string when zero/empty.
+func EncodeBIP21URI(address string, amountBTC float64, label, message string) string {
+	values := url.Values{}
+	if amountBTC > 0 {
+		values.Set("amount", strconv.FormatFloat(amountBTC, 'f', -1, 64))
+	}
+	if label != "" {
+		values.Set("label", label)
+	}
+	if message != "" {
+		values.Set("message", message)
+	}
+
+	uri := "bitcoin:" + address
+	if encoded := values.Encode(); encoded != "" {
+		uri += "?" + encoded
+	}
+	return uri
+}
+
+// DecodeBIP21URI parses a "bitcoin:" URI into its address and optional query parameters
+func DecodeBIP21URI(uri string) (*BIP21Payment, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BIP21 URI: %w", err)
+	}
+	if parsed.Scheme != "bitcoin" {
+		return nil, errors.New("not a bitcoin: URI")
+	}
+
+	payment := &BIP21Payment{Address: parsed.Opaque}
+	query := parsed.Query()
+	if amountStr := query.Get("amount"); amountStr != "" {
+		amount, err := strconv.ParseFloat(amountStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount in BIP21 URI: %w", err)
+		}
+		payment.Amount = amount
+	}
+	payment.Label = query.Get("label")
+	payment.Message = query.Get("message")
+	return payment, nil
+}
+
+// PaymentOutput is one destination/amount pair a PaymentDetails asks the payer to pay
+type PaymentOutput struct {
+	AmountSat int64  `json:"amount_sat"`
+	Script    string `json:"script"` // hex-encoded pkScript
+}
+
+// PaymentDetails describes what a merchant is requesting payment for, mirroring BIP70's
+// PaymentDetails message
+type PaymentDetails struct {
+	Network      string          `json:"network"` // "main" or "test"
+	Outputs      []PaymentOutput `json:"outputs"`
+	Time         int64           `json:"time"`
+	Expires      int64           `json:"expires"`
+	Memo         string          `json:"memo,omitempty"`
+	PaymentURL   string          `json:"payment_url,omitempty"`
+	MerchantData []byte          `json:"merchant_data,omitempty"`
+}
+
+// PaymentRequestMessage wraps a serialized PaymentDetails with an optional X.509 signature,
+// mirroring BIP70's PaymentRequest message
+type PaymentRequestMessage struct {
+	PaymentDetailsVersion    int32  `json:"payment_details_version"`
+	PkiType                  string `json:"pki_type"` // "none" or "x509+sha256"
+	PkiData                  []byte `json:"pki_data,omitempty"`
+	SerializedPaymentDetails []byte `json:"serialized_payment_details"`
+	Signature                []byte `json:"signature,omitempty"`
+}
+
+// PaymentMessage is what a customer's wallet sends back after broadcasting the invoice's
+// transaction(s), mirroring BIP70's Payment message
+type PaymentMessage struct {
+	MerchantData []byte          `json:"merchant_data,omitempty"`
+	Transactions []string        `json:"transactions"` // hex-encoded raw transactions
+	RefundTo     []PaymentOutput `json:"refund_to,omitempty"`
+	Memo         string          `json:"memo,omitempty"`
+}
+
+// PaymentACKMessage acknowledges a received Payment, mirroring BIP70's PaymentACK message
+type PaymentACKMessage struct {
+	Payment []byte `json:"payment"`
+	Memo    string `json:"memo,omitempty"`
+}
+
+// Invoice is a merchant-facing payment request: a BIP21 URI for QR codes/wallet deep
+// links, plus a signed BIP70-style PaymentRequest blob for wallets that support it
+type Invoice struct {
+	ID             string
+	Address        string
+	AmountBTC      float64
+	Memo           string
+	CreatedAt      time.Time
+	ExpiresAt      time.Time
+	URI            string // BIP21 URI, suitable for rendering as a QR code
+	PaymentRequest []byte // signed PaymentRequestMessage, JSON-encoded
+	CallbackURL    string
+}
+
+// CreateInvoice generates a fresh receive address and builds an Invoice for amountBTC,
+// valid for expiry from now. callbackURL, if set, is advertised as the PaymentDetails
+// payment_url a wallet should POST its Payment message to (see HandlePayment).
+func (c *BitcoinClient) CreateInvoice(amountBTC float64, memo string, expiry time.Duration, callbackURL string) (*Invoice, error) {
+	address, err := c.GenerateAddress()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invoice address: %w", err)
+	}
+
+	addr, err := btcutil.DecodeAddress(address, c.chainParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode invoice address: %w", err)
+	}
+	script, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build invoice output script: %w", err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate invoice id: %w", err)
+	}
+
+	now := time.Now()
+	network := "main"
+	if c.testMode {
+		network = "test"
+	}
+
+	details := PaymentDetails{
+		Network: network,
+		Outputs: []PaymentOutput{{
+			AmountSat: int64(amountBTC * 1e8),
+			Script:    hex.EncodeToString(script),
+		}},
+		Time:         now.Unix(),
+		Expires:      now.Add(expiry).Unix(),
+		Memo:         memo,
+		PaymentURL:   callbackURL,
+		MerchantData: id,
+	}
+
+	blob, err := c.signPaymentRequest(details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign payment request: %w", err)
+	}
+
+	return &Invoice{
+		ID:             hex.EncodeToString(id),
+		Address:        address,
+		AmountBTC:      amountBTC,
+		Memo:           memo,
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(expiry),
+		URI:            EncodeBIP21URI(address, amountBTC, "", memo),
+		PaymentRequest: blob,
+		CallbackURL:    callbackURL,
+	}, nil
+}
+
+// signPaymentRequest serializes details and, when the client has a signing certificate
+// and key configured, signs the serialized bytes with RSA-SHA256 under PkiType
+// "x509+sha256"; otherwise it returns an unsigned ("none") PaymentRequestMessage.
+func (c *BitcoinClient) signPaymentRequest(details PaymentDetails) ([]byte, error) {
+	serializedDetails, err := json.Marshal(details)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize payment details: %w", err)
+	}
+
+	request := PaymentRequestMessage{
+		PaymentDetailsVersion:    1,
+		PkiType:                  "none",
+		SerializedPaymentDetails: serializedDetails,
+	}
+
+	if c.paymentSigningCert != nil && c.paymentSigningKey != nil {
+		hash := sha256.Sum256(serializedDetails)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, c.paymentSigningKey, crypto.SHA256, hash[:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign payment details: %w", err)
+		}
+		request.PkiType = "x509+sha256"
+		request.PkiData = c.paymentSigningCert.Raw
+		request.Signature = signature
+	}
+
+	return json.Marshal(request)
+}
+
+// VerifyPaymentRequest parses a PaymentRequestMessage blob, verifies its X.509 signature
+// (when present), and returns the PaymentDetails it carries. It does not check the
+// signing certificate against a trust store; callers that need that should validate the
+// parsed certificate's chain themselves before trusting the result.
+func VerifyPaymentRequest(blob []byte) (*PaymentDetails, error) {
+	var request PaymentRequestMessage
+	if err := json.Unmarshal(blob, &request); err != nil {
+		return nil, fmt.Errorf("failed to parse payment request: %w", err)
+	}
+
+	switch request.PkiType {
+	case "none":
+		// unsigned; caller accepts on trust of the transport (e.g. TLS to a known merchant)
+	case "x509+sha256":
+		cert, err := x509.ParseCertificate(request.PkiData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signing certificate: %w", err)
+		}
+		pubKey, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("signing certificate does not use an RSA public key")
+		}
+		hash := sha256.Sum256(request.SerializedPaymentDetails)
+		if err := rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, hash[:], request.Signature); err != nil {
+			return nil, fmt.Errorf("payment request signature verification failed: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported pki_type %q", request.PkiType)
+	}
+
+	var details PaymentDetails
+	if err := json.Unmarshal(request.SerializedPaymentDetails, &details); err != nil {
+		return nil, fmt.Errorf("failed to parse payment details: %w", err)
+	}
+	if time.Now().Unix() > details.Expires {
+		return &details, errors.New("payment request has expired")
+	}
+	return &details, nil
+}
+
+// HandlePayment processes a customer's PaymentMessage against an invoice: it broadcasts
+// every enclosed raw transaction via the existing RPC path and returns a PaymentACK. The
+// caller (an HTTP handler accepting application/bitcoin-payment POSTs, for example) is
+// responsible for wiring this to the invoice's configured callback URL.
+func (c *BitcoinClient) HandlePayment(paymentBlob []byte) (*PaymentACKMessage, error) {
+	var payment PaymentMessage
+	if err := json.Unmarshal(paymentBlob, &payment); err != nil {
+		return nil, fmt.Errorf("failed to parse payment message: %w", err)
+	}
+	if len(payment.Transactions) == 0 {
+		return nil, errors.New("payment message contains no transactions")
+	}
+
+	for _, rawTxHex := range payment.Transactions {
+		if _, err := c.broadcastRawTxHex(rawTxHex); err != nil {
+			return nil, fmt.Errorf("failed to broadcast payment transaction: %w", err)
+		}
+	}
+
+	return &PaymentACKMessage{
+		Payment: paymentBlob,
+		Memo:    "Payment received, thank you.",
+	}, nil
+}
+
+// broadcastRawTxHex deserializes a hex-encoded raw transaction and broadcasts it
+func (c *BitcoinClient) broadcastRawTxHex(rawTxHex string) (string, error) {
+	txBytes, err := hex.DecodeString(rawTxHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction hex: %w", err)
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return "", fmt.Errorf("failed to deserialize transaction: %w", err)
+	}
+	txHash, err := c.client.SendRawTransaction(tx, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	return txHash.String(), nil
+}
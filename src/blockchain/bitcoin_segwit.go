@@ -0,0 +1,104 @@
+// SegWit (bech32) and Taproot address support for BitcoinClient
+// GenerateAddress only ever produced legacy P2PKH addresses. GenerateAddressOfType adds
+// nested (P2SH-P2WPKH) and native SegWit (P2WPKH), plus Taproot (P2TR) key-path-only
+// addresses, and signPSBTInput (in psbt.go) gains the matching witness construction for
+// each so SendTransaction can actually spend what this generates.
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// AddressType identifies the script a GenerateAddressOfType-derived address pays to
+type AddressType string
+
+const (
+	AddressTypeP2PKH      AddressType = "P2PKH"       // legacy pay-to-pubkey-hash
+	AddressTypeP2SHP2WPKH AddressType = "P2SH-P2WPKH" // nested SegWit, wrapped in P2SH for wallets that don't understand bech32
+	AddressTypeP2WPKH     AddressType = "P2WPKH"      // native SegWit v0, bech32 "bc1q..."
+	AddressTypeP2TR       AddressType = "P2TR"        // Taproot, key-path spend only, bech32m "bc1p..."
+)
+
+// ErrUnsupportedAddressType is returned for an AddressType GenerateAddressOfType doesn't
+// know how to derive
+var ErrUnsupportedAddressType = errors.New("unsupported address type")
+
+// GenerateAddressOfType generates a new one-off key and derives an address of the
+// requested type from it. As with GenerateAddress, the private key is never logged; a
+// caller that isn't using an HDWallet is responsible for capturing and storing it.
+func (c *BitcoinClient) GenerateAddressOfType(addressType AddressType) (string, error) {
+	privateKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate private key: %w", err)
+	}
+
+	addr, err := addressForType(privateKey, addressType, c.chainParams)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("Generated new Bitcoin %s address: %s (no HDWallet configured; caller must capture and store the private key securely, it cannot be recovered from this address)", addressType, addr)
+	return addr, nil
+}
+
+// addressForType derives the address of addressType that privateKey controls
+func addressForType(privateKey *btcec.PrivateKey, addressType AddressType, chainParams *chaincfg.Params) (string, error) {
+	pubKey := privateKey.PubKey()
+
+	switch addressType {
+	case AddressTypeP2PKH:
+		pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+		addr, err := btcutil.NewAddressPubKeyHash(pubKeyHash, chainParams)
+		if err != nil {
+			return "", fmt.Errorf("failed to create P2PKH address: %w", err)
+		}
+		return addr.EncodeAddress(), nil
+
+	case AddressTypeP2WPKH:
+		pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+		addr, err := btcutil.NewAddressWitnessPubKeyHash(pubKeyHash, chainParams)
+		if err != nil {
+			return "", fmt.Errorf("failed to create P2WPKH address: %w", err)
+		}
+		return addr.EncodeAddress(), nil
+
+	case AddressTypeP2SHP2WPKH:
+		witnessProgram, err := witnessPubKeyHashProgram(pubKey)
+		if err != nil {
+			return "", err
+		}
+		scriptHash := btcutil.Hash160(witnessProgram)
+		addr, err := btcutil.NewAddressScriptHashFromHash(scriptHash, chainParams)
+		if err != nil {
+			return "", fmt.Errorf("failed to create P2SH-P2WPKH address: %w", err)
+		}
+		return addr.EncodeAddress(), nil
+
+	case AddressTypeP2TR:
+		tweakedKey := txscript.ComputeTaprootKeyNoScript(pubKey)
+		addr, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(tweakedKey), chainParams)
+		if err != nil {
+			return "", fmt.Errorf("failed to create P2TR address: %w", err)
+		}
+		return addr.EncodeAddress(), nil
+
+	default:
+		return "", ErrUnsupportedAddressType
+	}
+}
+
+// witnessPubKeyHashProgram builds the OP_0 <pubKeyHash> witness program a P2SH-P2WPKH
+// address's redeem script must be, and a P2WPKH witness's script code must match
+func witnessPubKeyHashProgram(pubKey *btcec.PublicKey) ([]byte, error) {
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(pubKeyHash).Script()
+}
@@ -46,6 +46,18 @@ type Transaction struct {
 	RawTransaction  string           `json:"raw_transaction,omitempty"`
 }
 
+// SendTransactionRequest carries the parameters for a BlockchainClient.SendTransaction call
+type SendTransactionRequest struct {
+	FromAddress string
+	ToAddress   string
+	Amount      float64
+	PrivateKey  string
+	// IdempotencyKey, when set, lets a caller retry a SendTransaction call (e.g. after a
+	// timed-out client that may or may not have actually broadcast) and get back the same
+	// transaction ID rather than risk a duplicate broadcast
+	IdempotencyKey string
+}
+
 // BlockchainClient defines the interface for blockchain interactions
 type BlockchainClient interface {
 	// GetName returns the name of the blockchain
@@ -66,8 +78,10 @@ type BlockchainClient interface {
 	// GetTransaction returns transaction details by transaction ID
 	GetTransaction(txID string) (*Transaction, error)
 	
-	// SendTransaction sends a transaction from one address to another
-	SendTransaction(fromAddress, toAddress string, amount float64, privateKey string) (string, error)
+	// SendTransaction sends a transaction from one address to another. A request carrying an
+	// IdempotencyKey already seen by this client returns the original call's result again
+	// instead of broadcasting a duplicate.
+	SendTransaction(request *SendTransactionRequest) (string, error)
 	
 	// EstimateFee estimates the fee for a transaction
 	EstimateFee(fromAddress, toAddress string, amount float64) (float64, error)
@@ -84,14 +98,37 @@ type BlockchainClient interface {
 
 // BlockchainClientFactory creates blockchain clients for different cryptocurrencies
 type BlockchainClientFactory struct {
-	clients map[string]BlockchainClient
+	clients  map[string]BlockchainClient
+	language Language
+}
+
+// FactoryOption configures NewBlockchainClientFactory
+type FactoryOption func(*BlockchainClientFactory)
+
+// WithLocalization sets the language LocalizeError translates a client's sentinel errors
+// into. The Language constants cover "en", "zh-CN", "zh-TW", "ja", "ko", "th", "vi", "id"
+// and "ms", but the sentinel errors carry translations only for "en", "zh-CN" and "th";
+// any other value, including the remaining constants, yields the English message.
+func WithLocalization(lang string) FactoryOption {
+	return func(f *BlockchainClientFactory) { f.language = Language(lang) }
 }
 
 // NewBlockchainClientFactory creates a new blockchain client factory
-func NewBlockchainClientFactory() *BlockchainClientFactory {
-	return &BlockchainClientFactory{
-		clients: make(map[string]BlockchainClient),
+func NewBlockchainClientFactory(opts ...FactoryOption) *BlockchainClientFactory {
+	factory := &BlockchainClientFactory{
+		clients:  make(map[string]BlockchainClient),
+		language: LanguageEnglish,
+	}
+	for _, opt := range opts {
+		opt(factory)
 	}
+	return factory
+}
+
+// LocalizeError wraps err in a LocalizedError translated into the factory's configured
+// language, if err is one of this package's sentinel errors
+func (f *BlockchainClientFactory) LocalizeError(err error) error {
+	return Localize(err, f.language)
 }
 
 // RegisterClient registers a blockchain client for a specific cryptocurrency
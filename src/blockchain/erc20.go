@@ -0,0 +1,273 @@
+// ERC-20 token support for the Ethereum blockchain client
+// Provides token registry, balance/transfer operations, and Transfer log decoding
+
+package blockchain
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Function/event selectors for the ERC-20 methods and the Transfer event we care about
+const (
+	erc20TransferMethodID  = "a9059cbb"                                                         // transfer(address,uint256)
+	erc20BalanceOfMethodID = "70a08231"                                                         // balanceOf(address)
+	erc20TransferEventSig  = "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef" // Transfer(address,address,uint256)
+)
+
+// ErrTokenNotRegistered is returned when an operation references an unregistered token symbol
+var ErrTokenNotRegistered = errors.New("token not registered")
+
+// TokenConfig describes an ERC-20 token supported by the Ethereum client
+type TokenConfig struct {
+	Symbol              string
+	ContractAddress     string
+	Decimals            int
+	ConfirmationsNeeded uint64 // required confirmations before a token payment is considered settled
+}
+
+// RegisterToken adds an ERC-20 token to the client's token registry
+func (c *EthereumClient) RegisterToken(token TokenConfig) {
+	if c.tokens == nil {
+		c.tokens = make(map[string]TokenConfig)
+	}
+	c.tokens[strings.ToUpper(token.Symbol)] = token
+}
+
+// GetToken returns the registered configuration for a token symbol
+func (c *EthereumClient) GetToken(symbol string) (TokenConfig, error) {
+	token, exists := c.tokens[strings.ToUpper(symbol)]
+	if !exists {
+		return TokenConfig{}, ErrTokenNotRegistered
+	}
+	return token, nil
+}
+
+// GetTokenBalance returns the balance of an address for a registered ERC-20 token
+func (c *EthereumClient) GetTokenBalance(address, symbol string) (float64, error) {
+	if !c.ValidateAddress(address) {
+		return 0, ErrInvalidAddress
+	}
+
+	token, err := c.GetToken(symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := encodeBalanceOfCall(address)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode balanceOf call: %w", err)
+	}
+
+	contract := common.HexToAddress(token.ContractAddress)
+	result, err := c.client.CallContract(context.Background(), ethereum.CallMsg{
+		To:   &contract,
+		Data: data,
+	}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to call balanceOf: %w", err)
+	}
+
+	balance := new(big.Int).SetBytes(result)
+	return tokenAmountToFloat(balance, token.Decimals), nil
+}
+
+// SendToken sends an ERC-20 token transfer from one address to another
+func (c *EthereumClient) SendToken(fromAddress, toAddress, symbol string, amount float64, privateKeyHex string) (string, error) {
+	if !c.ValidateAddress(fromAddress) || !c.ValidateAddress(toAddress) {
+		return "", ErrInvalidAddress
+	}
+
+	token, err := c.GetToken(symbol)
+	if err != nil {
+		return "", err
+	}
+
+	// Parse private key
+	if !strings.HasPrefix(privateKeyHex, "0x") {
+		privateKeyHex = "0x" + privateKeyHex
+	}
+	privateKeyBytes, err := hexutil.Decode(privateKeyHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %w", err)
+	}
+	privateKey, err := crypto.ToECDSA(privateKeyBytes)
+	if err != nil {
+		return "", fmt.Errorf("invalid private key: %w", err)
+	}
+
+	publicKey, ok := privateKey.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return "", errors.New("failed to cast public key to ECDSA")
+	}
+	from := crypto.PubkeyToAddress(*publicKey)
+	if from.Hex() != fromAddress {
+		return "", errors.New("private key does not match from address")
+	}
+
+	amountInt := floatToTokenAmount(amount, token.Decimals)
+	data, err := encodeTransferCall(toAddress, amountInt)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode transfer call: %w", err)
+	}
+
+	contract := common.HexToAddress(token.ContractAddress)
+
+	nonce, err := c.client.PendingNonceAt(context.Background(), from)
+	if err != nil {
+		return "", fmt.Errorf("failed to get nonce: %w", err)
+	}
+
+	gasPrice, err := c.client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to get gas price: %w", err)
+	}
+
+	callMsg := ethereum.CallMsg{
+		From: from,
+		To:   &contract,
+		Data: data,
+	}
+	gasLimit, err := c.client.EstimateGas(context.Background(), callMsg)
+	if err != nil {
+		return "", fmt.Errorf("failed to estimate gas: %w", err)
+	}
+
+	tx := types.NewTransaction(nonce, contract, big.NewInt(0), gasLimit, gasPrice, data)
+
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(c.chainID), privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	if err := c.client.SendTransaction(context.Background(), signedTx); err != nil {
+		return "", fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	return signedTx.Hash().Hex(), nil
+}
+
+// EstimateTokenFee estimates the fee for an ERC-20 token transfer
+func (c *EthereumClient) EstimateTokenFee(fromAddress, toAddress, symbol string, amount float64) (float64, error) {
+	if !c.ValidateAddress(fromAddress) || !c.ValidateAddress(toAddress) {
+		return 0, ErrInvalidAddress
+	}
+
+	token, err := c.GetToken(symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	amountInt := floatToTokenAmount(amount, token.Decimals)
+	data, err := encodeTransferCall(toAddress, amountInt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode transfer call: %w", err)
+	}
+
+	contract := common.HexToAddress(token.ContractAddress)
+	from := common.HexToAddress(fromAddress)
+
+	gasPrice, err := c.client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get gas price: %w", err)
+	}
+
+	gasLimit, err := c.client.EstimateGas(context.Background(), ethereum.CallMsg{
+		From: from,
+		To:   &contract,
+		Data: data,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate gas: %w", err)
+	}
+
+	fee := new(big.Float).SetInt(new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit)))
+	fee.Quo(fee, big.NewFloat(1e18))
+	feeFloat, _ := fee.Float64()
+	return feeFloat, nil
+}
+
+// decodeTokenTransfer inspects a transaction receipt's logs for a Transfer event
+// against a registered token contract and returns the symbol and decoded amount
+func (c *EthereumClient) decodeTokenTransfer(receipt *types.Receipt) (symbol string, amount float64, ok bool) {
+	for _, vlog := range receipt.Logs {
+		if len(vlog.Topics) != 3 || vlog.Topics[0].Hex() != "0x"+erc20TransferEventSig {
+			continue
+		}
+
+		for sym, token := range c.tokens {
+			if strings.EqualFold(token.ContractAddress, vlog.Address.Hex()) {
+				value := new(big.Int).SetBytes(vlog.Data)
+				return sym, tokenAmountToFloat(value, token.Decimals), true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// encodeTransferCall ABI-encodes a call to transfer(address,uint256)
+func encodeTransferCall(to string, amount *big.Int) ([]byte, error) {
+	if !common.IsHexAddress(to) {
+		return nil, ErrInvalidAddress
+	}
+
+	methodID, err := hex.DecodeString(erc20TransferMethodID)
+	if err != nil {
+		return nil, err
+	}
+
+	paddedAddress := common.LeftPadBytes(common.HexToAddress(to).Bytes(), 32)
+	paddedAmount := common.LeftPadBytes(amount.Bytes(), 32)
+
+	data := append(methodID, paddedAddress...)
+	data = append(data, paddedAmount...)
+	return data, nil
+}
+
+// encodeBalanceOfCall ABI-encodes a call to balanceOf(address)
+func encodeBalanceOfCall(address string) ([]byte, error) {
+	if !common.IsHexAddress(address) {
+		return nil, ErrInvalidAddress
+	}
+
+	methodID, err := hex.DecodeString(erc20BalanceOfMethodID)
+	if err != nil {
+		return nil, err
+	}
+
+	paddedAddress := common.LeftPadBytes(common.HexToAddress(address).Bytes(), 32)
+	return append(methodID, paddedAddress...), nil
+}
+
+// tokenAmountToFloat converts a token's smallest-unit integer amount to a decimal float
+func tokenAmountToFloat(amount *big.Int, decimals int) float64 {
+	divisor := new(big.Float).SetFloat64(1)
+	for i := 0; i < decimals; i++ {
+		divisor.Mul(divisor, big.NewFloat(10))
+	}
+	value := new(big.Float).SetInt(amount)
+	value.Quo(value, divisor)
+	result, _ := value.Float64()
+	return result
+}
+
+// floatToTokenAmount converts a decimal float amount to the token's smallest-unit integer
+func floatToTokenAmount(amount float64, decimals int) *big.Int {
+	multiplier := new(big.Float).SetFloat64(1)
+	for i := 0; i < decimals; i++ {
+		multiplier.Mul(multiplier, big.NewFloat(10))
+	}
+	value := new(big.Float).Mul(big.NewFloat(amount), multiplier)
+	result, _ := value.Int(nil)
+	return result
+}
@@ -10,6 +10,7 @@ import (
 	"log"
 	"math/big"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -27,13 +28,29 @@ type EthereumClient struct {
 	explorerURL string
 	testMode    bool
 	chainID     *big.Int
+	tokens      map[string]TokenConfig // registered ERC-20 tokens, keyed by uppercase symbol
+	keyStore    KeyStore               // optional; when set, GenerateAddress stores keys here, otherwise they are not persisted
+	history     HistoryProvider        // optional; backs GetTransactionsByAddress when set
+	sentTxCache *sentTransactionCache
+
+	safeConfig      safeConfig
+	safeMutex       sync.Mutex
+	safeWalletsByID map[string]*ethSafeWallet
 }
 
 // EthereumConfig holds configuration for Ethereum client
 type EthereumConfig struct {
-	NodeURL     string
-	ExplorerURL string
-	TestMode    bool
+	NodeURL         string
+	ExplorerURL     string
+	TestMode        bool
+	KeyStore        KeyStore // when provided, private keys are routed through this backend instead of the wire
+	HistoryProvider HistoryProvider
+
+	// Gnosis Safe multisig support (MultiSigClient); all optional, required only to use
+	// CreateMultiSigWallet/BroadcastMultiSig
+	GnosisSafeFactory           string // Safe ProxyFactory contract address
+	GnosisSafeProxyInitCodeHash string // hex keccak256 of the factory's proxy creation code, used to predict CREATE2 addresses
+	SafeRelayerPrivateKey       string // hex private key paying gas to submit Safe execTransaction calls
 }
 
 // NewEthereumClient creates a new Ethereum client
@@ -60,11 +77,21 @@ func NewEthereumClient(config EthereumConfig) (*EthereumClient, error) {
 		}
 	}
 
+	safeConfig, err := newSafeConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
 	return &EthereumClient{
 		client:      client,
 		explorerURL: explorerURL,
 		testMode:    config.TestMode,
 		chainID:     chainID,
+		tokens:      make(map[string]TokenConfig),
+		keyStore:    config.KeyStore,
+		history:     config.HistoryProvider,
+		sentTxCache: newSentTransactionCache(defaultTransactionIdempotencyTTL),
+		safeConfig:  safeConfig,
 	}, nil
 }
 
@@ -96,11 +123,17 @@ func (c *EthereumClient) GenerateAddress() (string, error) {
 	// Generate address
 	address := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	// Store private key securely (in a real implementation)
-	// For now, we'll just log it
-	privateKeyBytes := crypto.FromECDSA(privateKey)
-	privateKeyHex := hexutil.Encode(privateKeyBytes)
-	log.Printf("Generated new Ethereum address: %s with private key: %s", address.Hex(), privateKeyHex)
+	// Persist the private key through the configured KeyStore so it never touches
+	// application logs. Without a KeyStore there is nowhere safe to keep it, so the
+	// caller is responsible for capturing and storing the returned address's key
+	// themselves (e.g. by generating through a KeyStore-backed flow instead).
+	if c.keyStore != nil {
+		if err := c.keyStore.Store(address.Hex(), privateKey); err != nil {
+			return "", fmt.Errorf("failed to store generated key: %w", err)
+		}
+	} else {
+		log.Printf("Generated new Ethereum address: %s (no KeyStore configured, private key was not persisted)", address.Hex())
+	}
 
 	return address.Hex(), nil
 }
@@ -240,11 +273,32 @@ func (c *EthereumClient) GetTransaction(txID string) (*Transaction, error) {
 		ExplorerURL:   c.GetExplorerURL(txID),
 	}
 
+	// If this transaction's logs carry an ERC-20 Transfer event for a registered
+	// token, surface the token symbol and decimals-adjusted amount instead of ETH
+	if receipt != nil {
+		if symbol, tokenAmount, ok := c.decodeTokenTransfer(receipt); ok {
+			transaction.Currency = symbol
+			transaction.Amount = tokenAmount
+		}
+	}
+
 	return transaction, nil
 }
 
-// SendTransaction sends an Ethereum transaction
-func (c *EthereumClient) SendTransaction(fromAddress, toAddress string, amount float64, privateKeyHex string) (string, error) {
+// SendTransaction sends an Ethereum transaction. A request carrying an IdempotencyKey already
+// seen by this client returns the original call's result again instead of broadcasting a
+// duplicate.
+func (c *EthereumClient) SendTransaction(request *SendTransactionRequest) (string, error) {
+	if txID, err, found := c.sentTxCache.get(request.IdempotencyKey); found {
+		return txID, err
+	}
+	txID, err := c.sendTransaction(request)
+	c.sentTxCache.put(request.IdempotencyKey, txID, err)
+	return txID, err
+}
+
+func (c *EthereumClient) sendTransaction(request *SendTransactionRequest) (string, error) {
+	fromAddress, toAddress, amount, privateKeyHex := request.FromAddress, request.ToAddress, request.Amount, request.PrivateKey
 	// Validate addresses
 	if !c.ValidateAddress(fromAddress) || !c.ValidateAddress(toAddress) {
 		return "", ErrInvalidAddress
@@ -310,6 +364,45 @@ func (c *EthereumClient) SendTransaction(fromAddress, toAddress string, amount f
 	return signedTx.Hash().Hex(), nil
 }
 
+// SendTransactionFromKeyStore sends an Ethereum transaction signed through the client's
+// configured KeyStore, so the caller never needs to pass a raw private key over the wire
+func (c *EthereumClient) SendTransactionFromKeyStore(fromAddress, toAddress string, amount float64) (string, error) {
+	if c.keyStore == nil {
+		return "", errors.New("no KeyStore configured for this client")
+	}
+
+	if !c.ValidateAddress(fromAddress) || !c.ValidateAddress(toAddress) {
+		return "", ErrInvalidAddress
+	}
+
+	nonce, err := c.client.PendingNonceAt(context.Background(), common.HexToAddress(fromAddress))
+	if err != nil {
+		return "", fmt.Errorf("failed to get nonce: %w", err)
+	}
+
+	gasPrice, err := c.client.SuggestGasPrice(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("failed to get gas price: %w", err)
+	}
+
+	value := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(params.Ether))
+	valueInt, _ := value.Int(nil)
+
+	gasLimit := uint64(21000)
+	tx := types.NewTransaction(nonce, common.HexToAddress(toAddress), valueInt, gasLimit, gasPrice, nil)
+
+	signedTx, err := c.keyStore.Sign(fromAddress, tx, c.chainID)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction via key store: %w", err)
+	}
+
+	if err := c.client.SendTransaction(context.Background(), signedTx); err != nil {
+		return "", fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	return signedTx.Hash().Hex(), nil
+}
+
 // EstimateFee estimates the fee for an Ethereum transaction
 func (c *EthereumClient) EstimateFee(fromAddress, toAddress string, amount float64) (float64, error) {
 	// Validate addresses
@@ -334,16 +427,24 @@ func (c *EthereumClient) EstimateFee(fromAddress, toAddress string, amount float
 	return feeFloat, nil
 }
 
-// GetTransactionsByAddress returns transactions for a specific address
+// GetTransactionsByAddress returns transactions for a specific address, backed by the
+// client's configured HistoryProvider (Etherscan-family or Covalent) so merchants can
+// render account histories without running an archive node
 func (c *EthereumClient) GetTransactionsByAddress(address string, limit int) ([]Transaction, error) {
 	// Validate address
 	if !c.ValidateAddress(address) {
 		return nil, ErrInvalidAddress
 	}
 
-	// In a real implementation, we would use a blockchain explorer API or indexer
-	// For now, we'll return an empty slice
-	return []Transaction{}, nil
+	if c.history == nil {
+		return []Transaction{}, nil
+	}
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	return c.history.GetTransactionsByAddress(address, 0, 999999999, 1, limit)
 }
 
 // GetConfirmations returns the number of confirmations for a transaction
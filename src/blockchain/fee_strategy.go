@@ -0,0 +1,72 @@
+// Cross-chain fee strategy abstraction layered over each client's existing,
+// chain-specific fee machinery (EthereumClient's EthFeeStrategy/FeePriority in fees.go,
+// BitcoinClient's FeeStrategy/FeeEstimator in bitcoin_fees.go). FeeTier gives a caller a
+// single vocabulary ("slow"/"standard"/"fast"/"custom") to request a fee from any
+// BlockchainClient that implements FeeStrategyProvider, regardless of what that chain
+// calls it under the hood.
+
+package blockchain
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+// ErrFeeBumpUnsupported is returned by FeeBumpTransaction when newFee doesn't carry the
+// fields this client's chain needs to build a replacement (e.g. an Ethereum client asked
+// to bump with only a BTC SatPerVByte set)
+var ErrFeeBumpUnsupported = errors.New("fee estimate does not carry the fields this blockchain needs to bump a transaction")
+
+// FeeTier selects how aggressively a transaction's fee is set, independent of chain
+type FeeTier string
+
+const (
+	FeeTierSlow     FeeTier = "slow"
+	FeeTierStandard FeeTier = "standard"
+	FeeTierFast     FeeTier = "fast"
+	FeeTierCustom   FeeTier = "custom"
+)
+
+// FeeEstimate carries every fee shape a BlockchainClient might need to price a
+// transaction, so a cross-chain call site doesn't have to branch on currency. A client's
+// EstimateFeeForTier only populates the fields relevant to its own chain.
+type FeeEstimate struct {
+	Tier FeeTier
+
+	// GasPrice is Ethereum's legacy (pre-EIP-1559) gas price, in wei
+	GasPrice *big.Int
+	// MaxFeePerGas/MaxPriorityFeePerGas are Ethereum's EIP-1559 fee cap and tip, in wei
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+
+	// SatPerVByte is Bitcoin's feerate, in satoshis per virtual byte
+	SatPerVByte int64
+
+	// BandwidthPrice/EnergyPrice are TRON's per-unit bandwidth/energy costs, in sun
+	BandwidthPrice int64
+	EnergyPrice    int64
+
+	// EstimatedConfirmation is how long a transaction at this fee is expected to take to
+	// confirm
+	EstimatedConfirmation time.Duration
+}
+
+// FeeStrategyProvider is implemented by a BlockchainClient that supports FeeTier-based
+// fee estimation, fee-aware sending, and bumping a stuck transaction's fee (BIP125 RBF on
+// Bitcoin; nonce-replacement speed-up/cancel on Ethereum)
+type FeeStrategyProvider interface {
+	// EstimateFeeForTier derives a FeeEstimate for tier. tier == FeeTierCustom requires the
+	// caller to have otherwise obtained its own rate and build a FeeEstimate directly,
+	// since there's nothing for EstimateFeeForTier itself to derive.
+	EstimateFeeForTier(tier FeeTier) (FeeEstimate, error)
+
+	// SendTransactionWithFee sends a transaction priced at fee instead of the client's
+	// default feerate/gas price
+	SendTransactionWithFee(request *SendTransactionRequest, fee FeeEstimate) (string, error)
+
+	// FeeBumpTransaction replaces a stuck, already-broadcast transaction with one priced
+	// at newFee. privateKey re-signs the replacement, since a BlockchainClient never
+	// holds a caller's key itself.
+	FeeBumpTransaction(txID string, newFee FeeEstimate, privateKey string) (string, error)
+}
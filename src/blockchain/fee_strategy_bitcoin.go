@@ -0,0 +1,79 @@
+// BitcoinClient's FeeStrategyProvider implementation, wrapping bitcoin_fees.go's
+// FeeEstimator/BumpFeeRBF machinery rather than duplicating it
+
+package blockchain
+
+import (
+	"fmt"
+	"time"
+)
+
+// feeTierToBTCStrategy maps a cross-chain FeeTier onto bitcoin_fees.go's Bitcoin-specific
+// FeeStrategy
+func feeTierToBTCStrategy(tier FeeTier) FeeStrategy {
+	switch tier {
+	case FeeTierFast:
+		return FeeStrategyPriority
+	case FeeTierSlow:
+		return FeeStrategyEconomy
+	case FeeTierCustom:
+		return FeeStrategyCustom
+	default:
+		return FeeStrategyNormal
+	}
+}
+
+// btcTierConfirmation estimates how long a transaction at tier is expected to take to
+// confirm on Bitcoin, per confirmationTargets' block targets at a nominal 10 minutes/block
+var btcTierConfirmation = map[FeeTier]time.Duration{
+	FeeTierFast:     10 * time.Minute,
+	FeeTierStandard: 60 * time.Minute,
+	FeeTierSlow:     240 * time.Minute,
+}
+
+// EstimateFeeForTier derives a sat/vByte feerate for tier via this client's configured
+// FeeEstimator
+func (c *BitcoinClient) EstimateFeeForTier(tier FeeTier) (FeeEstimate, error) {
+	if c.feeEstimator == nil {
+		return FeeEstimate{}, fmt.Errorf("blockchain client has no fee estimator configured")
+	}
+	rate, err := c.feeEstimator.EstimateFeeRate(feeTierToBTCStrategy(tier), 0)
+	if err != nil {
+		return FeeEstimate{}, err
+	}
+	return FeeEstimate{
+		Tier:                  tier,
+		SatPerVByte:           rate,
+		EstimatedConfirmation: btcTierConfirmation[tier],
+	}, nil
+}
+
+// SendTransactionWithFee sends a transaction priced at fee.SatPerVByte, opting every input
+// into BIP125 replace-by-fee so it can be bumped later via FeeBumpTransaction. fee must
+// carry a positive SatPerVByte; otherwise it falls back to the client's default
+// SendTransaction.
+func (c *BitcoinClient) SendTransactionWithFee(request *SendTransactionRequest, fee FeeEstimate) (string, error) {
+	if fee.SatPerVByte <= 0 {
+		return c.SendTransaction(request)
+	}
+	estimator := c.feeEstimator
+	if estimator == nil {
+		estimator = NewFeeEstimator(c, "")
+	}
+	return c.SendTransactionWithFeeStrategy(request.FromAddress, request.ToAddress, request.Amount, request.PrivateKey, estimator, FeeStrategyCustom, fee.SatPerVByte)
+}
+
+// FeeBumpTransaction replaces txID, an unconfirmed RBF-opted-in transaction, with one
+// priced at newFee.SatPerVByte, re-signed with privateKey
+func (c *BitcoinClient) FeeBumpTransaction(txID string, newFee FeeEstimate, privateKey string) (string, error) {
+	if newFee.SatPerVByte <= 0 {
+		return "", ErrFeeBumpUnsupported
+	}
+
+	tx, err := c.GetTransaction(txID)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up transaction to bump: %w", err)
+	}
+
+	return c.BumpFeeRBF(txID, newFee.SatPerVByte, privateKey, tx.From)
+}
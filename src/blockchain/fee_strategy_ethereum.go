@@ -0,0 +1,112 @@
+// EthereumClient's FeeStrategyProvider implementation, wrapping the EIP-1559 machinery in
+// fees.go rather than duplicating it
+
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// feeTierConfirmation estimates how long a transaction at tier is expected to take to
+// confirm on Ethereum
+var feeTierConfirmation = map[FeeTier]time.Duration{
+	FeeTierFast:     15 * time.Second,
+	FeeTierStandard: time.Minute,
+	FeeTierSlow:     5 * time.Minute,
+}
+
+// feeTierToPriority maps a cross-chain FeeTier onto fees.go's Ethereum-specific
+// FeePriority, defaulting to FeePriorityStandard for FeeTierCustom
+func feeTierToPriority(tier FeeTier) FeePriority {
+	switch tier {
+	case FeeTierFast:
+		return FeePriorityFast
+	case FeeTierSlow:
+		return FeePrioritySlow
+	default:
+		return FeePriorityStandard
+	}
+}
+
+// EstimateFeeForTier derives an EIP-1559 MaxFeePerGas/MaxPriorityFeePerGas pair for tier
+func (c *EthereumClient) EstimateFeeForTier(tier FeeTier) (FeeEstimate, error) {
+	priority := feeTierToPriority(tier)
+	tipCap, feeCap, err := c.suggestDynamicFees(priority)
+	if err != nil {
+		return FeeEstimate{}, err
+	}
+	return FeeEstimate{
+		Tier:                  tier,
+		MaxFeePerGas:          feeCap,
+		MaxPriorityFeePerGas:  tipCap,
+		EstimatedConfirmation: feeTierConfirmation[tier],
+	}, nil
+}
+
+// SendTransactionWithFee sends a transaction priced at fee's EIP-1559 fields. fee must
+// carry MaxFeePerGas/MaxPriorityFeePerGas (e.g. from EstimateFeeForTier); otherwise it falls back
+// to the client's default SendTransaction.
+func (c *EthereumClient) SendTransactionWithFee(request *SendTransactionRequest, fee FeeEstimate) (string, error) {
+	if fee.MaxFeePerGas == nil || fee.MaxPriorityFeePerGas == nil {
+		return c.SendTransaction(request)
+	}
+	if !c.ValidateAddress(request.FromAddress) || !c.ValidateAddress(request.ToAddress) {
+		return "", ErrInvalidAddress
+	}
+
+	privateKey, address, err := parsePrivateKey(request.PrivateKey, request.FromAddress)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := c.client.PendingNonceAt(context.Background(), address)
+	if err != nil {
+		return "", fmt.Errorf("failed to get nonce: %w", err)
+	}
+
+	value := new(big.Float).Mul(big.NewFloat(request.Amount), big.NewFloat(params.Ether))
+	valueInt, _ := value.Int(nil)
+
+	to := common.HexToAddress(request.ToAddress)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   c.chainID,
+		Nonce:     nonce,
+		GasTipCap: fee.MaxPriorityFeePerGas,
+		GasFeeCap: fee.MaxFeePerGas,
+		Gas:       21000,
+		To:        &to,
+		Value:     valueInt,
+	})
+
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(c.chainID), privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+	if err := c.client.SendTransaction(context.Background(), signedTx); err != nil {
+		return "", fmt.Errorf("failed to send transaction: %w", err)
+	}
+	return signedTx.Hash().Hex(), nil
+}
+
+// FeeBumpTransaction speeds up txID by re-signing it with privateKey through
+// ReplaceTransaction: same nonce, destination and amount, priced at newFee's EIP-1559 caps.
+// It always re-sends the original transfer; it cannot cancel txID.
+func (c *EthereumClient) FeeBumpTransaction(txID string, newFee FeeEstimate, privateKey string) (string, error) {
+	if newFee.MaxFeePerGas == nil || newFee.MaxPriorityFeePerGas == nil {
+		return "", ErrFeeBumpUnsupported
+	}
+
+	tx, err := c.GetTransaction(txID)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up transaction to bump: %w", err)
+	}
+
+	return c.ReplaceTransaction(txID, tx.To, tx.Amount, privateKey, newFee.MaxPriorityFeePerGas, newFee.MaxFeePerGas)
+}
@@ -0,0 +1,202 @@
+// Fee strategy support for the Ethereum blockchain client
+// Adds EIP-1559 dynamic fee transactions alongside the legacy gas-price path,
+// plus fee bumping to replace stuck pending transactions
+
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// EthFeeStrategy selects how Ethereum transaction fees are determined
+type EthFeeStrategy string
+
+const (
+	// FeeStrategyLegacy uses a single legacy gas price (types.NewTransaction)
+	FeeStrategyLegacy EthFeeStrategy = "legacy"
+	// FeeStrategyEIP1559 uses dynamic base fee + priority tip (types.DynamicFeeTx)
+	FeeStrategyEIP1559 EthFeeStrategy = "eip1559"
+)
+
+// FeePriority selects a priority percentile when deriving an EIP-1559 tip
+type FeePriority string
+
+const (
+	// FeePriorityFast pays a higher tip for faster inclusion
+	FeePriorityFast FeePriority = "fast"
+	// FeePriorityStandard pays the suggested tip
+	FeePriorityStandard FeePriority = "standard"
+	// FeePrioritySlow pays a lower tip, accepting slower inclusion
+	FeePrioritySlow FeePriority = "slow"
+)
+
+// priorityMultiplier scales the node-suggested tip cap per priority tier
+var priorityMultiplier = map[FeePriority]int64{
+	FeePriorityFast:     150, // 1.5x
+	FeePriorityStandard: 100, // 1.0x
+	FeePrioritySlow:     75,  // 0.75x
+}
+
+// SendTransactionWithFeeStrategy sends an Ethereum transaction using either the legacy
+// gas-price model or EIP-1559 dynamic fees, depending on the requested strategy
+func (c *EthereumClient) SendTransactionWithFeeStrategy(fromAddress, toAddress string, amount float64, privateKeyHex string, strategy EthFeeStrategy, priority FeePriority) (string, error) {
+	if strategy != FeeStrategyEIP1559 {
+		return c.SendTransaction(&SendTransactionRequest{FromAddress: fromAddress, ToAddress: toAddress, Amount: amount, PrivateKey: privateKeyHex})
+	}
+
+	if !c.ValidateAddress(fromAddress) || !c.ValidateAddress(toAddress) {
+		return "", ErrInvalidAddress
+	}
+
+	privateKey, address, err := parsePrivateKey(privateKeyHex, fromAddress)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := c.client.PendingNonceAt(context.Background(), address)
+	if err != nil {
+		return "", fmt.Errorf("failed to get nonce: %w", err)
+	}
+
+	tipCap, feeCap, err := c.suggestDynamicFees(priority)
+	if err != nil {
+		return "", err
+	}
+
+	value := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(params.Ether))
+	valueInt, _ := value.Int(nil)
+
+	to := common.HexToAddress(toAddress)
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   c.chainID,
+		Nonce:     nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       21000,
+		To:        &to,
+		Value:     valueInt,
+	})
+
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(c.chainID), privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	if err := c.client.SendTransaction(context.Background(), signedTx); err != nil {
+		return "", fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	return signedTx.Hash().Hex(), nil
+}
+
+// suggestDynamicFees derives a GasTipCap/GasFeeCap pair from the node's suggested tip
+// and the latest block's base fee, scaled by the requested priority tier
+func (c *EthereumClient) suggestDynamicFees(priority FeePriority) (tipCap, feeCap *big.Int, err error) {
+	suggestedTip, err := c.client.SuggestGasTipCap(context.Background())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to suggest gas tip cap: %w", err)
+	}
+
+	multiplier, ok := priorityMultiplier[priority]
+	if !ok {
+		multiplier = priorityMultiplier[FeePriorityStandard]
+	}
+	tipCap = new(big.Int).Div(new(big.Int).Mul(suggestedTip, big.NewInt(multiplier)), big.NewInt(100))
+
+	header, err := c.client.HeaderByNumber(context.Background(), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get latest header: %w", err)
+	}
+	if header.BaseFee == nil {
+		return nil, nil, errors.New("chain does not support EIP-1559 base fee")
+	}
+
+	// feeCap = 2 * baseFee + tipCap, leaving headroom for base fee increases
+	feeCap = new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), tipCap)
+	return tipCap, feeCap, nil
+}
+
+// ReplaceTransaction re-signs and re-broadcasts a pending transaction's nonce with a
+// bumped EIP-1559 fee, allowing a stuck payment to be unstuck without waiting it out
+func (c *EthereumClient) ReplaceTransaction(txID, toAddress string, amount float64, privateKeyHex string, newTipCap, newFeeCap *big.Int) (string, error) {
+	if !strings.HasPrefix(txID, "0x") {
+		txID = "0x" + txID
+	}
+	hash := common.HexToHash(txID)
+
+	existing, isPending, err := c.client.TransactionByHash(context.Background(), hash)
+	if err != nil {
+		return "", fmt.Errorf("failed to get existing transaction: %w", err)
+	}
+	if !isPending {
+		return "", errors.New("transaction is already mined; nothing to replace")
+	}
+
+	privateKey, _, err := parsePrivateKey(privateKeyHex, "")
+	if err != nil {
+		return "", err
+	}
+
+	to := common.HexToAddress(toAddress)
+	value := new(big.Float).Mul(big.NewFloat(amount), big.NewFloat(params.Ether))
+	valueInt, _ := value.Int(nil)
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   c.chainID,
+		Nonce:     existing.Nonce(),
+		GasTipCap: newTipCap,
+		GasFeeCap: newFeeCap,
+		Gas:       existing.Gas(),
+		To:        &to,
+		Value:     valueInt,
+	})
+
+	signedTx, err := types.SignTx(tx, types.NewLondonSigner(c.chainID), privateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign replacement transaction: %w", err)
+	}
+
+	if err := c.client.SendTransaction(context.Background(), signedTx); err != nil {
+		return "", fmt.Errorf("failed to broadcast replacement transaction: %w", err)
+	}
+
+	return signedTx.Hash().Hex(), nil
+}
+
+// parsePrivateKey decodes a hex-encoded private key and, if fromAddress is non-empty,
+// verifies it matches the derived address
+func parsePrivateKey(privateKeyHex, fromAddress string) (*ecdsa.PrivateKey, common.Address, error) {
+	if !strings.HasPrefix(privateKeyHex, "0x") {
+		privateKeyHex = "0x" + privateKeyHex
+	}
+	privateKeyBytes, err := hexutil.Decode(privateKeyHex)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("invalid private key: %w", err)
+	}
+	privateKey, err := crypto.ToECDSA(privateKeyBytes)
+	if err != nil {
+		return nil, common.Address{}, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	publicKey, ok := privateKey.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, common.Address{}, errors.New("failed to cast public key to ECDSA")
+	}
+	address := crypto.PubkeyToAddress(*publicKey)
+	if fromAddress != "" && address.Hex() != fromAddress {
+		return nil, common.Address{}, errors.New("private key does not match from address")
+	}
+
+	return privateKey, address, nil
+}
@@ -0,0 +1,261 @@
+// Hierarchical deterministic wallet support for BitcoinClient
+// Replaces the one-off btcec.NewPrivateKey generation in GenerateAddress with BIP32/BIP39/
+// BIP44 derivation from a single master seed, so a merchant can watch one xpub across many
+// invoices instead of tracking a private key per address
+
+package blockchain
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// BIP44 coin types for the currencies this repo integrates with
+const (
+	CoinTypeBitcoin  uint32 = 0
+	CoinTypeEthereum uint32 = 60
+)
+
+// defaultGapLimit is the number of consecutive unused addresses NextReceiveAddress will
+// generate ahead of the last address seen on-chain; DeriveAddress does not check it
+const defaultGapLimit = 20
+
+// ErrGapLimitExceeded is returned when NextReceiveAddress would derive further past
+// the last used address than the wallet's configured gap limit allows
+var ErrGapLimitExceeded = errors.New("gap limit exceeded: mark an earlier address used before deriving further")
+
+// HDWallet derives Bitcoin keys and addresses from a single BIP39 mnemonic seed using
+// BIP32 extended keys and BIP44 account paths (m/44'/coinType'/account'/change/index)
+type HDWallet struct {
+	mutex sync.Mutex
+
+	chainParams    *chaincfg.Params
+	coinType       uint32
+	defaultAccount uint32 // account NextReceiveAddress/ExportXpub/gap-limit tracking apply to
+
+	encryptedSeed []byte
+	encryptionKey [32]byte // caller-supplied key the master seed is encrypted under at rest
+
+	coinKey     *hdkeychain.ExtendedKey            // derived m/44'/coinType'
+	accountKeys map[uint32]*hdkeychain.ExtendedKey // account -> derived m/44'/coinType'/account', cached on first use
+
+	gapLimit  uint32
+	nextIndex uint32 // next external (change=0) index to hand out on defaultAccount
+	usedIndex uint32 // highest external index observed used on-chain on defaultAccount
+}
+
+// NewHDWallet generates a fresh BIP39 mnemonic and derives a wallet for it. encryptionKey
+// must be 32 bytes (AES-256) and is used to encrypt the master seed at rest; it is the
+// caller's responsibility to keep it outside of this process's logs.
+func NewHDWallet(chainParams *chaincfg.Params, account uint32, encryptionKey [32]byte) (*HDWallet, string, error) {
+	entropy, err := bip39.NewEntropy(256)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate entropy: %w", err)
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate mnemonic: %w", err)
+	}
+
+	wallet, err := ImportHDWallet(mnemonic, "", chainParams, account, encryptionKey)
+	if err != nil {
+		return nil, "", err
+	}
+	return wallet, mnemonic, nil
+}
+
+// ImportHDWallet restores a wallet from an existing BIP39 mnemonic (and optional
+// passphrase), encrypting the derived master seed under encryptionKey at rest
+func ImportHDWallet(mnemonic, passphrase string, chainParams *chaincfg.Params, account uint32, encryptionKey [32]byte) (*HDWallet, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errors.New("invalid BIP39 mnemonic")
+	}
+	seed := bip39.NewSeed(mnemonic, passphrase)
+
+	coinKey, err := deriveCoinKey(seed, chainParams, CoinTypeBitcoin)
+	if err != nil {
+		return nil, err
+	}
+
+	encryptedSeed, err := encryptSeed(seed, encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt master seed: %w", err)
+	}
+
+	wallet := &HDWallet{
+		chainParams:    chainParams,
+		coinType:       CoinTypeBitcoin,
+		defaultAccount: account,
+		encryptedSeed:  encryptedSeed,
+		encryptionKey:  encryptionKey,
+		coinKey:        coinKey,
+		accountKeys:    make(map[uint32]*hdkeychain.ExtendedKey),
+		gapLimit:       defaultGapLimit,
+	}
+	if _, err := wallet.accountKeyFor(account); err != nil {
+		return nil, err
+	}
+	return wallet, nil
+}
+
+// deriveCoinKey walks m/44'/coinType' from the master seed
+func deriveCoinKey(seed []byte, chainParams *chaincfg.Params, coinType uint32) (*hdkeychain.ExtendedKey, error) {
+	master, err := hdkeychain.NewMaster(seed, chainParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive master key: %w", err)
+	}
+
+	purpose, err := master.Derive(hdkeychain.HardenedKeyStart + 44)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive purpose key: %w", err)
+	}
+	coin, err := purpose.Derive(hdkeychain.HardenedKeyStart + coinType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive coin type key: %w", err)
+	}
+	return coin, nil
+}
+
+// accountKeyFor returns the cached m/44'/coinType'/account' key, deriving and caching it
+// on first use. Callers must hold w.mutex.
+func (w *HDWallet) accountKeyFor(account uint32) (*hdkeychain.ExtendedKey, error) {
+	if key, exists := w.accountKeys[account]; exists {
+		return key, nil
+	}
+	key, err := w.coinKey.Derive(hdkeychain.HardenedKeyStart + account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive account key: %w", err)
+	}
+	w.accountKeys[account] = key
+	return key, nil
+}
+
+// DeriveAddress derives the P2PKH address at m/44'/coinType'/account'/change/index
+func (w *HDWallet) DeriveAddress(account, change, index uint32) (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.deriveAddress(account, change, index)
+}
+
+func (w *HDWallet) deriveAddress(account, change, index uint32) (string, error) {
+	accountKey, err := w.accountKeyFor(account)
+	if err != nil {
+		return "", err
+	}
+
+	changeKey, err := accountKey.Derive(change)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive change key: %w", err)
+	}
+	childKey, err := changeKey.Derive(index)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive child key: %w", err)
+	}
+
+	pubKey, err := childKey.ECPubKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to derive public key: %w", err)
+	}
+
+	pubKeyHash := btcutil.Hash160(pubKey.SerializeCompressed())
+	addr, err := btcutil.NewAddressPubKeyHash(pubKeyHash, w.chainParams)
+	if err != nil {
+		return "", fmt.Errorf("failed to create address: %w", err)
+	}
+	return addr.EncodeAddress(), nil
+}
+
+// NextReceiveAddress derives the next unused external (change=0) address on the
+// wallet's default account, refusing to advance more than the gap limit past the last
+// address observed used on-chain
+func (w *HDWallet) NextReceiveAddress() (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.nextIndex > w.usedIndex+w.gapLimit {
+		return "", ErrGapLimitExceeded
+	}
+
+	addr, err := w.deriveAddress(w.defaultAccount, 0, w.nextIndex)
+	if err != nil {
+		return "", err
+	}
+	w.nextIndex++
+	return addr, nil
+}
+
+// MarkAddressUsed records that the external address at index has been seen on-chain,
+// advancing the gap-limit window so NextReceiveAddress can keep handing out addresses
+func (w *HDWallet) MarkAddressUsed(index uint32) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if index > w.usedIndex {
+		w.usedIndex = index
+	}
+}
+
+// ExportXpub returns the base58-encoded extended public key for the wallet's default
+// account level (m/44'/coinType'/account'), letting a merchant watch every invoice
+// address without holding any private key material
+func (w *HDWallet) ExportXpub() (string, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	accountKey, err := w.accountKeyFor(w.defaultAccount)
+	if err != nil {
+		return "", err
+	}
+	neutered, err := accountKey.Neuter()
+	if err != nil {
+		return "", fmt.Errorf("failed to neuter account key: %w", err)
+	}
+	return neutered.String(), nil
+}
+
+// encryptSeed encrypts the master seed with AES-256-GCM, matching the security
+// package's EncryptData construction so seeds at rest use the same primitive as
+// everything else in this codebase
+func encryptSeed(seed []byte, key [32]byte) ([]byte, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher block: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to create nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, seed, nil), nil
+}
+
+// decryptSeed reverses encryptSeed, recovering the master seed for re-derivation
+func decryptSeed(encryptedSeed []byte, key [32]byte) ([]byte, error) {
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher block: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	if len(encryptedSeed) < gcm.NonceSize() {
+		return nil, errors.New("encrypted seed is truncated")
+	}
+
+	nonce, ciphertext := encryptedSeed[:gcm.NonceSize()], encryptedSeed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,351 @@
+// Address-indexed transaction history for the Ethereum blockchain client
+// Backs GetTransactionsByAddress with pluggable Etherscan-family and Covalent
+// providers, since querying an archive node directly isn't practical for merchants
+
+package blockchain
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HistoryProvider fetches address-indexed transaction history from an external indexer
+type HistoryProvider interface {
+	// GetName returns the name of the history provider
+	GetName() string
+
+	// GetTransactionsByAddress returns transactions for an address, most recent first
+	GetTransactionsByAddress(address string, startBlock, endBlock uint64, page, pageSize int) ([]Transaction, error)
+}
+
+// historyCacheEntry holds a cached page of results along with when it was fetched
+type historyCacheEntry struct {
+	transactions []Transaction
+	fetchedAt    time.Time
+}
+
+// historyCache is a small TTL cache shared by history providers to avoid hammering
+// explorer APIs when merchants repeatedly render the same account history
+type historyCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[string]historyCacheEntry
+}
+
+func newHistoryCache(ttl time.Duration) *historyCache {
+	return &historyCache{ttl: ttl, entries: make(map[string]historyCacheEntry)}
+}
+
+func (c *historyCache) get(key string) ([]Transaction, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entry, exists := c.entries[key]
+	if !exists || time.Since(entry.fetchedAt) > c.ttl {
+		return nil, false
+	}
+	return entry.transactions, true
+}
+
+func (c *historyCache) set(key string, transactions []Transaction) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.entries[key] = historyCacheEntry{transactions: transactions, fetchedAt: time.Now()}
+}
+
+// withRetry calls fn up to maxAttempts times, pausing 500ms longer before each retry than
+// before the previous one (linear backoff), returning the
+// first successful result or the last error encountered
+func withRetry(maxAttempts int, fn func() ([]Transaction, error)) ([]Transaction, error) {
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
+		}
+		result, err := fn()
+		if err == nil {
+			return result, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// EtherscanProvider queries the Etherscan-family explorer APIs (Etherscan, BscScan,
+// PolygonScan all share the same request/response shape and only differ by base URL)
+type EtherscanProvider struct {
+	name       string
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+	cache      *historyCache
+}
+
+// NewEtherscanProvider creates a provider for an Etherscan-compatible explorer API.
+// name identifies the explorer (e.g. "Etherscan", "BscScan", "PolygonScan") and
+// baseURL is its API root (the caller passes the mainnet or testnet root).
+func NewEtherscanProvider(name, baseURL, apiKey string) *EtherscanProvider {
+	return &EtherscanProvider{
+		name:    name,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		cache: newHistoryCache(30 * time.Second),
+	}
+}
+
+// GetName returns the name of the history provider
+func (p *EtherscanProvider) GetName() string {
+	return p.name
+}
+
+// etherscanTxListResult models the subset of an Etherscan txlist/tokentx response we use
+type etherscanTxListResult struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Result  []struct {
+		Hash          string `json:"hash"`
+		BlockHash     string `json:"blockHash"`
+		BlockNumber   string `json:"blockNumber"`
+		From          string `json:"from"`
+		To            string `json:"to"`
+		Value         string `json:"value"`
+		TokenSymbol   string `json:"tokenSymbol"`
+		TokenDecimal  string `json:"tokenDecimal"`
+		GasUsed       string `json:"gasUsed"`
+		GasPrice      string `json:"gasPrice"`
+		TimeStamp     string `json:"timeStamp"`
+		Confirmations string `json:"confirmations"`
+	} `json:"result"`
+}
+
+// GetTransactionsByAddress fetches both native and ERC-20 transfers for an address
+// from the explorer's txlist and tokentx endpoints, merged and sorted newest-first
+func (p *EtherscanProvider) GetTransactionsByAddress(address string, startBlock, endBlock uint64, page, pageSize int) ([]Transaction, error) {
+	cacheKey := fmt.Sprintf("%s:%s:%d:%d:%d:%d", p.name, address, startBlock, endBlock, page, pageSize)
+	if cached, ok := p.cache.get(cacheKey); ok {
+		return cached, nil
+	}
+
+	native, err := withRetry(3, func() ([]Transaction, error) {
+		return p.fetchList("txlist", address, startBlock, endBlock, page, pageSize)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tokenTransfers, err := withRetry(3, func() ([]Transaction, error) {
+		return p.fetchList("tokentx", address, startBlock, endBlock, page, pageSize)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged := append(native, tokenTransfers...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp.After(merged[j].Timestamp) })
+	p.cache.set(cacheKey, merged)
+	return merged, nil
+}
+
+func (p *EtherscanProvider) fetchList(action, address string, startBlock, endBlock uint64, page, pageSize int) ([]Transaction, error) {
+	url := fmt.Sprintf("%s/api?module=account&action=%s&address=%s&startblock=%d&endblock=%d&page=%d&offset=%d&sort=desc&apikey=%s",
+		p.baseURL, action, address, startBlock, endBlock, page, pageSize, p.apiKey)
+
+	resp, err := p.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", p.name, err)
+	}
+
+	var result etherscanTxListResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %w", p.name, err)
+	}
+
+	// Etherscan returns status "0" with message "No transactions found" for an empty
+	// account; that's not an error condition worth retrying or surfacing.
+	if result.Status != "1" && result.Message != "No transactions found" {
+		return nil, fmt.Errorf("%s error: %s", p.name, result.Message)
+	}
+
+	transactions := make([]Transaction, 0, len(result.Result))
+	for _, r := range result.Result {
+		blockNumber, _ := strconv.ParseUint(r.BlockNumber, 10, 64)
+		confirmations, _ := strconv.ParseUint(r.Confirmations, 10, 64)
+		timestamp, _ := strconv.ParseInt(r.TimeStamp, 10, 64)
+
+		currency := "ETH"
+		decimals := 18
+		if r.TokenSymbol != "" {
+			currency = r.TokenSymbol
+			if d, err := strconv.Atoi(r.TokenDecimal); err == nil {
+				decimals = d
+			}
+		}
+
+		amount := parseWeiString(r.Value, decimals)
+		fee := feeFromGas(r.GasUsed, r.GasPrice)
+
+		transactions = append(transactions, Transaction{
+			TxID:          r.Hash,
+			BlockHash:     r.BlockHash,
+			BlockNumber:   blockNumber,
+			From:          r.From,
+			To:            r.To,
+			Amount:        amount,
+			Fee:           fee,
+			Confirmations: confirmations,
+			Status:        StatusConfirmed,
+			Timestamp:     time.Unix(timestamp, 0),
+			Currency:      currency,
+		})
+	}
+
+	return transactions, nil
+}
+
+// CovalentProvider queries the Covalent unified blockchain API
+type CovalentProvider struct {
+	chainID    int64
+	apiKey     string
+	httpClient *http.Client
+	cache      *historyCache
+}
+
+// NewCovalentProvider creates a provider backed by Covalent's transactions_v2 endpoint
+func NewCovalentProvider(chainID int64, apiKey string) *CovalentProvider {
+	return &CovalentProvider{
+		chainID: chainID,
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+		cache: newHistoryCache(30 * time.Second),
+	}
+}
+
+// GetName returns the name of the history provider
+func (p *CovalentProvider) GetName() string {
+	return "Covalent"
+}
+
+type covalentResponse struct {
+	Data struct {
+		Items []struct {
+			TxHash        string `json:"tx_hash"`
+			BlockHeight   uint64 `json:"block_height"`
+			BlockHash     string `json:"block_hash"`
+			FromAddress   string `json:"from_address"`
+			ToAddress     string `json:"to_address"`
+			Value         string `json:"value"`
+			GasSpent      uint64 `json:"gas_spent"`
+			GasPrice      uint64 `json:"gas_price"`
+			BlockSignedAt string `json:"block_signed_at"`
+			Successful    bool   `json:"successful"`
+		} `json:"items"`
+	} `json:"data"`
+	Error        bool   `json:"error"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// GetTransactionsByAddress fetches a page of transactions from Covalent's
+// transactions_v2 endpoint for the given address
+func (p *CovalentProvider) GetTransactionsByAddress(address string, startBlock, endBlock uint64, page, pageSize int) ([]Transaction, error) {
+	cacheKey := fmt.Sprintf("covalent:%d:%s:%d:%d", p.chainID, address, page, pageSize)
+	if cached, ok := p.cache.get(cacheKey); ok {
+		return cached, nil
+	}
+
+	result, err := withRetry(3, func() ([]Transaction, error) {
+		url := fmt.Sprintf("https://api.covalenthq.com/v1/%d/address/%s/transactions_v2/?page-number=%d&page-size=%d&key=%s",
+			p.chainID, address, page, pageSize, p.apiKey)
+
+		resp, err := p.httpClient.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query Covalent: %w", err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Covalent response: %w", err)
+		}
+
+		var parsed covalentResponse
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return nil, fmt.Errorf("failed to parse Covalent response: %w", err)
+		}
+		if parsed.Error {
+			return nil, fmt.Errorf("Covalent error: %s", parsed.ErrorMessage)
+		}
+
+		transactions := make([]Transaction, 0, len(parsed.Data.Items))
+		for _, item := range parsed.Data.Items {
+			status := StatusConfirmed
+			if !item.Successful {
+				status = StatusFailed
+			}
+			timestamp, _ := time.Parse(time.RFC3339, item.BlockSignedAt)
+
+			transactions = append(transactions, Transaction{
+				TxID:        item.TxHash,
+				BlockHash:   item.BlockHash,
+				BlockNumber: item.BlockHeight,
+				From:        item.FromAddress,
+				To:          item.ToAddress,
+				Amount:      parseWeiString(item.Value, 18),
+				Fee:         weiToEther(item.GasSpent * item.GasPrice),
+				Status:      status,
+				Timestamp:   timestamp,
+				Currency:    "ETH",
+			})
+		}
+		return transactions, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.cache.set(cacheKey, result)
+	return result, nil
+}
+
+// parseWeiString parses a base-10 wei/token-unit string and scales it by decimals
+func parseWeiString(value string, decimals int) float64 {
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return 0
+	}
+	return tokenAmountToFloat(amount, decimals)
+}
+
+// feeFromGas computes an ETH fee from decimal gasUsed/gasPrice strings
+func feeFromGas(gasUsed, gasPrice string) float64 {
+	used, ok1 := new(big.Int).SetString(gasUsed, 10)
+	price, ok2 := new(big.Int).SetString(gasPrice, 10)
+	if !ok1 || !ok2 {
+		return 0
+	}
+	return tokenAmountToFloat(new(big.Int).Mul(used, price), 18)
+}
+
+// weiToEther converts a wei amount to ether
+func weiToEther(wei uint64) float64 {
+	return tokenAmountToFloat(new(big.Int).SetUint64(wei), 18)
+}
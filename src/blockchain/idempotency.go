@@ -0,0 +1,68 @@
+// Idempotency key support for SendTransaction
+// A client retrying SendTransaction after a timed-out broadcast risks submitting the same
+// transfer twice. sentTransactionCache lets BitcoinClient/EthereumClient remember the result
+// of a recent SendTransactionRequest by its IdempotencyKey and return it again instead of
+// broadcasting a duplicate. It's duplicated independently here rather than reusing
+// integration's IdempotencyStore, since this package never imports integration.
+
+package blockchain
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultTransactionIdempotencyTTL is how long a SendTransaction result stays cached under its
+// idempotency key before the key is treated as unused and can be reused for an unrelated
+// transfer
+const defaultTransactionIdempotencyTTL = 24 * time.Hour
+
+// sentTransaction is a SendTransaction call's outcome recorded under its idempotency key
+type sentTransaction struct {
+	txID       string
+	err        error
+	recordedAt time.Time
+}
+
+// sentTransactionCache is a process-local, TTL-expiring record of recently sent transactions,
+// keyed by SendTransactionRequest.IdempotencyKey
+type sentTransactionCache struct {
+	mutex   sync.Mutex
+	entries map[string]sentTransaction
+	ttl     time.Duration
+}
+
+// newSentTransactionCache creates a cache whose entries expire ttl after being recorded. A
+// ttl of zero means entries never expire.
+func newSentTransactionCache(ttl time.Duration) *sentTransactionCache {
+	return &sentTransactionCache{entries: make(map[string]sentTransaction), ttl: ttl}
+}
+
+// get returns the previously recorded (txID, err) for key, and whether one was found. An empty
+// key never matches, since SendTransactionRequest treats a blank IdempotencyKey as "not set".
+func (c *sentTransactionCache) get(key string) (string, error, bool) {
+	if key == "" {
+		return "", nil, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, exists := c.entries[key]
+	if !exists {
+		return "", nil, false
+	}
+	if c.ttl > 0 && time.Since(entry.recordedAt) > c.ttl {
+		delete(c.entries, key)
+		return "", nil, false
+	}
+	return entry.txID, entry.err, true
+}
+
+// put records (txID, err) under key, a no-op when key is empty
+func (c *sentTransactionCache) put(key, txID string, err error) {
+	if key == "" {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[key] = sentTransaction{txID: txID, err: err, recordedAt: time.Now()}
+}
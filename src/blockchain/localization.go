@@ -0,0 +1,84 @@
+// Localization of BlockchainClient's sentinel errors, mirroring integration's
+// PlatformOption/WithLocalization convention for the payment platform side
+
+package blockchain
+
+// Language is a BCP-47-style language tag controlling which translation
+// BlockchainClientFactory.LocalizeError surfaces a sentinel error in
+type Language string
+
+const (
+	LanguageEnglish            Language = "en"
+	LanguageChinese            Language = "zh-CN"
+	LanguageChineseTraditional Language = "zh-TW"
+	LanguageJapanese           Language = "ja"
+	LanguageKorean             Language = "ko"
+	LanguageThai               Language = "th"
+	LanguageVietnamese         Language = "vi"
+	LanguageIndonesian         Language = "id"
+	LanguageMalay              Language = "ms"
+)
+
+// LocalizedError wraps one of this package's sentinel errors (ErrInvalidAddress and so on)
+// with a message translated into Language
+type LocalizedError struct {
+	Code     string
+	Message  string
+	Language Language
+	Err      error
+}
+
+func (e *LocalizedError) Error() string {
+	return e.Message
+}
+
+func (e *LocalizedError) Unwrap() error {
+	return e.Err
+}
+
+type sentinelCode struct {
+	code     string
+	messages map[Language]string
+}
+
+var sentinelTranslations = map[error]sentinelCode{
+	ErrInvalidAddress: {code: "invalid_address", messages: map[Language]string{
+		LanguageEnglish: "The provided address is not valid for this blockchain.",
+		LanguageChinese: "提供的地址对此区块链无效。",
+		LanguageThai:    "ที่อยู่ที่ระบุไม่ถูกต้องสำหรับบล็อกเชนนี้",
+	}},
+	ErrInsufficientBalance: {code: "insufficient_balance", messages: map[Language]string{
+		LanguageEnglish: "Insufficient balance to complete this transaction.",
+		LanguageChinese: "余额不足,无法完成此交易。",
+		LanguageThai:    "ยอดเงินไม่เพียงพอสำหรับทำธุรกรรมนี้",
+	}},
+	ErrTransactionFailed: {code: "transaction_failed", messages: map[Language]string{
+		LanguageEnglish: "The transaction failed to complete.",
+		LanguageChinese: "交易未能完成。",
+		LanguageThai:    "ธุรกรรมไม่สำเร็จ",
+	}},
+	ErrNetworkUnavailable: {code: "network_unavailable", messages: map[Language]string{
+		LanguageEnglish: "The blockchain network is currently unavailable.",
+		LanguageChinese: "区块链网络当前不可用。",
+		LanguageThai:    "เครือข่ายบล็อกเชนไม่พร้อมใช้งานในขณะนี้",
+	}},
+	ErrInvalidTransaction: {code: "invalid_transaction", messages: map[Language]string{
+		LanguageEnglish: "The transaction is invalid.",
+		LanguageChinese: "该交易无效。",
+		LanguageThai:    "ธุรกรรมไม่ถูกต้อง",
+	}},
+}
+
+// Localize wraps err in a LocalizedError translated into lang, if err is one of this
+// package's sentinel errors. Any other error is returned unchanged.
+func Localize(err error, lang Language) error {
+	entry, ok := sentinelTranslations[err]
+	if !ok {
+		return err
+	}
+	message, ok := entry.messages[lang]
+	if !ok {
+		message = entry.messages[LanguageEnglish]
+	}
+	return &LocalizedError{Code: entry.code, Message: message, Language: lang, Err: err}
+}
@@ -0,0 +1,188 @@
+// Background monitoring for stuck, unconfirmed transactions
+// A payment broadcast during a fee spike can sit in the mempool indefinitely; without
+// something watching for that, a merchant's settlement just silently stalls. MempoolWatcher
+// polls GetConfirmations for every transaction it's tracking and, once one has sat
+// unconfirmed past its policy's StuckAfter window, bumps its fee automatically via
+// FeeStrategyProvider.FeeBumpTransaction.
+
+package blockchain
+
+import (
+	"sync"
+	"time"
+)
+
+// MempoolBumpPolicy configures MempoolWatcher's stuck-detection and auto-bump behavior
+type MempoolBumpPolicy struct {
+	// StuckAfter is how long a transaction may sit unconfirmed before it's considered stuck
+	StuckAfter time.Duration
+	// PollInterval is how often the watcher checks tracked transactions' confirmation status
+	PollInterval time.Duration
+	// BumpTier is the FeeTier a stuck transaction is bumped to
+	BumpTier FeeTier
+	// MaxBumps caps how many times a single transaction may be auto-bumped before the
+	// watcher stops trying and just keeps surfacing it via Stuck
+	MaxBumps int
+}
+
+// DefaultMempoolBumpPolicy considers a transaction stuck after 10 minutes unconfirmed,
+// checks every 30 seconds, and bumps to FeeTierFast up to 3 times
+func DefaultMempoolBumpPolicy() MempoolBumpPolicy {
+	return MempoolBumpPolicy{
+		StuckAfter:   10 * time.Minute,
+		PollInterval: 30 * time.Second,
+		BumpTier:     FeeTierFast,
+		MaxBumps:     3,
+	}
+}
+
+// StuckTransaction describes a tracked transaction that has exceeded its policy's
+// StuckAfter window, whether or not it's since been auto-bumped
+type StuckTransaction struct {
+	TxID      string
+	FirstSeen time.Time
+	Bumps     int
+}
+
+// trackedTransaction is a transaction MempoolWatcher is polling for confirmation,
+// alongside the credential it would need to sign a bumped replacement
+type trackedTransaction struct {
+	privateKey string
+	firstSeen  time.Time
+	bumps      int
+}
+
+// MempoolWatcher polls client for confirmation of every transaction passed to Track, and
+// auto-bumps one still unconfirmed past policy.StuckAfter via provider
+type MempoolWatcher struct {
+	client   BlockchainClient
+	provider FeeStrategyProvider
+	policy   MempoolBumpPolicy
+
+	mutex   sync.Mutex
+	pending map[string]trackedTransaction
+	stop    chan struct{}
+}
+
+// NewMempoolWatcher creates a MempoolWatcher over client/provider (typically the same
+// BlockchainClient, which must also implement FeeStrategyProvider). Call Start to begin
+// polling.
+func NewMempoolWatcher(client BlockchainClient, provider FeeStrategyProvider, policy MempoolBumpPolicy) *MempoolWatcher {
+	if policy.PollInterval <= 0 {
+		policy = DefaultMempoolBumpPolicy()
+	}
+	return &MempoolWatcher{
+		client:   client,
+		provider: provider,
+		policy:   policy,
+		pending:  make(map[string]trackedTransaction),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Track registers txID for confirmation polling, remembering privateKey so the watcher can
+// sign a bumped replacement if it gets stuck. Re-tracking an already-tracked txID is a
+// no-op.
+func (w *MempoolWatcher) Track(txID, privateKey string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if _, exists := w.pending[txID]; !exists {
+		w.pending[txID] = trackedTransaction{privateKey: privateKey, firstSeen: time.Now()}
+	}
+}
+
+// Start begins polling tracked transactions on policy.PollInterval, until Stop is called.
+// Start must only be called once per MempoolWatcher.
+func (w *MempoolWatcher) Start() {
+	go w.run()
+}
+
+// Stop ends the watcher's poll loop
+func (w *MempoolWatcher) Stop() {
+	close(w.stop)
+}
+
+func (w *MempoolWatcher) run() {
+	ticker := time.NewTicker(w.policy.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.checkPending()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// checkPending polls every tracked transaction, dropping confirmed ones and bumping the
+// fee of any that have sat unconfirmed past policy.StuckAfter
+func (w *MempoolWatcher) checkPending() {
+	for _, txID := range w.trackedIDs() {
+		confirmations, err := w.client.GetConfirmations(txID)
+		if err != nil {
+			continue
+		}
+		if confirmations > 0 {
+			w.untrack(txID)
+			continue
+		}
+		w.maybeBump(txID)
+	}
+}
+
+func (w *MempoolWatcher) trackedIDs() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	ids := make([]string, 0, len(w.pending))
+	for txID := range w.pending {
+		ids = append(ids, txID)
+	}
+	return ids
+}
+
+func (w *MempoolWatcher) untrack(txID string) {
+	w.mutex.Lock()
+	delete(w.pending, txID)
+	w.mutex.Unlock()
+}
+
+func (w *MempoolWatcher) maybeBump(txID string) {
+	w.mutex.Lock()
+	entry, exists := w.pending[txID]
+	w.mutex.Unlock()
+	if !exists {
+		return
+	}
+	if time.Since(entry.firstSeen) < w.policy.StuckAfter || entry.bumps >= w.policy.MaxBumps {
+		return
+	}
+
+	fee, err := w.provider.EstimateFeeForTier(w.policy.BumpTier)
+	if err != nil {
+		return
+	}
+	newTxID, err := w.provider.FeeBumpTransaction(txID, fee, entry.privateKey)
+	if err != nil {
+		return
+	}
+
+	w.mutex.Lock()
+	delete(w.pending, txID)
+	w.pending[newTxID] = trackedTransaction{privateKey: entry.privateKey, firstSeen: entry.firstSeen, bumps: entry.bumps + 1}
+	w.mutex.Unlock()
+}
+
+// Stuck returns every tracked transaction that has exceeded the policy's StuckAfter
+// window, whether or not it's since been auto-bumped
+func (w *MempoolWatcher) Stuck() []StuckTransaction {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	var stuck []StuckTransaction
+	for txID, entry := range w.pending {
+		if time.Since(entry.firstSeen) >= w.policy.StuckAfter {
+			stuck = append(stuck, StuckTransaction{TxID: txID, FirstSeen: entry.firstSeen, Bumps: entry.bumps})
+		}
+	}
+	return stuck
+}
@@ -0,0 +1,65 @@
+// Multi-signature (M-of-N) wallet support, shared shape across BitcoinClient and
+// EthereumClient
+// BlockchainClient's single-private-key SendTransaction can't express the co-signing a
+// merchant custody flow needs (a user key, a platform key, and a backup key, any two of
+// which must agree to move funds). MultiSigClient is a separate capability interface rather
+// than a change to BlockchainClient itself, the same way AddressIndexer/HistoryProvider/
+// KeyStore are optional add-ons a client may or may not implement.
+
+package blockchain
+
+import (
+	"errors"
+)
+
+// ErrMultiSigWalletNotFound is returned when walletID doesn't match a wallet this client
+// created via CreateMultiSigWallet
+var ErrMultiSigWalletNotFound = errors.New("multisig wallet not found")
+
+// ErrInvalidMultiSigThreshold is returned when m is not between 1 and n inclusive
+var ErrInvalidMultiSigThreshold = errors.New("multisig threshold must satisfy 1 <= m <= n")
+
+// ErrInsufficientSignatures is returned by BroadcastMultiSig when fewer than the wallet's
+// threshold of valid signatures are supplied
+var ErrInsufficientSignatures = errors.New("insufficient signatures to satisfy multisig threshold")
+
+// PartialSignature is one signer's contribution toward satisfying an UnsignedTx's multisig
+// threshold. Signatures holds one signature per UnsignedTx input, in the same order BTC's
+// PSBT inputs (or ETH's single execTransaction call) were built in, since a custody wallet's
+// consolidating spend may cover more than one input.
+type PartialSignature struct {
+	SignerPubKey string
+	Signatures   [][]byte
+}
+
+// UnsignedTx is a multisig spend awaiting enough PartialSignatures to broadcast. Exactly one
+// of BTC or ETH is set, matching which MultiSigClient built it.
+type UnsignedTx struct {
+	WalletID  string
+	ToAddress string
+	Amount    float64
+
+	BTC *btcMultiSigTx
+	ETH *ethMultiSigTx
+}
+
+// MultiSigClient is implemented by a BlockchainClient that also supports M-of-N custody
+// wallets. BitcoinClient backs it with P2WSH redeem scripts; EthereumClient backs it with
+// Gnosis-Safe-compatible contract calls.
+type MultiSigClient interface {
+	// CreateMultiSigWallet registers a new m-of-n wallet controlled by pubKeys (hex-encoded
+	// compressed public keys for Bitcoin, checksummed addresses for Ethereum owners) and
+	// returns its walletID and receiving address
+	CreateMultiSigWallet(m, n int, pubKeys []string) (walletID, address string, err error)
+
+	// BuildTransaction builds an unsigned spend of amount from walletID to toAddress, ready
+	// for each signer to call SignTransaction against
+	BuildTransaction(walletID, toAddress string, amount float64) (*UnsignedTx, error)
+
+	// SignTransaction produces one signer's PartialSignature over unsigned using privateKey
+	SignTransaction(unsigned *UnsignedTx, privateKey string) (*PartialSignature, error)
+
+	// BroadcastMultiSig combines sigs (at least the wallet's threshold, from distinct
+	// signers) onto unsigned and broadcasts the resulting transaction
+	BroadcastMultiSig(unsigned *UnsignedTx, sigs []PartialSignature) (txID string, err error)
+}
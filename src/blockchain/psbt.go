@@ -0,0 +1,440 @@
+// Coin selection and BIP174 PSBT transaction construction for BitcoinClient
+// Replaces SendTransaction's broken ListUnspentMinMaxAddresses(nil) call, fixed 0.0001 BTC
+// fee, and zero-length serialization buffer with a real coin-selection module and a PSBT
+// intermediate representation, so unsigned transactions can be handed off to external
+// signers instead of only ever being signed inline
+
+package blockchain
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// dustThresholdSat is the standard P2PKH dust limit; a change output below this is
+// folded into the fee instead of created
+const dustThresholdSat = 546
+
+// Approximate per-input/output/overhead vsize in vbytes, used to estimate fees before a
+// transaction is fully built. These match a conservative P2PKH/P2WPKH-mixed estimate.
+const (
+	txOverheadVBytes = 10
+	txInputVBytes    = 148
+	txOutputVBytes   = 34
+)
+
+// ErrInsufficientUTXOs is returned when no combination of the given UTXOs covers the
+// requested amount plus fee
+var ErrInsufficientUTXOs = errors.New("insufficient unspent outputs to cover amount and fee")
+
+// defaultFeeRateSatPerVByte is used where no FeeEstimator is wired in yet
+const defaultFeeRateSatPerVByte = 10
+
+// UTXO represents a single unspent transaction output available for spending
+type UTXO struct {
+	TxID     string
+	Vout     uint32
+	Amount   int64 // satoshis
+	PkScript []byte
+	Address  string
+}
+
+// UnspentOutputProvider abstracts how BitcoinClient discovers spendable outputs for an
+// address, decoupling coin selection from a specific RPC call or indexer backend
+type UnspentOutputProvider interface {
+	ListUnspent(address string, minConfirmations int) ([]UTXO, error)
+}
+
+// estimateVSize approximates a transaction's virtual size from its input/output counts
+func estimateVSize(numInputs, numOutputs int) int64 {
+	return txOverheadVBytes + int64(numInputs)*txInputVBytes + int64(numOutputs)*txOutputVBytes
+}
+
+// SelectCoins picks inputs from utxos to cover targetAmount satoshis at
+// feeRateSatPerVByte, preferring a branch-and-bound match that avoids a change output
+// entirely and falling back to a largest-first knapsack otherwise. It returns the
+// selected inputs and the change (0 if the branch-and-bound path found an exact match).
+func SelectCoins(utxos []UTXO, targetAmount, feeRateSatPerVByte int64) (selected []UTXO, changeSat int64, err error) {
+	if targetAmount <= 0 {
+		return nil, 0, errors.New("target amount must be positive")
+	}
+	if len(utxos) == 0 {
+		return nil, 0, ErrInsufficientUTXOs
+	}
+
+	sorted := make([]UTXO, len(utxos))
+	copy(sorted, utxos)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Amount > sorted[j].Amount })
+
+	if result, ok := branchAndBoundSelect(sorted, targetAmount, feeRateSatPerVByte); ok {
+		return result, 0, nil
+	}
+	return knapsackSelect(sorted, targetAmount, feeRateSatPerVByte)
+}
+
+// branchAndBoundSelect searches for a subset of utxos (descending by amount) whose total
+// lands within [target+fee, target+fee+dust] so no change output is needed, the same
+// goal Bitcoin Core's branch-and-bound coin selector optimizes for. The search is capped
+// at a fixed number of tries since the exact problem is NP-hard.
+func branchAndBoundSelect(sorted []UTXO, targetAmount, feeRateSatPerVByte int64) ([]UTXO, bool) {
+	const maxTries = 100000
+	tries := 0
+
+	var best []UTXO
+	current := make([]UTXO, 0, len(sorted))
+
+	var search func(index int, sum int64) bool
+	search = func(index int, sum int64) bool {
+		tries++
+		if tries > maxTries {
+			return false
+		}
+
+		fee := feeRateSatPerVByte * estimateVSize(len(current), 1)
+		if sum >= targetAmount+fee && sum <= targetAmount+fee+dustThresholdSat {
+			best = append(best[:0], current...)
+			return true
+		}
+		if index >= len(sorted) || sum > targetAmount+fee+dustThresholdSat {
+			return false
+		}
+
+		// include sorted[index]
+		current = append(current, sorted[index])
+		if search(index+1, sum+sorted[index].Amount) {
+			return true
+		}
+		current = current[:len(current)-1]
+
+		// exclude sorted[index]
+		return search(index+1, sum)
+	}
+
+	if search(0, 0) {
+		result := make([]UTXO, len(best))
+		copy(result, best)
+		return result, true
+	}
+	return nil, false
+}
+
+// knapsackSelect greedily accumulates the largest UTXOs first until the total covers
+// targetAmount plus the fee for a two-output transaction (destination + change), folding
+// any resulting change below dust into the fee instead of creating a dust output
+func knapsackSelect(sorted []UTXO, targetAmount, feeRateSatPerVByte int64) ([]UTXO, int64, error) {
+	var selected []UTXO
+	var total int64
+
+	for _, u := range sorted {
+		selected = append(selected, u)
+		total += u.Amount
+
+		feeWithChange := feeRateSatPerVByte * estimateVSize(len(selected), 2)
+		if total >= targetAmount+feeWithChange {
+			change := total - targetAmount - feeWithChange
+			if change < dustThresholdSat {
+				feeNoChange := feeRateSatPerVByte * estimateVSize(len(selected), 1)
+				if total >= targetAmount+feeNoChange {
+					return selected, 0, nil
+				}
+				continue
+			}
+			return selected, change, nil
+		}
+	}
+
+	return nil, 0, ErrInsufficientUTXOs
+}
+
+// BuildPSBT constructs an unsigned BIP174 PSBT paying amountSat to toAddress from the
+// given UTXOs, with a change output back to changeAddress when changeSat is above dust
+func BuildPSBT(chainParams *chaincfg.Params, utxos []UTXO, toAddress string, amountSat int64, changeAddress string, changeSat int64) (*psbt.Packet, error) {
+	destAddr, err := btcutil.DecodeAddress(toAddress, chainParams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination address: %w", err)
+	}
+	destScript, err := txscript.PayToAddrScript(destAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build destination script: %w", err)
+	}
+
+	tx := wire.NewMsgTx(2)
+	for _, u := range utxos {
+		hash, err := chainhash.NewHashFromStr(u.TxID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid utxo txid %s: %w", u.TxID, err)
+		}
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, u.Vout), nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(amountSat, destScript))
+
+	if changeSat >= dustThresholdSat {
+		changeAddr, err := btcutil.DecodeAddress(changeAddress, chainParams)
+		if err != nil {
+			return nil, fmt.Errorf("invalid change address: %w", err)
+		}
+		changeScript, err := txscript.PayToAddrScript(changeAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build change script: %w", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(changeSat, changeScript))
+	}
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PSBT: %w", err)
+	}
+	for i, u := range utxos {
+		packet.Inputs[i].WitnessUtxo = &wire.TxOut{Value: u.Amount, PkScript: u.PkScript}
+	}
+	return packet, nil
+}
+
+// SignPSBT signs every input in packet for which keys supplies a WIF-encoded private
+// key, computing the correct sighash per input's previous pkScript (P2PKH, P2SH-wrapped
+// P2WPKH, native P2WPKH, and Taproot key-path spends via BIP340 Schnorr signatures).
+// A P2SH input is always signed as P2SH-wrapped P2WPKH; a keyed input of any other script
+// type (such as P2WSH) makes SignPSBT return an error.
+func SignPSBT(packet *psbt.Packet, keys map[int]string) error {
+	tx := packet.UnsignedTx
+
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, in := range packet.Inputs {
+		if in.WitnessUtxo != nil {
+			fetcher.AddPrevOut(tx.TxIn[i].PreviousOutPoint, in.WitnessUtxo)
+		}
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+
+	for i := range tx.TxIn {
+		wifStr, exists := keys[i]
+		if !exists {
+			continue
+		}
+		if err := signPSBTInput(packet, tx, sigHashes, i, wifStr); err != nil {
+			return fmt.Errorf("failed to sign input %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func signPSBTInput(packet *psbt.Packet, tx *wire.MsgTx, sigHashes *txscript.TxSigHashes, index int, wifStr string) error {
+	wif, err := btcutil.DecodeWIF(wifStr)
+	if err != nil {
+		return fmt.Errorf("invalid private key: %w", err)
+	}
+
+	utxo := packet.Inputs[index].WitnessUtxo
+	if utxo == nil {
+		return errors.New("missing witness utxo for input")
+	}
+
+	switch txscript.GetScriptClass(utxo.PkScript) {
+	case txscript.WitnessV0PubKeyHashTy:
+		witness, err := txscript.WitnessSignature(tx, sigHashes, index, utxo.Value, utxo.PkScript, txscript.SigHashAll, wif.PrivKey, true)
+		if err != nil {
+			return err
+		}
+		packet.Inputs[index].FinalScriptWitness = serializeWitness(witness)
+
+	case txscript.PubKeyHashTy:
+		sigScript, err := txscript.SignatureScript(tx, index, utxo.PkScript, txscript.SigHashAll, wif.PrivKey, true)
+		if err != nil {
+			return err
+		}
+		packet.Inputs[index].FinalScriptSig = sigScript
+
+	case txscript.ScriptHashTy:
+		witnessProgram, err := witnessPubKeyHashProgram(wif.PrivKey.PubKey())
+		if err != nil {
+			return err
+		}
+		witness, err := txscript.WitnessSignature(tx, sigHashes, index, utxo.Value, witnessProgram, txscript.SigHashAll, wif.PrivKey, true)
+		if err != nil {
+			return err
+		}
+		redeemScriptPush, err := txscript.NewScriptBuilder().AddData(witnessProgram).Script()
+		if err != nil {
+			return fmt.Errorf("failed to build P2SH-P2WPKH sigScript: %w", err)
+		}
+		packet.Inputs[index].FinalScriptWitness = serializeWitness(witness)
+		packet.Inputs[index].FinalScriptSig = redeemScriptPush
+
+	case txscript.WitnessV1TaprootTy:
+		witness, err := txscript.TaprootWitnessSignature(tx, sigHashes, index, utxo.Value, utxo.PkScript, txscript.SigHashDefault, wif.PrivKey)
+		if err != nil {
+			return err
+		}
+		packet.Inputs[index].FinalScriptWitness = serializeWitness(witness)
+
+	default:
+		return fmt.Errorf("unsupported script type for single-key signing")
+	}
+	return nil
+}
+
+// serializeWitness encodes a transaction witness stack (as returned by
+// txscript.WitnessSignature) into the flat byte form PSBT's FinalScriptWitness expects
+func serializeWitness(witness wire.TxWitness) []byte {
+	var buf bytes.Buffer
+	wire.WriteVarInt(&buf, 0, uint64(len(witness)))
+	for _, item := range witness {
+		wire.WriteVarBytes(&buf, 0, item)
+	}
+	return buf.Bytes()
+}
+
+// BroadcastPSBT finalizes every signed input in packet, extracts the resulting
+// transaction, and broadcasts it via the Bitcoin RPC client
+func (c *BitcoinClient) BroadcastPSBT(packet *psbt.Packet) (string, error) {
+	for i := range packet.Inputs {
+		if packet.Inputs[i].FinalScriptSig == nil && packet.Inputs[i].FinalScriptWitness == nil {
+			return "", fmt.Errorf("input %d is not signed", i)
+		}
+	}
+
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract final transaction: %w", err)
+	}
+
+	txHash, err := c.client.SendRawTransaction(tx, true)
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast transaction: %w", err)
+	}
+	return txHash.String(), nil
+}
+
+// PrevOut mirrors bitcoind's signrawtransaction "prevtxs" entry: the previous output an
+// input spends, supplied explicitly when the signing node doesn't have that transaction
+// indexed
+type PrevOut struct {
+	TxID         string
+	Vout         uint32
+	ScriptPubKey []byte
+	Amount       int64 // satoshis; required to sign segwit inputs
+}
+
+// SignRawTransaction signs a raw transaction hex string given its previous outputs and a
+// set of WIF-encoded private keys, mirroring bitcoind's signrawtransaction RPC semantics
+// for integrations that already speak that API rather than PSBT
+func (c *BitcoinClient) SignRawTransaction(rawTxHex string, prevOuts []PrevOut, keys []string) (string, error) {
+	txBytes, err := hex.DecodeString(rawTxHex)
+	if err != nil {
+		return "", fmt.Errorf("invalid transaction hex: %w", err)
+	}
+
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return "", fmt.Errorf("failed to deserialize transaction: %w", err)
+	}
+
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	prevScripts := make(map[wire.OutPoint][]byte)
+	for _, po := range prevOuts {
+		hash, err := chainhash.NewHashFromStr(po.TxID)
+		if err != nil {
+			return "", fmt.Errorf("invalid prevout txid %s: %w", po.TxID, err)
+		}
+		outpoint := wire.NewOutPoint(hash, po.Vout)
+		fetcher.AddPrevOut(*outpoint, &wire.TxOut{Value: po.Amount, PkScript: po.ScriptPubKey})
+		prevScripts[*outpoint] = po.ScriptPubKey
+	}
+
+	wifKeys := make([]*btcutil.WIF, 0, len(keys))
+	for _, k := range keys {
+		wif, err := btcutil.DecodeWIF(k)
+		if err != nil {
+			return "", fmt.Errorf("invalid private key: %w", err)
+		}
+		wifKeys = append(wifKeys, wif)
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, fetcher)
+	for i, in := range tx.TxIn {
+		pkScript, exists := prevScripts[in.PreviousOutPoint]
+		if !exists {
+			continue
+		}
+		if err := signRawTxInput(tx, sigHashes, i, pkScript, fetcher, wifKeys, c.chainParams); err != nil {
+			return "", fmt.Errorf("failed to sign input %d: %w", i, err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := tx.Serialize(&out); err != nil {
+		return "", fmt.Errorf("failed to serialize signed transaction: %w", err)
+	}
+	return hex.EncodeToString(out.Bytes()), nil
+}
+
+// signRawTxInput picks whichever of wifKeys actually matches the input's previous pkScript and
+// signs with it, mirroring signrawtransaction's behavior of matching whichever key fits. Unlike
+// a naive trial-and-error loop, it can't pick the wrong key: txscript.WitnessSignature and
+// txscript.SignatureScript don't validate that a given private key matches the target script -
+// they return a syntactically valid signature for any key with a nil error - so the match has
+// to be checked explicitly via pubKeyMatchesPkScript before signing is even attempted.
+func signRawTxInput(tx *wire.MsgTx, sigHashes *txscript.TxSigHashes, index int, pkScript []byte, fetcher txscript.PrevOutputFetcher, wifKeys []*btcutil.WIF, chainParams *chaincfg.Params) error {
+	prevOut := fetcher.FetchPrevOutput(tx.TxIn[index].PreviousOutPoint)
+
+	for _, wif := range wifKeys {
+		if !pubKeyMatchesPkScript(wif, pkScript, chainParams) {
+			continue
+		}
+
+		switch txscript.GetScriptClass(pkScript) {
+		case txscript.WitnessV0PubKeyHashTy:
+			witness, err := txscript.WitnessSignature(tx, sigHashes, index, prevOut.Value, pkScript, txscript.SigHashAll, wif.PrivKey, true)
+			if err != nil {
+				return fmt.Errorf("failed to sign segwit input with matching key: %w", err)
+			}
+			tx.TxIn[index].Witness = witness
+			return nil
+
+		default:
+			sigScript, err := txscript.SignatureScript(tx, index, pkScript, txscript.SigHashAll, wif.PrivKey, true)
+			if err != nil {
+				return fmt.Errorf("failed to sign input with matching key: %w", err)
+			}
+			tx.TxIn[index].SignatureScript = sigScript
+			return nil
+		}
+	}
+	return fmt.Errorf("no supplied key matches this input's previous output")
+}
+
+// pubKeyMatchesPkScript reports whether wif's public key is the one pkScript actually pays to
+// (a P2PKH or P2WPKH pubkey hash), the same check a real signrawtransaction implementation does
+// before picking a candidate key for a given input
+func pubKeyMatchesPkScript(wif *btcutil.WIF, pkScript []byte, chainParams *chaincfg.Params) bool {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(pkScript, chainParams)
+	if err != nil || len(addrs) != 1 {
+		return false
+	}
+
+	var pubKeyHash []byte
+	switch addr := addrs[0].(type) {
+	case *btcutil.AddressPubKeyHash:
+		pubKeyHash = addr.Hash160()[:]
+	case *btcutil.AddressWitnessPubKeyHash:
+		pubKeyHash = addr.Hash160()[:]
+	default:
+		return false
+	}
+
+	serializedPubKey := wif.PrivKey.PubKey().SerializeUncompressed()
+	if wif.CompressPubKey {
+		serializedPubKey = wif.PrivKey.PubKey().SerializeCompressed()
+	}
+	return bytes.Equal(pubKeyHash, btcutil.Hash160(serializedPubKey))
+}
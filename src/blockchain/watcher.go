@@ -0,0 +1,255 @@
+// Real-time payment watcher for the Ethereum blockchain client
+// Replaces polling GetConfirmations with push-based settlement: subscribes to new
+// heads and Transfer logs over a WebSocket endpoint and emits typed payment events,
+// including reorg detection for merchant checkout flows
+
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// PaymentEventType identifies the kind of event emitted by a PaymentWatcher
+type PaymentEventType string
+
+const (
+	// EventPaymentSeen indicates a matching transfer was observed, unconfirmed
+	EventPaymentSeen PaymentEventType = "payment_seen"
+	// EventPaymentConfirmed indicates a transfer reached the configured confirmation depth
+	EventPaymentConfirmed PaymentEventType = "payment_confirmed"
+	// EventPaymentReorged indicates a previously seen transfer's block was reorganized out
+	EventPaymentReorged PaymentEventType = "payment_reorged"
+)
+
+// PaymentEvent describes a deposit-address payment observed by a PaymentWatcher
+type PaymentEvent struct {
+	Type          PaymentEventType
+	TxID          string
+	Address       string
+	Currency      string // a registered token symbol; native ETH transfers are not reported
+	Amount        float64
+	BlockNumber   uint64
+	BlockHash     string
+	Confirmations uint64
+}
+
+// watchedPayment tracks a transfer the watcher has already emitted PaymentSeen for,
+// so it can detect reorgs and fire PaymentConfirmed once it is deep enough
+type watchedPayment struct {
+	event       PaymentEvent
+	blockNumber uint64
+	blockHash   common.Hash
+}
+
+// recentBlockWindow is how many recent block heights PaymentWatcher keeps hashes for
+const recentBlockWindow = 128
+
+// PaymentWatcher subscribes to new blocks and Transfer logs for a set of deposit
+// addresses and ERC-20 contracts, and pushes typed events as payments settle
+type PaymentWatcher struct {
+	client            *EthereumClient
+	confirmationDepth uint64
+	addresses         map[common.Address]bool
+	events            chan PaymentEvent
+
+	mutex        sync.Mutex
+	pending      map[common.Hash]*watchedPayment // txHash -> payment awaiting confirmation depth
+	recentBlocks [recentBlockWindow]common.Hash  // ring buffer of recent block hashes, indexed by height % recentBlockWindow
+	recentHeight uint64
+
+	cancel context.CancelFunc
+}
+
+// NewPaymentWatcher creates a watcher that emits events on the returned channel once
+// Start is called. confirmationDepth controls how many blocks must pass before a seen
+// payment is reported as confirmed.
+func (c *EthereumClient) NewPaymentWatcher(confirmationDepth uint64) *PaymentWatcher {
+	return &PaymentWatcher{
+		client:            c,
+		confirmationDepth: confirmationDepth,
+		addresses:         make(map[common.Address]bool),
+		events:            make(chan PaymentEvent, 256),
+		pending:           make(map[common.Hash]*watchedPayment),
+	}
+}
+
+// Watch registers a deposit address to watch for incoming transfers of the client's
+// registered ERC-20 tokens; native ETH transfers to it are not detected
+func (w *PaymentWatcher) Watch(address string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.addresses[common.HexToAddress(address)] = true
+}
+
+// Events returns the channel PaymentSeen/PaymentConfirmed/PaymentReorged events arrive on
+func (w *PaymentWatcher) Events() <-chan PaymentEvent {
+	return w.events
+}
+
+// Start subscribes to new block headers and ERC-20 Transfer logs for the watcher's
+// registered token contracts, and begins emitting payment events. It runs until the
+// context is cancelled or Stop is called.
+func (w *PaymentWatcher) Start(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+
+	headCh := make(chan *types.Header)
+	headSub, err := w.client.client.SubscribeNewHead(ctx, headCh)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to subscribe to new heads: %w", err)
+	}
+
+	var contracts []common.Address
+	for _, token := range w.client.tokens {
+		contracts = append(contracts, common.HexToAddress(token.ContractAddress))
+	}
+
+	logCh := make(chan types.Log)
+	var logSub ethereum.Subscription
+	if len(contracts) > 0 {
+		query := ethereum.FilterQuery{
+			Addresses: contracts,
+			Topics:    [][]common.Hash{{common.HexToHash("0x" + erc20TransferEventSig)}},
+		}
+		logSub, err = w.client.client.SubscribeFilterLogs(ctx, query, logCh)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("failed to subscribe to transfer logs: %w", err)
+		}
+	}
+
+	go w.run(ctx, headCh, headSub, logCh, logSub)
+	return nil
+}
+
+// Stop cancels the underlying subscriptions and stops emitting events
+func (w *PaymentWatcher) Stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+func (w *PaymentWatcher) run(ctx context.Context, headCh chan *types.Header, headSub ethereum.Subscription, logCh chan types.Log, logSub ethereum.Subscription) {
+	defer close(w.events)
+	defer headSub.Unsubscribe()
+	if logSub != nil {
+		defer logSub.Unsubscribe()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-headSub.Err():
+			if err != nil {
+				return
+			}
+		case header := <-headCh:
+			w.onNewHead(header)
+		case vlog := <-logCh:
+			w.onTokenTransferLog(vlog)
+		}
+	}
+}
+
+// onNewHead detects chain reorganizations by comparing the new header against the
+// ring buffer of previously seen block hashes at the same height, and advances
+// already-seen payments toward their confirmation depth
+func (w *PaymentWatcher) onNewHead(header *types.Header) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	height := header.Number.Uint64()
+
+	// Detect a reorg: if we already recorded a hash at this height and it differs
+	// from the incoming header, every payment anchored to the old hash is reorged out
+	slot := height % recentBlockWindow
+	if height <= w.recentHeight && w.recentHeight-height < recentBlockWindow {
+		if previous := w.recentBlocks[slot]; previous != (common.Hash{}) && previous != header.Hash() {
+			w.reorgBlock(height, previous)
+		}
+	}
+
+	w.recentBlocks[slot] = header.Hash()
+	w.recentHeight = height
+
+	for txHash, pending := range w.pending {
+		if height < pending.blockNumber {
+			continue
+		}
+		confirmations := height - pending.blockNumber
+		if confirmations >= w.confirmationDepth {
+			pending.event.Type = EventPaymentConfirmed
+			pending.event.Confirmations = confirmations
+			w.events <- pending.event
+			delete(w.pending, txHash)
+		}
+	}
+}
+
+// reorgBlock emits PaymentReorged for every pending payment anchored to the
+// superseded block hash at the given height
+func (w *PaymentWatcher) reorgBlock(height uint64, oldHash common.Hash) {
+	for txHash, pending := range w.pending {
+		if pending.blockHash == oldHash {
+			pending.event.Type = EventPaymentReorged
+			w.events <- pending.event
+			delete(w.pending, txHash)
+		}
+	}
+}
+
+// onTokenTransferLog decodes an ERC-20 Transfer log and, if it pays into a watched
+// address, records it as seen and emits PaymentSeen
+func (w *PaymentWatcher) onTokenTransferLog(vlog types.Log) {
+	if len(vlog.Topics) != 3 {
+		return
+	}
+	to := common.BytesToAddress(vlog.Topics[2].Bytes())
+
+	w.mutex.Lock()
+	watched := w.addresses[to]
+	w.mutex.Unlock()
+	if !watched {
+		return
+	}
+
+	var symbol string
+	var decimals int
+	for sym, token := range w.client.tokens {
+		if strings.EqualFold(token.ContractAddress, vlog.Address.Hex()) {
+			symbol = sym
+			decimals = token.Decimals
+			break
+		}
+	}
+	if symbol == "" {
+		return
+	}
+
+	amount := tokenAmountToFloat(new(big.Int).SetBytes(vlog.Data), decimals)
+	event := PaymentEvent{
+		Type:        EventPaymentSeen,
+		TxID:        vlog.TxHash.Hex(),
+		Address:     to.Hex(),
+		Currency:    symbol,
+		Amount:      amount,
+		BlockNumber: vlog.BlockNumber,
+		BlockHash:   vlog.BlockHash.Hex(),
+	}
+
+	w.mutex.Lock()
+	w.pending[vlog.TxHash] = &watchedPayment{event: event, blockNumber: vlog.BlockNumber, blockHash: vlog.BlockHash}
+	w.mutex.Unlock()
+
+	w.events <- event
+}
@@ -0,0 +1,129 @@
+// Binance doesn't quote fiat pairs directly, so a crypto<->fiat rate routes through USDT as
+// a stable-dollar proxy, same as exchange.BinanceProvider
+
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// binanceTickerPrice is the shape of Binance's GET /api/v3/ticker/price response
+type binanceTickerPrice struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+// BinanceProvider is a RateProvider backed by Binance's public ticker/price endpoint
+type BinanceProvider struct {
+	apiURL     string
+	httpClient *http.Client
+	quoter     *quoter
+}
+
+// NewBinanceProvider creates a BinanceProvider
+func NewBinanceProvider() (*BinanceProvider, error) {
+	q, err := newQuoter()
+	if err != nil {
+		return nil, err
+	}
+	return &BinanceProvider{
+		apiURL:     "https://api.binance.com/api/v3",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		quoter:     q,
+	}, nil
+}
+
+// GetName returns the provider's identifier
+func (p *BinanceProvider) GetName() string {
+	return "binance"
+}
+
+// GetRate returns the current rate converting one unit of from into to, via a USDT-routed
+// cross rate (e.g. BTC -> SGD goes through BTC/USDT and SGD/USDT)
+func (p *BinanceProvider) GetRate(from, to string) (Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	fromToUSDT, err := p.rateToUSDT(from)
+	if err != nil {
+		return Rate{}, err
+	}
+	toToUSDT, err := p.rateToUSDT(to)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return Rate{From: from, To: to, Value: fromToUSDT / toToUSDT, Source: p.GetName(), Timestamp: time.Now()}, nil
+}
+
+// rateToUSDT returns currency's price in USDT, treating USDT/USD/USDC itself as parity
+func (p *BinanceProvider) rateToUSDT(currency string) (float64, error) {
+	if currency == "USDT" || currency == "USD" || currency == "USDC" {
+		return 1.0, nil
+	}
+	return p.symbolPrice(currency, "USDT")
+}
+
+// symbolPrice fetches the ticker price for base+target (e.g. BTCUSDT), trying the direct
+// symbol first and its inverse second
+func (p *BinanceProvider) symbolPrice(base, target string) (float64, error) {
+	if price, err := p.fetchSymbol(base + target); err == nil {
+		return price, nil
+	}
+	price, err := p.fetchSymbol(target + base)
+	if err != nil {
+		return 0, err
+	}
+	return 1.0 / price, nil
+}
+
+func (p *BinanceProvider) fetchSymbol(symbol string) (float64, error) {
+	url := fmt.Sprintf("%s/ticker/price?symbol=%s", p.apiURL, symbol)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build binance request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("binance request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read binance response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("binance returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var ticker binanceTickerPrice
+	if err := json.Unmarshal(body, &ticker); err != nil {
+		return 0, fmt.Errorf("failed to parse binance response for %s: %w", symbol, err)
+	}
+	price, err := strconv.ParseFloat(ticker.Price, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse binance price for %s: %w", symbol, err)
+	}
+	return price, nil
+}
+
+// Quote locks GetRate's current rate for ttl
+func (p *BinanceProvider) Quote(from, to string, amount float64, ttl time.Duration) (*Quote, error) {
+	rate, err := p.GetRate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return p.quoter.issue(rate, amount, ttl)
+}
+
+// Redeem validates and consumes a token previously issued by this provider's Quote
+func (p *BinanceProvider) Redeem(token string) (*Quote, error) {
+	return p.quoter.Redeem(token)
+}
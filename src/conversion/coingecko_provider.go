@@ -0,0 +1,117 @@
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// coinGeckoIDs maps a crypto currency code to CoinGecko's coin id
+var coinGeckoIDs = map[string]string{
+	"BTC":  "bitcoin",
+	"ETH":  "ethereum",
+	"USDT": "tether",
+	"USDC": "usd-coin",
+}
+
+// CoinGeckoProvider is a RateProvider backed by the CoinGecko simple price API
+type CoinGeckoProvider struct {
+	apiURL     string
+	httpClient *http.Client
+	quoter     *quoter
+}
+
+// NewCoinGeckoProvider creates a CoinGeckoProvider
+func NewCoinGeckoProvider() (*CoinGeckoProvider, error) {
+	q, err := newQuoter()
+	if err != nil {
+		return nil, err
+	}
+	return &CoinGeckoProvider{
+		apiURL:     "https://api.coingecko.com/api/v3",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		quoter:     q,
+	}, nil
+}
+
+// GetName returns the provider's identifier
+func (p *CoinGeckoProvider) GetName() string {
+	return "coingecko"
+}
+
+// GetRate returns the current rate converting one unit of from into to. One side must be a
+// crypto currency (BTC, ETH, USDT, USDC) and the other a fiat currency code CoinGecko
+// recognizes (e.g. SGD, THB, JPY, USD).
+func (p *CoinGeckoProvider) GetRate(from, to string) (Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	if TypeOf(from) == AssetTypeCrypto {
+		value, err := p.spotPrice(from, to)
+		if err != nil {
+			return Rate{}, err
+		}
+		return Rate{From: from, To: to, Value: value, Source: p.GetName(), Timestamp: time.Now()}, nil
+	}
+
+	// fiat -> crypto: fetch the inverse spot price and invert it
+	value, err := p.spotPrice(to, from)
+	if err != nil {
+		return Rate{}, err
+	}
+	return Rate{From: from, To: to, Value: 1 / value, Source: p.GetName(), Timestamp: time.Now()}, nil
+}
+
+// spotPrice returns the price of one unit of cryptoCurrency denominated in fiatCurrency
+func (p *CoinGeckoProvider) spotPrice(cryptoCurrency, fiatCurrency string) (float64, error) {
+	coinID, ok := coinGeckoIDs[cryptoCurrency]
+	if !ok {
+		return 0, ErrProviderFailed
+	}
+
+	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=%s", p.apiURL, coinID, strings.ToLower(fiatCurrency))
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build coingecko request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("coingecko request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read coingecko response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("coingecko returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var parsed map[string]map[string]float64
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return 0, fmt.Errorf("failed to parse coingecko response: %w", err)
+	}
+	price, ok := parsed[coinID][strings.ToLower(fiatCurrency)]
+	if !ok {
+		return 0, ErrProviderFailed
+	}
+	return price, nil
+}
+
+// Quote locks GetRate's current rate for ttl
+func (p *CoinGeckoProvider) Quote(from, to string, amount float64, ttl time.Duration) (*Quote, error) {
+	rate, err := p.GetRate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return p.quoter.issue(rate, amount, ttl)
+}
+
+// Redeem validates and consumes a token previously issued by this provider's Quote
+func (p *CoinGeckoProvider) Redeem(token string) (*Quote, error) {
+	return p.quoter.Redeem(token)
+}
@@ -0,0 +1,337 @@
+// Conversion package for Asian Cryptocurrency Payment System
+// Provides fiat<->crypto rate quoting so a merchant can price an order in fiat (SGD, THB,
+// JPY, USD, ...) and settle in crypto (BTC, ETH, USDT, USDC) via a BlockchainClient. A Quote
+// locks a RateProvider's rate for a bounded TTL behind a signed, single-use token; an
+// expired or already-redeemed token forces the caller to re-quote rather than settle at a
+// stale rate.
+
+package conversion
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Common errors
+var (
+	ErrQuoteNotFound  = errors.New("conversion quote not found or expired")
+	ErrQuoteExpired   = errors.New("conversion quote has expired")
+	ErrQuoteRedeemed  = errors.New("conversion quote has already been redeemed")
+	ErrSpreadTooWide  = errors.New("conversion rate providers disagree by more than the allowed spread")
+	ErrNoProviders    = errors.New("composite provider has no underlying providers configured")
+	ErrProviderFailed = errors.New("rate provider failed to return a rate")
+)
+
+// AssetType classifies a currency code as fiat or crypto, for ConversionRecord's
+// from_type/to_type reconciliation fields
+type AssetType string
+
+const (
+	AssetTypeFiat   AssetType = "fiat"
+	AssetTypeCrypto AssetType = "crypto"
+)
+
+// cryptoCurrencies are the settlement currencies this package treats as crypto; anything
+// else passed as a currency code is treated as fiat
+var cryptoCurrencies = map[string]bool{
+	"BTC":  true,
+	"ETH":  true,
+	"USDT": true,
+	"USDC": true,
+}
+
+// TypeOf classifies currency as fiat or crypto
+func TypeOf(currency string) AssetType {
+	if cryptoCurrencies[strings.ToUpper(currency)] {
+		return AssetTypeCrypto
+	}
+	return AssetTypeFiat
+}
+
+// Rate is a point-in-time exchange rate between two currencies, as reported by a
+// RateProvider. Value converts one unit of From into To (amount_in_to = amount_in_from *
+// Value).
+type Rate struct {
+	From      string
+	To        string
+	Value     float64
+	Source    string
+	Timestamp time.Time
+}
+
+// Quote locks a Rate for a bounded window. Token must be presented to Redeem before
+// ExpiresAt to confirm the conversion; Redeem consumes the Quote so the same Token can't
+// settle twice.
+type Quote struct {
+	QuoteID         string
+	Token           string
+	FromCurrency    string
+	ToCurrency      string
+	FromType        AssetType
+	ToType          AssetType
+	Amount          float64
+	ConvertedAmount float64
+	Rate            float64
+	Source          string
+	IssuedAt        time.Time
+	ExpiresAt       time.Time
+	redeemed        bool
+}
+
+// RateProvider is a fiat<->crypto rate source that can both report a spot Rate and lock
+// one into a redeemable Quote
+type RateProvider interface {
+	// GetName returns the provider's identifier, used as Rate.Source/Quote.Source
+	GetName() string
+	// GetRate returns the current spot rate converting one unit of from into to
+	GetRate(from, to string) (Rate, error)
+	// Quote locks GetRate's current rate for ttl, returning a Quote whose Token must be
+	// redeemed (via Redeem) before it expires
+	Quote(from, to string, amount float64, ttl time.Duration) (*Quote, error)
+}
+
+// quoter implements the token-signing and in-memory bookkeeping shared by every
+// RateProvider's Quote/Redeem pair, so a concrete provider only has to implement GetRate
+type quoter struct {
+	secret []byte
+	mutex  sync.Mutex
+	quotes map[string]*Quote
+}
+
+// newQuoter creates a quoter with a fresh random HMAC secret
+func newQuoter() (*quoter, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate quote signing secret: %w", err)
+	}
+	return &quoter{secret: secret, quotes: make(map[string]*Quote)}, nil
+}
+
+// quoteID generates a fresh random identifier for a new Quote
+func quoteID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate quote id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// issue builds and stores a Quote from rate, signing its redemption token
+func (q *quoter) issue(rate Rate, amount float64, ttl time.Duration) (*Quote, error) {
+	id, err := quoteID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	quote := &Quote{
+		QuoteID:         id,
+		Token:           q.sign(id),
+		FromCurrency:    rate.From,
+		ToCurrency:      rate.To,
+		FromType:        TypeOf(rate.From),
+		ToType:          TypeOf(rate.To),
+		Amount:          amount,
+		ConvertedAmount: amount * rate.Value,
+		Rate:            rate.Value,
+		Source:          rate.Source,
+		IssuedAt:        now,
+		ExpiresAt:       now.Add(ttl),
+	}
+	q.mutex.Lock()
+	q.quotes[id] = quote
+	q.mutex.Unlock()
+	return quote, nil
+}
+
+// sign returns id's signed redemption token
+func (q *quoter) sign(id string) string {
+	mac := hmac.New(sha256.New, q.secret)
+	mac.Write([]byte(id))
+	return id + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Redeem validates token's signature and returns its Quote if unexpired and not already
+// redeemed, consuming it so the same token can't settle a second conversion
+func (q *quoter) Redeem(token string) (*Quote, error) {
+	id, ok := q.verify(token)
+	if !ok {
+		return nil, ErrQuoteNotFound
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	quote, exists := q.quotes[id]
+	if !exists {
+		return nil, ErrQuoteNotFound
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		delete(q.quotes, id)
+		return nil, ErrQuoteExpired
+	}
+	if quote.redeemed {
+		return nil, ErrQuoteRedeemed
+	}
+	quote.redeemed = true
+	return quote, nil
+}
+
+func (q *quoter) verify(token string) (string, bool) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	id, sigHex := parts[0], parts[1]
+	sig, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return "", false
+	}
+	mac := hmac.New(sha256.New, q.secret)
+	mac.Write([]byte(id))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return "", false
+	}
+	return id, true
+}
+
+// CompositeProvider combines several RateProviders, reporting the median of their quoted
+// rates and rejecting if they disagree by more than SpreadThreshold
+type CompositeProvider struct {
+	providers       []RateProvider
+	spreadThreshold float64
+	quoter          *quoter
+}
+
+// NewCompositeProvider builds a CompositeProvider over providers, rejecting a GetRate/Quote
+// call if the providers' rates spread (max-min)/median exceeds spreadThreshold (e.g. 0.02
+// for 2%)
+func NewCompositeProvider(providers []RateProvider, spreadThreshold float64) (*CompositeProvider, error) {
+	if len(providers) == 0 {
+		return nil, ErrNoProviders
+	}
+	q, err := newQuoter()
+	if err != nil {
+		return nil, err
+	}
+	return &CompositeProvider{providers: providers, spreadThreshold: spreadThreshold, quoter: q}, nil
+}
+
+// GetName returns the composite provider's identifier
+func (c *CompositeProvider) GetName() string {
+	return "composite"
+}
+
+// GetRate queries every underlying provider for from/to, returning the median value and
+// rejecting with ErrSpreadTooWide if the providers disagree by more than SpreadThreshold
+func (c *CompositeProvider) GetRate(from, to string) (Rate, error) {
+	values := make([]float64, 0, len(c.providers))
+	for _, provider := range c.providers {
+		rate, err := provider.GetRate(from, to)
+		if err != nil {
+			continue
+		}
+		values = append(values, rate.Value)
+	}
+	if len(values) == 0 {
+		return Rate{}, ErrProviderFailed
+	}
+
+	sort.Float64s(values)
+	median := medianOf(values)
+	spread := values[len(values)-1] - values[0]
+	if median > 0 && spread/median > c.spreadThreshold {
+		return Rate{}, ErrSpreadTooWide
+	}
+
+	return Rate{From: from, To: to, Value: median, Source: c.GetName(), Timestamp: time.Now()}, nil
+}
+
+// Quote locks GetRate's median rate for ttl
+func (c *CompositeProvider) Quote(from, to string, amount float64, ttl time.Duration) (*Quote, error) {
+	rate, err := c.GetRate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return c.quoter.issue(rate, amount, ttl)
+}
+
+// Redeem validates and consumes a token previously issued by this provider's Quote
+func (c *CompositeProvider) Redeem(token string) (*Quote, error) {
+	return c.quoter.Redeem(token)
+}
+
+func medianOf(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// ConversionRecord is an audit-trail entry for a settled Quote, for fiat/crypto
+// reconciliation
+type ConversionRecord struct {
+	ID              string
+	QuoteID         string
+	FromCurrency    string
+	ToCurrency      string
+	FromType        AssetType
+	ToType          AssetType
+	Amount          float64
+	ConvertedAmount float64
+	Rate            float64
+	Source          string
+	RecordedAt      time.Time
+}
+
+// ConversionLedger records ConversionRecords for later reconciliation, e.g. against a
+// merchant's settlement reports
+type ConversionLedger struct {
+	mutex   sync.RWMutex
+	records []ConversionRecord
+}
+
+// NewConversionLedger creates an empty in-memory ConversionLedger
+func NewConversionLedger() *ConversionLedger {
+	return &ConversionLedger{}
+}
+
+// Record appends an audit entry for a redeemed quote
+func (l *ConversionLedger) Record(quote *Quote) ConversionRecord {
+	id, err := quoteID()
+	if err != nil {
+		id = quote.QuoteID
+	}
+	record := ConversionRecord{
+		ID:              id,
+		QuoteID:         quote.QuoteID,
+		FromCurrency:    quote.FromCurrency,
+		ToCurrency:      quote.ToCurrency,
+		FromType:        quote.FromType,
+		ToType:          quote.ToType,
+		Amount:          quote.Amount,
+		ConvertedAmount: quote.ConvertedAmount,
+		Rate:            quote.Rate,
+		Source:          quote.Source,
+		RecordedAt:      time.Now(),
+	}
+	l.mutex.Lock()
+	l.records = append(l.records, record)
+	l.mutex.Unlock()
+	return record
+}
+
+// Records returns a copy of every entry recorded so far
+func (l *ConversionLedger) Records() []ConversionRecord {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	out := make([]ConversionRecord, len(l.records))
+	copy(out, l.records)
+	return out
+}
@@ -0,0 +1,157 @@
+// Kraken doesn't quote fiat-to-fiat pairs, so a crypto<->fiat rate routes through USD as an
+// intermediate, same as exchange.KrakenProvider
+
+package conversion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// krakenAssetCode maps a common currency code to the Kraken asset code used in its pair
+// names, for the handful of assets that differ (Kraken quirkily calls Bitcoin "XBT")
+var krakenAssetCode = map[string]string{
+	"BTC": "XBT",
+}
+
+// krakenTickerResponse is the shape of Kraken's GET /0/public/Ticker response. Result is
+// keyed by Kraken's own pair name (e.g. "XXBTZUSD"), which doesn't always match the
+// requested pair string, so fetchPair reads whichever single entry comes back rather than
+// indexing by the request pair.
+type krakenTickerResponse struct {
+	Error  []string                         `json:"error"`
+	Result map[string]krakenTickerAssetPair `json:"result"`
+}
+
+type krakenTickerAssetPair struct {
+	// C is the [last trade price, lot volume] pair Kraken's ticker returns
+	C []string `json:"c"`
+}
+
+// KrakenProvider is a RateProvider backed by Kraken's public Ticker endpoint
+type KrakenProvider struct {
+	apiURL     string
+	httpClient *http.Client
+	quoter     *quoter
+}
+
+// NewKrakenProvider creates a KrakenProvider
+func NewKrakenProvider() (*KrakenProvider, error) {
+	q, err := newQuoter()
+	if err != nil {
+		return nil, err
+	}
+	return &KrakenProvider{
+		apiURL:     "https://api.kraken.com/0/public",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		quoter:     q,
+	}, nil
+}
+
+// GetName returns the provider's identifier
+func (p *KrakenProvider) GetName() string {
+	return "kraken"
+}
+
+// GetRate returns the current rate converting one unit of from into to, via a USD-routed
+// cross rate
+func (p *KrakenProvider) GetRate(from, to string) (Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	fromToUSD, err := p.rateToUSD(from)
+	if err != nil {
+		return Rate{}, err
+	}
+	toToUSD, err := p.rateToUSD(to)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return Rate{From: from, To: to, Value: fromToUSD / toToUSD, Source: p.GetName(), Timestamp: time.Now()}, nil
+}
+
+func (p *KrakenProvider) rateToUSD(currency string) (float64, error) {
+	if currency == "USD" || currency == "USDT" || currency == "USDC" {
+		return 1.0, nil
+	}
+	return p.pairPrice(currency, "USD")
+}
+
+// pairPrice fetches the ticker price for base/target, trying the direct pair first and its
+// inverse second
+func (p *KrakenProvider) pairPrice(base, target string) (float64, error) {
+	if price, err := p.fetchPair(base, target); err == nil {
+		return price, nil
+	}
+	price, err := p.fetchPair(target, base)
+	if err != nil {
+		return 0, err
+	}
+	return 1.0 / price, nil
+}
+
+func (p *KrakenProvider) fetchPair(base, target string) (float64, error) {
+	pair := p.assetCode(base) + p.assetCode(target)
+	url := fmt.Sprintf("%s/Ticker?pair=%s", p.apiURL, pair)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to build kraken request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("kraken request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read kraken response: %w", err)
+	}
+
+	var ticker krakenTickerResponse
+	if err := json.Unmarshal(body, &ticker); err != nil {
+		return 0, fmt.Errorf("failed to parse kraken response for %s: %w", pair, err)
+	}
+	if len(ticker.Error) > 0 {
+		return 0, fmt.Errorf("%w: %s", ErrProviderFailed, strings.Join(ticker.Error, "; "))
+	}
+
+	for _, assetPair := range ticker.Result {
+		if len(assetPair.C) == 0 {
+			continue
+		}
+		price, err := strconv.ParseFloat(assetPair.C[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse kraken price for %s: %w", pair, err)
+		}
+		return price, nil
+	}
+	return 0, ErrProviderFailed
+}
+
+func (p *KrakenProvider) assetCode(currency string) string {
+	if code, ok := krakenAssetCode[currency]; ok {
+		return code
+	}
+	return currency
+}
+
+// Quote locks GetRate's current rate for ttl
+func (p *KrakenProvider) Quote(from, to string, amount float64, ttl time.Duration) (*Quote, error) {
+	rate, err := p.GetRate(from, to)
+	if err != nil {
+		return nil, err
+	}
+	return p.quoter.issue(rate, amount, ttl)
+}
+
+// Redeem validates and consumes a token previously issued by this provider's Quote
+func (p *KrakenProvider) Redeem(token string) (*Quote, error) {
+	return p.quoter.Redeem(token)
+}
@@ -0,0 +1,135 @@
+// Package crypto provides an encrypted-at-rest column type for the database package.
+// Wallet.PrivateKey, Merchant.APISecret, and Merchant.WebhookSecret were annotated
+// "Encrypted" in comments while actually being stored as plain text; EncryptedString closes
+// that gap by transparently encrypting on write and decrypting on read, with the actual key
+// material supplied by a pluggable KeyProvider (a static env-var key, AWS KMS envelope
+// encryption, or HashiCorp Vault transit) so a deployment can choose its own key custody
+// model without touching column types.
+package crypto
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+// ErrNoKeyProvider is returned when EncryptedString.Value/Scan run before a KeyProvider has
+// been registered via SetKeyProvider
+var ErrNoKeyProvider = errors.New("crypto: no KeyProvider registered")
+
+// KeyProvider encrypts and decrypts column plaintext. Each implementation's ciphertext is
+// fully self-describing (it carries whatever key material or version metadata it needs to
+// decrypt itself later), so EncryptedString never has to know which provider produced it.
+type KeyProvider interface {
+	Encrypt(ctx context.Context, plaintext []byte) (ciphertext []byte, err error)
+	Decrypt(ctx context.Context, ciphertext []byte) (plaintext []byte, err error)
+}
+
+var currentProvider KeyProvider
+
+// SetKeyProvider registers the KeyProvider EncryptedString columns encrypt and decrypt
+// through. Call this once at startup before any encrypted column is read or written.
+func SetKeyProvider(provider KeyProvider) {
+	currentProvider = provider
+}
+
+// CurrentKeyProvider returns the KeyProvider registered via SetKeyProvider
+func CurrentKeyProvider() KeyProvider {
+	return currentProvider
+}
+
+// EncryptedString is a string column that's encrypted under the registered KeyProvider on
+// write and decrypted on read. Its Go-side value is always the plaintext; only the stored
+// database value is ciphertext.
+type EncryptedString string
+
+// GormDataType tells GORM to store EncryptedString as a text column
+func (EncryptedString) GormDataType() string {
+	return "text"
+}
+
+// Value encrypts e under the registered KeyProvider, returning it base64-encoded for storage
+func (e EncryptedString) Value() (driver.Value, error) {
+	if e == "" {
+		return nil, nil
+	}
+	if currentProvider == nil {
+		return nil, ErrNoKeyProvider
+	}
+	ciphertext, err := currentProvider.Encrypt(context.Background(), []byte(e))
+	if err != nil {
+		return nil, fmt.Errorf("crypto: failed to encrypt column: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Scan decrypts a base64-encoded ciphertext column value under the registered KeyProvider
+func (e *EncryptedString) Scan(value interface{}) error {
+	if value == nil {
+		*e = ""
+		return nil
+	}
+
+	var encoded string
+	switch v := value.(type) {
+	case string:
+		encoded = v
+	case []byte:
+		encoded = string(v)
+	default:
+		return fmt.Errorf("crypto: cannot scan %T into EncryptedString", value)
+	}
+	if encoded == "" {
+		*e = ""
+		return nil
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("crypto: failed to decode stored ciphertext: %w", err)
+	}
+	if currentProvider == nil {
+		return ErrNoKeyProvider
+	}
+	plaintext, err := currentProvider.Decrypt(context.Background(), ciphertext)
+	if err != nil {
+		return fmt.Errorf("crypto: failed to decrypt column: %w", err)
+	}
+	*e = EncryptedString(plaintext)
+	return nil
+}
+
+// serializerName is the name a plain string field registers this package's encryption under
+// via the struct tag gorm:"serializer:aesenvelope", for models that prefer not to change
+// their field's Go type to EncryptedString
+const serializerName = "aesenvelope"
+
+// aesEnvelopeSerializer implements schema.SerializerInterface, letting a plain string field
+// opt into the same encrypt-on-write/decrypt-on-read behavior as EncryptedString via a
+// struct tag instead of changing its Go type
+type aesEnvelopeSerializer struct{}
+
+func init() {
+	schema.RegisterSerializer(serializerName, aesEnvelopeSerializer{})
+}
+
+// Scan implements schema.SerializerInterface
+func (aesEnvelopeSerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var encrypted EncryptedString
+	if err := encrypted.Scan(dbValue); err != nil {
+		return err
+	}
+	field.ReflectValueOf(ctx, dst).SetString(string(encrypted))
+	return nil
+}
+
+// Value implements schema.SerializerInterface
+func (aesEnvelopeSerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	str, _ := fieldValue.(string)
+	return EncryptedString(str).Value()
+}
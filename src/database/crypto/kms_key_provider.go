@@ -0,0 +1,191 @@
+package crypto
+
+import (
+	"container/list"
+	"context"
+	"crypto/aes"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// kmsClient is the subset of *kms.Client AWSKMSKeyProvider needs, narrowed for testability
+type kmsClient interface {
+	GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error)
+	Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error)
+}
+
+// AWSKMSKeyProvider encrypts with per-row AES-256-GCM data encryption keys (DEKs), each
+// wrapped by a KMS customer master key (the KEK). The wrapped DEK travels alongside its
+// ciphertext as a sidecar within the same stored value, so unwrapping it only needs the
+// column's own bytes plus a KMS call; an LRU cache of already-unwrapped DEKs keeps repeat
+// reads of the same row off the KMS API.
+type AWSKMSKeyProvider struct {
+	client   kmsClient
+	keyID    string
+	dekCache *lruCache
+}
+
+// NewAWSKMSKeyProvider builds an AWSKMSKeyProvider whose KEK is keyID (a KMS key ARN or
+// alias), caching up to dekCacheSize unwrapped DEKs
+func NewAWSKMSKeyProvider(ctx context.Context, keyID string, dekCacheSize int) (*AWSKMSKeyProvider, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: failed to load AWS config: %w", err)
+	}
+	return &AWSKMSKeyProvider{
+		client:   kms.NewFromConfig(cfg),
+		keyID:    keyID,
+		dekCache: newLRUCache(dekCacheSize),
+	}, nil
+}
+
+// Encrypt generates a fresh DEK via KMS GenerateDataKey, seals plaintext under it with
+// AES-256-GCM, and returns an envelope of [wrapped DEK | nonce | sealed plaintext]
+func (p *AWSKMSKeyProvider) Encrypt(ctx context.Context, plaintext []byte) ([]byte, error) {
+	out, err := p.client.GenerateDataKey(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(p.keyID),
+		KeySpec: types.DataKeySpecAes256,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("crypto: KMS GenerateDataKey failed: %w", err)
+	}
+
+	gcm, err := newGCM(out.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("crypto: failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nil, nonce, plaintext, nil)
+
+	p.dekCache.put(string(out.CiphertextBlob), out.Plaintext)
+	return encodeEnvelope(out.CiphertextBlob, nonce, sealed), nil
+}
+
+// Decrypt unwraps the envelope's DEK (from cache, or via KMS Decrypt on a miss) and opens
+// its sealed plaintext
+func (p *AWSKMSKeyProvider) Decrypt(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	wrappedDEK, nonce, sealed, err := decodeEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, ok := p.dekCache.get(string(wrappedDEK))
+	if !ok {
+		out, err := p.client.Decrypt(ctx, &kms.DecryptInput{
+			CiphertextBlob: wrappedDEK,
+			KeyId:          aws.String(p.keyID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("crypto: KMS Decrypt failed to unwrap DEK: %w", err)
+		}
+		dek = out.Plaintext
+		p.dekCache.put(string(wrappedDEK), dek)
+	}
+
+	gcm, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, sealed, nil)
+}
+
+// encodeEnvelope packs a wrapped DEK, its nonce, and sealed plaintext into one byte slice:
+// a 2-byte big-endian wrapped-DEK length, the wrapped DEK, then nonce||sealed
+func encodeEnvelope(wrappedDEK, nonce, sealed []byte) []byte {
+	out := make([]byte, 2+len(wrappedDEK)+len(nonce)+len(sealed))
+	binary.BigEndian.PutUint16(out[:2], uint16(len(wrappedDEK)))
+	offset := 2
+	offset += copy(out[offset:], wrappedDEK)
+	offset += copy(out[offset:], nonce)
+	copy(out[offset:], sealed)
+	return out
+}
+
+// decodeEnvelope reverses encodeEnvelope, splitting out the wrapped DEK and nonce||sealed
+// portion (the nonce is the first 12 bytes of that portion)
+func decodeEnvelope(envelope []byte) (wrappedDEK, nonce, sealed []byte, err error) {
+	if len(envelope) < 2 {
+		return nil, nil, nil, fmt.Errorf("crypto: envelope too short to contain a length prefix")
+	}
+	dekLen := int(binary.BigEndian.Uint16(envelope[:2]))
+	if len(envelope) < 2+dekLen+aes.BlockSize {
+		return nil, nil, nil, fmt.Errorf("crypto: envelope too short for its declared DEK length")
+	}
+	wrappedDEK = envelope[2 : 2+dekLen]
+	rest := envelope[2+dekLen:]
+
+	// AES-GCM's standard nonce size is 12 bytes, the size newGCM's cipher.NewGCM uses; a
+	// GCM built with any other nonce size does not decode here
+	const gcmNonceSize = 12
+	if len(rest) < gcmNonceSize {
+		return nil, nil, nil, fmt.Errorf("crypto: envelope too short to contain a nonce")
+	}
+	nonce, sealed = rest[:gcmNonceSize], rest[gcmNonceSize:]
+	return wrappedDEK, nonce, sealed, nil
+}
+
+// lruCache is a small fixed-capacity least-recently-used cache of unwrapped DEKs, so
+// AWSKMSKeyProvider doesn't call KMS Decrypt on every read of a hot row
+type lruCache struct {
+	mutex    sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+}
+
+type lruEntry struct {
+	key   string
+	value []byte
+}
+
+func newLRUCache(capacity int) *lruCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &lruCache{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func (c *lruCache) get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*lruEntry).value, true
+}
+
+func (c *lruCache) put(key string, value []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*lruEntry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+	elem := c.order.PushFront(&lruEntry{key: key, value: value})
+	c.items[key] = elem
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+}
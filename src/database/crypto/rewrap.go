@@ -0,0 +1,107 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// defaultRewrapBatchSize is how many rows Rewrap re-encrypts per SELECT/UPDATE round, to
+// avoid holding an unbounded result set in memory during key rotation
+const defaultRewrapBatchSize = 500
+
+// Rewrap re-encrypts every row of table's column from oldProvider to newProvider, for
+// rotating a KEK without re-keying an entire database offline. It decrypts each row under
+// oldProvider and re-encrypts under newProvider in batches of batchSize (defaultRewrapBatchSize
+// if batchSize <= 0), returning the number of rows rewrapped.
+func Rewrap(ctx context.Context, db *gorm.DB, table, idColumn, column string, oldProvider, newProvider KeyProvider, batchSize int) (int, error) {
+	if batchSize <= 0 {
+		batchSize = defaultRewrapBatchSize
+	}
+
+	rewrapped := 0
+	offset := 0
+	for {
+		var rows []map[string]interface{}
+		err := db.Table(table).
+			Select(fmt.Sprintf("%s, %s", idColumn, column)).
+			Order(idColumn).
+			Limit(batchSize).
+			Offset(offset).
+			Find(&rows).Error
+		if err != nil {
+			return rewrapped, fmt.Errorf("crypto: failed to read rows to rewrap from %s: %w", table, err)
+		}
+		if len(rows) == 0 {
+			return rewrapped, nil
+		}
+
+		for _, row := range rows {
+			id := row[idColumn]
+			encoded, _ := row[column].(string)
+			if encoded == "" {
+				continue
+			}
+
+			ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return rewrapped, fmt.Errorf("crypto: row %v in %s.%s has invalid ciphertext: %w", id, table, column, err)
+			}
+			plaintext, err := oldProvider.Decrypt(ctx, ciphertext)
+			if err != nil {
+				return rewrapped, fmt.Errorf("crypto: failed to decrypt row %v under old key: %w", id, err)
+			}
+			rewrappedCiphertext, err := newProvider.Encrypt(ctx, plaintext)
+			if err != nil {
+				return rewrapped, fmt.Errorf("crypto: failed to re-encrypt row %v under new key: %w", id, err)
+			}
+
+			if err := db.Table(table).Where(fmt.Sprintf("%s = ?", idColumn), id).
+				Update(column, base64.StdEncoding.EncodeToString(rewrappedCiphertext)).Error; err != nil {
+				return rewrapped, fmt.Errorf("crypto: failed to write rewrapped row %v: %w", id, err)
+			}
+			rewrapped++
+		}
+
+		offset += batchSize
+	}
+}
+
+// SelfTest round-trips canaryPlaintext through provider, failing startup before any traffic
+// is served if the registered KeyProvider can't actually encrypt and decrypt
+func SelfTest(ctx context.Context, provider KeyProvider, canaryPlaintext string) error {
+	ciphertext, err := provider.Encrypt(ctx, []byte(canaryPlaintext))
+	if err != nil {
+		return fmt.Errorf("crypto: self-test failed to encrypt canary: %w", err)
+	}
+	plaintext, err := provider.Decrypt(ctx, ciphertext)
+	if err != nil {
+		return fmt.Errorf("crypto: self-test failed to decrypt canary: %w", err)
+	}
+	if string(plaintext) != canaryPlaintext {
+		return fmt.Errorf("crypto: self-test round-trip mismatch: got %q, want %q", plaintext, canaryPlaintext)
+	}
+	return nil
+}
+
+// SelfTestCanaryRow decrypts a known canary row (idColumn = canaryID) from table.column
+// under provider, failing startup before any traffic is served if an already-stored
+// ciphertext can't be decrypted with the currently configured key
+func SelfTestCanaryRow(ctx context.Context, db *gorm.DB, table, idColumn, column, canaryID string, provider KeyProvider) error {
+	var row map[string]interface{}
+	if err := db.Table(table).Select(column).Where(fmt.Sprintf("%s = ?", idColumn), canaryID).Take(&row).Error; err != nil {
+		return fmt.Errorf("crypto: self-test canary row %s not found in %s: %w", canaryID, table, err)
+	}
+
+	encoded, _ := row[column].(string)
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("crypto: self-test canary row %s has invalid ciphertext: %w", canaryID, err)
+	}
+	if _, err := provider.Decrypt(ctx, ciphertext); err != nil {
+		return fmt.Errorf("crypto: self-test failed to decrypt canary row %s: %w", canaryID, err)
+	}
+	return nil
+}
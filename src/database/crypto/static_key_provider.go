@@ -0,0 +1,75 @@
+package crypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+)
+
+// StaticKeyProvider encrypts with a single AES-256 key read once from an environment
+// variable. It's the simplest KeyProvider, suited to development and single-node
+// deployments that don't need envelope encryption or external key custody.
+type StaticKeyProvider struct {
+	key []byte // 32 bytes
+}
+
+// NewStaticKeyProvider builds a StaticKeyProvider from a base64-encoded 32-byte key read
+// from the envVar environment variable
+func NewStaticKeyProvider(envVar string) (*StaticKeyProvider, error) {
+	encoded := os.Getenv(envVar)
+	if encoded == "" {
+		return nil, fmt.Errorf("crypto: environment variable %s is not set", envVar)
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: %s is not valid base64: %w", envVar, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("crypto: %s must decode to 32 bytes for AES-256, got %d", envVar, len(key))
+	}
+	return &StaticKeyProvider{key: key}, nil
+}
+
+// Encrypt seals plaintext with AES-256-GCM under a random per-call nonce, prefixing the
+// nonce onto the returned ciphertext
+func (p *StaticKeyProvider) Encrypt(_ context.Context, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(p.key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("crypto: failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypt opens a ciphertext produced by Encrypt, reading its nonce back off the front
+func (p *StaticKeyProvider) Decrypt(_ context.Context, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(p.key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("crypto: ciphertext shorter than nonce size")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: failed to construct AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("crypto: failed to construct GCM: %w", err)
+	}
+	return gcm, nil
+}
@@ -7,12 +7,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 // DBConfig holds database configuration
@@ -23,11 +27,16 @@ type DBConfig struct {
 	PostgresPassword string
 	PostgresDBName   string
 	PostgresSSLMode  string
-	MongoHost        string
-	MongoPort        int
-	MongoUser        string
-	MongoPassword    string
-	MongoDBName      string
+	// PostgresReadReplicas lists DSN-style "host:port" addresses of read-only replicas. When
+	// non-empty, ConnectPostgres registers them via gorm's dbresolver plugin so read-only
+	// queries (rate lookups, transaction history) are routed to a replica while writes stay
+	// on the primary.
+	PostgresReadReplicas []string
+	MongoHost            string
+	MongoPort            int
+	MongoUser            string
+	MongoPassword        string
+	MongoDBName          string
 }
 
 // DBManager manages database connections and operations
@@ -70,11 +79,54 @@ func (m *DBManager) ConnectPostgres() error {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
+	if len(m.Config.PostgresReadReplicas) > 0 {
+		replicas := make([]gorm.Dialector, 0, len(m.Config.PostgresReadReplicas))
+		for _, addr := range m.Config.PostgresReadReplicas {
+			replicas = append(replicas, postgres.Open(m.replicaDSN(addr)))
+		}
+		err := db.Use(dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		}).SetConnMaxLifetime(time.Hour).SetMaxIdleConns(10).SetMaxOpenConns(100))
+		if err != nil {
+			return fmt.Errorf("failed to register PostgreSQL read replicas: %w", err)
+		}
+		log.Printf("Registered %d PostgreSQL read replica(s)", len(replicas))
+	}
+
 	m.PostgresDB = db
 	log.Println("Connected to PostgreSQL database")
 	return nil
 }
 
+// splitHostPort splits addr ("host" or "host:port") into its host and port, falling back to
+// defaultPort when addr doesn't specify one
+func splitHostPort(addr string, defaultPort int) (string, int) {
+	host, portStr, found := strings.Cut(addr, ":")
+	if !found {
+		return host, defaultPort
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return host, defaultPort
+	}
+	return host, port
+}
+
+// replicaDSN builds the DSN for a read replica at addr (a "host:port" string), reusing the
+// primary's user/password/dbname/sslmode
+func (m *DBManager) replicaDSN(addr string) string {
+	host, port := splitHostPort(addr, m.Config.PostgresPort)
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		host,
+		port,
+		m.Config.PostgresUser,
+		m.Config.PostgresPassword,
+		m.Config.PostgresDBName,
+		m.Config.PostgresSSLMode,
+	)
+}
+
 // ConnectMongo establishes connection to MongoDB database
 func (m *DBManager) ConnectMongo() error {
 	uri := fmt.Sprintf("mongodb://%s:%s@%s:%d",
@@ -130,22 +182,23 @@ func (m *DBManager) Close() error {
 	return nil
 }
 
-// MigrateSchema migrates the database schema for PostgreSQL
+// MigrateSchema brings the database up to the latest migration this build knows about. It's
+// equivalent to Migrate(context.Background(), 0).
 func (m *DBManager) MigrateSchema() error {
+	return m.Migrate(context.Background(), 0)
+}
+
+// Migrate applies every pending migration up to and including targetVersion (0 means "every
+// migration this build knows about"), via the versioned Migrator rather than a single implicit
+// AutoMigrate call, so schema changes are tracked, ordered, and safe to run repeatedly across
+// deploys
+func (m *DBManager) Migrate(ctx context.Context, targetVersion int) error {
 	if m.PostgresDB == nil {
 		return fmt.Errorf("PostgreSQL connection not established")
 	}
 
-	// Auto migrate all models
-	// Add all models that need to be migrated here
-	err := m.PostgresDB.AutoMigrate(
-		&Transaction{},
-		&Wallet{},
-		&Merchant{},
-		&Customer{},
-		&ExchangeRate{},
-	)
-	if err != nil {
+	migrator := NewMigrator(m.PostgresDB, BaselineMigrations())
+	if err := migrator.Migrate(ctx, targetVersion); err != nil {
 		return fmt.Errorf("failed to migrate schema: %w", err)
 	}
 
@@ -153,6 +206,45 @@ func (m *DBManager) MigrateSchema() error {
 	return nil
 }
 
+// mongoLogCollectionTTLs maps each MongoDB log collection this method indexes to how long its
+// documents are retained before MongoDB's TTL monitor expires them, 0 meaning "never expire"
+var mongoLogCollectionTTLs = map[string]time.Duration{
+	"webhook_logs":      30 * 24 * time.Hour,
+	"audit_logs":        0,
+	"notification_logs": 30 * 24 * time.Hour,
+}
+
+// EnsureIndexes creates the indexes this package's MongoDB log collections rely on: a compound
+// {merchant_id:1, created_at:-1} index on each collection for per-merchant history lookups, and
+// a TTL index on created_at for collections whose entries should expire automatically (e.g.
+// webhook delivery logs). It's idempotent, so it's safe to call on every service startup.
+func (m *DBManager) EnsureIndexes(ctx context.Context) error {
+	if m.MongoDB == nil {
+		return fmt.Errorf("MongoDB connection not established")
+	}
+
+	for collection, ttl := range mongoLogCollectionTTLs {
+		models := []mongo.IndexModel{
+			{
+				Keys: bson.D{{Key: "merchant_id", Value: 1}, {Key: "created_at", Value: -1}},
+			},
+		}
+		if ttl > 0 {
+			models = append(models, mongo.IndexModel{
+				Keys:    map[string]int{"created_at": 1},
+				Options: options.Index().SetExpireAfterSeconds(int32(ttl.Seconds())),
+			})
+		}
+
+		if _, err := m.MongoDB.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return fmt.Errorf("failed to create indexes on %s: %w", collection, err)
+		}
+	}
+
+	log.Println("MongoDB log collection indexes ensured")
+	return nil
+}
+
 // DefaultConfig returns a default database configuration for development
 func DefaultConfig() *DBConfig {
 	return &DBConfig{
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// exchangeRateCompactionAge is how old a tick has to be before the background compactor rolls
+// it into a 1-minute OHLC candle and the hot ExchangeRate table stops needing to carry it
+const exchangeRateCompactionAge = 7 * 24 * time.Hour
+
+// exchangeRateCompactInterval is how often the background compactor sweeps for ticks to roll up
+const exchangeRateCompactInterval = 1 * time.Hour
+
+// ExchangeRateCandle is a 1-minute OHLC bucket of compacted ExchangeRate ticks, keeping the hot
+// tick table small once ticks age out past exchangeRateCompactionAge
+type ExchangeRateCandle struct {
+	gorm.Model
+	ID             uint      `gorm:"primaryKey;autoIncrement"`
+	BaseCurrency   string    `gorm:"size:10;not null;uniqueIndex:idx_candle_bucket"`
+	TargetCurrency string    `gorm:"size:10;not null;uniqueIndex:idx_candle_bucket"`
+	Source         string    `gorm:"size:50;not null;uniqueIndex:idx_candle_bucket"`
+	BucketStart    time.Time `gorm:"not null;uniqueIndex:idx_candle_bucket"`
+	Open           float64   `gorm:"not null"`
+	High           float64   `gorm:"not null"`
+	Low            float64   `gorm:"not null"`
+	Close          float64   `gorm:"not null"`
+	TickCount      int       `gorm:"not null"`
+}
+
+func (ExchangeRateCandle) TableName() string {
+	return "exchange_rate_candles"
+}
+
+// ExchangeRateRepository handles database operations for exchange rate ticks and their
+// compacted candles
+type ExchangeRateRepository struct {
+	db          *gorm.DB
+	stopCompact context.CancelFunc
+}
+
+// NewExchangeRateRepository creates a new exchange rate repository, starting a background
+// goroutine that compacts ticks older than exchangeRateCompactionAge into 1-minute OHLC candles
+func NewExchangeRateRepository(db *gorm.DB) *ExchangeRateRepository {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &ExchangeRateRepository{db: db, stopCompact: cancel}
+	go r.compactLoop(ctx)
+	return r
+}
+
+// Stop cancels the background compaction goroutine
+func (r *ExchangeRateRepository) Stop() {
+	r.stopCompact()
+}
+
+// RecordTick inserts a single exchange rate tick
+func (r *ExchangeRateRepository) RecordTick(tick *ExchangeRate) error {
+	return r.db.Create(tick).Error
+}
+
+// TWAP computes a time-weighted average rate for base/target from all ticks across every
+// source within [at-window, at], using piecewise-constant interpolation: each tick's rate is
+// weighted by how long it held (the gap until the next tick, or until at for the last one)
+func (r *ExchangeRateRepository) TWAP(base, target string, window time.Duration, at time.Time) (float64, error) {
+	windowStart := at.Add(-window)
+
+	var ticks []ExchangeRate
+	err := r.db.Where("base_currency = ? AND target_currency = ? AND timestamp BETWEEN ? AND ?", base, target, windowStart, at).
+		Order("timestamp ASC").
+		Find(&ticks).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to load ticks for TWAP: %w", err)
+	}
+	if len(ticks) == 0 {
+		return 0, fmt.Errorf("no exchange rate ticks for %s/%s in the requested window", base, target)
+	}
+
+	var weightedSum, totalWeight float64
+	for i, tick := range ticks {
+		intervalEnd := at
+		if i+1 < len(ticks) {
+			intervalEnd = ticks[i+1].Timestamp
+		}
+		weight := intervalEnd.Sub(tick.Timestamp).Seconds()
+		if weight < 0 {
+			weight = 0
+		}
+		weightedSum += tick.Rate * weight
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		// every tick landed at the same instant (or there's only one right at `at`); fall
+		// back to a plain average rather than dividing by zero
+		var sum float64
+		for _, tick := range ticks {
+			sum += tick.Rate
+		}
+		return sum / float64(len(ticks)), nil
+	}
+	return weightedSum / totalWeight, nil
+}
+
+// MedianAcrossSources pulls the most recent tick per Source for base/target within maxAgeSec
+// of at, and returns the median of those rates plus the sources that contributed, so a single
+// manipulated feed can't skew the quote locked onto a Transaction.ExchangeRate
+func (r *ExchangeRateRepository) MedianAcrossSources(base, target string, at time.Time, maxAgeSec int) (float64, []string, error) {
+	oldestAllowed := at.Add(-time.Duration(maxAgeSec) * time.Second)
+
+	var ticks []ExchangeRate
+	err := r.db.Where("base_currency = ? AND target_currency = ? AND timestamp BETWEEN ? AND ?", base, target, oldestAllowed, at).
+		Order("source ASC, timestamp DESC").
+		Find(&ticks).Error
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to load ticks for median: %w", err)
+	}
+
+	latestBySource := make(map[string]ExchangeRate, len(ticks))
+	for _, tick := range ticks {
+		if _, seen := latestBySource[tick.Source]; !seen {
+			latestBySource[tick.Source] = tick
+		}
+	}
+	if len(latestBySource) == 0 {
+		return 0, nil, fmt.Errorf("no exchange rate ticks for %s/%s from any source within %ds", base, target, maxAgeSec)
+	}
+
+	sources := make([]string, 0, len(latestBySource))
+	rates := make([]float64, 0, len(latestBySource))
+	for source, tick := range latestBySource {
+		sources = append(sources, source)
+		rates = append(rates, tick.Rate)
+	}
+	sort.Strings(sources)
+	sort.Float64s(rates)
+
+	return median(rates), sources, nil
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// compactLoop periodically rolls ticks older than exchangeRateCompactionAge into
+// exchange_rate_candles until ctx is cancelled via Stop
+func (r *ExchangeRateRepository) compactLoop(ctx context.Context) {
+	ticker := time.NewTicker(exchangeRateCompactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.compactOnce(time.Now()); err != nil {
+				continue
+			}
+		}
+	}
+}
+
+// compactOnce rolls every tick older than now-exchangeRateCompactionAge into 1-minute OHLC
+// candles (grouped by base/target/source/minute), upserting into exchange_rate_candles, then
+// deletes the compacted ticks from the hot table
+func (r *ExchangeRateRepository) compactOnce(now time.Time) error {
+	cutoff := now.Add(-exchangeRateCompactionAge)
+
+	var ticks []ExchangeRate
+	if err := r.db.Where("timestamp < ?", cutoff).Order("timestamp ASC").Find(&ticks).Error; err != nil {
+		return fmt.Errorf("failed to load ticks to compact: %w", err)
+	}
+	if len(ticks) == 0 {
+		return nil
+	}
+
+	type bucketKey struct {
+		base, target, source string
+		bucketStart          time.Time
+	}
+	buckets := make(map[bucketKey]*ExchangeRateCandle)
+	order := make([]bucketKey, 0)
+
+	for _, tick := range ticks {
+		bucketStart := tick.Timestamp.Truncate(time.Minute)
+		key := bucketKey{tick.BaseCurrency, tick.TargetCurrency, tick.Source, bucketStart}
+		candle, exists := buckets[key]
+		if !exists {
+			candle = &ExchangeRateCandle{
+				BaseCurrency:   tick.BaseCurrency,
+				TargetCurrency: tick.TargetCurrency,
+				Source:         tick.Source,
+				BucketStart:    bucketStart,
+				Open:           tick.Rate,
+				High:           tick.Rate,
+				Low:            tick.Rate,
+				Close:          tick.Rate,
+				TickCount:      0,
+			}
+			buckets[key] = candle
+			order = append(order, key)
+		}
+		if tick.Rate > candle.High {
+			candle.High = tick.Rate
+		}
+		if tick.Rate < candle.Low {
+			candle.Low = tick.Rate
+		}
+		candle.Close = tick.Rate
+		candle.TickCount++
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		for _, key := range order {
+			candle := buckets[key]
+			if err := upsertCandle(tx, candle); err != nil {
+				return err
+			}
+		}
+		return tx.Where("timestamp < ?", cutoff).Delete(&ExchangeRate{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to compact exchange rate ticks: %w", err)
+	}
+	return nil
+}
+
+// upsertCandle merges candle into any existing candle for the same bucket (base, target,
+// source, minute), since compactOnce can run against a bucket more than once as new ticks age
+// past the cutoff
+func upsertCandle(tx *gorm.DB, candle *ExchangeRateCandle) error {
+	var existing ExchangeRateCandle
+	err := tx.Where("base_currency = ? AND target_currency = ? AND source = ? AND bucket_start = ?",
+		candle.BaseCurrency, candle.TargetCurrency, candle.Source, candle.BucketStart).
+		Take(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return tx.Create(candle).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up existing candle: %w", err)
+	}
+
+	if candle.High > existing.High {
+		existing.High = candle.High
+	}
+	if candle.Low < existing.Low {
+		existing.Low = candle.Low
+	}
+	existing.Close = candle.Close
+	existing.TickCount += candle.TickCount
+	return tx.Save(&existing).Error
+}
@@ -0,0 +1,231 @@
+// Double-entry ledger subsystem for the Asian Cryptocurrency Payment System
+// Wallet.Balance is a single mutable row: a crash mid-update or a concurrent write can lose a
+// merchant's history with no trail to reconstruct it from. Account/AccountOperation/
+// LedgerRepository give merchant balances an append-only operation log instead, so a
+// statement can be rebuilt from history and any drift from Wallet.Balance can be detected
+// rather than silently compounding.
+
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AccountType classifies what an Account represents in a ledger posting
+type AccountType string
+
+const (
+	// AccountTypeAsset holds funds the system has actually received, e.g. a crypto clearing
+	// account credited when a customer's payment confirms
+	AccountTypeAsset AccountType = "asset"
+	// AccountTypeLiability tracks funds the system owes out, e.g. what's payable to a
+	// merchant once fees are deducted
+	AccountTypeLiability AccountType = "liability"
+	// AccountTypeFee tracks platform fee income earned on a transaction
+	AccountTypeFee AccountType = "fee"
+	// AccountTypeSettlement tracks funds moved out in a merchant payout settlement
+	AccountTypeSettlement AccountType = "settlement"
+)
+
+// SynchroneousType marks whether an AccountOperation posted synchronously with its triggering
+// transaction or was applied later by an async reconciliation/settlement job
+type SynchroneousType string
+
+const (
+	// SynchroneousTypeSync is an operation posted in the same request as its transaction
+	SynchroneousTypeSync SynchroneousType = "sync"
+	// SynchroneousTypeAsync is an operation posted later, e.g. by a settlement batch
+	SynchroneousTypeAsync SynchroneousType = "async"
+)
+
+// balanceTolerance is the largest per-currency drift a batch's signed amounts may sum to and
+// still be accepted as "balanced", absorbing float64 rounding noise across many legs
+const balanceTolerance = 0.0000001
+
+// Account is a ledger account holding a running Balance for one merchant and currency.
+// AccountOperation rows against it form the append-only history Balance is derived from.
+type Account struct {
+	gorm.Model
+	ID           string      `gorm:"primaryKey;type:uuid"`
+	MerchantID   string      `gorm:"size:50;not null;index"`
+	CurrencyName string      `gorm:"size:10;not null"`
+	Type         AccountType `gorm:"size:20;not null"`
+	Balance      float64     `gorm:"not null;default:0"`
+}
+
+// AccountOperation is one signed leg of a balanced ledger posting against an Account. A
+// crypto payment posts at least four of these in one LedgerRepository.PostBatch call, rather
+// than the system mutating Wallet.Balance directly.
+type AccountOperation struct {
+	gorm.Model
+	ID               string           `gorm:"primaryKey;type:uuid"`
+	AccountID        string           `gorm:"size:36;not null;index"`
+	TransactionID    string           `gorm:"size:36;not null;index"`
+	Amount           float64          `gorm:"not null"`
+	PrevBalance      float64          `gorm:"not null"`
+	NewBalance       float64          `gorm:"not null"`
+	Timestamp        time.Time        `gorm:"not null;index"`
+	SynchroneousType SynchroneousType `gorm:"size:10;not null"`
+}
+
+// ErrUnbalancedBatch is returned by PostBatch when a batch's signed amounts don't net to zero
+// within balanceTolerance for every currency they touch
+var ErrUnbalancedBatch = errors.New("ledger batch does not net to zero per currency")
+
+// ReconciliationResult is the outcome of LedgerRepository.Reconcile: the Account's current
+// stored Balance compared against what its operation history recomputes to
+type ReconciliationResult struct {
+	AccountID         string
+	StoredBalance     float64
+	RecomputedBalance float64
+	Drift             float64
+}
+
+// Drifted reports whether r.Drift exceeds balanceTolerance
+func (r *ReconciliationResult) Drifted() bool {
+	return math.Abs(r.Drift) > balanceTolerance
+}
+
+// LedgerRepository handles double-entry ledger operations for merchant accounts
+type LedgerRepository struct {
+	db *gorm.DB
+}
+
+// NewLedgerRepository creates a new ledger repository
+func NewLedgerRepository(db *gorm.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// PostBatch atomically posts ops inside a single gorm transaction, locking and updating each
+// leg's Account balance in turn, and rolls the whole batch back if its signed amounts don't
+// net to zero for every currency it touches.
+func (r *LedgerRepository) PostBatch(ops []*AccountOperation) error {
+	if len(ops) == 0 {
+		return errors.New("ledger batch must contain at least one operation")
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		sumsByCurrency := make(map[string]float64)
+
+		for _, op := range ops {
+			var account Account
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", op.AccountID).First(&account).Error; err != nil {
+				return fmt.Errorf("failed to lock account %s: %w", op.AccountID, err)
+			}
+
+			op.PrevBalance = account.Balance
+			op.NewBalance = account.Balance + op.Amount
+			sumsByCurrency[account.CurrencyName] += op.Amount
+
+			account.Balance = op.NewBalance
+			if err := tx.Save(&account).Error; err != nil {
+				return fmt.Errorf("failed to update account %s balance: %w", op.AccountID, err)
+			}
+		}
+
+		for currency, sum := range sumsByCurrency {
+			if math.Abs(sum) > balanceTolerance {
+				return fmt.Errorf("%w: %s legs sum to %f", ErrUnbalancedBatch, currency, sum)
+			}
+		}
+
+		for _, op := range ops {
+			if op.Timestamp.IsZero() {
+				op.Timestamp = time.Now()
+			}
+			if err := tx.Create(op).Error; err != nil {
+				return fmt.Errorf("failed to record operation against account %s: %w", op.AccountID, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// CryptoPaymentLegs is the set of account IDs a crypto payment posts operations against
+type CryptoPaymentLegs struct {
+	CustomerAccountID   string
+	MerchantAccountID   string
+	FeeAccountID        string
+	NetworkFeeAccountID string
+}
+
+// PostCryptoPaymentLegs posts the four legs a crypto payment generates: amount credited to
+// the customer clearing account, and that amount distributed as a debit to the merchant's
+// payable liability, the platform's fee income, and the network fee expense. All four legs
+// carry transactionID and post atomically via PostBatch, so the payment can be reconstructed
+// from operations alone.
+func (r *LedgerRepository) PostCryptoPaymentLegs(transactionID string, legs CryptoPaymentLegs, amount, platformFee, networkFee float64, synchroneousType SynchroneousType) error {
+	now := time.Now()
+	newOp := func(accountID string, signedAmount float64) *AccountOperation {
+		return &AccountOperation{
+			AccountID:        accountID,
+			TransactionID:    transactionID,
+			Amount:           signedAmount,
+			Timestamp:        now,
+			SynchroneousType: synchroneousType,
+		}
+	}
+
+	ops := []*AccountOperation{
+		newOp(legs.CustomerAccountID, amount),
+		newOp(legs.MerchantAccountID, -(amount - platformFee - networkFee)),
+		newOp(legs.FeeAccountID, -platformFee),
+		newOp(legs.NetworkFeeAccountID, -networkFee),
+	}
+
+	return r.PostBatch(ops)
+}
+
+// Reconcile recomputes merchantID's currency-denominated account balance from its full
+// operation history and flags any drift from the account's stored Balance
+func (r *LedgerRepository) Reconcile(merchantID, currency string) (*ReconciliationResult, error) {
+	var account Account
+	if err := r.db.Where("merchant_id = ? AND currency_name = ?", merchantID, currency).First(&account).Error; err != nil {
+		return nil, fmt.Errorf("failed to find account for merchant %s currency %s: %w", merchantID, currency, err)
+	}
+
+	var recomputed float64
+	if err := r.db.Model(&AccountOperation{}).
+		Where("account_id = ?", account.ID).
+		Select("COALESCE(SUM(amount), 0)").
+		Row().Scan(&recomputed); err != nil {
+		return nil, fmt.Errorf("failed to sum operations for account %s: %w", account.ID, err)
+	}
+
+	return &ReconciliationResult{
+		AccountID:         account.ID,
+		StoredBalance:     account.Balance,
+		RecomputedBalance: recomputed,
+		Drift:             account.Balance - recomputed,
+	}, nil
+}
+
+// Statement returns accountID's operations posted between from and to (inclusive), ordered
+// oldest first, for generating a merchant statement over a period
+func (r *LedgerRepository) Statement(accountID string, from, to time.Time) ([]AccountOperation, error) {
+	var ops []AccountOperation
+	err := r.db.Where("account_id = ? AND timestamp BETWEEN ? AND ?", accountID, from, to).
+		Order("timestamp ASC").
+		Find(&ops).Error
+	return ops, err
+}
+
+// FindAccountByMerchantAndCurrency finds a merchant's ledger account for a given currency
+func (r *LedgerRepository) FindAccountByMerchantAndCurrency(merchantID, currency string) (*Account, error) {
+	var account Account
+	err := r.db.Where("merchant_id = ? AND currency_name = ?", merchantID, currency).First(&account).Error
+	return &account, err
+}
+
+// CreateAccount creates a new ledger account
+func (r *LedgerRepository) CreateAccount(account *Account) error {
+	return r.db.Create(account).Error
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one versioned, ordered schema change. Up runs inside a transaction (Migrator
+// never calls Down);
+// PostBackfill runs afterwards, outside that transaction, for data backfills that are too
+// slow or too large to hold a single schema-change transaction open for (e.g. populating a
+// new column row-by-row on a big table).
+type Migration struct {
+	Version      int
+	Description  string
+	Up           func(*gorm.DB) error
+	Down         func(*gorm.DB) error
+	PostBackfill func(*gorm.DB) error
+}
+
+// schemaMigration is the schema_migrations table row recording an applied Migration, along
+// with a checksum of its Description so a Migration whose intent changed after being applied
+// is easy to spot in a DB audit
+type schemaMigration struct {
+	Version     int `gorm:"primaryKey"`
+	Description string
+	Checksum    string
+	AppliedAt   time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// ErrDBAheadOfCode is returned when the database's highest applied migration version is newer
+// than the highest version the running code knows about, which would otherwise silently run
+// the app against a schema it doesn't understand (a rollback-forward mistake, e.g. a canary
+// deploy of old code against a DB already migrated by new code)
+var ErrDBAheadOfCode = fmt.Errorf("database schema is ahead of this build's known migrations")
+
+// Migrator applies Migrations in version order, tracking which have run in schema_migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator builds a Migrator over migrations, which need not already be sorted by Version
+func NewMigrator(db *gorm.DB, migrations []Migration) *Migrator {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return &Migrator{db: db, migrations: sorted}
+}
+
+// Migrate applies every pending migration up to and including targetVersion (0 means "all
+// registered migrations"), in order, each inside its own transaction followed by its
+// PostBackfill step. It refuses to run at all if the database's highest applied version
+// exceeds the highest version this Migrator knows about.
+func (m *Migrator) Migrate(ctx context.Context, targetVersion int) error {
+	if err := m.db.AutoMigrate(&schemaMigration{}); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	maxKnown := m.maxVersion()
+	if targetVersion == 0 {
+		targetVersion = maxKnown
+	}
+
+	dbVersion, err := m.appliedVersion()
+	if err != nil {
+		return err
+	}
+	if dbVersion > maxKnown {
+		return fmt.Errorf("%w: database is at version %d, this build only knows migrations up to %d", ErrDBAheadOfCode, dbVersion, maxKnown)
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version <= dbVersion || migration.Version > targetVersion {
+			continue
+		}
+		if err := m.apply(ctx, migration); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+		log.Printf("applied migration %d: %s", migration.Version, migration.Description)
+	}
+	return nil
+}
+
+func (m *Migrator) apply(ctx context.Context, migration Migration) error {
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if migration.Up != nil {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&schemaMigration{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Checksum:    checksumDescription(migration.Description),
+			AppliedAt:   time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if migration.PostBackfill != nil {
+		if err := migration.PostBackfill(m.db.WithContext(ctx)); err != nil {
+			return fmt.Errorf("post-backfill failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// appliedVersion returns the highest version recorded in schema_migrations, or 0 if none have
+// been applied yet
+func (m *Migrator) appliedVersion() (int, error) {
+	var row schemaMigration
+	err := m.db.Order("version DESC").Limit(1).Take(&row).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read applied migration version: %w", err)
+	}
+	return row.Version, nil
+}
+
+func (m *Migrator) maxVersion() int {
+	max := 0
+	for _, migration := range m.migrations {
+		if migration.Version > max {
+			max = migration.Version
+		}
+	}
+	return max
+}
+
+func checksumDescription(description string) string {
+	sum := sha256.Sum256([]byte(description))
+	return hex.EncodeToString(sum[:])
+}
+
+// BaselineMigrations returns the initial migration set this package ships: a version 1
+// baseline establishing Transaction/Wallet/Merchant/Customer/ExchangeRate via AutoMigrate
+// (standing in for the hand schemas a longer-lived system would have accumulated), a
+// version 2 migration adding the composite indexes FindByMerchantID and status/recency
+// lookups need, version 3 adding the ledger Account/AccountOperation and UTXO tables, and
+// version 4 adding the exchange_rate_candles table.
+func BaselineMigrations() []Migration {
+	return []Migration{
+		{
+			Version:     1,
+			Description: "baseline schema for Transaction, Wallet, Merchant, Customer, ExchangeRate",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(
+					&Transaction{},
+					&Wallet{},
+					&Merchant{},
+					&Customer{},
+					&ExchangeRate{},
+				)
+			},
+		},
+		{
+			Version:     2,
+			Description: "add indexes for merchant history and status/recency lookups on Transaction",
+			Up: func(tx *gorm.DB) error {
+				if err := tx.Exec("CREATE INDEX IF NOT EXISTS idx_transactions_merchant_created ON transactions (merchant_id, created_at)").Error; err != nil {
+					return err
+				}
+				return tx.Exec("CREATE INDEX IF NOT EXISTS idx_transactions_status_updated ON transactions (status, updated_at)").Error
+			},
+			Down: func(tx *gorm.DB) error {
+				if err := tx.Exec("DROP INDEX IF EXISTS idx_transactions_merchant_created").Error; err != nil {
+					return err
+				}
+				return tx.Exec("DROP INDEX IF EXISTS idx_transactions_status_updated").Error
+			},
+		},
+		{
+			Version:     3,
+			Description: "add ledger accounts, account operations, and UTXO reservation tables",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&Account{}, &AccountOperation{}, &UTXO{})
+			},
+		},
+		{
+			Version:     4,
+			Description: "add exchange_rate_candles table for compacted OHLC ticks",
+			Up: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&ExchangeRateCandle{})
+			},
+		},
+	}
+}
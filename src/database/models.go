@@ -4,9 +4,14 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
+
+	dbcrypto "github.com/aucoventureresearchcentre/comprehensive-asian-crypto-payment-integration-code/src/database/crypto"
 )
 
 // Transaction represents a cryptocurrency payment transaction
@@ -23,63 +28,64 @@ type Transaction struct {
 	CreatedAt          time.Time `gorm:"not null"`
 	UpdatedAt          time.Time `gorm:"not null"`
 	CompletedAt        time.Time
-	ExchangeRate       float64   `gorm:"not null"`
-	Fee                float64   `gorm:"not null"`
-	MerchantID         string    `gorm:"size:50;not null"`
-	CustomerID         string    `gorm:"size:50"`
-	CountryCode        string    `gorm:"size:2;not null"`
-	PaymentMethod      string    `gorm:"size:20;not null"`
-	BlockchainTxID     string    `gorm:"size:100"`
-	Confirmations      int       `gorm:"default:0"`
-	CallbackURL        string    `gorm:"size:255"`
-	SuccessURL         string    `gorm:"size:255"`
-	CancelURL          string    `gorm:"size:255"`
-	IPAddress          string    `gorm:"size:45"`
-	UserAgent          string    `gorm:"size:255"`
-	Metadata           string    `gorm:"type:jsonb"`
+	ExchangeRate       float64 `gorm:"not null"`
+	Fee                float64 `gorm:"not null"`
+	MerchantID         string  `gorm:"size:50;not null;uniqueIndex:idx_merchant_idempotency_key"`
+	CustomerID         string  `gorm:"size:50"`
+	CountryCode        string  `gorm:"size:2;not null"`
+	PaymentMethod      string  `gorm:"size:20;not null"`
+	BlockchainTxID     string  `gorm:"size:100"`
+	Confirmations      int     `gorm:"default:0"`
+	CallbackURL        string  `gorm:"size:255"`
+	SuccessURL         string  `gorm:"size:255"`
+	CancelURL          string  `gorm:"size:255"`
+	IPAddress          string  `gorm:"size:45"`
+	UserAgent          string  `gorm:"size:255"`
+	Metadata           string  `gorm:"type:jsonb"`
+	IdempotencyKey     string  `gorm:"size:100;uniqueIndex:idx_merchant_idempotency_key,where:idempotency_key <> ''"`
 }
 
 // Wallet represents a cryptocurrency wallet
 type Wallet struct {
 	gorm.Model
-	ID           string    `gorm:"primaryKey;type:uuid"`
-	Currency     string    `gorm:"size:10;not null"`
-	Address      string    `gorm:"size:100;not null;uniqueIndex"`
-	Balance      float64   `gorm:"not null"`
-	Type         string    `gorm:"size:10;not null"` // hot or cold
-	MerchantID   string    `gorm:"size:50"`
-	CreatedAt    time.Time `gorm:"not null"`
-	UpdatedAt    time.Time `gorm:"not null"`
-	LastSyncedAt time.Time `gorm:"not null"`
-	PublicKey    string    `gorm:"size:255"`
-	PrivateKey   string    `gorm:"size:255"` // Encrypted
-	IsActive     bool      `gorm:"default:true"`
-	Labels       string    `gorm:"type:jsonb"`
+	ID           string                   `gorm:"primaryKey;type:uuid"`
+	Currency     string                   `gorm:"size:10;not null"`
+	Address      string                   `gorm:"size:100;not null;uniqueIndex"`
+	Balance      float64                  `gorm:"not null"`
+	Type         string                   `gorm:"size:10;not null"` // hot or cold
+	MerchantID   string                   `gorm:"size:50"`
+	CreatedAt    time.Time                `gorm:"not null"`
+	UpdatedAt    time.Time                `gorm:"not null"`
+	LastSyncedAt time.Time                `gorm:"not null"`
+	PublicKey    string                   `gorm:"size:255"`
+	PrivateKey   dbcrypto.EncryptedString `gorm:"size:255"` // AES-256-GCM via dbcrypto.CurrentKeyProvider
+	IsActive     bool                     `gorm:"default:true"`
+	Labels       string                   `gorm:"type:jsonb"`
 }
 
 // Merchant represents a business using the payment system
 type Merchant struct {
 	gorm.Model
-	ID               string    `gorm:"primaryKey;type:uuid"`
-	Name             string    `gorm:"size:100;not null"`
-	Email            string    `gorm:"size:100;not null;uniqueIndex"`
-	Phone            string    `gorm:"size:20"`
-	CountryCode      string    `gorm:"size:2;not null"`
-	APIKey           string    `gorm:"size:64;not null;uniqueIndex"`
-	APISecret        string    `gorm:"size:128;not null"` // Encrypted
-	WebhookURL       string    `gorm:"size:255"`
-	WebhookSecret    string    `gorm:"size:64"`
-	CallbackURL      string    `gorm:"size:255"`
-	SuccessURL       string    `gorm:"size:255"`
-	CancelURL        string    `gorm:"size:255"`
-	CreatedAt        time.Time `gorm:"not null"`
-	UpdatedAt        time.Time `gorm:"not null"`
-	IsActive         bool      `gorm:"default:true"`
-	VerificationStatus string  `gorm:"size:20;default:'pending'"`
-	FeePercentage    float64   `gorm:"default:1.0"`
-	SettlementCurrency string  `gorm:"size:10;default:'USD'"`
-	SettlementAddress string   `gorm:"size:100"`
-	Settings         string    `gorm:"type:jsonb"`
+	ID                 string                   `gorm:"primaryKey;type:uuid"`
+	Name               string                   `gorm:"size:100;not null"`
+	Email              string                   `gorm:"size:100;not null;uniqueIndex"`
+	Phone              string                   `gorm:"size:20"`
+	CountryCode        string                   `gorm:"size:2;not null"`
+	APIKey             string                   `gorm:"size:64;not null;uniqueIndex"`
+	APISecret          dbcrypto.EncryptedString `gorm:"size:128;not null"` // AES-256-GCM via dbcrypto.CurrentKeyProvider
+	WebhookURL         string                   `gorm:"size:255"`
+	WebhookSecret      dbcrypto.EncryptedString `gorm:"size:64"` // AES-256-GCM via dbcrypto.CurrentKeyProvider
+	CallbackURL        string                   `gorm:"size:255"`
+	SuccessURL         string                   `gorm:"size:255"`
+	CancelURL          string                   `gorm:"size:255"`
+	CreatedAt          time.Time                `gorm:"not null"`
+	UpdatedAt          time.Time                `gorm:"not null"`
+	IsActive           bool                     `gorm:"default:true"`
+	VerificationStatus string                   `gorm:"size:20;default:'pending'"`
+	FeePercentage      float64                  `gorm:"default:1.0"`
+	SettlementCurrency string                   `gorm:"size:10;default:'USD'"`
+	SettlementAddress  string                   `gorm:"size:100"`
+	Settings           string                   `gorm:"type:jsonb"`
 }
 
 // Customer represents a customer making payments
@@ -111,39 +117,156 @@ type ExchangeRate struct {
 
 // AuditLog represents system audit logs (stored in MongoDB)
 type AuditLog struct {
-	ID        string    `bson:"_id,omitempty"`
-	Action    string    `bson:"action"`
-	EntityType string   `bson:"entity_type"`
-	EntityID  string    `bson:"entity_id"`
-	UserID    string    `bson:"user_id,omitempty"`
-	IPAddress string    `bson:"ip_address,omitempty"`
-	Timestamp time.Time `bson:"timestamp"`
-	Details   map[string]interface{} `bson:"details,omitempty"`
+	ID         string                 `bson:"_id,omitempty"`
+	Action     string                 `bson:"action"`
+	EntityType string                 `bson:"entity_type"`
+	EntityID   string                 `bson:"entity_id"`
+	UserID     string                 `bson:"user_id,omitempty"`
+	IPAddress  string                 `bson:"ip_address,omitempty"`
+	Timestamp  time.Time              `bson:"timestamp"`
+	Details    map[string]interface{} `bson:"details,omitempty"`
 }
 
 // SystemLog represents system logs (stored in MongoDB)
 type SystemLog struct {
-	ID        string    `bson:"_id,omitempty"`
-	Level     string    `bson:"level"` // info, warning, error, critical
-	Message   string    `bson:"message"`
-	Component string    `bson:"component"`
-	Timestamp time.Time `bson:"timestamp"`
+	ID        string                 `bson:"_id,omitempty"`
+	Level     string                 `bson:"level"` // info, warning, error, critical
+	Message   string                 `bson:"message"`
+	Component string                 `bson:"component"`
+	Timestamp time.Time              `bson:"timestamp"`
 	Details   map[string]interface{} `bson:"details,omitempty"`
 }
 
+// DefaultIdempotencyKeyTTL is how long an idempotency key's result is kept cached after its
+// last use, once NewTransactionRepository's background sweeper is free to garbage-collect it
+const DefaultIdempotencyKeyTTL = 24 * time.Hour
+
+// idempotencyKeySweepInterval is how often the background sweeper checks for expired
+// idempotency key entries, independent of their configured TTL
+const idempotencyKeySweepInterval = 10 * time.Minute
+
+// idempotencyCall tracks one in-flight or completed Create call for a given (merchant,
+// idempotency key) pair, so concurrent retries collapse to a single DB insert and every
+// caller observes the same Transaction pointer and error.
+type idempotencyCall struct {
+	done       chan struct{}
+	tx         *Transaction
+	err        error
+	lastAccess time.Time
+}
+
 // TransactionRepository handles database operations for transactions
 type TransactionRepository struct {
-	db *gorm.DB
+	db             *gorm.DB
+	idempotencyTTL time.Duration
+	callsMutex     sync.Mutex
+	calls          map[string]*idempotencyCall
+	stopSweeper    context.CancelFunc
 }
 
-// NewTransactionRepository creates a new transaction repository
+// NewTransactionRepository creates a new transaction repository, starting a background
+// goroutine that garbage-collects idempotency keys unused for DefaultIdempotencyKeyTTL
 func NewTransactionRepository(db *gorm.DB) *TransactionRepository {
-	return &TransactionRepository{db: db}
+	return NewTransactionRepositoryWithIdempotencyTTL(db, DefaultIdempotencyKeyTTL)
+}
+
+// NewTransactionRepositoryWithIdempotencyTTL creates a new transaction repository whose
+// idempotency keys are garbage-collected idempotencyTTL after their last use
+func NewTransactionRepositoryWithIdempotencyTTL(db *gorm.DB, idempotencyTTL time.Duration) *TransactionRepository {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &TransactionRepository{
+		db:             db,
+		idempotencyTTL: idempotencyTTL,
+		calls:          make(map[string]*idempotencyCall),
+		stopSweeper:    cancel,
+	}
+	go r.sweepIdempotencyKeys(ctx)
+	return r
+}
+
+// Stop cancels the repository's idempotency key sweeper goroutine
+func (r *TransactionRepository) Stop() {
+	r.stopSweeper()
+}
+
+func (r *TransactionRepository) sweepIdempotencyKeys(ctx context.Context) {
+	ticker := time.NewTicker(idempotencyKeySweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.callsMutex.Lock()
+			now := time.Now()
+			for key, call := range r.calls {
+				select {
+				case <-call.done:
+					if now.Sub(call.lastAccess) > r.idempotencyTTL {
+						delete(r.calls, key)
+					}
+				default:
+					// still in flight; leave it for a later sweep
+				}
+			}
+			r.callsMutex.Unlock()
+		}
+	}
+}
+
+// Create creates a new transaction. If idempotencyKey is non-empty and a transaction already
+// exists for (tx.MerchantID, idempotencyKey), that stored transaction is returned instead of
+// inserting a duplicate; concurrent callers sharing the same key collapse onto a single DB
+// insert via an in-process singleflight-style call, and every caller receives the same
+// *Transaction and error.
+func (r *TransactionRepository) Create(tx *Transaction, idempotencyKey string) (*Transaction, error) {
+	if idempotencyKey == "" {
+		return tx, r.db.Create(tx).Error
+	}
+
+	key := tx.MerchantID + "|" + idempotencyKey
+
+	r.callsMutex.Lock()
+	if call, ok := r.calls[key]; ok {
+		call.lastAccess = time.Now()
+		r.callsMutex.Unlock()
+		<-call.done
+		return call.tx, call.err
+	}
+
+	call := &idempotencyCall{done: make(chan struct{}), lastAccess: time.Now()}
+	r.calls[key] = call
+	r.callsMutex.Unlock()
+
+	tx.IdempotencyKey = idempotencyKey
+	call.tx, call.err = r.createIdempotent(tx, idempotencyKey)
+	close(call.done)
+	return call.tx, call.err
 }
 
-// Create creates a new transaction
-func (r *TransactionRepository) Create(tx *Transaction) error {
-	return r.db.Create(tx).Error
+// createIdempotent does the actual lookup-or-insert for Create once a call has won the
+// in-process singleflight race for its key
+func (r *TransactionRepository) createIdempotent(tx *Transaction, idempotencyKey string) (*Transaction, error) {
+	var existing Transaction
+	err := r.db.Where("merchant_id = ? AND idempotency_key = ?", tx.MerchantID, idempotencyKey).First(&existing).Error
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := r.db.Create(tx).Error; err != nil {
+		// Another process may have inserted the same (merchant, key) pair between our
+		// lookup and insert, tripping the unique index; fetch what it created instead of
+		// surfacing a duplicate-key error to every retrying caller.
+		var raced Transaction
+		if lookupErr := r.db.Where("merchant_id = ? AND idempotency_key = ?", tx.MerchantID, idempotencyKey).First(&raced).Error; lookupErr == nil {
+			return &raced, nil
+		}
+		return nil, err
+	}
+	return tx, nil
 }
 
 // FindByID finds a transaction by ID
@@ -0,0 +1,264 @@
+// UTXO reservation and coin selection for Bitcoin/Litecoin hot wallet payouts
+// Wallet.Balance is a single float a payout debits optimistically; nothing stops two
+// concurrent payouts from both believing they can spend the same on-chain output. UTXO
+// tracks individual unspent outputs and UTXOReserver hands a payout a set of them under a
+// row lock, so a wallet's balance becomes something a real Bitcoin/Litecoin transaction can
+// actually be built and signed from.
+
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// utxoChangeTolerance is how far above the requested amount a selected UTXO set may land
+// and still be considered a branch-and-bound match that needs no change output (a fixed
+// band; blockchain.SelectCoins uses its 546-sat dust limit plus fee instead)
+const utxoChangeTolerance = 1000 // satoshis
+
+// utxoBnBMaxTries caps the branch-and-bound search before it falls back to the randomized
+// knapsack accumulator, since exact subset-sum is NP-hard
+const utxoBnBMaxTries = 100000
+
+// ErrInsufficientUTXOs is returned when no combination of a wallet's available UTXOs covers
+// a Reserve call's requested amount
+var ErrInsufficientUTXOs = errors.New("insufficient unspent outputs to cover requested amount")
+
+// ErrReservationNotFound is returned by Release/MarkSpent when no UTXO carries the given
+// reservation ID
+var ErrReservationNotFound = errors.New("reservation not found")
+
+// utxoReapInterval is how often the background reaper clears expired reservations
+const utxoReapInterval = 1 * time.Minute
+
+// UTXO is a single unspent transaction output held by one of the system's hot wallets
+type UTXO struct {
+	gorm.Model
+	ID            string    `gorm:"primaryKey;type:uuid"`
+	WalletID      string    `gorm:"size:36;not null;index"`
+	TxHash        string    `gorm:"size:100;not null"`
+	Vout          uint32    `gorm:"not null"`
+	Amount        int64     `gorm:"not null"` // satoshis
+	Confirmations int       `gorm:"default:0"`
+	Spent         bool      `gorm:"default:false;index"`
+	SpentTxID     string    `gorm:"size:100"`
+	ReservedUntil time.Time `gorm:"index"`
+	ReservationID string    `gorm:"size:64;index"`
+}
+
+// UTXOReserver reserves a wallet's unspent outputs for an in-flight payout, so two
+// concurrent payouts can't select and spend the same output
+type UTXOReserver struct {
+	db         *gorm.DB
+	stopReaper context.CancelFunc
+}
+
+// NewUTXOReserver creates a UTXOReserver and starts a background goroutine that releases
+// reservations whose TTL has expired, so a crashed payout client doesn't strand outputs
+func NewUTXOReserver(db *gorm.DB) *UTXOReserver {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &UTXOReserver{db: db, stopReaper: cancel}
+	go r.reapExpiredReservations(ctx)
+	return r
+}
+
+// Stop cancels the reservation reaper goroutine
+func (r *UTXOReserver) Stop() {
+	r.stopReaper()
+}
+
+func (r *UTXOReserver) reapExpiredReservations(ctx context.Context) {
+	ticker := time.NewTicker(utxoReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.db.Model(&UTXO{}).
+				Where("spent = ? AND reservation_id <> '' AND reserved_until < ?", false, time.Now()).
+				Updates(map[string]interface{}{"reservation_id": "", "reserved_until": time.Time{}})
+		}
+	}
+}
+
+// Reserve locks walletID's available UTXOs under a SELECT ... FOR UPDATE transaction and
+// selects a subset summing to at least amount satoshis, marking them reserved for ttl and
+// returning that set along with a reservation ID. Selection first tries branch-and-bound for
+// a set landing close to amount to minimize change, falling back to a randomized-knapsack
+// accumulator if branch-and-bound exhausts its search budget.
+func (r *UTXOReserver) Reserve(walletID string, amount int64, ttl time.Duration) ([]UTXO, string, error) {
+	if amount <= 0 {
+		return nil, "", errors.New("reservation amount must be positive")
+	}
+
+	reservationID, err := generateReservationID()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate reservation id: %w", err)
+	}
+
+	var selected []UTXO
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var candidates []UTXO
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("wallet_id = ? AND spent = ? AND (reservation_id = '' OR reserved_until < ?)", walletID, false, now).
+			Order("amount DESC").
+			Find(&candidates).Error; err != nil {
+			return fmt.Errorf("failed to lock candidate utxos: %w", err)
+		}
+
+		result, ok := selectUTXOs(candidates, amount)
+		if !ok {
+			return ErrInsufficientUTXOs
+		}
+
+		reservedUntil := now.Add(ttl)
+		for _, u := range result {
+			if err := tx.Model(&UTXO{}).Where("id = ?", u.ID).
+				Updates(map[string]interface{}{
+					"reservation_id": reservationID,
+					"reserved_until": reservedUntil,
+				}).Error; err != nil {
+				return fmt.Errorf("failed to reserve utxo %s: %w", u.ID, err)
+			}
+		}
+
+		selected = result
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return selected, reservationID, nil
+}
+
+// Release clears reservationID from every UTXO it was holding, making them available again
+func (r *UTXOReserver) Release(reservationID string) error {
+	result := r.db.Model(&UTXO{}).Where("reservation_id = ?", reservationID).
+		Updates(map[string]interface{}{"reservation_id": "", "reserved_until": time.Time{}})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReservationNotFound
+	}
+	return nil
+}
+
+// MarkSpent marks every UTXO under reservationID as spent by spendTxID, clearing its
+// reservation
+func (r *UTXOReserver) MarkSpent(reservationID, spendTxID string) error {
+	result := r.db.Model(&UTXO{}).Where("reservation_id = ?", reservationID).
+		Updates(map[string]interface{}{
+			"spent":          true,
+			"spent_tx_id":    spendTxID,
+			"reservation_id": "",
+			"reserved_until": time.Time{},
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrReservationNotFound
+	}
+	return nil
+}
+
+// selectUTXOs picks a subset of candidates summing to at least targetAmount, preferring a
+// branch-and-bound match within utxoChangeTolerance of targetAmount and falling back to a
+// randomized-knapsack accumulator if branch-and-bound can't find one within its search budget
+func selectUTXOs(candidates []UTXO, targetAmount int64) ([]UTXO, bool) {
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	sorted := make([]UTXO, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Amount > sorted[j].Amount })
+
+	if result, ok := utxoBranchAndBound(sorted, targetAmount); ok {
+		return result, true
+	}
+	return utxoRandomizedKnapsack(sorted, targetAmount)
+}
+
+// utxoBranchAndBound searches for a subset of sorted (descending by amount) whose total
+// lands within [targetAmount, targetAmount+utxoChangeTolerance], capping its search at
+// utxoBnBMaxTries since exact subset-sum is NP-hard
+func utxoBranchAndBound(sorted []UTXO, targetAmount int64) ([]UTXO, bool) {
+	tries := 0
+	var best []UTXO
+	current := make([]UTXO, 0, len(sorted))
+
+	var search func(index int, sum int64) bool
+	search = func(index int, sum int64) bool {
+		tries++
+		if tries > utxoBnBMaxTries {
+			return false
+		}
+
+		if sum >= targetAmount && sum <= targetAmount+utxoChangeTolerance {
+			best = append(best[:0], current...)
+			return true
+		}
+		if index >= len(sorted) || sum > targetAmount+utxoChangeTolerance {
+			return false
+		}
+
+		current = append(current, sorted[index])
+		if search(index+1, sum+sorted[index].Amount) {
+			return true
+		}
+		current = current[:len(current)-1]
+
+		return search(index+1, sum)
+	}
+
+	if search(0, 0) {
+		result := make([]UTXO, len(best))
+		copy(result, best)
+		return result, true
+	}
+	return nil, false
+}
+
+// utxoRandomizedKnapsack shuffles sorted and greedily accumulates outputs until their total
+// covers targetAmount, trading an exact minimal-change match for a cheap fallback once
+// branch-and-bound has given up
+func utxoRandomizedKnapsack(sorted []UTXO, targetAmount int64) ([]UTXO, bool) {
+	shuffled := make([]UTXO, len(sorted))
+	copy(shuffled, sorted)
+	mathrand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	var selected []UTXO
+	var total int64
+	for _, u := range shuffled {
+		selected = append(selected, u)
+		total += u.Amount
+		if total >= targetAmount {
+			return selected, true
+		}
+	}
+	return nil, false
+}
+
+// generateReservationID returns a random 32-byte hex-encoded reservation ID, following the
+// same convention as this system's session token generation
+func generateReservationID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
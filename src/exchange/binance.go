@@ -0,0 +1,238 @@
+// Binance exchange rate provider for Asian Cryptocurrency Payment System
+// Uses Binance's public ticker/price endpoint for crypto pairs; Binance doesn't quote fiat
+// pairs directly, so a crypto<->fiat rate routes through USDT as a stable-dollar proxy
+
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterProviderFactory("binance", func(config ProviderConfig) (RateProvider, error) {
+		return NewBinanceProvider(config), nil
+	})
+}
+
+// binanceTickerPrice is the shape of Binance's GET /api/v3/ticker/price response
+type binanceTickerPrice struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+// BinanceProvider implements RateProvider using Binance's public ticker/price endpoint
+type BinanceProvider struct {
+	apiURL              string
+	http                *providerHTTPClient
+	supportedCurrencies []string
+	cryptoCurrencies    map[string]bool
+}
+
+// NewBinanceProvider creates a new Binance provider. config.HTTPClient overrides the default
+// throttled HTTP client; config.APIKey is ignored, since Binance's public ticker endpoint
+// doesn't require one.
+func NewBinanceProvider(config ProviderConfig) *BinanceProvider {
+	provider := &BinanceProvider{
+		apiURL: "https://api.binance.com/api/v3",
+		http:   newProviderHTTPClient(10, 10),
+		supportedCurrencies: []string{
+			"BTC", "ETH", "USDT", "USDC", "BNB", "XRP", "ADA", "SOL", "DOT", "DOGE",
+			"USD", "EUR",
+		},
+		cryptoCurrencies: map[string]bool{
+			"BTC": true, "ETH": true, "USDT": true, "USDC": true, "BNB": true,
+			"XRP": true, "ADA": true, "SOL": true, "DOT": true, "DOGE": true,
+		},
+	}
+	if config.HTTPClient != nil {
+		provider.http.client = config.HTTPClient
+	}
+	return provider
+}
+
+// GetName returns the name of the provider
+func (p *BinanceProvider) GetName() string {
+	return "Binance"
+}
+
+// GetRate returns the exchange rate between two currencies
+func (p *BinanceProvider) GetRate(ctx context.Context, baseCurrency, targetCurrency string) (*ExchangeRate, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	targetCurrency = strings.ToUpper(targetCurrency)
+
+	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+
+	if !p.isCryptoCurrency(baseCurrency) && !p.isCryptoCurrency(targetCurrency) {
+		return nil, fmt.Errorf("%w: Binance only quotes pairs with at least one crypto leg", ErrInvalidCurrency)
+	}
+
+	price, err := p.symbolPrice(ctx, baseCurrency, targetCurrency)
+	if err == nil {
+		return &ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           price,
+			Source:         p.GetName(),
+			Timestamp:      time.Now(),
+		}, nil
+	}
+
+	// Fall back to a USDT-routed cross rate, e.g. BTC/ETH via BTC/USDT and ETH/USDT
+	baseToUSDT, err := p.rateToUSDT(ctx, baseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	targetToUSDT, err := p.rateToUSDT(ctx, targetCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           baseToUSDT / targetToUSDT,
+		Source:         p.GetName(),
+		Timestamp:      time.Now(),
+	}, nil
+}
+
+// rateToUSDT returns currency's price in USDT, treating USDT/USD/USDC itself as parity
+func (p *BinanceProvider) rateToUSDT(ctx context.Context, currency string) (float64, error) {
+	if currency == "USDT" || currency == "USD" || currency == "USDC" {
+		return 1.0, nil
+	}
+	return p.symbolPrice(ctx, currency, "USDT")
+}
+
+// symbolPrice fetches the ticker price for base+target (e.g. BTCUSDT), trying the direct
+// symbol first and its inverse second
+func (p *BinanceProvider) symbolPrice(ctx context.Context, base, target string) (float64, error) {
+	if price, err := p.fetchSymbol(ctx, base+target); err == nil {
+		return price, nil
+	}
+	price, err := p.fetchSymbol(ctx, target+base)
+	if err != nil {
+		return 0, err
+	}
+	return 1.0 / price, nil
+}
+
+func (p *BinanceProvider) fetchSymbol(ctx context.Context, symbol string) (float64, error) {
+	url := fmt.Sprintf("%s/ticker/price?symbol=%s", p.apiURL, symbol)
+
+	var ticker binanceTickerPrice
+	if err := p.http.getJSON(ctx, url, nil, &ticker); err != nil {
+		return 0, err
+	}
+
+	price, err := strconv.ParseFloat(ticker.Price, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse Binance price for %s: %w", symbol, err)
+	}
+	return price, nil
+}
+
+// GetHistoricalRate returns the exchange rate between two currencies at a specific time, via
+// Binance's klines endpoint, reading the close price of the 1-minute candle covering at
+func (p *BinanceProvider) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, at time.Time) (*ExchangeRate, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	targetCurrency = strings.ToUpper(targetCurrency)
+
+	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+	if !p.isCryptoCurrency(baseCurrency) && !p.isCryptoCurrency(targetCurrency) {
+		return nil, fmt.Errorf("%w: Binance only quotes pairs with at least one crypto leg", ErrInvalidCurrency)
+	}
+
+	price, err := p.historicalSymbolPrice(ctx, baseCurrency, targetCurrency, at)
+	if err == nil {
+		return &ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           price,
+			Source:         p.GetName(),
+			Timestamp:      at,
+		}, nil
+	}
+
+	baseToUSDT, err := p.historicalRateToUSDT(ctx, baseCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+	targetToUSDT, err := p.historicalRateToUSDT(ctx, targetCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           baseToUSDT / targetToUSDT,
+		Source:         p.GetName(),
+		Timestamp:      at,
+	}, nil
+}
+
+func (p *BinanceProvider) historicalRateToUSDT(ctx context.Context, currency string, at time.Time) (float64, error) {
+	if currency == "USDT" || currency == "USD" || currency == "USDC" {
+		return 1.0, nil
+	}
+	return p.historicalSymbolPrice(ctx, currency, "USDT", at)
+}
+
+func (p *BinanceProvider) historicalSymbolPrice(ctx context.Context, base, target string, at time.Time) (float64, error) {
+	if price, err := p.fetchHistoricalSymbol(ctx, base+target, at); err == nil {
+		return price, nil
+	}
+	price, err := p.fetchHistoricalSymbol(ctx, target+base, at)
+	if err != nil {
+		return 0, err
+	}
+	return 1.0 / price, nil
+}
+
+func (p *BinanceProvider) fetchHistoricalSymbol(ctx context.Context, symbol string, at time.Time) (float64, error) {
+	url := fmt.Sprintf("%s/klines?symbol=%s&interval=1m&startTime=%d&limit=1", p.apiURL, symbol, at.Truncate(time.Minute).UnixMilli())
+
+	var klines [][]interface{}
+	if err := p.http.getJSON(ctx, url, nil, &klines); err != nil {
+		return 0, err
+	}
+	if len(klines) == 0 {
+		return 0, ErrRateNotFound
+	}
+
+	closePrice, ok := klines[0][4].(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected Binance kline shape for %s", symbol)
+	}
+	price, err := strconv.ParseFloat(closePrice, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse Binance historical price for %s: %w", symbol, err)
+	}
+	return price, nil
+}
+
+// GetSupportedCurrencies returns the list of supported currencies
+func (p *BinanceProvider) GetSupportedCurrencies() []string {
+	return p.supportedCurrencies
+}
+
+func (p *BinanceProvider) isSupportedCurrency(currency string) bool {
+	for _, c := range p.supportedCurrencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *BinanceProvider) isCryptoCurrency(currency string) bool {
+	return p.cryptoCurrencies[currency]
+}
@@ -4,6 +4,7 @@
 package exchange
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,12 +13,22 @@ import (
 	"time"
 )
 
+func init() {
+	RegisterProviderFactory("coingecko", func(config ProviderConfig) (RateProvider, error) {
+		provider := NewCoinGeckoProvider()
+		if config.HTTPClient != nil {
+			provider.httpClient = config.HTTPClient
+		}
+		return provider, nil
+	})
+}
+
 // CoinGeckoProvider implements the RateProvider interface using CoinGecko API
 type CoinGeckoProvider struct {
-	apiURL             string
-	httpClient         *http.Client
+	apiURL              string
+	httpClient          *http.Client
 	supportedCurrencies []string
-	coinIdMap          map[string]string // Maps currency codes to CoinGecko IDs
+	coinIdMap           map[string]string // Maps currency codes to CoinGecko IDs
 }
 
 // NewCoinGeckoProvider creates a new CoinGecko provider
@@ -36,19 +47,19 @@ func NewCoinGeckoProvider() *CoinGeckoProvider {
 			"MYR", "SGD", "IDR", "THB", "BND", "KHR", "VND", "LAK", "PHP", "MMK",
 		},
 		coinIdMap: map[string]string{
-			"BTC": "bitcoin",
-			"ETH": "ethereum",
+			"BTC":  "bitcoin",
+			"ETH":  "ethereum",
 			"USDT": "tether",
 			"USDC": "usd-coin",
-			"BNB": "binancecoin",
-			"XRP": "ripple",
-			"ADA": "cardano",
-			"SOL": "solana",
-			"DOT": "polkadot",
+			"BNB":  "binancecoin",
+			"XRP":  "ripple",
+			"ADA":  "cardano",
+			"SOL":  "solana",
+			"DOT":  "polkadot",
 			"DOGE": "dogecoin",
 		},
 	}
-	
+
 	return provider
 }
 
@@ -58,49 +69,49 @@ func (p *CoinGeckoProvider) GetName() string {
 }
 
 // GetRate returns the exchange rate between two currencies
-func (p *CoinGeckoProvider) GetRate(baseCurrency, targetCurrency string) (*ExchangeRate, error) {
+func (p *CoinGeckoProvider) GetRate(ctx context.Context, baseCurrency, targetCurrency string) (*ExchangeRate, error) {
 	baseCurrency = strings.ToUpper(baseCurrency)
 	targetCurrency = strings.ToUpper(targetCurrency)
-	
+
 	// Validate currencies
 	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
 		return nil, ErrInvalidCurrency
 	}
-	
+
 	// Handle different scenarios:
 	// 1. Crypto to Fiat (most common)
 	// 2. Fiat to Crypto
 	// 3. Crypto to Crypto
 	// 4. Fiat to Fiat
-	
+
 	if p.isCryptoCurrency(baseCurrency) && !p.isCryptoCurrency(targetCurrency) {
 		// Crypto to Fiat
-		return p.getCryptoToFiatRate(baseCurrency, targetCurrency)
+		return p.getCryptoToFiatRate(ctx, baseCurrency, targetCurrency)
 	} else if !p.isCryptoCurrency(baseCurrency) && p.isCryptoCurrency(targetCurrency) {
 		// Fiat to Crypto - get inverse rate and then invert it
-		rate, err := p.getCryptoToFiatRate(targetCurrency, baseCurrency)
+		rate, err := p.getCryptoToFiatRate(ctx, targetCurrency, baseCurrency)
 		if err != nil {
 			return nil, err
 		}
-		
+
 		// Invert the rate
 		rate.BaseCurrency = baseCurrency
 		rate.TargetCurrency = targetCurrency
 		rate.Rate = 1.0 / rate.Rate
-		
+
 		return rate, nil
 	} else if p.isCryptoCurrency(baseCurrency) && p.isCryptoCurrency(targetCurrency) {
 		// Crypto to Crypto - get both in USD and then calculate
-		baseToUSD, err := p.getCryptoToFiatRate(baseCurrency, "USD")
+		baseToUSD, err := p.getCryptoToFiatRate(ctx, baseCurrency, "USD")
 		if err != nil {
 			return nil, err
 		}
-		
-		targetToUSD, err := p.getCryptoToFiatRate(targetCurrency, "USD")
+
+		targetToUSD, err := p.getCryptoToFiatRate(ctx, targetCurrency, "USD")
 		if err != nil {
 			return nil, err
 		}
-		
+
 		// Calculate cross rate
 		rate := &ExchangeRate{
 			BaseCurrency:   baseCurrency,
@@ -109,13 +120,13 @@ func (p *CoinGeckoProvider) GetRate(baseCurrency, targetCurrency string) (*Excha
 			Source:         p.GetName(),
 			Timestamp:      time.Now(),
 		}
-		
+
 		return rate, nil
 	} else {
 		// Fiat to Fiat - use a third-party API or service
 		// For simplicity, we'll use USD as an intermediate currency
 		// In a real implementation, you might want to use a dedicated forex API
-		
+
 		// This is a placeholder implementation
 		return &ExchangeRate{
 			BaseCurrency:   baseCurrency,
@@ -128,52 +139,56 @@ func (p *CoinGeckoProvider) GetRate(baseCurrency, targetCurrency string) (*Excha
 }
 
 // getCryptoToFiatRate gets the exchange rate from a cryptocurrency to a fiat currency
-func (p *CoinGeckoProvider) getCryptoToFiatRate(cryptoCurrency, fiatCurrency string) (*ExchangeRate, error) {
+func (p *CoinGeckoProvider) getCryptoToFiatRate(ctx context.Context, cryptoCurrency, fiatCurrency string) (*ExchangeRate, error) {
 	// Convert currency codes to CoinGecko format
 	coinId, exists := p.coinIdMap[cryptoCurrency]
 	if !exists {
 		return nil, ErrInvalidCurrency
 	}
-	
+
 	fiatCurrency = strings.ToLower(fiatCurrency)
-	
+
 	// Build API URL
 	url := fmt.Sprintf("%s/simple/price?ids=%s&vs_currencies=%s", p.apiURL, coinId, fiatCurrency)
-	
+
 	// Make request
-	resp, err := p.httpClient.Get(url)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build exchange rate request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch exchange rate: %w", err)
 	}
 	defer resp.Body.Close()
-	
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, ErrProviderUnavailable
 	}
-	
+
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
-	
+
 	// Parse response
 	var result map[string]map[string]float64
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-	
+
 	// Extract rate
 	coinData, exists := result[coinId]
 	if !exists {
 		return nil, ErrRateNotFound
 	}
-	
+
 	rate, exists := coinData[fiatCurrency]
 	if !exists {
 		return nil, ErrRateNotFound
 	}
-	
+
 	// Create exchange rate object
 	exchangeRate := &ExchangeRate{
 		BaseCurrency:   cryptoCurrency,
@@ -182,10 +197,112 @@ func (p *CoinGeckoProvider) getCryptoToFiatRate(cryptoCurrency, fiatCurrency str
 		Source:         p.GetName(),
 		Timestamp:      time.Now(),
 	}
-	
+
 	return exchangeRate, nil
 }
 
+// GetHistoricalRate returns the exchange rate between two currencies at a specific time,
+// via CoinGecko's /coins/{id}/history endpoint, which only resolves a coin's price in fiat
+// (and USD, for a crypto-to-crypto cross rate) on a given calendar date - intraday precision
+// isn't available on CoinGecko's free tier
+func (p *CoinGeckoProvider) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, at time.Time) (*ExchangeRate, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	targetCurrency = strings.ToUpper(targetCurrency)
+
+	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+
+	if p.isCryptoCurrency(baseCurrency) && !p.isCryptoCurrency(targetCurrency) {
+		return p.getHistoricalCryptoToFiatRate(ctx, baseCurrency, targetCurrency, at)
+	} else if !p.isCryptoCurrency(baseCurrency) && p.isCryptoCurrency(targetCurrency) {
+		rate, err := p.getHistoricalCryptoToFiatRate(ctx, targetCurrency, baseCurrency, at)
+		if err != nil {
+			return nil, err
+		}
+		rate.BaseCurrency = baseCurrency
+		rate.TargetCurrency = targetCurrency
+		rate.Rate = 1.0 / rate.Rate
+		return rate, nil
+	} else if p.isCryptoCurrency(baseCurrency) && p.isCryptoCurrency(targetCurrency) {
+		baseToUSD, err := p.getHistoricalCryptoToFiatRate(ctx, baseCurrency, "USD", at)
+		if err != nil {
+			return nil, err
+		}
+		targetToUSD, err := p.getHistoricalCryptoToFiatRate(ctx, targetCurrency, "USD", at)
+		if err != nil {
+			return nil, err
+		}
+		return &ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           baseToUSD.Rate / targetToUSD.Rate,
+			Source:         p.GetName(),
+			Timestamp:      at,
+		}, nil
+	}
+
+	// Fiat to fiat - same placeholder GetRate falls back to, since CoinGecko isn't a forex API
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           1.0,
+		Source:         p.GetName(),
+		Timestamp:      at,
+	}, nil
+}
+
+func (p *CoinGeckoProvider) getHistoricalCryptoToFiatRate(ctx context.Context, cryptoCurrency, fiatCurrency string, at time.Time) (*ExchangeRate, error) {
+	coinId, exists := p.coinIdMap[cryptoCurrency]
+	if !exists {
+		return nil, ErrInvalidCurrency
+	}
+	fiatCurrency = strings.ToLower(fiatCurrency)
+
+	url := fmt.Sprintf("%s/coins/%s/history?date=%s&localization=false", p.apiURL, coinId, at.Format("02-01-2006"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build historical rate request: %w", err)
+	}
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch historical rate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrProviderUnavailable
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var result struct {
+		MarketData struct {
+			CurrentPrice map[string]float64 `json:"current_price"`
+		} `json:"market_data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	rate, exists := result.MarketData.CurrentPrice[fiatCurrency]
+	if !exists {
+		return nil, ErrRateNotFound
+	}
+
+	return &ExchangeRate{
+		BaseCurrency:   cryptoCurrency,
+		TargetCurrency: strings.ToUpper(fiatCurrency),
+		Rate:           rate,
+		Source:         p.GetName(),
+		Timestamp:      at,
+	}, nil
+}
+
 // GetSupportedCurrencies returns the list of supported currencies
 func (p *CoinGeckoProvider) GetSupportedCurrencies() []string {
 	return p.supportedCurrencies
@@ -0,0 +1,250 @@
+// CoinMarketCap exchange rate provider for Asian Cryptocurrency Payment System
+// Uses CoinMarketCap's quotes/latest endpoint, which prices a crypto symbol directly against
+// any fiat currency CoinMarketCap tracks, so no USD-routing fallback is needed here the way
+// Binance/Kraken need one
+
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterProviderFactory("coinmarketcap", func(config ProviderConfig) (RateProvider, error) {
+		return NewCoinMarketCapProvider(config), nil
+	})
+}
+
+// cmcAPIKeyEnvVar is the environment variable CoinMarketCapProvider falls back to when
+// ProviderConfig.APIKey isn't set, matching CoinMarketCap's own documented env var name
+const cmcAPIKeyEnvVar = "CMC_PRO_API_KEY"
+
+// cmcQuotesResponse is the shape of CoinMarketCap's GET /v1/cryptocurrency/quotes/latest
+// response
+type cmcQuotesResponse struct {
+	Status struct {
+		ErrorCode    int    `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+	} `json:"status"`
+	Data map[string]struct {
+		Quote map[string]struct {
+			Price float64 `json:"price"`
+		} `json:"quote"`
+	} `json:"data"`
+}
+
+// CoinMarketCapProvider implements RateProvider using the CoinMarketCap Pro API
+type CoinMarketCapProvider struct {
+	apiURL              string
+	apiKey              string
+	http                *providerHTTPClient
+	supportedCurrencies []string
+	cryptoCurrencies    map[string]bool
+}
+
+// NewCoinMarketCapProvider creates a new CoinMarketCap provider. config.APIKey takes
+// precedence over the CMC_PRO_API_KEY environment variable; config.HTTPClient overrides the
+// default throttled HTTP client.
+func NewCoinMarketCapProvider(config ProviderConfig) *CoinMarketCapProvider {
+	apiKey := config.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv(cmcAPIKeyEnvVar)
+	}
+
+	provider := &CoinMarketCapProvider{
+		apiURL: "https://pro-api.coinmarketcap.com/v1",
+		apiKey: apiKey,
+		http:   newProviderHTTPClient(5, 5),
+		supportedCurrencies: []string{
+			"BTC", "ETH", "USDT", "USDC", "BNB", "XRP", "ADA", "SOL", "DOT", "DOGE",
+			"USD", "EUR", "JPY", "GBP", "AUD", "CAD", "CHF", "CNY", "HKD", "NZD",
+			"MYR", "SGD", "IDR", "THB", "BND", "KHR", "VND", "LAK", "PHP", "MMK",
+		},
+		cryptoCurrencies: map[string]bool{
+			"BTC": true, "ETH": true, "USDT": true, "USDC": true, "BNB": true,
+			"XRP": true, "ADA": true, "SOL": true, "DOT": true, "DOGE": true,
+		},
+	}
+	if config.HTTPClient != nil {
+		provider.http.client = config.HTTPClient
+	}
+	return provider
+}
+
+// GetName returns the name of the provider
+func (p *CoinMarketCapProvider) GetName() string {
+	return "CoinMarketCap"
+}
+
+// GetRate returns the exchange rate between two currencies
+func (p *CoinMarketCapProvider) GetRate(ctx context.Context, baseCurrency, targetCurrency string) (*ExchangeRate, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	targetCurrency = strings.ToUpper(targetCurrency)
+
+	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("%w: CoinMarketCap requires an API key (set ProviderConfig.APIKey or %s)", ErrInvalidConfiguration, cmcAPIKeyEnvVar)
+	}
+
+	if !p.isCryptoCurrency(baseCurrency) && !p.isCryptoCurrency(targetCurrency) {
+		return nil, fmt.Errorf("%w: CoinMarketCap only quotes pairs with at least one crypto leg", ErrInvalidCurrency)
+	}
+
+	if p.isCryptoCurrency(baseCurrency) {
+		price, err := p.quote(ctx, baseCurrency, targetCurrency)
+		if err != nil {
+			return nil, err
+		}
+		return &ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           price,
+			Source:         p.GetName(),
+			Timestamp:      time.Now(),
+		}, nil
+	}
+
+	// Fiat to crypto - quote the crypto leg against the fiat target, then invert
+	price, err := p.quote(ctx, targetCurrency, baseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           1.0 / price,
+		Source:         p.GetName(),
+		Timestamp:      time.Now(),
+	}, nil
+}
+
+func (p *CoinMarketCapProvider) quote(ctx context.Context, cryptoSymbol, convertCurrency string) (float64, error) {
+	url := fmt.Sprintf("%s/cryptocurrency/quotes/latest?symbol=%s&convert=%s", p.apiURL, cryptoSymbol, convertCurrency)
+	headers := map[string]string{"X-CMC_PRO_API_KEY": p.apiKey}
+
+	var response cmcQuotesResponse
+	if err := p.http.getJSON(ctx, url, headers, &response); err != nil {
+		return 0, err
+	}
+	if response.Status.ErrorCode != 0 {
+		return 0, fmt.Errorf("%w: %s", ErrProviderUnavailable, response.Status.ErrorMessage)
+	}
+
+	data, ok := response.Data[cryptoSymbol]
+	if !ok {
+		return 0, ErrRateNotFound
+	}
+	quote, ok := data.Quote[convertCurrency]
+	if !ok {
+		return 0, ErrRateNotFound
+	}
+	return quote.Price, nil
+}
+
+// cmcHistoricalQuotesResponse is the shape of CoinMarketCap's GET
+// /v2/cryptocurrency/quotes/historical response
+type cmcHistoricalQuotesResponse struct {
+	Status struct {
+		ErrorCode    int    `json:"error_code"`
+		ErrorMessage string `json:"error_message"`
+	} `json:"status"`
+	Data map[string]struct {
+		Quotes []struct {
+			Quote map[string]struct {
+				Price float64 `json:"price"`
+			} `json:"quote"`
+		} `json:"quotes"`
+	} `json:"data"`
+}
+
+// GetHistoricalRate returns the exchange rate between two currencies at a specific time, via
+// CoinMarketCap's historical quotes endpoint
+func (p *CoinMarketCapProvider) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, at time.Time) (*ExchangeRate, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	targetCurrency = strings.ToUpper(targetCurrency)
+
+	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("%w: CoinMarketCap requires an API key (set ProviderConfig.APIKey or %s)", ErrInvalidConfiguration, cmcAPIKeyEnvVar)
+	}
+	if !p.isCryptoCurrency(baseCurrency) && !p.isCryptoCurrency(targetCurrency) {
+		return nil, fmt.Errorf("%w: CoinMarketCap only quotes pairs with at least one crypto leg", ErrInvalidCurrency)
+	}
+
+	if p.isCryptoCurrency(baseCurrency) {
+		price, err := p.historicalQuote(ctx, baseCurrency, targetCurrency, at)
+		if err != nil {
+			return nil, err
+		}
+		return &ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           price,
+			Source:         p.GetName(),
+			Timestamp:      at,
+		}, nil
+	}
+
+	// Fiat to crypto - quote the crypto leg against the fiat target, then invert
+	price, err := p.historicalQuote(ctx, targetCurrency, baseCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           1.0 / price,
+		Source:         p.GetName(),
+		Timestamp:      at,
+	}, nil
+}
+
+func (p *CoinMarketCapProvider) historicalQuote(ctx context.Context, cryptoSymbol, convertCurrency string, at time.Time) (float64, error) {
+	url := fmt.Sprintf("%s/cryptocurrency/quotes/historical?symbol=%s&convert=%s&time_end=%s&count=1",
+		p.apiURL, cryptoSymbol, convertCurrency, at.Format(time.RFC3339))
+	headers := map[string]string{"X-CMC_PRO_API_KEY": p.apiKey}
+
+	var response cmcHistoricalQuotesResponse
+	if err := p.http.getJSON(ctx, url, headers, &response); err != nil {
+		return 0, err
+	}
+	if response.Status.ErrorCode != 0 {
+		return 0, fmt.Errorf("%w: %s", ErrProviderUnavailable, response.Status.ErrorMessage)
+	}
+
+	data, ok := response.Data[cryptoSymbol]
+	if !ok || len(data.Quotes) == 0 {
+		return 0, ErrRateNotFound
+	}
+	quote, ok := data.Quotes[0].Quote[convertCurrency]
+	if !ok {
+		return 0, ErrRateNotFound
+	}
+	return quote.Price, nil
+}
+
+// GetSupportedCurrencies returns the list of supported currencies
+func (p *CoinMarketCapProvider) GetSupportedCurrencies() []string {
+	return p.supportedCurrencies
+}
+
+func (p *CoinMarketCapProvider) isSupportedCurrency(currency string) bool {
+	for _, c := range p.supportedCurrencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *CoinMarketCapProvider) isCryptoCurrency(currency string) bool {
+	return p.cryptoCurrencies[currency]
+}
@@ -4,20 +4,34 @@
 package exchange
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // Common errors
 var (
-	ErrRateNotFound      = errors.New("exchange rate not found")
-	ErrInvalidCurrency   = errors.New("invalid currency")
+	ErrRateNotFound        = errors.New("exchange rate not found")
+	ErrInvalidCurrency     = errors.New("invalid currency")
 	ErrProviderUnavailable = errors.New("exchange rate provider unavailable")
+	// ErrProviderNotFound is returned by GetRateFromProvider when no registered provider
+	// matches the requested name
+	ErrProviderNotFound = errors.New("exchange rate provider not registered")
+	// ErrNoConsensus is returned when every registered provider either errored or was skipped
+	// as unhealthy, leaving no rates to reach a consensus from
+	ErrNoConsensus = errors.New("no providers returned a rate")
+	// ErrInvalidConfiguration is returned when a provider is missing configuration it needs
+	// to operate, such as an API key
+	ErrInvalidConfiguration = errors.New("invalid exchange rate provider configuration")
 )
 
 // ExchangeRate represents a currency exchange rate
@@ -27,139 +41,663 @@ type ExchangeRate struct {
 	Rate           float64   `json:"rate"`
 	Source         string    `json:"source"`
 	Timestamp      time.Time `json:"timestamp"`
+	// Sources lists every provider whose rate contributed to the consensus (populated by
+	// GetRate; empty for a rate returned by GetRateFromProvider, where Source alone suffices)
+	Sources []string `json:"sources,omitempty"`
 }
 
 // RateProvider defines the interface for exchange rate providers
 type RateProvider interface {
 	// GetName returns the name of the provider
 	GetName() string
-	
+
 	// GetRate returns the exchange rate between two currencies
-	GetRate(baseCurrency, targetCurrency string) (*ExchangeRate, error)
-	
+	GetRate(ctx context.Context, baseCurrency, targetCurrency string) (*ExchangeRate, error)
+
+	// GetHistoricalRate returns the exchange rate between two currencies at a specific time
+	GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, at time.Time) (*ExchangeRate, error)
+
 	// GetSupportedCurrencies returns the list of supported currencies
 	GetSupportedCurrencies() []string
 }
 
+// ProviderHealth tracks one provider's recent call outcomes, so ExchangeRateService can back
+// off from a provider that's failing instead of querying it (and waiting out its timeout) on
+// every single GetRate call
+type ProviderHealth struct {
+	SuccessCount  int
+	ErrorCount    int
+	LastLatency   time.Duration
+	LastSuccessAt time.Time
+	LastFailureAt time.Time
+
+	consecutiveFail int
+	skipUntil       time.Time
+}
+
+// ConsensusMethod selects how ExchangeRateService.GetRate reduces multiple providers' rates
+// down to a single number once outliers have been dropped
+type ConsensusMethod int
+
+const (
+	// ConsensusMedian takes the median of the surviving rates
+	ConsensusMedian ConsensusMethod = iota
+	// ConsensusTrimmedMean drops the highest and lowest surviving rate (when there are more
+	// than two) and averages the rest
+	ConsensusTrimmedMean
+)
+
+const (
+	// defaultProviderTimeout bounds how long GetRate waits on any single provider before
+	// treating it as failed for this call
+	defaultProviderTimeout = 5 * time.Second
+	// defaultOutlierThreshold is how far (as a fraction of the median) a provider's rate may
+	// deviate before GetRate discards it as an outlier
+	defaultOutlierThreshold = 0.02
+	// providerBackoffBase is the initial skip duration applied after a provider's first
+	// consecutive failure; it doubles with each further consecutive failure, up to
+	// providerBackoffMax
+	providerBackoffBase = 5 * time.Second
+	providerBackoffMax  = 5 * time.Minute
+	// defaultQuoteTTL bounds how long a Quote remains valid and lookupable via LookupQuote
+	defaultQuoteTTL = 30 * time.Second
+)
+
+// commonCryptoCurrencies classifies a currency as crypto for cache TTL purposes only; each
+// RateProvider maintains its own authoritative supported-currency list for validation
+var commonCryptoCurrencies = map[string]bool{
+	"BTC": true, "ETH": true, "USDT": true, "USDC": true, "BNB": true,
+	"XRP": true, "ADA": true, "SOL": true, "DOT": true, "DOGE": true,
+}
+
 // ExchangeRateService manages exchange rates from multiple providers
 type ExchangeRateService struct {
 	providers     []RateProvider
 	cacheEnabled  bool
 	cacheDuration time.Duration
-	cache         map[string]*ExchangeRate
-	cacheMutex    sync.RWMutex
+	cache         RateCache
+	group         singleflight.Group
+
+	// ProviderTimeout bounds how long GetRate waits on any single provider. Defaults to
+	// defaultProviderTimeout if zero.
+	ProviderTimeout time.Duration
+	// ConsensusMethod selects how surviving rates are reduced to one. Defaults to
+	// ConsensusMedian.
+	ConsensusMethod ConsensusMethod
+	// OutlierThreshold is the fractional deviation from the median a provider's rate may
+	// have before it's dropped as an outlier (e.g. 0.02 for 2%). Defaults to
+	// defaultOutlierThreshold if zero.
+	OutlierThreshold float64
+	// Archive backs GetRateAt with a nearest-neighbour lookup against previously-recorded
+	// rates, before falling back to a provider's historical endpoint. Nil disables it.
+	Archive *RateArchiveStore
+	// CryptoCacheTTL overrides cacheDuration for pairs with a crypto leg, which move faster
+	// than fiat pairs and so should be cached for a shorter window. Defaults to cacheDuration
+	// if zero.
+	CryptoCacheTTL time.Duration
+	// FiatCacheTTL overrides cacheDuration for pairs with no crypto leg. Defaults to
+	// cacheDuration if zero.
+	FiatCacheTTL time.Duration
+	// CacheMetrics records cache hit/miss/coalesce counters, if set. Nil disables metrics.
+	CacheMetrics *RateCacheMetrics
+	// SpreadPolicy computes the bid/ask spread Quote applies to a consensus rate. Defaults to
+	// a zero-spread FlatSpread if nil.
+	SpreadPolicy SpreadPolicy
+	// QuoteTTL bounds how long a Quote remains valid and lookupable via LookupQuote. Defaults
+	// to defaultQuoteTTL if zero.
+	QuoteTTL time.Duration
+
+	healthMutex sync.Mutex
+	health      map[string]*ProviderHealth
+
+	quoteMutex sync.Mutex
+	quotes     map[string]*Quote
 }
 
-// NewExchangeRateService creates a new exchange rate service
+// NewExchangeRateService creates a new exchange rate service backed by an in-process
+// MemoryRateCache. Call SetCache to switch to a RedisRateCache shared across replicas.
 func NewExchangeRateService(cacheEnabled bool, cacheDuration time.Duration) *ExchangeRateService {
 	service := &ExchangeRateService{
 		providers:     make([]RateProvider, 0),
 		cacheEnabled:  cacheEnabled,
 		cacheDuration: cacheDuration,
-		cache:         make(map[string]*ExchangeRate),
+		cache:         NewMemoryRateCache(),
+		health:        make(map[string]*ProviderHealth),
+		quotes:        make(map[string]*Quote),
 	}
-	
+
 	return service
 }
 
+// SetCache replaces the service's RateCache, e.g. with a RedisRateCache shared across
+// replicas in place of the default in-process MemoryRateCache
+func (s *ExchangeRateService) SetCache(cache RateCache) {
+	s.cache = cache
+}
+
+// cacheTTLFor returns the TTL a base/target pair's cache entry should use: CryptoCacheTTL if
+// either leg is a common crypto currency, FiatCacheTTL otherwise, falling back to
+// cacheDuration when the relevant override is unset
+func (s *ExchangeRateService) cacheTTLFor(baseCurrency, targetCurrency string) time.Duration {
+	if commonCryptoCurrencies[strings.ToUpper(baseCurrency)] || commonCryptoCurrencies[strings.ToUpper(targetCurrency)] {
+		if s.CryptoCacheTTL > 0 {
+			return s.CryptoCacheTTL
+		}
+		return s.cacheDuration
+	}
+	if s.FiatCacheTTL > 0 {
+		return s.FiatCacheTTL
+	}
+	return s.cacheDuration
+}
+
 // RegisterProvider adds a rate provider to the service
 func (s *ExchangeRateService) RegisterProvider(provider RateProvider) {
 	s.providers = append(s.providers, provider)
 }
 
-// GetRate returns the exchange rate between two currencies
-func (s *ExchangeRateService) GetRate(baseCurrency, targetCurrency string) (*ExchangeRate, error) {
+// ProviderHealth returns a snapshot of name's tracked health, and whether it has been queried
+// at least once
+func (s *ExchangeRateService) ProviderHealth(name string) (ProviderHealth, bool) {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+	h, ok := s.health[name]
+	if !ok {
+		return ProviderHealth{}, false
+	}
+	return *h, true
+}
+
+// healthFor returns (creating if necessary) the ProviderHealth tracked for name. Callers must
+// hold s.healthMutex.
+func (s *ExchangeRateService) healthFor(name string) *ProviderHealth {
+	h, ok := s.health[name]
+	if !ok {
+		h = &ProviderHealth{}
+		s.health[name] = h
+	}
+	return h
+}
+
+// skippingUnhealthy reports whether provider should be skipped this round under its
+// exponential backoff, without querying it
+func (s *ExchangeRateService) skippingUnhealthy(name string) bool {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+	h := s.healthFor(name)
+	return !h.skipUntil.IsZero() && time.Now().Before(h.skipUntil)
+}
+
+// recordSuccess updates name's health after a successful call taking latency
+func (s *ExchangeRateService) recordSuccess(name string, latency time.Duration) {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+	h := s.healthFor(name)
+	h.SuccessCount++
+	h.LastLatency = latency
+	h.LastSuccessAt = time.Now()
+	h.consecutiveFail = 0
+	h.skipUntil = time.Time{}
+}
+
+// recordFailure updates name's health after a failed call, doubling its backoff skip window
+func (s *ExchangeRateService) recordFailure(name string) {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+	h := s.healthFor(name)
+	h.ErrorCount++
+	h.LastFailureAt = time.Now()
+	h.consecutiveFail++
+
+	backoff := providerBackoffBase << uint(h.consecutiveFail-1)
+	if backoff > providerBackoffMax || backoff <= 0 {
+		backoff = providerBackoffMax
+	}
+	h.skipUntil = time.Now().Add(backoff)
+}
+
+// providerResult carries one provider's outcome back from its goroutine in GetRate
+type providerResult struct {
+	rate *ExchangeRate
+	err  error
+}
+
+// GetRate returns a consensus exchange rate between two currencies, queried in parallel from
+// every currently-healthy registered provider
+func (s *ExchangeRateService) GetRate(ctx context.Context, baseCurrency, targetCurrency string) (*ExchangeRate, error) {
+	cacheKey := cacheKeyFor(baseCurrency, targetCurrency)
+	ttl := s.cacheTTLFor(baseCurrency, targetCurrency)
+
 	// Check cache first if enabled
 	if s.cacheEnabled {
-		cacheKey := fmt.Sprintf("%s-%s", baseCurrency, targetCurrency)
-		s.cacheMutex.RLock()
-		cachedRate, exists := s.cache[cacheKey]
-		s.cacheMutex.RUnlock()
-		
-		if exists && time.Since(cachedRate.Timestamp) < s.cacheDuration {
+		cachedRate, exists, err := s.cache.Get(ctx, cacheKey)
+		if err == nil && exists && time.Since(cachedRate.Timestamp) < ttl {
+			s.CacheMetrics.recordHit(baseCurrency, targetCurrency)
 			return cachedRate, nil
 		}
+		s.CacheMetrics.recordMiss(baseCurrency, targetCurrency)
+	}
+
+	// singleflight collapses concurrent GetRate calls for the same pair onto a single
+	// provider round-trip, so a burst of requests can't each trigger their own fan-out
+	result, err, shared := s.group.Do(cacheKey, func() (interface{}, error) {
+		return s.fetchConsensus(ctx, baseCurrency, targetCurrency)
+	})
+	if shared {
+		s.CacheMetrics.recordCoalesced(baseCurrency, targetCurrency)
 	}
-	
-	// Try each provider until we get a rate
+	if err != nil {
+		return nil, err
+	}
+
+	consensus := result.(*ExchangeRate)
+	if s.cacheEnabled {
+		_ = s.cache.Set(ctx, cacheKey, consensus, ttl)
+	}
+	return consensus, nil
+}
+
+// fetchConsensus queries every currently-healthy registered provider in parallel and reduces
+// their results to a single consensus rate. It's the uncached, uncoalesced body GetRate runs
+// behind its singleflight group.
+func (s *ExchangeRateService) fetchConsensus(ctx context.Context, baseCurrency, targetCurrency string) (*ExchangeRate, error) {
+	timeout := s.ProviderTimeout
+	if timeout <= 0 {
+		timeout = defaultProviderTimeout
+	}
+
+	results := make(chan providerResult, len(s.providers))
+	queried := 0
+	for _, provider := range s.providers {
+		if s.skippingUnhealthy(provider.GetName()) {
+			continue
+		}
+		queried++
+		go func(provider RateProvider) {
+			providerCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			rate, err := provider.GetRate(providerCtx, baseCurrency, targetCurrency)
+			latency := time.Since(start)
+
+			if err != nil {
+				s.recordFailure(provider.GetName())
+			} else {
+				s.recordSuccess(provider.GetName(), latency)
+			}
+			results <- providerResult{rate: rate, err: err}
+		}(provider)
+	}
+
+	var rates []*ExchangeRate
 	var lastError error
+	for i := 0; i < queried; i++ {
+		result := <-results
+		if result.err != nil {
+			lastError = result.err
+			continue
+		}
+		rates = append(rates, result.rate)
+	}
+
+	consensus, err := s.computeConsensus(baseCurrency, targetCurrency, rates)
+	if err != nil {
+		if lastError != nil {
+			return nil, lastError
+		}
+		return nil, err
+	}
+	return consensus, nil
+}
+
+// GetRateFromProvider returns the exchange rate from a single named provider, bypassing the
+// consensus pipeline, for callers that need a specific source
+func (s *ExchangeRateService) GetRateFromProvider(ctx context.Context, name, baseCurrency, targetCurrency string) (*ExchangeRate, error) {
+	cacheKey := cacheKeyForProvider(baseCurrency, targetCurrency, name)
+	ttl := s.cacheTTLFor(baseCurrency, targetCurrency)
+
+	if s.cacheEnabled {
+		cachedRate, exists, err := s.cache.Get(ctx, cacheKey)
+		if err == nil && exists && time.Since(cachedRate.Timestamp) < ttl {
+			s.CacheMetrics.recordHit(baseCurrency, targetCurrency)
+			return cachedRate, nil
+		}
+		s.CacheMetrics.recordMiss(baseCurrency, targetCurrency)
+	}
+
+	result, err, shared := s.group.Do("provider:"+cacheKey, func() (interface{}, error) {
+		return s.fetchFromProvider(ctx, name, baseCurrency, targetCurrency)
+	})
+	if shared {
+		s.CacheMetrics.recordCoalesced(baseCurrency, targetCurrency)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rate := result.(*ExchangeRate)
+	if s.cacheEnabled {
+		_ = s.cache.Set(ctx, cacheKey, rate, ttl)
+	}
+	return rate, nil
+}
+
+// fetchFromProvider queries the single named provider. It's the uncached, uncoalesced body
+// GetRateFromProvider runs behind its singleflight group.
+func (s *ExchangeRateService) fetchFromProvider(ctx context.Context, name, baseCurrency, targetCurrency string) (*ExchangeRate, error) {
 	for _, provider := range s.providers {
-		rate, err := provider.GetRate(baseCurrency, targetCurrency)
+		if provider.GetName() != name {
+			continue
+		}
+
+		timeout := s.ProviderTimeout
+		if timeout <= 0 {
+			timeout = defaultProviderTimeout
+		}
+		providerCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		start := time.Now()
+		rate, err := provider.GetRate(providerCtx, baseCurrency, targetCurrency)
+		if err != nil {
+			s.recordFailure(name)
+			return nil, err
+		}
+		s.recordSuccess(name, time.Since(start))
+		return rate, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrProviderNotFound, name)
+}
+
+// defaultArchiveTolerance bounds how far from `at` GetRateAt accepts an archived tick as a
+// stand-in before falling back to a provider's historical endpoint
+const defaultArchiveTolerance = 5 * time.Minute
+
+// GetRateAt returns the exchange rate between two currencies at a specific time, so a
+// merchant can reconcile a transaction against the rate at the moment it was captured rather
+// than the rate at reporting time. It checks s.Archive for a nearby recorded tick first
+// (within defaultArchiveTolerance), then falls back to the first provider whose
+// GetHistoricalRate call succeeds.
+func (s *ExchangeRateService) GetRateAt(ctx context.Context, baseCurrency, targetCurrency string, at time.Time) (*ExchangeRate, error) {
+	if s.Archive != nil {
+		rate, found, err := s.Archive.Nearest(ctx, baseCurrency, targetCurrency, at, defaultArchiveTolerance)
+		if err == nil && found {
+			return rate, nil
+		}
+	}
+
+	var lastError error
+	for _, provider := range s.providers {
+		rate, err := provider.GetHistoricalRate(ctx, baseCurrency, targetCurrency, at)
 		if err == nil {
-			// Update cache if enabled
-			if s.cacheEnabled {
-				cacheKey := fmt.Sprintf("%s-%s", baseCurrency, targetCurrency)
-				s.cacheMutex.Lock()
-				s.cache[cacheKey] = rate
-				s.cacheMutex.Unlock()
-			}
 			return rate, nil
 		}
 		lastError = err
 	}
-	
-	// If we get here, all providers failed
 	if lastError != nil {
 		return nil, lastError
 	}
 	return nil, ErrRateNotFound
 }
 
+// computeConsensus reduces rates down to a single ExchangeRate, dropping outliers that
+// deviate from the median by more than s.OutlierThreshold before applying s.ConsensusMethod
+func (s *ExchangeRateService) computeConsensus(baseCurrency, targetCurrency string, rates []*ExchangeRate) (*ExchangeRate, error) {
+	if len(rates) == 0 {
+		return nil, ErrNoConsensus
+	}
+
+	values := make([]float64, len(rates))
+	for i, rate := range rates {
+		values[i] = rate.Rate
+	}
+	median := medianOf(values)
+
+	threshold := s.OutlierThreshold
+	if threshold <= 0 {
+		threshold = defaultOutlierThreshold
+	}
+
+	var surviving []*ExchangeRate
+	for _, rate := range rates {
+		if median == 0 || deviation(rate.Rate, median) <= threshold {
+			surviving = append(surviving, rate)
+		}
+	}
+	if len(surviving) == 0 {
+		// Every provider disagreed wildly with the median; trust the median itself rather
+		// than returning nothing.
+		surviving = rates
+	}
+
+	sources := make([]string, len(surviving))
+	survivingValues := make([]float64, len(surviving))
+	for i, rate := range surviving {
+		sources[i] = rate.Source
+		survivingValues[i] = rate.Rate
+	}
+
+	var consensusRate float64
+	switch s.ConsensusMethod {
+	case ConsensusTrimmedMean:
+		consensusRate = trimmedMeanOf(survivingValues)
+	default:
+		consensusRate = medianOf(survivingValues)
+	}
+
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           consensusRate,
+		Source:         "consensus",
+		Timestamp:      time.Now(),
+		Sources:        sources,
+	}, nil
+}
+
+// deviation returns |value-median|/median as a fraction
+func deviation(value, median float64) float64 {
+	if median == 0 {
+		return 0
+	}
+	d := (value - median) / median
+	if d < 0 {
+		d = -d
+	}
+	return d
+}
+
+// medianOf returns the median of values, which must be non-empty
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// trimmedMeanOf averages values after dropping the single highest and lowest entry, provided
+// there are more than two; otherwise it falls back to a plain mean
+func trimmedMeanOf(values []float64) float64 {
+	if len(values) <= 2 {
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	trimmed := sorted[1 : len(sorted)-1]
+
+	var sum float64
+	for _, v := range trimmed {
+		sum += v
+	}
+	return sum / float64(len(trimmed))
+}
+
 // GetRateWithSpread returns the exchange rate with a spread applied
-func (s *ExchangeRateService) GetRateWithSpread(baseCurrency, targetCurrency string, spreadPercentage float64) (*ExchangeRate, error) {
-	rate, err := s.GetRate(baseCurrency, targetCurrency)
+func (s *ExchangeRateService) GetRateWithSpread(ctx context.Context, baseCurrency, targetCurrency string, spreadPercentage float64) (*ExchangeRate, error) {
+	rate, err := s.GetRate(ctx, baseCurrency, targetCurrency)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// Apply spread
 	spreadFactor := 1.0 + (spreadPercentage / 100.0)
 	rate.Rate = rate.Rate * spreadFactor
-	
+
 	return rate, nil
 }
 
+// QuoteRequest describes a caller's request for a buy/sell quote
+type QuoteRequest struct {
+	BaseCurrency   string
+	TargetCurrency string
+	Amount         float64
+	// MerchantID scopes a merchant-specific SpreadPolicy rate, such as PerCorridorSpread's
+	// per-merchant override; it may be empty if the policy in use doesn't need one
+	MerchantID string
+}
+
+// Quote is a short-lived, honoured buy/sell rate pair. Its QuoteID can be looked up via
+// LookupQuote during settlement, so a transaction is charged the rate it was quoted rather
+// than whatever the rate has since moved to.
+type Quote struct {
+	QuoteID        string
+	BaseCurrency   string
+	TargetCurrency string
+	BuyRate        float64
+	SellRate       float64
+	Sources        []string
+	Policy         string
+	IssuedAt       time.Time
+	ExpiresAt      time.Time
+}
+
+// Quote fetches the consensus rate for req and applies s.SpreadPolicy (a zero-spread
+// FlatSpread if unset) to produce a buy/sell pair, returning it as a Quote whose QuoteID can
+// later be looked up via LookupQuote during settlement
+func (s *ExchangeRateService) Quote(ctx context.Context, req QuoteRequest) (*Quote, error) {
+	rate, err := s.GetRate(ctx, req.BaseCurrency, req.TargetCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := s.SpreadPolicy
+	if policy == nil {
+		policy = FlatSpread{}
+	}
+	if observer, ok := policy.(*VolatilityAdjustedSpread); ok {
+		observer.Observe(req.BaseCurrency, req.TargetCurrency, rate.Rate, rate.Timestamp)
+	}
+
+	spread, err := policy.Quote(ctx, req.BaseCurrency, req.TargetCurrency, req.Amount, req.MerchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteID, err := generateQuoteID()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := s.QuoteTTL
+	if ttl <= 0 {
+		ttl = defaultQuoteTTL
+	}
+	now := time.Now()
+
+	quote := &Quote{
+		QuoteID:        quoteID,
+		BaseCurrency:   req.BaseCurrency,
+		TargetCurrency: req.TargetCurrency,
+		BuyRate:        rate.Rate * (1.0 + spread.AskPercentage/100.0),
+		SellRate:       rate.Rate * (1.0 - spread.BidPercentage/100.0),
+		Sources:        rate.Sources,
+		Policy:         spread.Policy,
+		IssuedAt:       now,
+		ExpiresAt:      now.Add(ttl),
+	}
+
+	s.quoteMutex.Lock()
+	s.quotes[quoteID] = quote
+	s.quoteMutex.Unlock()
+
+	return quote, nil
+}
+
+// LookupQuote returns a previously-issued Quote by ID, provided it hasn't expired, for
+// settlement to honor the rate a customer was originally quoted
+func (s *ExchangeRateService) LookupQuote(quoteID string) (*Quote, bool) {
+	s.quoteMutex.Lock()
+	defer s.quoteMutex.Unlock()
+
+	quote, ok := s.quotes[quoteID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(quote.ExpiresAt) {
+		delete(s.quotes, quoteID)
+		return nil, false
+	}
+	return quote, true
+}
+
+// generateQuoteID returns a random 16-byte hex-encoded quote ID, read from crypto/rand the
+// same way integration.generateSessionToken reads its 32-byte session tokens
+func generateQuoteID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("failed to generate quote id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
 // ClearCache clears the exchange rate cache
-func (s *ExchangeRateService) ClearCache() {
-	s.cacheMutex.Lock()
-	s.cache = make(map[string]*ExchangeRate)
-	s.cacheMutex.Unlock()
+func (s *ExchangeRateService) ClearCache(ctx context.Context) error {
+	return s.cache.Clear(ctx)
 }
 
 // GetSupportedCurrencies returns the list of supported currencies across all providers
 func (s *ExchangeRateService) GetSupportedCurrencies() []string {
 	currencyMap := make(map[string]bool)
-	
+
 	for _, provider := range s.providers {
 		for _, currency := range provider.GetSupportedCurrencies() {
 			currencyMap[currency] = true
 		}
 	}
-	
+
 	currencies := make([]string, 0, len(currencyMap))
 	for currency := range currencyMap {
 		currencies = append(currencies, currency)
 	}
-	
+
 	return currencies
 }
 
 // ConvertAmount converts an amount from one currency to another
-func (s *ExchangeRateService) ConvertAmount(amount float64, fromCurrency, toCurrency string) (float64, error) {
-	rate, err := s.GetRate(fromCurrency, toCurrency)
+func (s *ExchangeRateService) ConvertAmount(ctx context.Context, amount float64, fromCurrency, toCurrency string) (float64, error) {
+	rate, err := s.GetRate(ctx, fromCurrency, toCurrency)
 	if err != nil {
 		return 0, err
 	}
-	
+
 	return amount * rate.Rate, nil
 }
 
 // ConvertAmountWithSpread converts an amount with a spread applied
-func (s *ExchangeRateService) ConvertAmountWithSpread(amount float64, fromCurrency, toCurrency string, spreadPercentage float64) (float64, error) {
-	rate, err := s.GetRateWithSpread(fromCurrency, toCurrency, spreadPercentage)
+func (s *ExchangeRateService) ConvertAmountWithSpread(ctx context.Context, amount float64, fromCurrency, toCurrency string, spreadPercentage float64) (float64, error) {
+	rate, err := s.GetRateWithSpread(ctx, fromCurrency, toCurrency, spreadPercentage)
 	if err != nil {
 		return 0, err
 	}
-	
+
 	return amount * rate.Rate, nil
 }
@@ -0,0 +1,159 @@
+// Background exchange rate archival, so a merchant can later reconcile a transaction against
+// the rate at the exact moment a payment was captured rather than the rate at reporting time.
+// Follows the same self-contained gorm model + store pattern integration's
+// SQLIdempotencyStore uses, rather than depending on the database package directly.
+
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExchangeRateArchiveRecord is the gorm model RateArchiveStore persists rates in
+type ExchangeRateArchiveRecord struct {
+	gorm.Model
+	BaseCurrency   string    `gorm:"size:10;not null;index:idx_archive_lookup"`
+	TargetCurrency string    `gorm:"size:10;not null;index:idx_archive_lookup"`
+	Rate           float64   `gorm:"not null"`
+	Source         string    `gorm:"size:50;not null"`
+	Timestamp      time.Time `gorm:"not null;index:idx_archive_lookup"`
+}
+
+// RateArchiveStore persists exchange rate ticks to SQL via gorm, and serves nearest-neighbour
+// lookups for GetRateAt. The caller is responsible for having migrated
+// ExchangeRateArchiveRecord (db.AutoMigrate(&ExchangeRateArchiveRecord{})) beforehand.
+type RateArchiveStore struct {
+	db *gorm.DB
+}
+
+// NewRateArchiveStore creates a RateArchiveStore backed by db
+func NewRateArchiveStore(db *gorm.DB) *RateArchiveStore {
+	return &RateArchiveStore{db: db}
+}
+
+// Record persists rate as an archived tick
+func (s *RateArchiveStore) Record(ctx context.Context, rate *ExchangeRate) error {
+	record := ExchangeRateArchiveRecord{
+		BaseCurrency:   rate.BaseCurrency,
+		TargetCurrency: rate.TargetCurrency,
+		Rate:           rate.Rate,
+		Source:         rate.Source,
+		Timestamp:      rate.Timestamp,
+	}
+	if err := s.db.WithContext(ctx).Create(&record).Error; err != nil {
+		return fmt.Errorf("failed to archive exchange rate: %w", err)
+	}
+	return nil
+}
+
+// Nearest returns the archived tick for base/target closest to at, provided it falls within
+// tolerance, and whether one was found
+func (s *RateArchiveStore) Nearest(ctx context.Context, base, target string, at time.Time, tolerance time.Duration) (*ExchangeRate, bool, error) {
+	windowStart := at.Add(-tolerance)
+	windowEnd := at.Add(tolerance)
+
+	var candidates []ExchangeRateArchiveRecord
+	err := s.db.WithContext(ctx).
+		Where("base_currency = ? AND target_currency = ? AND timestamp BETWEEN ? AND ?", base, target, windowStart, windowEnd).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up archived exchange rate: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, false, nil
+	}
+
+	nearest := candidates[0]
+	nearestGap := absDuration(nearest.Timestamp.Sub(at))
+	for _, candidate := range candidates[1:] {
+		gap := absDuration(candidate.Timestamp.Sub(at))
+		if gap < nearestGap {
+			nearest = candidate
+			nearestGap = gap
+		}
+	}
+
+	return &ExchangeRate{
+		BaseCurrency:   nearest.BaseCurrency,
+		TargetCurrency: nearest.TargetCurrency,
+		Rate:           nearest.Rate,
+		Source:         nearest.Source,
+		Timestamp:      nearest.Timestamp,
+	}, true, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// CurrencyPair is one base/target combination an ExchangeRateArchiver keeps archived
+type CurrencyPair struct {
+	Base   string
+	Target string
+}
+
+// ExchangeRateArchiver periodically fetches the current consensus rate for a configured
+// currency matrix and persists each into a RateArchiveStore
+type ExchangeRateArchiver struct {
+	service  *ExchangeRateService
+	store    *RateArchiveStore
+	pairs    []CurrencyPair
+	interval time.Duration
+
+	stopOnce sync.Once
+	cancel   context.CancelFunc
+}
+
+// NewExchangeRateArchiver creates an ExchangeRateArchiver that fetches service.GetRate for
+// every pair in pairs, once per interval, persisting the result into store
+func NewExchangeRateArchiver(service *ExchangeRateService, store *RateArchiveStore, pairs []CurrencyPair, interval time.Duration) *ExchangeRateArchiver {
+	return &ExchangeRateArchiver{service: service, store: store, pairs: pairs, interval: interval}
+}
+
+// Start begins archiving on a ticker until ctx is cancelled or Stop is called
+func (a *ExchangeRateArchiver) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	a.cancel = cancel
+
+	ticker := time.NewTicker(a.interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.archiveOnce(ctx)
+			}
+		}
+	}()
+}
+
+// Stop halts the archiver's ticker loop
+func (a *ExchangeRateArchiver) Stop() {
+	a.stopOnce.Do(func() {
+		if a.cancel != nil {
+			a.cancel()
+		}
+	})
+}
+
+// archiveOnce fetches and archives the current rate for every configured pair, continuing
+// past any single pair's failure so one bad provider doesn't stall the rest of the matrix
+func (a *ExchangeRateArchiver) archiveOnce(ctx context.Context) {
+	for _, pair := range a.pairs {
+		rate, err := a.service.GetRate(ctx, pair.Base, pair.Target)
+		if err != nil {
+			continue
+		}
+		_ = a.store.Record(ctx, rate)
+	}
+}
@@ -0,0 +1,150 @@
+// Frankfurter exchange rate provider for Asian Cryptocurrency Payment System
+// Frankfurter (European Central Bank reference rates) covers real fiat-to-fiat FX, replacing
+// the hardcoded 1.0 placeholder CoinGeckoProvider falls back to for fiat pairs. It doesn't
+// quote cryptocurrencies at all.
+
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterProviderFactory("frankfurter", func(config ProviderConfig) (RateProvider, error) {
+		return NewFrankfurterProvider(config), nil
+	})
+}
+
+// frankfurterResponse is the shape of Frankfurter's GET /latest response
+type frankfurterResponse struct {
+	Amount float64            `json:"amount"`
+	Base   string             `json:"base"`
+	Date   string             `json:"date"`
+	Rates  map[string]float64 `json:"rates"`
+}
+
+// FrankfurterProvider implements RateProvider using the Frankfurter fiat FX API
+type FrankfurterProvider struct {
+	apiURL              string
+	http                *providerHTTPClient
+	supportedCurrencies []string
+}
+
+// NewFrankfurterProvider creates a new Frankfurter provider. config.HTTPClient overrides the
+// default throttled HTTP client; config.APIKey is ignored, since Frankfurter is a free,
+// unauthenticated API.
+func NewFrankfurterProvider(config ProviderConfig) *FrankfurterProvider {
+	provider := &FrankfurterProvider{
+		apiURL: "https://api.frankfurter.app",
+		http:   newProviderHTTPClient(5, 5),
+		supportedCurrencies: []string{
+			"USD", "EUR", "JPY", "GBP", "AUD", "CAD", "CHF", "CNY", "HKD", "NZD",
+			"MYR", "SGD", "IDR", "THB", "PHP",
+		},
+	}
+	if config.HTTPClient != nil {
+		provider.http.client = config.HTTPClient
+	}
+	return provider
+}
+
+// GetName returns the name of the provider
+func (p *FrankfurterProvider) GetName() string {
+	return "Frankfurter"
+}
+
+// GetRate returns the exchange rate between two fiat currencies
+func (p *FrankfurterProvider) GetRate(ctx context.Context, baseCurrency, targetCurrency string) (*ExchangeRate, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	targetCurrency = strings.ToUpper(targetCurrency)
+
+	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+	if baseCurrency == targetCurrency {
+		return &ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           1.0,
+			Source:         p.GetName(),
+			Timestamp:      time.Now(),
+		}, nil
+	}
+
+	url := fmt.Sprintf("%s/latest?from=%s&to=%s", p.apiURL, baseCurrency, targetCurrency)
+
+	var response frankfurterResponse
+	if err := p.http.getJSON(ctx, url, nil, &response); err != nil {
+		return nil, err
+	}
+
+	rate, ok := response.Rates[targetCurrency]
+	if !ok {
+		return nil, ErrRateNotFound
+	}
+
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           rate,
+		Source:         p.GetName(),
+		Timestamp:      time.Now(),
+	}, nil
+}
+
+// GetHistoricalRate returns the exchange rate between two fiat currencies on a specific date,
+// via Frankfurter's dated endpoint (e.g. /2024-01-15) in place of /latest
+func (p *FrankfurterProvider) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, at time.Time) (*ExchangeRate, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	targetCurrency = strings.ToUpper(targetCurrency)
+
+	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+	if baseCurrency == targetCurrency {
+		return &ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           1.0,
+			Source:         p.GetName(),
+			Timestamp:      at,
+		}, nil
+	}
+
+	url := fmt.Sprintf("%s/%s?from=%s&to=%s", p.apiURL, at.Format("2006-01-02"), baseCurrency, targetCurrency)
+
+	var response frankfurterResponse
+	if err := p.http.getJSON(ctx, url, nil, &response); err != nil {
+		return nil, err
+	}
+
+	rate, ok := response.Rates[targetCurrency]
+	if !ok {
+		return nil, ErrRateNotFound
+	}
+
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           rate,
+		Source:         p.GetName(),
+		Timestamp:      at,
+	}, nil
+}
+
+// GetSupportedCurrencies returns the list of supported currencies
+func (p *FrankfurterProvider) GetSupportedCurrencies() []string {
+	return p.supportedCurrencies
+}
+
+func (p *FrankfurterProvider) isSupportedCurrency(currency string) bool {
+	for _, c := range p.supportedCurrencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
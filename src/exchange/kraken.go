@@ -0,0 +1,285 @@
+// Kraken exchange rate provider for Asian Cryptocurrency Payment System
+// Uses Kraken's public Ticker endpoint for crypto pairs; fiat-to-fiat pairs are rejected, and a
+// pair with no direct Kraken market is routed through USD as an intermediate
+
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterProviderFactory("kraken", func(config ProviderConfig) (RateProvider, error) {
+		return NewKrakenProvider(config), nil
+	})
+}
+
+// krakenTickerResponse is the shape of Kraken's GET /0/public/Ticker response. Result is
+// keyed by Kraken's own pair name (e.g. "XXBTZUSD"), which doesn't always match the
+// requested pair string, so GetRate reads whichever single entry comes back rather than
+// indexing by the request pair.
+type krakenTickerResponse struct {
+	Error  []string                         `json:"error"`
+	Result map[string]krakenTickerAssetPair `json:"result"`
+}
+
+type krakenTickerAssetPair struct {
+	// C is the [last trade price, lot volume] pair Kraken's ticker returns
+	C []string `json:"c"`
+}
+
+// krakenAssetCode maps a common currency code to the Kraken asset code used in its pair
+// names, for the handful of assets that differ (Kraken quirkily calls Bitcoin "XBT")
+var krakenAssetCode = map[string]string{
+	"BTC": "XBT",
+}
+
+// KrakenProvider implements RateProvider using Kraken's public Ticker endpoint
+type KrakenProvider struct {
+	apiURL              string
+	http                *providerHTTPClient
+	supportedCurrencies []string
+	cryptoCurrencies    map[string]bool
+}
+
+// NewKrakenProvider creates a new Kraken provider. config.HTTPClient overrides the default
+// throttled HTTP client; config.APIKey is ignored, since Kraken's public ticker endpoint
+// doesn't require one.
+func NewKrakenProvider(config ProviderConfig) *KrakenProvider {
+	provider := &KrakenProvider{
+		apiURL: "https://api.kraken.com/0/public",
+		http:   newProviderHTTPClient(1, 3),
+		supportedCurrencies: []string{
+			"BTC", "ETH", "USDT", "USDC", "XRP", "ADA", "SOL", "DOT", "DOGE",
+			"USD", "EUR", "GBP",
+		},
+		cryptoCurrencies: map[string]bool{
+			"BTC": true, "ETH": true, "USDT": true, "USDC": true,
+			"XRP": true, "ADA": true, "SOL": true, "DOT": true, "DOGE": true,
+		},
+	}
+	if config.HTTPClient != nil {
+		provider.http.client = config.HTTPClient
+	}
+	return provider
+}
+
+// GetName returns the name of the provider
+func (p *KrakenProvider) GetName() string {
+	return "Kraken"
+}
+
+// GetRate returns the exchange rate between two currencies
+func (p *KrakenProvider) GetRate(ctx context.Context, baseCurrency, targetCurrency string) (*ExchangeRate, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	targetCurrency = strings.ToUpper(targetCurrency)
+
+	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+	if !p.isCryptoCurrency(baseCurrency) && !p.isCryptoCurrency(targetCurrency) {
+		return nil, fmt.Errorf("%w: Kraken only quotes pairs with at least one crypto leg", ErrInvalidCurrency)
+	}
+
+	price, err := p.pairPrice(ctx, baseCurrency, targetCurrency)
+	if err == nil {
+		return &ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           price,
+			Source:         p.GetName(),
+			Timestamp:      time.Now(),
+		}, nil
+	}
+
+	baseToUSD, err := p.rateToUSD(ctx, baseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	targetToUSD, err := p.rateToUSD(ctx, targetCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           baseToUSD / targetToUSD,
+		Source:         p.GetName(),
+		Timestamp:      time.Now(),
+	}, nil
+}
+
+func (p *KrakenProvider) rateToUSD(ctx context.Context, currency string) (float64, error) {
+	if currency == "USD" || currency == "USDT" || currency == "USDC" {
+		return 1.0, nil
+	}
+	return p.pairPrice(ctx, currency, "USD")
+}
+
+// pairPrice fetches the ticker price for base/target, trying the direct pair first and its
+// inverse second
+func (p *KrakenProvider) pairPrice(ctx context.Context, base, target string) (float64, error) {
+	if price, err := p.fetchPair(ctx, base, target); err == nil {
+		return price, nil
+	}
+	price, err := p.fetchPair(ctx, target, base)
+	if err != nil {
+		return 0, err
+	}
+	return 1.0 / price, nil
+}
+
+func (p *KrakenProvider) fetchPair(ctx context.Context, base, target string) (float64, error) {
+	pair := p.assetCode(base) + p.assetCode(target)
+	url := fmt.Sprintf("%s/Ticker?pair=%s", p.apiURL, pair)
+
+	var ticker krakenTickerResponse
+	if err := p.http.getJSON(ctx, url, nil, &ticker); err != nil {
+		return 0, err
+	}
+	if len(ticker.Error) > 0 {
+		return 0, fmt.Errorf("%w: %s", ErrProviderUnavailable, strings.Join(ticker.Error, "; "))
+	}
+
+	for _, assetPair := range ticker.Result {
+		if len(assetPair.C) == 0 {
+			continue
+		}
+		price, err := strconv.ParseFloat(assetPair.C[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse Kraken price for %s: %w", pair, err)
+		}
+		return price, nil
+	}
+	return 0, ErrRateNotFound
+}
+
+func (p *KrakenProvider) assetCode(currency string) string {
+	if code, ok := krakenAssetCode[currency]; ok {
+		return code
+	}
+	return currency
+}
+
+// krakenOHLCResponse is the shape of Kraken's GET /0/public/OHLC response. Like Ticker,
+// Result is keyed by Kraken's own pair name plus a "last" field this provider ignores.
+type krakenOHLCResponse struct {
+	Error  []string                   `json:"error"`
+	Result map[string]json.RawMessage `json:"result"`
+}
+
+// GetHistoricalRate returns the exchange rate between two currencies at a specific time, via
+// Kraken's OHLC endpoint, reading the close price of the 1-minute candle nearest at
+func (p *KrakenProvider) GetHistoricalRate(ctx context.Context, baseCurrency, targetCurrency string, at time.Time) (*ExchangeRate, error) {
+	baseCurrency = strings.ToUpper(baseCurrency)
+	targetCurrency = strings.ToUpper(targetCurrency)
+
+	if !p.isSupportedCurrency(baseCurrency) || !p.isSupportedCurrency(targetCurrency) {
+		return nil, ErrInvalidCurrency
+	}
+	if !p.isCryptoCurrency(baseCurrency) && !p.isCryptoCurrency(targetCurrency) {
+		return nil, fmt.Errorf("%w: Kraken only quotes pairs with at least one crypto leg", ErrInvalidCurrency)
+	}
+
+	price, err := p.historicalPairPrice(ctx, baseCurrency, targetCurrency, at)
+	if err == nil {
+		return &ExchangeRate{
+			BaseCurrency:   baseCurrency,
+			TargetCurrency: targetCurrency,
+			Rate:           price,
+			Source:         p.GetName(),
+			Timestamp:      at,
+		}, nil
+	}
+
+	baseToUSD, err := p.historicalRateToUSD(ctx, baseCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+	targetToUSD, err := p.historicalRateToUSD(ctx, targetCurrency, at)
+	if err != nil {
+		return nil, err
+	}
+	return &ExchangeRate{
+		BaseCurrency:   baseCurrency,
+		TargetCurrency: targetCurrency,
+		Rate:           baseToUSD / targetToUSD,
+		Source:         p.GetName(),
+		Timestamp:      at,
+	}, nil
+}
+
+func (p *KrakenProvider) historicalRateToUSD(ctx context.Context, currency string, at time.Time) (float64, error) {
+	if currency == "USD" || currency == "USDT" || currency == "USDC" {
+		return 1.0, nil
+	}
+	return p.historicalPairPrice(ctx, currency, "USD", at)
+}
+
+func (p *KrakenProvider) historicalPairPrice(ctx context.Context, base, target string, at time.Time) (float64, error) {
+	if price, err := p.fetchHistoricalPair(ctx, base, target, at); err == nil {
+		return price, nil
+	}
+	price, err := p.fetchHistoricalPair(ctx, target, base, at)
+	if err != nil {
+		return 0, err
+	}
+	return 1.0 / price, nil
+}
+
+func (p *KrakenProvider) fetchHistoricalPair(ctx context.Context, base, target string, at time.Time) (float64, error) {
+	pair := p.assetCode(base) + p.assetCode(target)
+	url := fmt.Sprintf("%s/OHLC?pair=%s&interval=1&since=%d", p.apiURL, pair, at.Unix())
+
+	var ohlc krakenOHLCResponse
+	if err := p.http.getJSON(ctx, url, nil, &ohlc); err != nil {
+		return 0, err
+	}
+	if len(ohlc.Error) > 0 {
+		return 0, fmt.Errorf("%w: %s", ErrProviderUnavailable, strings.Join(ohlc.Error, "; "))
+	}
+
+	for _, raw := range ohlc.Result {
+		var candles [][]interface{}
+		if err := json.Unmarshal(raw, &candles); err != nil {
+			continue // this entry is Result["last"], not a candle list
+		}
+		if len(candles) == 0 {
+			continue
+		}
+		closePrice, ok := candles[0][4].(string)
+		if !ok {
+			continue
+		}
+		price, err := strconv.ParseFloat(closePrice, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse Kraken historical price for %s: %w", pair, err)
+		}
+		return price, nil
+	}
+	return 0, ErrRateNotFound
+}
+
+// GetSupportedCurrencies returns the list of supported currencies
+func (p *KrakenProvider) GetSupportedCurrencies() []string {
+	return p.supportedCurrencies
+}
+
+func (p *KrakenProvider) isSupportedCurrency(currency string) bool {
+	for _, c := range p.supportedCurrencies {
+		if c == currency {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *KrakenProvider) isCryptoCurrency(currency string) bool {
+	return p.cryptoCurrencies[currency]
+}
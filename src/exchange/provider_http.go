@@ -0,0 +1,137 @@
+// Shared HTTP helper for RateProvider implementations that fetch rates from a JSON REST API
+// Throttles outbound requests with a token bucket (so a quote burst across many currency
+// pairs doesn't trip a provider's own rate limit, the same concern EtherscanProvider's
+// explorer-API clients have) and retries a failed request with a linearly growing pause,
+// mirroring blockchain's withRetry policy for explorer API calls.
+
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	providerHTTPMaxAttempts  = 3
+	providerHTTPRetryBackoff = 300 * time.Millisecond
+)
+
+// providerHTTPClient is embedded by RateProvider implementations that talk to a JSON REST API
+type providerHTTPClient struct {
+	client            *http.Client
+	requestsPerSecond float64
+	burst             int
+
+	mutex      sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
+
+// newProviderHTTPClient creates a providerHTTPClient throttled to requestsPerSecond (with
+// bursts up to burst), falling back to 5 req/s and a burst of 5 if not set
+func newProviderHTTPClient(requestsPerSecond float64, burst int) *providerHTTPClient {
+	if requestsPerSecond <= 0 {
+		requestsPerSecond = 5
+	}
+	if burst <= 0 {
+		burst = 5
+	}
+	return &providerHTTPClient{
+		client:            &http.Client{Timeout: 10 * time.Second},
+		requestsPerSecond: requestsPerSecond,
+		burst:             burst,
+		tokens:            float64(burst),
+		lastRefill:        time.Now(),
+	}
+}
+
+func (c *providerHTTPClient) takeToken() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	c.tokens += now.Sub(c.lastRefill).Seconds() * c.requestsPerSecond
+	if c.tokens > float64(c.burst) {
+		c.tokens = float64(c.burst)
+	}
+	c.lastRefill = now
+	if c.tokens < 1 {
+		return false
+	}
+	c.tokens--
+	return true
+}
+
+// throttle blocks until a token is available or ctx is done
+func (c *providerHTTPClient) throttle(ctx context.Context) error {
+	for {
+		if c.takeToken() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// getJSON throttles, fetches url (retrying up to providerHTTPMaxAttempts times, pausing
+// attempt × providerHTTPRetryBackoff before each retry, on network errors, non-200
+// responses, or an unparsable body), and
+// decodes the response into target
+func (c *providerHTTPClient) getJSON(ctx context.Context, url string, headers map[string]string, target interface{}) error {
+	var lastErr error
+	for attempt := 0; attempt < providerHTTPMaxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * providerHTTPRetryBackoff):
+			}
+		}
+		if err := c.throttle(ctx); err != nil {
+			return err
+		}
+
+		if err := c.fetchOnce(ctx, url, headers, target); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+func (c *providerHTTPClient) fetchOnce(ctx context.Context, url string, headers map[string]string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", ErrProviderUnavailable, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Registry of RateProvider factories, so a deployment can build its ExchangeRateService's
+// provider list from config (e.g. `providers: [coingecko, binance, frankfurter]`) instead of
+// every provider's constructor being wired into the service by hand
+
+package exchange
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ProviderConfig configures a RateProvider built through NewProvider
+type ProviderConfig struct {
+	// APIKey authenticates with providers that require one (e.g. CoinMarketCapProvider's
+	// CMC_PRO_API_KEY); ignored by providers that don't need one
+	APIKey string
+	// HTTPClient overrides the provider's default *http.Client, mainly for tests
+	HTTPClient *http.Client
+}
+
+// ProviderFactory builds a RateProvider from config. Register one under a name with
+// RegisterProviderFactory.
+type ProviderFactory func(config ProviderConfig) (RateProvider, error)
+
+var (
+	providerRegistryMutex sync.Mutex
+	providerRegistry      = map[string]ProviderFactory{}
+)
+
+// RegisterProviderFactory makes a RateProvider buildable by name through NewProvider. Each
+// provider implementation registers itself this way from an init() in its own file.
+func RegisterProviderFactory(name string, factory ProviderFactory) {
+	providerRegistryMutex.Lock()
+	defer providerRegistryMutex.Unlock()
+	providerRegistry[name] = factory
+}
+
+// NewProvider builds the RateProvider registered under name, as selected by a deployment's
+// `providers: [...]` config list
+func NewProvider(name string, config ProviderConfig) (RateProvider, error) {
+	providerRegistryMutex.Lock()
+	factory, ok := providerRegistry[name]
+	providerRegistryMutex.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotFound, name)
+	}
+	return factory(config)
+}
@@ -0,0 +1,220 @@
+// Distributed rate caching for ExchangeRateService
+// The original cache was an in-process map, so every replica of the payment service queried
+// upstream providers independently, which could trip a provider's own rate limits under load.
+// RateCache abstracts that storage so a fleet of replicas can share a Redis-backed cache
+// instead, the same way DistributedRateLimiter moved rate limiting out of a single process.
+
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RateCache stores and retrieves previously-fetched ExchangeRates. Implementations key
+// entries by whatever cacheKeyFor/cacheKeyForProvider produce (base+target, or
+// base+target+provider), so callers never need to know the storage details.
+type RateCache interface {
+	// Get returns the cached rate for key and whether it was found. A found entry may still
+	// be stale; callers compare its Timestamp against their own TTL.
+	Get(ctx context.Context, key string) (*ExchangeRate, bool, error)
+	// Set stores rate under key, expiring it after ttl.
+	Set(ctx context.Context, key string, rate *ExchangeRate, ttl time.Duration) error
+	// Clear removes every entry the cache holds.
+	Clear(ctx context.Context) error
+}
+
+// cacheKeyFor builds the cache key GetRate's consensus result is stored under
+func cacheKeyFor(baseCurrency, targetCurrency string) string {
+	return fmt.Sprintf("%s-%s", baseCurrency, targetCurrency)
+}
+
+// cacheKeyForProvider builds the cache key a single named provider's rate is stored under, so
+// it can't collide with the consensus entry for the same pair
+func cacheKeyForProvider(baseCurrency, targetCurrency, provider string) string {
+	return fmt.Sprintf("%s-%s-%s", baseCurrency, targetCurrency, provider)
+}
+
+// MemoryRateCache is a process-local RateCache backed by a mutex-guarded map, matching
+// ExchangeRateService's original in-process cache behavior
+type MemoryRateCache struct {
+	mutex   sync.RWMutex
+	entries map[string]*ExchangeRate
+}
+
+// NewMemoryRateCache creates an empty MemoryRateCache
+func NewMemoryRateCache() *MemoryRateCache {
+	return &MemoryRateCache{entries: make(map[string]*ExchangeRate)}
+}
+
+// Get returns the cached rate for key, ignoring ttl (MemoryRateCache never expires entries
+// proactively; GetRate itself checks Timestamp against its TTL)
+func (c *MemoryRateCache) Get(_ context.Context, key string) (*ExchangeRate, bool, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	rate, ok := c.entries[key]
+	return rate, ok, nil
+}
+
+// Set stores rate under key. ttl is accepted to satisfy RateCache but unused, since
+// MemoryRateCache relies on the caller's own Timestamp-based staleness check.
+func (c *MemoryRateCache) Set(_ context.Context, key string, rate *ExchangeRate, _ time.Duration) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries[key] = rate
+	return nil
+}
+
+// Clear removes every entry
+func (c *MemoryRateCache) Clear(_ context.Context) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.entries = make(map[string]*ExchangeRate)
+	return nil
+}
+
+// redisRateCacheEntry is the JSON shape an ExchangeRate is marshalled to before being stored
+// in Redis
+type redisRateCacheEntry struct {
+	BaseCurrency   string    `json:"base_currency"`
+	TargetCurrency string    `json:"target_currency"`
+	Rate           float64   `json:"rate"`
+	Source         string    `json:"source"`
+	Timestamp      time.Time `json:"timestamp"`
+	Sources        []string  `json:"sources,omitempty"`
+}
+
+// RedisRateCache is a RateCache backed by Redis, so every replica of the payment service
+// shares one set of cached rates instead of each querying providers independently
+type RedisRateCache struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisRateCache creates a RedisRateCache against client, namespacing every key under
+// keyPrefix
+func NewRedisRateCache(client *redis.Client, keyPrefix string) *RedisRateCache {
+	return &RedisRateCache{client: client, keyPrefix: keyPrefix}
+}
+
+func (c *RedisRateCache) namespacedKey(key string) string {
+	return c.keyPrefix + ":" + key
+}
+
+// Get returns the cached rate for key, and whether it was found
+func (c *RedisRateCache) Get(ctx context.Context, key string) (*ExchangeRate, bool, error) {
+	raw, err := c.client.Get(ctx, c.namespacedKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cached exchange rate: %w", err)
+	}
+
+	var entry redisRateCacheEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cached exchange rate: %w", err)
+	}
+	return &ExchangeRate{
+		BaseCurrency:   entry.BaseCurrency,
+		TargetCurrency: entry.TargetCurrency,
+		Rate:           entry.Rate,
+		Source:         entry.Source,
+		Timestamp:      entry.Timestamp,
+		Sources:        entry.Sources,
+	}, true, nil
+}
+
+// Set stores rate under key, expiring it from Redis after ttl
+func (c *RedisRateCache) Set(ctx context.Context, key string, rate *ExchangeRate, ttl time.Duration) error {
+	entry := redisRateCacheEntry{
+		BaseCurrency:   rate.BaseCurrency,
+		TargetCurrency: rate.TargetCurrency,
+		Rate:           rate.Rate,
+		Source:         rate.Source,
+		Timestamp:      rate.Timestamp,
+		Sources:        rate.Sources,
+	}
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode exchange rate for caching: %w", err)
+	}
+	if err := c.client.Set(ctx, c.namespacedKey(key), raw, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write cached exchange rate: %w", err)
+	}
+	return nil
+}
+
+// Clear deletes every key under keyPrefix. This scans with SCAN rather than KEYS, so it's
+// safe to call against a production Redis instance without blocking other clients.
+func (c *RedisRateCache) Clear(ctx context.Context) error {
+	iter := c.client.Scan(ctx, 0, c.keyPrefix+":*", 0).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return fmt.Errorf("failed to clear cached exchange rate: %w", err)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan cached exchange rates: %w", err)
+	}
+	return nil
+}
+
+// RateCacheMetrics records cache hit/miss/coalesce counters for an ExchangeRateService. A nil
+// *RateCacheMetrics is safe to call methods on; every method is a no-op in that case, so
+// metrics remain optional.
+type RateCacheMetrics struct {
+	hits      *prometheus.CounterVec
+	misses    *prometheus.CounterVec
+	coalesced *prometheus.CounterVec
+}
+
+// NewRateCacheMetrics registers exchange_rate_cache_hits_total, exchange_rate_cache_misses_total,
+// and exchange_rate_cache_coalesced_total against registerer, each labeled by base/target
+func NewRateCacheMetrics(registerer prometheus.Registerer) *RateCacheMetrics {
+	hits := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "exchange_rate_cache_hits_total",
+		Help: "Total exchange rate requests served from cache, by currency pair",
+	}, []string{"base", "target"})
+
+	misses := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "exchange_rate_cache_misses_total",
+		Help: "Total exchange rate requests that missed cache and queried providers, by currency pair",
+	}, []string{"base", "target"})
+
+	coalesced := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "exchange_rate_cache_coalesced_total",
+		Help: "Total exchange rate requests that coalesced onto an in-flight provider call via singleflight, by currency pair",
+	}, []string{"base", "target"})
+
+	registerer.MustRegister(hits, misses, coalesced)
+
+	return &RateCacheMetrics{hits: hits, misses: misses, coalesced: coalesced}
+}
+
+func (m *RateCacheMetrics) recordHit(base, target string) {
+	if m == nil {
+		return
+	}
+	m.hits.WithLabelValues(base, target).Inc()
+}
+
+func (m *RateCacheMetrics) recordMiss(base, target string) {
+	if m == nil {
+		return
+	}
+	m.misses.WithLabelValues(base, target).Inc()
+}
+
+func (m *RateCacheMetrics) recordCoalesced(base, target string) {
+	if m == nil {
+		return
+	}
+	m.coalesced.WithLabelValues(base, target).Inc()
+}
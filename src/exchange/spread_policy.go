@@ -0,0 +1,255 @@
+// Pluggable fee/spread policy engine for Asian Cryptocurrency Payment System
+// Replaces GetRateWithSpread's single flat spreadPercentage with a SpreadPolicy abstraction,
+// so a deployment can price corridors, merchants, and notional tiers differently instead of
+// applying one spread everywhere.
+
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Spread is the bid/ask adjustment a SpreadPolicy applies to a consensus mid-rate: BidPercentage
+// is subtracted when the house buys the base currency, AskPercentage is added when it sells.
+type Spread struct {
+	BidPercentage float64
+	AskPercentage float64
+	// Policy names the SpreadPolicy that produced this Spread, for audit and debugging
+	Policy string
+}
+
+// SpreadPolicy computes the bid/ask spread that should apply to a quote for baseCurrency/
+// targetCurrency, amount, and merchantID
+type SpreadPolicy interface {
+	// Name identifies the policy, surfaced on the Spread and Quote it produces
+	Name() string
+	// Quote returns the Spread to apply for this base/target/amount/merchant combination
+	Quote(ctx context.Context, baseCurrency, targetCurrency string, amount float64, merchantID string) (Spread, error)
+}
+
+// FlatSpread applies the same bid/ask percentage to every quote, matching the behavior
+// GetRateWithSpread's spreadPercentage argument used to provide on its own
+type FlatSpread struct {
+	BidPercentage float64
+	AskPercentage float64
+}
+
+// NewFlatSpread creates a FlatSpread that applies the same percentage to both sides
+func NewFlatSpread(percentage float64) FlatSpread {
+	return FlatSpread{BidPercentage: percentage, AskPercentage: percentage}
+}
+
+// Name identifies this policy
+func (p FlatSpread) Name() string { return "flat" }
+
+// Quote returns the configured bid/ask percentages, regardless of amount or merchant
+func (p FlatSpread) Quote(_ context.Context, _, _ string, _ float64, _ string) (Spread, error) {
+	return Spread{BidPercentage: p.BidPercentage, AskPercentage: p.AskPercentage, Policy: p.Name()}, nil
+}
+
+// SpreadTier is one notional-amount breakpoint a TieredSpread selects between
+type SpreadTier struct {
+	// MinAmount is the smallest amount (inclusive) this tier applies to
+	MinAmount     float64
+	BidPercentage float64
+	AskPercentage float64
+}
+
+// TieredSpread selects a narrower spread for larger notional amounts, the way OTC desks
+// typically price larger trades tighter than retail-sized ones
+type TieredSpread struct {
+	tiers []SpreadTier // sorted ascending by MinAmount
+}
+
+// NewTieredSpread creates a TieredSpread over tiers, which need not already be sorted by
+// MinAmount
+func NewTieredSpread(tiers []SpreadTier) *TieredSpread {
+	sorted := append([]SpreadTier(nil), tiers...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].MinAmount < sorted[j].MinAmount })
+	return &TieredSpread{tiers: sorted}
+}
+
+// Name identifies this policy
+func (p *TieredSpread) Name() string { return "tiered" }
+
+// Quote returns the highest tier whose MinAmount does not exceed amount
+func (p *TieredSpread) Quote(_ context.Context, _, _ string, amount float64, _ string) (Spread, error) {
+	if len(p.tiers) == 0 {
+		return Spread{}, fmt.Errorf("%w: TieredSpread has no tiers configured", ErrInvalidConfiguration)
+	}
+
+	selected := p.tiers[0]
+	for _, tier := range p.tiers {
+		if amount >= tier.MinAmount {
+			selected = tier
+		}
+	}
+	return Spread{BidPercentage: selected.BidPercentage, AskPercentage: selected.AskPercentage, Policy: p.Name()}, nil
+}
+
+// CorridorSpreadRecord is the gorm model PerCorridorSpread reads configured spreads from. A
+// row with an empty MerchantID is the corridor's default, used when no merchant-specific row
+// matches.
+type CorridorSpreadRecord struct {
+	gorm.Model
+	BaseCurrency   string  `gorm:"size:10;not null;index:idx_corridor_spread_lookup"`
+	TargetCurrency string  `gorm:"size:10;not null;index:idx_corridor_spread_lookup"`
+	MerchantID     string  `gorm:"size:50;index:idx_corridor_spread_lookup"`
+	BidPercentage  float64 `gorm:"not null"`
+	AskPercentage  float64 `gorm:"not null"`
+}
+
+// PerCorridorSpread loads a configured spread per base/target corridor (optionally overridden
+// per merchant) from Postgres, following the same self-contained gorm model + store pattern
+// RateArchiveStore uses rather than depending on the database package directly
+type PerCorridorSpread struct {
+	db *gorm.DB
+}
+
+// NewPerCorridorSpread creates a PerCorridorSpread backed by db. The caller is responsible for
+// having migrated CorridorSpreadRecord beforehand.
+func NewPerCorridorSpread(db *gorm.DB) *PerCorridorSpread {
+	return &PerCorridorSpread{db: db}
+}
+
+// Name identifies this policy
+func (p *PerCorridorSpread) Name() string { return "per_corridor" }
+
+// Quote looks up the merchant-specific corridor spread first, falling back to the corridor's
+// default (MerchantID == "") row if none is configured for merchantID
+func (p *PerCorridorSpread) Quote(ctx context.Context, baseCurrency, targetCurrency string, _ float64, merchantID string) (Spread, error) {
+	record, err := p.lookup(ctx, baseCurrency, targetCurrency, merchantID)
+	if err == gorm.ErrRecordNotFound && merchantID != "" {
+		record, err = p.lookup(ctx, baseCurrency, targetCurrency, "")
+	}
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return Spread{}, fmt.Errorf("%w: no corridor spread configured for %s/%s", ErrInvalidConfiguration, baseCurrency, targetCurrency)
+		}
+		return Spread{}, fmt.Errorf("failed to load corridor spread: %w", err)
+	}
+	return Spread{BidPercentage: record.BidPercentage, AskPercentage: record.AskPercentage, Policy: p.Name()}, nil
+}
+
+func (p *PerCorridorSpread) lookup(ctx context.Context, baseCurrency, targetCurrency, merchantID string) (CorridorSpreadRecord, error) {
+	var record CorridorSpreadRecord
+	err := p.db.WithContext(ctx).
+		Where("base_currency = ? AND target_currency = ? AND merchant_id = ?", baseCurrency, targetCurrency, merchantID).
+		Order("id DESC").
+		First(&record).Error
+	return record, err
+}
+
+// volatilitySample is one rate observation VolatilityAdjustedSpread keeps to compute rolling
+// volatility
+type volatilitySample struct {
+	rate float64
+	at   time.Time
+}
+
+// VolatilityAdjustedSpread widens an underlying policy's spread when a currency pair's recent
+// rate movement exceeds a configured threshold, so the house isn't caught quoting a stale
+// tight spread through a volatile move
+type VolatilityAdjustedSpread struct {
+	// Base is the underlying policy whose bid/ask this widens
+	Base SpreadPolicy
+	// VolatilityThreshold is the coefficient of variation (stddev/mean) of recent rates above
+	// which the spread widens
+	VolatilityThreshold float64
+	// WideningMultiplier scales Base's bid/ask percentages when volatility exceeds threshold
+	WideningMultiplier float64
+	// Window bounds how far back Observe'd samples are kept when computing volatility
+	Window time.Duration
+
+	mutex   sync.Mutex
+	history map[string][]volatilitySample
+}
+
+// NewVolatilityAdjustedSpread creates a VolatilityAdjustedSpread wrapping base, widening by
+// multiplier whenever the coefficient of variation of samples within window exceeds threshold
+func NewVolatilityAdjustedSpread(base SpreadPolicy, threshold, multiplier float64, window time.Duration) *VolatilityAdjustedSpread {
+	return &VolatilityAdjustedSpread{
+		Base:                base,
+		VolatilityThreshold: threshold,
+		WideningMultiplier:  multiplier,
+		Window:              window,
+		history:             make(map[string][]volatilitySample),
+	}
+}
+
+// Name identifies this policy
+func (p *VolatilityAdjustedSpread) Name() string { return "volatility_adjusted" }
+
+// Observe records rate as a recent sample for baseCurrency/targetCurrency, trimming samples
+// older than Window. ExchangeRateService.Quote calls this with every rate it fetches, so later
+// Quote calls can judge how volatile the pair has recently been.
+func (p *VolatilityAdjustedSpread) Observe(baseCurrency, targetCurrency string, rate float64, at time.Time) {
+	key := cacheKeyFor(baseCurrency, targetCurrency)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	samples := append(p.history[key], volatilitySample{rate: rate, at: at})
+	cutoff := at.Add(-p.Window)
+	kept := make([]volatilitySample, 0, len(samples))
+	for _, sample := range samples {
+		if sample.at.After(cutoff) {
+			kept = append(kept, sample)
+		}
+	}
+	p.history[key] = kept
+}
+
+// Quote widens Base's spread by WideningMultiplier when the pair's recent coefficient of
+// variation exceeds VolatilityThreshold
+func (p *VolatilityAdjustedSpread) Quote(ctx context.Context, baseCurrency, targetCurrency string, amount float64, merchantID string) (Spread, error) {
+	spread, err := p.Base.Quote(ctx, baseCurrency, targetCurrency, amount, merchantID)
+	if err != nil {
+		return Spread{}, err
+	}
+
+	key := cacheKeyFor(baseCurrency, targetCurrency)
+	p.mutex.Lock()
+	samples := append([]volatilitySample(nil), p.history[key]...)
+	p.mutex.Unlock()
+
+	if coefficientOfVariation(samples) > p.VolatilityThreshold {
+		spread.BidPercentage *= p.WideningMultiplier
+		spread.AskPercentage *= p.WideningMultiplier
+	}
+	spread.Policy = p.Name()
+	return spread, nil
+}
+
+// coefficientOfVariation returns samples' population stddev divided by their mean, or 0 if
+// there are too few samples or the mean is zero
+func coefficientOfVariation(samples []volatilitySample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+
+	var sum float64
+	for _, sample := range samples {
+		sum += sample.rate
+	}
+	mean := sum / float64(len(samples))
+	if mean == 0 {
+		return 0
+	}
+
+	var variance float64
+	for _, sample := range samples {
+		d := sample.rate - mean
+		variance += d * d
+	}
+	variance /= float64(len(samples))
+
+	return math.Sqrt(variance) / mean
+}
@@ -5,6 +5,7 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -22,22 +23,26 @@ import (
 
 // CambodiaWingConfig holds configuration for Wing integration
 type CambodiaWingConfig struct {
-	MerchantID     string
-	MerchantKey    string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	MerchantID       string
+	MerchantKey      string
+	APIEndpoint      string
+	CallbackURL      string
+	RedirectURL      string
+	TestMode         bool
+	IdempotencyStore IdempotencyStore // optional; when set, CreatePayment/RefundPayment short-circuit retried IdempotencyKeys
 }
 
 // CambodiaWing implements PaymentPlatform interface for Cambodia's Wing
 type CambodiaWing struct {
-	config CambodiaWingConfig
-	client *http.Client
+	config      CambodiaWingConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	language    Language
 }
 
-// NewCambodiaWing creates a new Wing payment platform
-func NewCambodiaWing(config CambodiaWingConfig) *CambodiaWing {
+// NewCambodiaWing creates a new Wing payment platform. By default errors and descriptions
+// are in English; pass WithLocalization to change that.
+func NewCambodiaWing(config CambodiaWingConfig, opts ...PlatformOption) *CambodiaWing {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -47,11 +52,22 @@ func NewCambodiaWing(config CambodiaWingConfig) *CambodiaWing {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "Wing")
+
 	return &CambodiaWing{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		language:    options.language,
 	}
 }
 
@@ -76,7 +92,14 @@ func (p *CambodiaWing) GetSupportedCurrencies() []string {
 }
 
 // CreatePayment creates a payment
-func (p *CambodiaWing) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+func (p *CambodiaWing) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "USD" && request.Currency != "KHR" {
 		return nil, errors.New("currency must be USD or KHR for Wing payments")
@@ -86,21 +109,32 @@ func (p *CambodiaWing) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	if cached, found, err := p.checkIdempotentPayment(idempotencyKey); err != nil {
+		return nil, err
+	} else if found {
+		return cached, nil
+	}
+
 	// Prepare Wing request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	wingRequest := map[string]interface{}{
-		"merchant_id":     p.config.MerchantID,
-		"order_id":        request.OrderID,
-		"amount":          fmt.Sprintf("%.2f", request.Amount),
-		"currency":        request.Currency,
-		"description":     request.Description,
-		"customer_name":   request.CustomerName,
-		"customer_email":  request.CustomerEmail,
-		"customer_phone":  request.CustomerPhone,
-		"return_url":      p.config.RedirectURL,
-		"callback_url":    p.config.CallbackURL,
-		"timestamp":       timestamp,
+		"merchant_id":    p.config.MerchantID,
+		"order_id":       request.OrderID,
+		"amount":         fmt.Sprintf("%.2f", request.Amount),
+		"currency":       request.Currency,
+		"description":    localizedDescription(request.Description, request.OrderID, p.language),
+		"customer_name":  request.CustomerName,
+		"customer_email": request.CustomerEmail,
+		"customer_phone": request.CustomerPhone,
+		"return_url":     p.config.RedirectURL,
+		"callback_url":   p.config.CallbackURL,
+		"timestamp":      timestamp,
 	}
 
 	// Set payment method
@@ -120,18 +154,17 @@ func (p *CambodiaWing) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v1/payment/create", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payment/create", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -155,7 +188,7 @@ func (p *CambodiaWing) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		if msg, ok := wingResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("Wing error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("Wing error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -171,28 +204,70 @@ func (p *CambodiaWing) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 
 	// Create response
 	response := &PaymentResponse{
-		PaymentID:     paymentID,
-		Status:        StatusPending,
-		Amount:        request.Amount,
-		Currency:      request.Currency,
-		PaymentMethod: request.PaymentMethod,
-		PaymentURL:    paymentURL,
-		QRCodeURL:     qrCodeURL,
-		RedirectURL:   paymentURL,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-		ExpiresAt:     time.Unix(int64(expiryTime), 0),
-		Metadata:      make(map[string]string),
+		PaymentID:            paymentID,
+		Status:               StatusPending,
+		Amount:               request.Amount,
+		Currency:             request.Currency,
+		PaymentMethod:        request.PaymentMethod,
+		PaymentURL:           paymentURL,
+		QRCodeURL:            qrCodeURL,
+		RedirectURL:          paymentURL,
+		CreatedAt:            time.Now(),
+		UpdatedAt:            time.Now(),
+		ExpiresAt:            time.Unix(int64(expiryTime), 0),
+		Metadata:             make(map[string]string),
+		LocalizedDescription: localizedDescription(request.Description, request.OrderID, p.language),
+	}
+
+	p.storeIdempotentPayment(idempotencyKey, response)
+	return response, nil
+}
+
+// checkIdempotentPayment returns a previously stored PaymentResponse for idempotencyKey, if
+// a store is configured and one exists
+func (p *CambodiaWing) checkIdempotentPayment(idempotencyKey string) (*PaymentResponse, bool, error) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return nil, false, nil
+	}
+	raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationCreatePayment)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check idempotency store: %w", err)
 	}
+	if !found {
+		return nil, false, nil
+	}
+	var response PaymentResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, false, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+	}
+	return &response, true, nil
+}
 
-	return response, nil
+// storeIdempotentPayment persists response under idempotencyKey when a store is configured,
+// silently skipping persistence rather than failing the call if marshaling errors
+func (p *CambodiaWing) storeIdempotentPayment(idempotencyKey string, response *PaymentResponse) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return
+	}
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationCreatePayment, raw)
 }
 
 // GetPaymentStatus gets the status of a payment
-func (p *CambodiaWing) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+func (p *CambodiaWing) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare status request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	statusRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"payment_id":  request.PaymentID,
@@ -210,7 +285,7 @@ func (p *CambodiaWing) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v1/payment/status", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payment/status", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -218,6 +293,7 @@ func (p *CambodiaWing) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 	// Add headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
 
 	// Make API request
 	resp, err := p.client.Do(req)
@@ -244,7 +320,7 @@ func (p *CambodiaWing) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 		if msg, ok := statusResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("Wing error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("Wing error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -260,7 +336,7 @@ func (p *CambodiaWing) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 	paymentType, _ := data["payment_type"].(string)
 	transactionID, _ := data["transaction_id"].(string)
 	createdAtStr, _ := data["created_at"].(string)
-	
+
 	// Parse created at
 	createdAt, _ := time.Parse("2006-01-02T15:04:05Z", createdAtStr)
 
@@ -306,10 +382,36 @@ func (p *CambodiaWing) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 }
 
 // RefundPayment refunds a payment
-func (p *CambodiaWing) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+func (p *CambodiaWing) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationRefundPayment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check idempotency store: %w", err)
+		}
+		if found {
+			var cached RefundResponse
+			if err := json.Unmarshal(raw, &cached); err != nil {
+				return nil, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+			}
+			return &cached, nil
+		}
+	}
+
 	// Prepare refund request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	refundRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"payment_id":  request.PaymentID,
@@ -329,18 +431,17 @@ func (p *CambodiaWing) RefundPayment(request *RefundRequest) (*RefundResponse, e
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v1/payment/refund", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payment/refund", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -364,7 +465,7 @@ func (p *CambodiaWing) RefundPayment(request *RefundRequest) (*RefundResponse, e
 		if msg, ok := refundResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("Wing refund error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("Wing refund error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract refund details
@@ -389,6 +490,12 @@ func (p *CambodiaWing) RefundPayment(request *RefundRequest) (*RefundResponse, e
 		CreatedAt:     time.Now(),
 	}
 
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		if raw, err := json.Marshal(response); err == nil {
+			_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationRefundPayment, raw)
+		}
+	}
+
 	return response, nil
 }
 
@@ -430,16 +537,24 @@ type CambodiaABAConfig struct {
 	CallbackURL    string
 	RedirectURL    string
 	TestMode       bool
+	// SessionStore backs InitMultiPayment/AddPartialPayment/CompleteMultiPayment's split-tender
+	// sessions. Defaults to an InMemorySessionStore if unset.
+	SessionStore     SessionStore
+	IdempotencyStore IdempotencyStore // optional; when set, CreatePayment/RefundPayment short-circuit retried IdempotencyKeys
 }
 
 // CambodiaABA implements PaymentPlatform interface for Cambodia's ABA
 type CambodiaABA struct {
-	config CambodiaABAConfig
-	client *http.Client
+	config      CambodiaABAConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	sessions    SessionStore
+	language    Language
 }
 
-// NewCambodiaABA creates a new ABA payment platform
-func NewCambodiaABA(config CambodiaABAConfig) *CambodiaABA {
+// NewCambodiaABA creates a new ABA payment platform. By default errors and descriptions are
+// in English; pass WithLocalization to change that.
+func NewCambodiaABA(config CambodiaABAConfig, opts ...PlatformOption) *CambodiaABA {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -449,11 +564,28 @@ func NewCambodiaABA(config CambodiaABAConfig) *CambodiaABA {
 		}
 	}
 
+	sessions := config.SessionStore
+	if sessions == nil {
+		sessions = NewInMemorySessionStore()
+	}
+
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "ABA")
+
 	return &CambodiaABA{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		sessions:    sessions,
+		language:    options.language,
 	}
 }
 
@@ -478,7 +610,14 @@ func (p *CambodiaABA) GetSupportedCurrencies() []string {
 }
 
 // CreatePayment creates a payment
-func (p *CambodiaABA) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+func (p *CambodiaABA) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "USD" && request.Currency != "KHR" {
 		return nil, errors.New("currency must be USD or KHR for ABA payments")
@@ -488,22 +627,33 @@ func (p *CambodiaABA) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	if cached, found, err := p.checkIdempotentPayment(idempotencyKey); err != nil {
+		return nil, err
+	} else if found {
+		return cached, nil
+	}
+
 	// Prepare ABA request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	abaRequest := map[string]interface{}{
-		"merchant_id":     p.config.MerchantID,
-		"order_id":        request.OrderID,
-		"amount":          request.Amount,
-		"currency":        request.Currency,
-		"description":     request.Description,
-		"customer_name":   request.CustomerName,
-		"customer_email":  request.CustomerEmail,
-		"customer_phone":  request.CustomerPhone,
-		"return_url":      p.config.RedirectURL,
+		"merchant_id":          p.config.MerchantID,
+		"order_id":             request.OrderID,
+		"amount":               request.Amount,
+		"currency":             request.Currency,
+		"description":          localizedDescription(request.Description, request.OrderID, p.language),
+		"customer_name":        request.CustomerName,
+		"customer_email":       request.CustomerEmail,
+		"customer_phone":       request.CustomerPhone,
+		"return_url":           p.config.RedirectURL,
 		"continue_success_url": p.config.RedirectURL,
-		"callback_url":    p.config.CallbackURL,
-		"timestamp":       timestamp,
+		"callback_url":         p.config.CallbackURL,
+		"timestamp":            timestamp,
 	}
 
 	// Set payment method
@@ -525,20 +675,19 @@ func (p *CambodiaABA) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/payment-gateway/v1/payments", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Merchant-ID", p.config.MerchantID)
-	req.Header.Set("API-Key", p.config.MerchantAPIKey)
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/payment-gateway/v1/payments", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("Merchant-ID", p.config.MerchantID)
+		req.Header.Set("API-Key", p.config.MerchantAPIKey)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -562,7 +711,7 @@ func (p *CambodiaABA) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		if msg, ok := abaResponse["description"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("ABA error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("ABA error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -571,27 +720,69 @@ func (p *CambodiaABA) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 
 	// Create response
 	response := &PaymentResponse{
-		PaymentID:     paymentID,
-		Status:        StatusPending,
-		Amount:        request.Amount,
-		Currency:      request.Currency,
-		PaymentMethod: request.PaymentMethod,
-		PaymentURL:    checkoutURL,
-		RedirectURL:   checkoutURL,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
-		ExpiresAt:     time.Now().Add(24 * time.Hour),
-		Metadata:      make(map[string]string),
+		PaymentID:            paymentID,
+		Status:               StatusPending,
+		Amount:               request.Amount,
+		Currency:             request.Currency,
+		PaymentMethod:        request.PaymentMethod,
+		PaymentURL:           checkoutURL,
+		RedirectURL:          checkoutURL,
+		CreatedAt:            time.Now(),
+		UpdatedAt:            time.Now(),
+		ExpiresAt:            time.Now().Add(24 * time.Hour),
+		Metadata:             make(map[string]string),
+		LocalizedDescription: localizedDescription(request.Description, request.OrderID, p.language),
+	}
+
+	p.storeIdempotentPayment(idempotencyKey, response)
+	return response, nil
+}
+
+// checkIdempotentPayment returns a previously stored PaymentResponse for idempotencyKey, if
+// a store is configured and one exists
+func (p *CambodiaABA) checkIdempotentPayment(idempotencyKey string) (*PaymentResponse, bool, error) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return nil, false, nil
+	}
+	raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationCreatePayment)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check idempotency store: %w", err)
+	}
+	if !found {
+		return nil, false, nil
 	}
+	var response PaymentResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, false, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+	}
+	return &response, true, nil
+}
 
-	return response, nil
+// storeIdempotentPayment persists response under idempotencyKey when a store is configured,
+// silently skipping persistence rather than failing the call if marshaling errors
+func (p *CambodiaABA) storeIdempotentPayment(idempotencyKey string, response *PaymentResponse) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return
+	}
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationCreatePayment, raw)
 }
 
 // GetPaymentStatus gets the status of a payment
-func (p *CambodiaABA) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+func (p *CambodiaABA) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare status request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	statusRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"tran_id":     request.PaymentID,
@@ -609,7 +800,7 @@ func (p *CambodiaABA) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/payment-gateway/v1/payments/check-transaction", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/payment-gateway/v1/payments/check-transaction", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -617,6 +808,7 @@ func (p *CambodiaABA) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 	// Add headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
 	req.Header.Set("Merchant-ID", p.config.MerchantID)
 	req.Header.Set("API-Key", p.config.MerchantAPIKey)
 
@@ -645,7 +837,7 @@ func (p *CambodiaABA) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 		if msg, ok := statusResponse["description"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("ABA error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("ABA error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -655,7 +847,7 @@ func (p *CambodiaABA) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 	paymentOption, _ := statusResponse["payment_option"].(string)
 	transactionID, _ := statusResponse["transaction_id"].(string)
 	createdAtStr, _ := statusResponse["created_date"].(string)
-	
+
 	// Parse created at
 	createdAt, _ := time.Parse("2006-01-02 15:04:05", createdAtStr)
 
@@ -703,10 +895,36 @@ func (p *CambodiaABA) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 }
 
 // RefundPayment refunds a payment
-func (p *CambodiaABA) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+func (p *CambodiaABA) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationRefundPayment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check idempotency store: %w", err)
+		}
+		if found {
+			var cached RefundResponse
+			if err := json.Unmarshal(raw, &cached); err != nil {
+				return nil, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+			}
+			return &cached, nil
+		}
+	}
+
 	// Prepare refund request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	refundRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"tran_id":     request.PaymentID,
@@ -726,20 +944,19 @@ func (p *CambodiaABA) RefundPayment(request *RefundRequest) (*RefundResponse, er
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/payment-gateway/v1/payments/refund", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Merchant-ID", p.config.MerchantID)
-	req.Header.Set("API-Key", p.config.MerchantAPIKey)
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/payment-gateway/v1/payments/refund", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("Merchant-ID", p.config.MerchantID)
+		req.Header.Set("API-Key", p.config.MerchantAPIKey)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -763,7 +980,7 @@ func (p *CambodiaABA) RefundPayment(request *RefundRequest) (*RefundResponse, er
 		if msg, ok := refundResponse["description"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("ABA refund error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("ABA refund error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract refund details
@@ -783,6 +1000,12 @@ func (p *CambodiaABA) RefundPayment(request *RefundRequest) (*RefundResponse, er
 		CreatedAt:     time.Now(),
 	}
 
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		if raw, err := json.Marshal(response); err == nil {
+			_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationRefundPayment, raw)
+		}
+	}
+
 	return response, nil
 }
 
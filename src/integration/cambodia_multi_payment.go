@@ -0,0 +1,93 @@
+// Native split-tender support for ABA (abapay/cards/qr), which unlike Wing can realistically
+// collect one order across more than one partial charge. This implements MultiPaymentCapable
+// directly on CambodiaABA rather than only letting ABA participate via
+// MultiPaymentCoordinator's cross-platform routing (multi_payment.go), mirroring how that
+// interface already exists for exactly this "native flow" case. It's built entirely on
+// MultiPaymentSession/SessionStore/generateSessionToken, the same primitives
+// MultiPaymentCoordinator itself uses, rather than a second parallel session implementation.
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// InitMultiPayment opens a new split-tender session for request.TotalAmount against this
+// ABA integration, persisting it in p.sessions
+func (p *CambodiaABA) InitMultiPayment(ctx context.Context, request *MultiPaymentRequest) (*MultiPaymentSession, error) {
+	if request.TotalAmount <= 0 {
+		return nil, errors.New("multi-payment total amount must be positive")
+	}
+
+	token, err := generateSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := NewMultiPaymentSession(request.ExternalID, request.Currency, request.TotalAmount)
+	session.Token = token
+	session.ExternalID = request.ExternalID
+	session.AllowedMethods = request.AllowedMethods
+
+	if err := p.sessions.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return session, nil
+}
+
+// AddPartialPayment charges amount via method against the session identified by
+// paymentToken, recording the result as a child charge the same way
+// MultiPaymentCoordinator.AddChildPayment does
+func (p *CambodiaABA) AddPartialPayment(ctx context.Context, paymentToken string, amount float64, method PaymentMethod) (*PaymentResponse, error) {
+	session, found, err := p.sessions.Get(paymentToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return nil, ErrMultiPaymentSessionNotFound
+	}
+	if !session.methodAllowed(method) {
+		return nil, ErrMultiPaymentMethodNotAllowed
+	}
+
+	session.mutex.Lock()
+	request := &PaymentRequest{
+		Amount:        amount,
+		Currency:      session.Currency,
+		OrderID:       session.ExternalID,
+		PaymentMethod: method,
+	}
+	response, err := session.addChargeLocked(ctx, p, request)
+	session.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.sessions.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return response, nil
+}
+
+// CompleteMultiPayment finalizes the session identified by paymentToken, the same way
+// MultiPaymentSession.Finalize does: MultiPaymentCompleted if its RemainingAmount has reached
+// zero, MultiPaymentCancelled otherwise
+func (p *CambodiaABA) CompleteMultiPayment(ctx context.Context, paymentToken string) (*MultiPaymentSession, error) {
+	session, found, err := p.sessions.Get(paymentToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return nil, ErrMultiPaymentSessionNotFound
+	}
+
+	if err := session.Finalize(); err != nil {
+		return session, err
+	}
+	if err := p.sessions.Put(session); err != nil {
+		return session, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return session, nil
+}
@@ -0,0 +1,283 @@
+// Inbound webhook handling for Cambodia's Wing and ABA payment callbacks
+// CambodiaWing and CambodiaABA both set CallbackURL when creating a payment, but until now
+// nothing verified or dispatched what Wing/ABA post back to it. WingWebhookHandler and
+// ABAWebhookHandler follow webhook.go's FPXWebhookHandler/GrabPayWebhookHandler template:
+// recompute the same signature the platform's generateSignature/generateHash produces on the
+// outbound side, reject on mismatch, guard against replay via webhook.go's shared
+// replayCache, and dispatch a normalized WebhookEvent to every registered listener.
+
+package integration
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WingWebhookHandler verifies and dispatches inbound Wing payment callbacks. It implements
+// http.Handler so it can be registered directly against a caller's mux, or mounted on a
+// WebhookRouter alongside other platforms' handlers.
+type WingWebhookHandler struct {
+	merchantKey string
+	options     webhookOptions
+	replay      *replayCache
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewWingWebhookHandler creates a handler verifying callbacks signed with merchantKey, the
+// same shared secret CambodiaWingConfig.MerchantKey uses for outbound requests
+func NewWingWebhookHandler(merchantKey string, opts ...WebhookOption) *WingWebhookHandler {
+	options := defaultWebhookOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &WingWebhookHandler{
+		merchantKey: merchantKey,
+		options:     options,
+		replay:      newReplayCache(options.replayTTL),
+	}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *WingWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// ServeHTTP verifies a Wing callback's HMAC-SHA256 signature and payment_id+timestamp replay
+// window, then dispatches a normalized WebhookEvent to every registered listener
+func (h *WingWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var params map[string]interface{}
+	if err := json.Unmarshal(body, &params); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	signature, _ := params["signature"].(string)
+	delete(params, "signature")
+
+	if !hmac.Equal([]byte(signature), []byte(h.wingSignature(params))) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	paymentID, _ := params["payment_id"].(string)
+	timestamp, _ := params["timestamp"].(string)
+	if err := h.checkReplay(paymentID, timestamp); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	status, _ := params["status"].(string)
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: paymentID,
+		Status:    mapWingStatus(status),
+		Raw:       json.RawMessage(body),
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// wingSignature recomputes the HMAC-SHA256 signature over params the same way
+// CambodiaWing.generateSignature does on the outbound side
+func (h *WingWebhookHandler) wingSignature(params map[string]interface{}) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(fmt.Sprintf("%v", params[k]))
+		sb.WriteString("&")
+	}
+	signStr := strings.TrimSuffix(sb.String(), "&")
+
+	mac := hmac.New(sha256.New, []byte(h.merchantKey))
+	mac.Write([]byte(signStr))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkReplay rejects a callback whose timestamp is outside the configured clock skew or
+// whose payment_id+timestamp pair has already been seen
+func (h *WingWebhookHandler) checkReplay(paymentID, timestamp string) error {
+	if timestamp != "" {
+		parsed, err := time.Parse("20060102150405", timestamp)
+		if err == nil && absDuration(time.Since(parsed)) > h.options.clockSkew {
+			return fmt.Errorf("callback timestamp outside allowed clock skew")
+		}
+	}
+	if !h.replay.checkAndRemember(paymentID + ":" + timestamp) {
+		return fmt.Errorf("callback already processed (replay)")
+	}
+	return nil
+}
+
+// mapWingStatus maps a Wing callback's status string to a PaymentStatus, mirroring
+// CambodiaWing.GetPaymentStatus's mapping
+func mapWingStatus(status string) PaymentStatus {
+	switch status {
+	case "completed", "success":
+		return StatusCompleted
+	case "failed", "cancelled":
+		return StatusFailed
+	default:
+		return StatusPending
+	}
+}
+
+// ABAWebhookHandler verifies and dispatches inbound ABA PayWay payment callbacks. It
+// implements http.Handler so it can be registered directly against a caller's mux, or mounted
+// on a WebhookRouter alongside other platforms' handlers.
+type ABAWebhookHandler struct {
+	merchantSecret string
+	options        webhookOptions
+	replay         *replayCache
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewABAWebhookHandler creates a handler verifying callbacks hashed with merchantSecret, the
+// same shared secret CambodiaABAConfig.MerchantSecret uses for outbound requests
+func NewABAWebhookHandler(merchantSecret string, opts ...WebhookOption) *ABAWebhookHandler {
+	options := defaultWebhookOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &ABAWebhookHandler{
+		merchantSecret: merchantSecret,
+		options:        options,
+		replay:         newReplayCache(options.replayTTL),
+	}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *ABAWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// ServeHTTP verifies an ABA callback's SHA-256+secret hash and tran_id+timestamp replay
+// window, then dispatches a normalized WebhookEvent to every registered listener
+func (h *ABAWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var params map[string]interface{}
+	if err := json.Unmarshal(body, &params); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	hash, _ := params["hash"].(string)
+	delete(params, "hash")
+
+	if !hmac.Equal([]byte(hash), []byte(h.abaHash(params))) {
+		http.Error(w, "invalid hash", http.StatusUnauthorized)
+		return
+	}
+
+	paymentID, _ := params["tran_id"].(string)
+	timestamp, _ := params["timestamp"].(string)
+	if err := h.checkReplay(paymentID, timestamp); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	status, _ := params["status"].(string)
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: paymentID,
+		Status:    mapABAStatus(status),
+		Raw:       json.RawMessage(body),
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// abaHash recomputes the SHA-256 hash over params the same way CambodiaABA.generateHash does
+// on the outbound side: a sorted key=value concatenation with the merchant secret appended
+func (h *ABAWebhookHandler) abaHash(params map[string]interface{}) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(fmt.Sprintf("%v", params[k]))
+		sb.WriteString("&")
+	}
+	signStr := strings.TrimSuffix(sb.String(), "&")
+	signStr += h.merchantSecret
+
+	sum := sha256.Sum256([]byte(signStr))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkReplay rejects a callback whose timestamp is outside the configured clock skew or
+// whose tran_id+timestamp pair has already been seen
+func (h *ABAWebhookHandler) checkReplay(paymentID, timestamp string) error {
+	if timestamp != "" {
+		parsed, err := time.Parse("20060102150405", timestamp)
+		if err == nil && absDuration(time.Since(parsed)) > h.options.clockSkew {
+			return fmt.Errorf("callback timestamp outside allowed clock skew")
+		}
+	}
+	if !h.replay.checkAndRemember(paymentID + ":" + timestamp) {
+		return fmt.Errorf("callback already processed (replay)")
+	}
+	return nil
+}
+
+// mapABAStatus maps an ABA callback's status code to a PaymentStatus, mirroring
+// CambodiaABA.GetPaymentStatus's mapping
+func mapABAStatus(status string) PaymentStatus {
+	switch status {
+	case "2":
+		return StatusCompleted
+	case "0", "1":
+		return StatusPending
+	default:
+		return StatusFailed
+	}
+}
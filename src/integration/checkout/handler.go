@@ -0,0 +1,168 @@
+// Package checkout provides a default hosted checkout page for integration.PaymentLink, so a
+// merchant without their own frontend can drop a working payment page in behind a link rather
+// than building one against PaymentLinkManager directly. It imports integration rather than
+// the reverse, the same direction database/crypto is imported by database: checkout exists to
+// serve integration's PaymentLinkManager, not the other way round.
+package checkout
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aucoventureresearchcentre/comprehensive-asian-crypto-payment-integration-code/src/integration"
+)
+
+// Handler serves a hosted checkout page for every PaymentLink known to its
+// PaymentLinkManager: GET renders a form (or a fixed-amount confirmation), POST charges the
+// link via PaymentLinkManager.Pay and renders the link's SuccessAction outcome.
+type Handler struct {
+	manager *integration.PaymentLinkManager
+}
+
+// NewHandler creates a checkout Handler serving links known to manager
+func NewHandler(manager *integration.PaymentLinkManager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// ServeHTTP expects to be mounted under a path prefix ending in the link token, e.g.
+// "/pay/<token>" routed to this Handler with the prefix stripped, or the full request path if
+// mounted at the root - either way it uses the final path segment as the token.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := lastPathSegment(r.URL.Path)
+	if token == "" {
+		http.Error(w, "missing payment link token", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.manager.GetPaymentLink(token)
+	if err != nil {
+		if err == integration.ErrPaymentLinkNotFound {
+			http.Error(w, "payment link not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to look up payment link", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		h.renderForm(w, link)
+	case http.MethodPost:
+		h.submit(w, r, link, token)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) renderForm(w http.ResponseWriter, link *integration.PaymentLink) {
+	if !link.Active {
+		h.renderMessage(w, http.StatusGone, "This payment link is no longer active.")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprintf(w, "<html><body><h1>%s</h1><form method=\"post\">", html.EscapeString(link.Request.Description))
+
+	if link.Request.FixedAmount > 0 {
+		fmt.Fprintf(w, "<p>Amount: %.2f %s</p><input type=\"hidden\" name=\"amount\" value=\"%.2f\">",
+			link.Request.FixedAmount, html.EscapeString(defaultCurrency(link)), link.Request.FixedAmount)
+	} else {
+		fmt.Fprintf(w, "<label>Amount: <input type=\"text\" name=\"amount\"></label><br>")
+	}
+
+	fmt.Fprint(w, "<label>Currency: <select name=\"currency\">")
+	for _, currency := range link.Request.AllowedCurrencies {
+		fmt.Fprintf(w, "<option value=\"%s\">%s</option>", html.EscapeString(currency), html.EscapeString(currency))
+	}
+	fmt.Fprint(w, "</select></label><br>")
+	fmt.Fprint(w, "<label>Email: <input type=\"email\" name=\"customer_email\"></label><br>")
+	fmt.Fprint(w, "<button type=\"submit\">Pay</button></form></body></html>")
+}
+
+func (h *Handler) submit(w http.ResponseWriter, r *http.Request, link *integration.PaymentLink, token string) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "failed to parse payment form", http.StatusBadRequest)
+		return
+	}
+
+	amount, currency, err := parseAmountAndCurrency(r, link)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	customer := integration.PaymentRequest{
+		CustomerEmail: r.FormValue("customer_email"),
+		CustomerName:  r.FormValue("customer_name"),
+	}
+
+	response, err := h.manager.Pay(r.Context(), token, amount, currency, customer)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("payment failed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	h.renderSuccess(w, r, link.Request.SuccessAction, response)
+}
+
+func (h *Handler) renderSuccess(w http.ResponseWriter, r *http.Request, action integration.SuccessAction, response *integration.PaymentResponse) {
+	if action.RedirectURL != "" {
+		http.Redirect(w, r, action.RedirectURL, http.StatusSeeOther)
+		return
+	}
+
+	message := action.ShowMessage
+	if message == "" {
+		message = fmt.Sprintf("Payment %s %s.", response.PaymentID, response.Status)
+	}
+	h.renderMessage(w, http.StatusOK, message)
+}
+
+func (h *Handler) renderMessage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<html><body><p>%s</p></body></html>", html.EscapeString(message))
+}
+
+// parseAmountAndCurrency reads and validates the form's amount/currency fields, falling back
+// to link's fixed amount/default currency where the link doesn't ask the customer to supply
+// them
+func parseAmountAndCurrency(r *http.Request, link *integration.PaymentLink) (float64, string, error) {
+	amount := link.Request.FixedAmount
+	if amount <= 0 {
+		parsed, err := strconv.ParseFloat(r.FormValue("amount"), 64)
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid amount")
+		}
+		amount = parsed
+	}
+
+	currency := r.FormValue("currency")
+	if currency == "" {
+		currency = defaultCurrency(link)
+	}
+	if currency == "" {
+		return 0, "", fmt.Errorf("no currency specified")
+	}
+	return amount, currency, nil
+}
+
+func defaultCurrency(link *integration.PaymentLink) string {
+	if len(link.Request.AllowedCurrencies) == 0 {
+		return ""
+	}
+	return link.Request.AllowedCurrencies[0]
+}
+
+func lastPathSegment(path string) string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return ""
+	}
+	segments := strings.Split(trimmed, "/")
+	return segments[len(segments)-1]
+}
@@ -0,0 +1,236 @@
+// Idempotent payment control tower shared by payment platform integrations
+// A network timeout after CreatePayment has already reached the upstream gateway leaves a
+// caller unsure whether to retry; retrying blind risks a duplicate charge, since U-Money and
+// LDB have no idempotency-key parameter of their own. PaymentControlTower tracks each
+// (platform, OrderID) pair's lifecycle so a platform's CreatePayment can refuse a duplicate
+// submission instead of dispatching it twice.
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PaymentState is a PaymentRecord's position in its CreatePayment lifecycle
+type PaymentState string
+
+const (
+	// PaymentInitiated marks an OrderID the control tower has reserved but not yet dispatched
+	PaymentInitiated PaymentState = "INITIATED"
+	// PaymentInFlight marks an OrderID currently being submitted to the upstream platform
+	PaymentInFlight PaymentState = "IN_FLIGHT"
+	// PaymentSettled marks an OrderID the upstream platform has confirmed
+	PaymentSettled PaymentState = "SETTLED"
+	// PaymentFailed marks an OrderID the upstream platform rejected or that errored before
+	// reaching it; a failed OrderID may be retried
+	PaymentFailed PaymentState = "FAILED"
+)
+
+// ErrAlreadyPaid is returned when CreatePayment is called again for an OrderID the control
+// tower has already recorded as PaymentSettled
+var ErrAlreadyPaid = errors.New("payment already settled for this order id")
+
+// ErrPaymentInFlight is returned when CreatePayment is called again for an OrderID the
+// control tower has already recorded as PaymentInFlight, i.e. a prior call is still being
+// submitted to the upstream platform
+var ErrPaymentInFlight = errors.New("payment already in flight for this order id")
+
+// PaymentRecord tracks one (Platform, OrderID) pair's lifecycle through a PaymentControlTower
+type PaymentRecord struct {
+	Platform  string
+	OrderID   string
+	PaymentID string
+	State     PaymentState
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ControlTowerStore persists PaymentRecords for a PaymentControlTower, keyed by
+// (Platform, OrderID). An implementation must be safe for concurrent use.
+type ControlTowerStore interface {
+	Get(platform, orderID string) (*PaymentRecord, bool, error)
+	Put(record *PaymentRecord) error
+	// Reserve atomically looks up (platform, orderID) and, if it's unseen or PaymentFailed,
+	// inserts a new PaymentInFlight record for it - all under the same lock/transaction, so two
+	// concurrent Reserve calls for the same pair can't both observe the pre-reservation state
+	// and both proceed. It returns the record as it now stands and whether this call performed
+	// the reservation; when reserved is false, record is the conflicting PaymentSettled or
+	// PaymentInFlight record that was already there.
+	Reserve(platform, orderID string) (record *PaymentRecord, reserved bool, err error)
+	// ListInFlight returns every PaymentInFlight record for platform, so a restarted process
+	// can reconcile them against the upstream platform's GetPaymentStatus.
+	ListInFlight(platform string) ([]*PaymentRecord, error)
+}
+
+// InMemoryControlTowerStore implements ControlTowerStore in process memory. Records do not
+// survive a restart, so a process using it can't reconcile in-flight payments left over from
+// before a crash; use a persistent ControlTowerStore (e.g. backed by bbolt or SQL) where that
+// matters.
+type InMemoryControlTowerStore struct {
+	mutex   sync.Mutex
+	records map[string]*PaymentRecord
+}
+
+// NewInMemoryControlTowerStore builds an empty InMemoryControlTowerStore
+func NewInMemoryControlTowerStore() *InMemoryControlTowerStore {
+	return &InMemoryControlTowerStore{records: make(map[string]*PaymentRecord)}
+}
+
+func controlTowerKey(platform, orderID string) string {
+	return platform + "|" + orderID
+}
+
+func (s *InMemoryControlTowerStore) Get(platform, orderID string) (*PaymentRecord, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	record, ok := s.records[controlTowerKey(platform, orderID)]
+	return record, ok, nil
+}
+
+func (s *InMemoryControlTowerStore) Put(record *PaymentRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.records[controlTowerKey(record.Platform, record.OrderID)] = record
+	return nil
+}
+
+func (s *InMemoryControlTowerStore) Reserve(platform, orderID string) (*PaymentRecord, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := controlTowerKey(platform, orderID)
+	existing, ok := s.records[key]
+	if ok {
+		switch existing.State {
+		case PaymentSettled, PaymentInFlight:
+			return existing, false, nil
+		}
+	}
+
+	now := time.Now()
+	record := &PaymentRecord{Platform: platform, OrderID: orderID, State: PaymentInFlight, CreatedAt: now, UpdatedAt: now}
+	if ok {
+		record.CreatedAt = existing.CreatedAt
+		record.PaymentID = existing.PaymentID
+	}
+	s.records[key] = record
+	return record, true, nil
+}
+
+func (s *InMemoryControlTowerStore) ListInFlight(platform string) ([]*PaymentRecord, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var inFlight []*PaymentRecord
+	for _, record := range s.records {
+		if record.Platform == platform && record.State == PaymentInFlight {
+			inFlight = append(inFlight, record)
+		}
+	}
+	return inFlight, nil
+}
+
+// PaymentControlTower guards CreatePayment against duplicate submissions for the same
+// (platform, OrderID), backed by a pluggable ControlTowerStore
+type PaymentControlTower struct {
+	store ControlTowerStore
+}
+
+// NewPaymentControlTower builds a PaymentControlTower backed by store
+func NewPaymentControlTower(store ControlTowerStore) *PaymentControlTower {
+	return &PaymentControlTower{store: store}
+}
+
+// Begin reserves orderID against platform for dispatch, transitioning it to PaymentInFlight.
+// It returns ErrAlreadyPaid if orderID has already settled, or ErrPaymentInFlight if another
+// call is still in flight for it. A previously PaymentFailed or unseen OrderID is allowed
+// through, so a caller can safely retry a CreatePayment that failed before reaching the
+// upstream platform. The lookup and the reservation happen atomically in the store (see
+// ControlTowerStore.Reserve), so two concurrent Begin calls for the same (platform, orderID)
+// can't both observe an unreserved state and both dispatch to the upstream gateway.
+func (t *PaymentControlTower) Begin(platform, orderID string) (*PaymentRecord, error) {
+	record, reserved, err := t.store.Reserve(platform, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve payment record: %w", err)
+	}
+	if !reserved {
+		switch record.State {
+		case PaymentSettled:
+			return record, ErrAlreadyPaid
+		case PaymentInFlight:
+			return record, ErrPaymentInFlight
+		}
+	}
+	return record, nil
+}
+
+// MarkSettled transitions orderID to PaymentSettled once the upstream platform has confirmed
+// the payment, recording its paymentID
+func (t *PaymentControlTower) MarkSettled(platform, orderID, paymentID string) error {
+	record, found, err := t.store.Get(platform, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to look up payment record: %w", err)
+	}
+	if !found {
+		record = &PaymentRecord{Platform: platform, OrderID: orderID, CreatedAt: time.Now()}
+	}
+	record.PaymentID = paymentID
+	record.State = PaymentSettled
+	record.UpdatedAt = time.Now()
+	return t.store.Put(record)
+}
+
+// MarkFailed transitions orderID to PaymentFailed, allowing a later Begin call to retry it
+func (t *PaymentControlTower) MarkFailed(platform, orderID string) error {
+	record, found, err := t.store.Get(platform, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to look up payment record: %w", err)
+	}
+	if !found {
+		record = &PaymentRecord{Platform: platform, OrderID: orderID, CreatedAt: time.Now()}
+	}
+	record.State = PaymentFailed
+	record.UpdatedAt = time.Now()
+	return t.store.Put(record)
+}
+
+// Reconcile replays every PaymentInFlight record left over for platform (e.g. after a
+// process restart following a crash mid-submission) by asking platform for each one's
+// current status via GetPaymentStatus, settling or failing the record accordingly. A record
+// with no PaymentID yet (the process crashed before the upstream platform ever
+// acknowledged it) is marked PaymentFailed, since there is nothing to reconcile it against.
+func (t *PaymentControlTower) Reconcile(ctx context.Context, platform PaymentPlatform) error {
+	inFlight, err := t.store.ListInFlight(platform.GetName())
+	if err != nil {
+		return fmt.Errorf("failed to list in-flight payment records: %w", err)
+	}
+
+	for _, record := range inFlight {
+		if record.PaymentID == "" {
+			if err := t.MarkFailed(record.Platform, record.OrderID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		response, err := platform.GetPaymentStatus(ctx, &PaymentStatusRequest{PaymentID: record.PaymentID})
+		if err != nil {
+			continue
+		}
+
+		switch response.Status {
+		case StatusCompleted:
+			if err := t.MarkSettled(record.Platform, record.OrderID, record.PaymentID); err != nil {
+				return err
+			}
+		case StatusFailed:
+			if err := t.MarkFailed(record.Platform, record.OrderID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
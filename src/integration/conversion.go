@@ -0,0 +1,144 @@
+// Fiat<->crypto conversion port for PaymentPlatform.CreatePayment
+// This package doesn't import the sibling conversion package (the same way
+// blockchain/localization.go mirrors this package's localization shape rather than
+// importing it), so ConversionProvider is this package's own small port:
+// adapt a conversion.RateProvider (CoinGeckoProvider, BinanceProvider, KrakenProvider,
+// CompositeProvider, ...) to it at your composition root.
+
+package integration
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Common errors
+var (
+	ErrConversionQuoteExpired  = errors.New("conversion quote has expired")
+	ErrConversionQuoteRedeemed = errors.New("conversion quote has already been redeemed")
+)
+
+// ConversionAssetType classifies a currency code as fiat or crypto, for ConversionRecord's
+// from_type/to_type reconciliation fields
+type ConversionAssetType string
+
+const (
+	ConversionAssetFiat   ConversionAssetType = "fiat"
+	ConversionAssetCrypto ConversionAssetType = "crypto"
+)
+
+// conversionCryptoCurrencies are the settlement currencies ConversionAssetTypeOf treats as
+// crypto; anything else passed as a currency code is treated as fiat
+var conversionCryptoCurrencies = map[string]bool{
+	"BTC":  true,
+	"ETH":  true,
+	"USDT": true,
+	"USDC": true,
+}
+
+// ConversionAssetTypeOf classifies currency as fiat or crypto
+func ConversionAssetTypeOf(currency string) ConversionAssetType {
+	if conversionCryptoCurrencies[strings.ToUpper(currency)] {
+		return ConversionAssetCrypto
+	}
+	return ConversionAssetFiat
+}
+
+// ConversionQuote locks a rate converting FromCurrency into ToCurrency for a bounded
+// window, returned by a ConversionProvider
+type ConversionQuote struct {
+	QuoteID         string
+	Token           string
+	FromCurrency    string
+	ToCurrency      string
+	FromType        ConversionAssetType
+	ToType          ConversionAssetType
+	Amount          float64
+	ConvertedAmount float64
+	Rate            float64
+	Source          string
+	IssuedAt        time.Time
+	ExpiresAt       time.Time
+}
+
+// ConversionProvider locks a fiat<->crypto rate into a ConversionQuote that
+// ConversionPlatform settles a PaymentRequest against
+type ConversionProvider interface {
+	// Quote locks the current rate converting one unit of from into to for ttl
+	Quote(from, to string, amount float64, ttl time.Duration) (*ConversionQuote, error)
+	// Redeem validates token and returns its ConversionQuote if unexpired and not already
+	// redeemed, consuming it so the same token can't settle a second payment
+	Redeem(token string) (*ConversionQuote, error)
+}
+
+// ConversionRecord is an audit-trail entry for a settled ConversionQuote, for fiat/crypto
+// reconciliation
+type ConversionRecord struct {
+	ID              string
+	QuoteID         string
+	PaymentID       string
+	FromCurrency    string
+	ToCurrency      string
+	FromType        ConversionAssetType
+	ToType          ConversionAssetType
+	Amount          float64
+	ConvertedAmount float64
+	Rate            float64
+	Source          string
+	RecordedAt      time.Time
+}
+
+// ConversionLedger records ConversionRecords for later reconciliation, e.g. against a
+// merchant's settlement reports
+type ConversionLedger struct {
+	mutex   sync.RWMutex
+	records []ConversionRecord
+}
+
+// NewConversionLedger creates an empty in-memory ConversionLedger
+func NewConversionLedger() *ConversionLedger {
+	return &ConversionLedger{}
+}
+
+// Record appends an audit entry for quote, settled under paymentID
+func (l *ConversionLedger) Record(quote *ConversionQuote, paymentID string) ConversionRecord {
+	record := ConversionRecord{
+		ID:              NewIdempotencyKey(),
+		QuoteID:         quote.QuoteID,
+		PaymentID:       paymentID,
+		FromCurrency:    quote.FromCurrency,
+		ToCurrency:      quote.ToCurrency,
+		FromType:        quote.FromType,
+		ToType:          quote.ToType,
+		Amount:          quote.Amount,
+		ConvertedAmount: quote.ConvertedAmount,
+		Rate:            quote.Rate,
+		Source:          quote.Source,
+		RecordedAt:      time.Now(),
+	}
+	l.mutex.Lock()
+	l.records = append(l.records, record)
+	l.mutex.Unlock()
+	return record
+}
+
+// Records returns a copy of every entry recorded so far
+func (l *ConversionLedger) Records() []ConversionRecord {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	out := make([]ConversionRecord, len(l.records))
+	copy(out, l.records)
+	return out
+}
+
+// conversionMetadataQuoteID/conversionMetadataOriginalValue/conversionMetadataOriginalUnit
+// are the PaymentResponse.Metadata keys ConversionPlatform annotates a converted payment
+// with (the quote ID plus the original amount and currency), so a caller can look up the
+// ConversionQuote that priced it
+const (
+	conversionMetadataQuoteID       = "conversion_quote_id"
+	conversionMetadataOriginalValue = "conversion_original_amount"
+	conversionMetadataOriginalUnit  = "conversion_original_currency"
+)
@@ -0,0 +1,92 @@
+// Generic fiat->crypto settlement for any PaymentPlatform
+// A merchant often wants to price an order in a fiat currency (SGD, THB, JPY, USD, ...) a
+// customer recognizes, but settle in a cryptocurrency (BTC, ETH, USDT, USDC) via a
+// registered BlockchainClient. ConversionPlatform wraps any PaymentPlatform so that
+// conversion happens once, in one place, instead of every platform re-implementing it.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultConversionQuoteTTL bounds how long ConversionPlatform's locked rate stays valid
+// before a caller must re-quote
+const DefaultConversionQuoteTTL = 30 * time.Second
+
+// ConversionPlatform wraps a PaymentPlatform so CreatePayment settles in SettlementCurrency
+// (typically crypto) regardless of what currency request.Currency is priced in. If
+// request.Currency already equals SettlementCurrency, the request passes through
+// unconverted.
+type ConversionPlatform struct {
+	PaymentPlatform
+	provider           ConversionProvider
+	settlementCurrency string
+	quoteTTL           time.Duration
+	ledger             *ConversionLedger
+}
+
+// NewConversionPlatform wraps platform so a CreatePayment request priced in a currency
+// other than settlementCurrency is quoted and converted through provider first. A nil
+// ledger disables audit recording; quoteTTL of zero uses DefaultConversionQuoteTTL.
+func NewConversionPlatform(platform PaymentPlatform, provider ConversionProvider, settlementCurrency string, quoteTTL time.Duration, ledger *ConversionLedger) *ConversionPlatform {
+	if quoteTTL <= 0 {
+		quoteTTL = DefaultConversionQuoteTTL
+	}
+	return &ConversionPlatform{
+		PaymentPlatform:    platform,
+		provider:           provider,
+		settlementCurrency: settlementCurrency,
+		quoteTTL:           quoteTTL,
+		ledger:             ledger,
+	}
+}
+
+// CreatePayment converts request's amount into the platform's settlement currency before
+// delegating to the wrapped platform, if request.Currency differs from it
+func (p *ConversionPlatform) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	if request.Currency == "" || request.Currency == p.settlementCurrency {
+		return p.PaymentPlatform.CreatePayment(ctx, request, opts...)
+	}
+
+	quote, err := p.provider.Quote(request.Currency, p.settlementCurrency, request.Amount, p.quoteTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quote conversion from %s to %s: %w", request.Currency, p.settlementCurrency, err)
+	}
+
+	redeemed, err := p.provider.Redeem(quote.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem conversion quote: %w", err)
+	}
+
+	converted := *request
+	converted.Amount = redeemed.ConvertedAmount
+	converted.Currency = p.settlementCurrency
+	converted.Metadata = annotateConversionMetadata(request.Metadata, redeemed)
+
+	response, err := p.PaymentPlatform.CreatePayment(ctx, &converted, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.ledger != nil {
+		p.ledger.Record(redeemed, response.PaymentID)
+	}
+	return response, nil
+}
+
+// annotateConversionMetadata returns a copy of metadata carrying the original fiat amount
+// and the quote that settled it, so a caller can trace a converted PaymentResponse back to
+// what the customer was originally quoted
+func annotateConversionMetadata(metadata map[string]string, quote *ConversionQuote) map[string]string {
+	out := make(map[string]string, len(metadata)+3)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	out[conversionMetadataQuoteID] = quote.QuoteID
+	out[conversionMetadataOriginalValue] = fmt.Sprintf("%g", quote.Amount)
+	out[conversionMetadataOriginalUnit] = quote.FromCurrency
+	return out
+}
@@ -0,0 +1,126 @@
+// Retrying HTTP client shared by payment platform integrations
+// A bare client.Post leaves CreatePayment/RefundPayment unsafe to retry on a timeout or
+// transient 5xx; RetryingHTTPClient adds exponential backoff around those failure modes so
+// callers paired with an IdempotencyStore can retry without risking a duplicate charge.
+
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy configures RetryingHTTPClient's exponential backoff
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Jitter randomizes each backoff by up to this fraction (0-1) of its computed duration,
+	// so a burst of requests that fail together don't all retry in lockstep. 0 disables
+	// jitter and retries at exactly the computed backoff.
+	Jitter float64
+}
+
+// DefaultRetryPolicy retries up to 3 times with backoff starting at 200ms, capped at 5s, and
+// jittered by up to 20% to avoid synchronized retry storms
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// jittered returns d adjusted by a random amount up to fraction*d, so concurrent callers
+// backing off from the same failure don't all retry at the same instant
+func jittered(d time.Duration, fraction float64) time.Duration {
+	if fraction <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Float64()*fraction*float64(d))
+}
+
+// RetryingHTTPClient wraps an *http.Client, retrying requests that fail with a network
+// error or a 5xx response using exponential backoff
+type RetryingHTTPClient struct {
+	client *http.Client
+	policy RetryPolicy
+}
+
+// NewRetryingHTTPClient wraps client with policy's retry behavior
+func NewRetryingHTTPClient(client *http.Client, policy RetryPolicy) *RetryingHTTPClient {
+	return &RetryingHTTPClient{client: client, policy: policy}
+}
+
+// WithHTTPClient replaces the *http.Client a platform constructor would otherwise build
+// itself (a bare client with a 30s timeout), e.g. to reuse a process-wide client or one
+// configured with a custom dialer. Set before WithTransport/WithObserver are applied, so
+// those still wrap client.Transport.
+func WithHTTPClient(client *http.Client) PlatformOption {
+	return func(o *platformOptions) { o.httpClient = client }
+}
+
+// WithRetry overrides a platform's default RetryPolicy (DefaultRetryPolicy) for its
+// retryClient
+func WithRetry(policy RetryPolicy) PlatformOption {
+	return func(o *platformOptions) { o.retryPolicy = policy }
+}
+
+// PostJSON POSTs body to url with contentType, retrying on network errors or 5xx
+// responses. Unlike *http.Client.Post, body is a []byte rather than an io.Reader so the
+// request can be rebuilt from scratch on each retry.
+func (c *RetryingHTTPClient) PostJSON(url, contentType string, body []byte) (*http.Response, error) {
+	return c.do(func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", contentType)
+		return req, nil
+	})
+}
+
+// Do retries buildRequest's request on network errors or 5xx responses. buildRequest is
+// called fresh on every attempt so its request body isn't exhausted by a prior retry.
+func (c *RetryingHTTPClient) Do(buildRequest func() (*http.Request, error)) (*http.Response, error) {
+	return c.do(buildRequest)
+}
+
+func (c *RetryingHTTPClient) do(buildRequest func() (*http.Request, error)) (*http.Response, error) {
+	var lastErr error
+	backoff := c.policy.InitialBackoff
+
+	for attempt := 0; attempt <= c.policy.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(jittered(backoff, c.policy.Jitter))
+			backoff *= 2
+			if backoff > c.policy.MaxBackoff {
+				backoff = c.policy.MaxBackoff
+			}
+		}
+
+		req, err := buildRequest()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request: %w", err)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("server error %d: %s", resp.StatusCode, string(body))
+			continue
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("request failed after %d attempts: %w", c.policy.MaxRetries+1, lastErr)
+}
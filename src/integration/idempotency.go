@@ -0,0 +1,167 @@
+// Idempotency key support shared by payment platform integrations
+// CreatePayment/RefundPayment retried after a timed-out client.Post will happily double
+// charge a customer. IdempotencyStore lets a platform persist the (key -> response)
+// mapping for a caller-supplied IdempotencyKey and short-circuit duplicates instead.
+
+package integration
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// NewIdempotencyKey generates a fresh random idempotency key, for a caller that doesn't
+// already have a natural one (e.g. its own order ID) to pass as PaymentRequest.IdempotencyKey.
+// It's a hand-rolled RFC 4122 version 4 UUID rather than a dependency on an external uuid
+// package, matching how multi_payment.go's generateSessionToken mints its own random tokens.
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand.Read only fails if the system CSPRNG is unavailable, which would make
+		// the process unsafe to run regardless; panicking here matches that severity rather
+		// than returning a zero-value key a caller might mistake for a valid one.
+		panic(fmt.Sprintf("failed to generate idempotency key: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// IdempotentOperation distinguishes which operation an idempotency key was issued for,
+// since the same key string should never satisfy both a payment and its refund
+type IdempotentOperation string
+
+const (
+	OperationCreatePayment IdempotentOperation = "create_payment"
+	OperationRefundPayment IdempotentOperation = "refund_payment"
+)
+
+// IdempotencyStore persists the response a platform returned for a given idempotency
+// key/operation pair, so a retried call can return the original response instead of
+// re-executing a side-effecting request
+type IdempotencyStore interface {
+	// Get returns the stored response for key/operation, and whether one was found
+	Get(key string, operation IdempotentOperation) (json.RawMessage, bool, error)
+	// Put persists response under key/operation. Implementations should treat a second
+	// Put for the same key/operation as a no-op rather than an error, since a request
+	// that raced with itself may call Put twice with the same result.
+	Put(key string, operation IdempotentOperation, response json.RawMessage) error
+}
+
+// idempotencyEntry is a stored response alongside when it was recorded, so
+// InMemoryIdempotencyStore can expire entries older than its configured ttl
+type idempotencyEntry struct {
+	response   json.RawMessage
+	recordedAt time.Time
+}
+
+// InMemoryIdempotencyStore is an IdempotencyStore backed by a process-local map, suitable
+// for single-instance deployments or tests
+type InMemoryIdempotencyStore struct {
+	mutex   sync.RWMutex
+	entries map[string]idempotencyEntry
+	ttl     time.Duration // zero means entries never expire
+}
+
+// NewInMemoryIdempotencyStore creates an empty in-memory idempotency store whose entries
+// never expire
+func NewInMemoryIdempotencyStore() *InMemoryIdempotencyStore {
+	return &InMemoryIdempotencyStore{entries: make(map[string]idempotencyEntry)}
+}
+
+// NewInMemoryIdempotencyStoreWithTTL creates an in-memory idempotency store whose entries
+// are treated as not-found once older than ttl, so a key can safely be reused for a later,
+// unrelated request instead of being held onto forever
+func NewInMemoryIdempotencyStoreWithTTL(ttl time.Duration) *InMemoryIdempotencyStore {
+	return &InMemoryIdempotencyStore{entries: make(map[string]idempotencyEntry), ttl: ttl}
+}
+
+// Get returns the stored response for key/operation, and whether one was found
+func (s *InMemoryIdempotencyStore) Get(key string, operation IdempotentOperation) (json.RawMessage, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	entry, exists := s.entries[idempotencyMapKey(key, operation)]
+	if !exists {
+		return nil, false, nil
+	}
+	if s.ttl > 0 && time.Since(entry.recordedAt) > s.ttl {
+		return nil, false, nil
+	}
+	return entry.response, true, nil
+}
+
+// Put persists response under key/operation
+func (s *InMemoryIdempotencyStore) Put(key string, operation IdempotentOperation, response json.RawMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.entries[idempotencyMapKey(key, operation)] = idempotencyEntry{response: response, recordedAt: time.Now()}
+	return nil
+}
+
+func idempotencyMapKey(key string, operation IdempotentOperation) string {
+	return string(operation) + ":" + key
+}
+
+// IdempotencyRecord is the gorm model SQLIdempotencyStore persists responses in
+type IdempotencyRecord struct {
+	gorm.Model
+	Key       string `gorm:"size:255;uniqueIndex:idx_idempotency_key_operation"`
+	Operation string `gorm:"size:30;uniqueIndex:idx_idempotency_key_operation"`
+	Response  string `gorm:"type:jsonb"`
+}
+
+// SQLIdempotencyStore is an IdempotencyStore backed by a SQL table via gorm, for
+// deployments running more than one instance of a payment platform integration
+type SQLIdempotencyStore struct {
+	db  *gorm.DB
+	ttl time.Duration // zero means records never expire
+}
+
+// NewSQLIdempotencyStore creates a SQL-backed idempotency store using db, which must
+// already have the IdempotencyRecord table migrated (db.AutoMigrate(&IdempotencyRecord{})).
+// Its records never expire; use NewSQLIdempotencyStoreWithTTL to reclaim old keys instead.
+func NewSQLIdempotencyStore(db *gorm.DB) *SQLIdempotencyStore {
+	return &SQLIdempotencyStore{db: db}
+}
+
+// NewSQLIdempotencyStoreWithTTL creates a SQL-backed idempotency store whose records are
+// treated as not-found once older than ttl, so a key can safely be reused for a later,
+// unrelated request instead of being held onto forever
+func NewSQLIdempotencyStoreWithTTL(db *gorm.DB, ttl time.Duration) *SQLIdempotencyStore {
+	return &SQLIdempotencyStore{db: db, ttl: ttl}
+}
+
+// Get returns the stored response for key/operation, and whether one was found
+func (s *SQLIdempotencyStore) Get(key string, operation IdempotentOperation) (json.RawMessage, bool, error) {
+	var record IdempotencyRecord
+	err := s.db.Where("key = ? AND operation = ?", key, string(operation)).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up idempotency record: %w", err)
+	}
+	if s.ttl > 0 && time.Since(record.CreatedAt) > s.ttl {
+		return nil, false, nil
+	}
+	return json.RawMessage(record.Response), true, nil
+}
+
+// Put persists response under key/operation, ignoring a duplicate-key conflict from a
+// concurrent request that raced to store the same result first
+func (s *SQLIdempotencyStore) Put(key string, operation IdempotentOperation, response json.RawMessage) error {
+	record := IdempotencyRecord{Key: key, Operation: string(operation), Response: string(response)}
+	if err := s.db.Create(&record).Error; err != nil {
+		if _, found, getErr := s.Get(key, operation); getErr == nil && found {
+			return nil
+		}
+		return fmt.Errorf("failed to persist idempotency record: %w", err)
+	}
+	return nil
+}
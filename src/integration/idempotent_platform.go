@@ -0,0 +1,102 @@
+// Generic idempotency enforcement for any PaymentPlatform
+// MalaysiaFPX and MalaysiaGrabPay each hand-roll the same check-before/store-after logic
+// around their IdempotencyStore. IdempotentPlatform lifts that logic out into a single
+// decorator any PaymentPlatform can be wrapped in, so a platform that doesn't want to
+// duplicate it can rely on this one instead.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// IdempotentPlatform wraps a PaymentPlatform, short-circuiting a retried CreatePayment or
+// RefundPayment call that shares an already-seen idempotency key with the stored response
+// from the call that key first produced, instead of re-executing it against the underlying
+// platform
+type IdempotentPlatform struct {
+	PaymentPlatform
+	store IdempotencyStore
+}
+
+// NewIdempotentPlatform wraps platform so CreatePayment/RefundPayment calls carrying an
+// IdempotencyKey (via PaymentRequest.IdempotencyKey/RefundRequest.IdempotencyKey or
+// WithIdempotencyKey) are recorded in and short-circuited by store. A call with no
+// idempotency key set passes straight through.
+func NewIdempotentPlatform(platform PaymentPlatform, store IdempotencyStore) *IdempotentPlatform {
+	return &IdempotentPlatform{PaymentPlatform: platform, store: store}
+}
+
+// CreatePayment returns the response previously stored for request's idempotency key, if any,
+// otherwise delegates to the wrapped platform and stores the result under that key
+func (p *IdempotentPlatform) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	key := resolveIdempotencyKey(request.IdempotencyKey, opts...)
+	if key == "" {
+		return p.PaymentPlatform.CreatePayment(ctx, request, opts...)
+	}
+
+	if raw, found, err := p.store.Get(key, OperationCreatePayment); err != nil {
+		return nil, fmt.Errorf("failed to check idempotency store: %w", err)
+	} else if found {
+		var response PaymentResponse
+		if err := json.Unmarshal(raw, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+		}
+		return &response, nil
+	}
+
+	response, err := p.PaymentPlatform.CreatePayment(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+	storeIdempotentResponse(p.store, key, OperationCreatePayment, response)
+	return response, nil
+}
+
+// RefundPayment returns the response previously stored for request's idempotency key, if any,
+// otherwise delegates to the wrapped platform and stores the result under that key
+func (p *IdempotentPlatform) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	key := resolveIdempotencyKey(request.IdempotencyKey, opts...)
+	if key == "" {
+		return p.PaymentPlatform.RefundPayment(ctx, request, opts...)
+	}
+
+	if raw, found, err := p.store.Get(key, OperationRefundPayment); err != nil {
+		return nil, fmt.Errorf("failed to check idempotency store: %w", err)
+	} else if found {
+		var response RefundResponse
+		if err := json.Unmarshal(raw, &response); err != nil {
+			return nil, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+		}
+		return &response, nil
+	}
+
+	response, err := p.PaymentPlatform.RefundPayment(ctx, request, opts...)
+	if err != nil {
+		return nil, err
+	}
+	storeIdempotentResponse(p.store, key, OperationRefundPayment, response)
+	return response, nil
+}
+
+// resolveIdempotencyKey returns the idempotency key a call should use: opts' WithIdempotencyKey
+// if set, otherwise requestKey (PaymentRequest.IdempotencyKey/RefundRequest.IdempotencyKey)
+func resolveIdempotencyKey(requestKey string, opts ...CallOption) string {
+	options := resolveCallOptions(opts...)
+	if options.IdempotencyKey != "" {
+		return options.IdempotencyKey
+	}
+	return requestKey
+}
+
+// storeIdempotentResponse persists response under key/operation, silently dropping a
+// marshal/store failure rather than failing an otherwise-successful call
+func storeIdempotentResponse(store IdempotencyStore, key string, operation IdempotentOperation, response interface{}) {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	_ = store.Put(key, operation, raw)
+}
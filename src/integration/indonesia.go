@@ -5,40 +5,50 @@ package integration
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
+
+	"github.com/aucoventureresearchcentre/comprehensive-asian-crypto-payment-integration-code/src/integration/midtrans"
+	"github.com/aucoventureresearchcentre/comprehensive-asian-crypto-payment-integration-code/src/integration/ovo"
+	"github.com/aucoventureresearchcentre/comprehensive-asian-crypto-payment-integration-code/src/integration/signing"
 )
 
 // IndonesiaGoPay holds configuration for GoPay integration
 type IndonesiaGoPayConfig struct {
-	ClientID       string
-	ClientSecret   string
-	MerchantID     string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	ClientID     string
+	ClientSecret string
+	MerchantID   string
+	APIEndpoint  string
+	CallbackURL  string
+	RedirectURL  string
+	TestMode     bool
+	// QRISGenerator, if set, renders a cross-wallet QRIS QR (see IndonesiaQRIS) into
+	// PaymentResponse.QRCodeData for MethodQRCode payments, alongside GoPay's own
+	// app-specific qr_code_url/deeplink
+	QRISGenerator *IndonesiaQRIS
 }
 
 // IndonesiaGoPay implements PaymentPlatform interface for Indonesia's GoPay
 type IndonesiaGoPay struct {
-	config IndonesiaGoPayConfig
-	client *http.Client
+	config      IndonesiaGoPayConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	language    Language
 }
 
-// NewIndonesiaGoPay creates a new GoPay payment platform
-func NewIndonesiaGoPay(config IndonesiaGoPayConfig) *IndonesiaGoPay {
+// NewIndonesiaGoPay creates a new GoPay payment platform. By default errors are in English
+// and requests retry with DefaultRetryPolicy; pass WithLocalization/WithRetry to change that,
+// or WithHTTPClient/WithTransport/WithObserver to customize the underlying HTTP client. Every
+// outbound request carries a generated X-Request-ID header, set before the request reaches
+// a supplied WithTransport.
+func NewIndonesiaGoPay(config IndonesiaGoPayConfig, opts ...PlatformOption) *IndonesiaGoPay {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -48,11 +58,22 @@ func NewIndonesiaGoPay(config IndonesiaGoPayConfig) *IndonesiaGoPay {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newRequestIDTransport(newObservingTransport(options.transport, options.observer, "GoPay"))
+
 	return &IndonesiaGoPay{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		language:    options.language,
 	}
 }
 
@@ -77,7 +98,14 @@ func (p *IndonesiaGoPay) GetSupportedCurrencies() []string {
 }
 
 // CreatePayment creates a payment
-func (p *IndonesiaGoPay) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+func (p *IndonesiaGoPay) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "IDR" {
 		return nil, errors.New("currency must be IDR for GoPay payments")
@@ -94,50 +122,43 @@ func (p *IndonesiaGoPay) CreatePayment(request *PaymentRequest) (*PaymentRespons
 	}
 
 	// Prepare GoPay request
-	goPayRequest := map[string]interface{}{
-		"transaction_details": map[string]interface{}{
-			"order_id":     request.OrderID,
-			"gross_amount": int(request.Amount),
+	chargeRequest := midtrans.ChargeRequest{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:     request.OrderID,
+			GrossAmount: int64(request.Amount),
 		},
-		"item_details": []map[string]interface{}{
-			{
-				"id":       "item1",
-				"price":    int(request.Amount),
-				"quantity": 1,
-				"name":     request.Description,
-			},
+		ItemDetails: []midtrans.ItemDetail{
+			{ID: "item1", Price: int64(request.Amount), Quantity: 1, Name: request.Description},
 		},
-		"customer_details": map[string]interface{}{
-			"first_name": request.CustomerName,
-			"email":      request.CustomerEmail,
-			"phone":      request.CustomerPhone,
+		CustomerDetails: midtrans.CustomerDetails{
+			FirstName: request.CustomerName,
+			Email:     request.CustomerEmail,
+			Phone:     request.CustomerPhone,
 		},
-		"payment_type": "gopay",
-		"gopay": map[string]interface{}{
-			"enable_callback": true,
-			"callback_url":    p.config.CallbackURL,
+		PaymentType: "gopay",
+		GoPay: midtrans.GoPayDetails{
+			EnableCallback: true,
+			CallbackURL:    p.config.CallbackURL,
 		},
 	}
 
-	// Convert to JSON
-	jsonData, err := json.Marshal(goPayRequest)
+	jsonData, err := json.Marshal(chargeRequest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v2/charge", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Basic "+token)
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v2/charge", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("Authorization", "Basic "+token)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -149,44 +170,34 @@ func (p *IndonesiaGoPay) CreatePayment(request *PaymentRequest) (*PaymentRespons
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Parse response
-	var goPayResponse map[string]interface{}
+	var goPayResponse midtrans.ChargeResponse
 	if err := json.Unmarshal(body, &goPayResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
-	if status, ok := goPayResponse["status_code"].(string); ok && status != "201" {
-		errorMsg := "unknown error"
-		if msg, ok := goPayResponse["status_message"].(string); ok {
-			errorMsg = msg
+	if goPayResponse.StatusCode != "" && goPayResponse.StatusCode != "201" {
+		errorMsg := goPayResponse.StatusMessage
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("GoPay error: %s", errorMsg)
+		return nil, newPlatformError("unknown_error", fmt.Sprintf("GoPay error: %s", errorMsg), resp.StatusCode, body, p.language)
 	}
 
-	// Extract payment details
-	transactionID, _ := goPayResponse["transaction_id"].(string)
-	orderID, _ := goPayResponse["order_id"].(string)
-	
 	// Extract actions (payment URLs)
 	var paymentURL, qrCodeURL string
-	if actions, ok := goPayResponse["actions"].([]interface{}); ok {
-		for _, action := range actions {
-			if actionMap, ok := action.(map[string]interface{}); ok {
-				if name, ok := actionMap["name"].(string); ok {
-					if name == "deeplink-redirect" {
-						paymentURL, _ = actionMap["url"].(string)
-					} else if name == "generate-qr-code" {
-						qrCodeURL, _ = actionMap["url"].(string)
-					}
-				}
-			}
+	for _, action := range goPayResponse.Actions {
+		switch action.Name {
+		case "deeplink-redirect":
+			paymentURL = action.URL
+		case "generate-qr-code":
+			qrCodeURL = action.URL
 		}
 	}
 
 	// Create response
 	response := &PaymentResponse{
-		PaymentID:     transactionID,
+		PaymentID:     goPayResponse.TransactionID,
 		Status:        StatusPending,
 		Amount:        request.Amount,
 		Currency:      request.Currency,
@@ -197,32 +208,41 @@ func (p *IndonesiaGoPay) CreatePayment(request *PaymentRequest) (*PaymentRespons
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 		ExpiresAt:     time.Now().Add(24 * time.Hour),
-		Metadata:      map[string]string{"order_id": orderID},
+		Metadata:      map[string]string{"order_id": goPayResponse.OrderID},
+	}
+	if request.PaymentMethod == MethodQRCode {
+		populateQRISData(p.config.QRISGenerator, request, response)
 	}
 
 	return response, nil
 }
 
 // GetPaymentStatus gets the status of a payment
-func (p *IndonesiaGoPay) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+func (p *IndonesiaGoPay) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Get access token
 	token, err := p.getAccessToken()
 	if err != nil {
 		return nil, err
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("GET", p.config.APIEndpoint+"/v2/"+request.PaymentID+"/status", nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Basic "+token)
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", p.config.APIEndpoint+"/v2/"+request.PaymentID+"/status", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("Authorization", "Basic "+token)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -234,37 +254,28 @@ func (p *IndonesiaGoPay) GetPaymentStatus(request *PaymentStatusRequest) (*Payme
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Parse response
-	var statusResponse map[string]interface{}
+	var statusResponse midtrans.StatusResponse
 	if err := json.Unmarshal(body, &statusResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
-	if status, ok := statusResponse["status_code"].(string); ok && status != "200" {
-		errorMsg := "unknown error"
-		if msg, ok := statusResponse["status_message"].(string); ok {
-			errorMsg = msg
+	if statusResponse.StatusCode != "" && statusResponse.StatusCode != "200" {
+		errorMsg := statusResponse.StatusMessage
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("GoPay error: %s", errorMsg)
-	}
-
-	// Extract payment details
-	transactionID, _ := statusResponse["transaction_id"].(string)
-	orderID, _ := statusResponse["order_id"].(string)
-	transactionStatus, _ := statusResponse["transaction_status"].(string)
-	grossAmount, _ := statusResponse["gross_amount"].(string)
-	amount, _ := strconv.ParseFloat(grossAmount, 64)
-	
-	// Extract timestamps
-	transactionTimeStr, _ := statusResponse["transaction_time"].(string)
-	transactionTime, _ := time.Parse("2006-01-02 15:04:05", transactionTimeStr)
-	
+		return nil, newPlatformError("unknown_error", fmt.Sprintf("GoPay error: %s", errorMsg), resp.StatusCode, body, p.language)
+	}
+
+	amount, _ := strconv.ParseFloat(statusResponse.GrossAmount, 64)
+	transactionTime, _ := time.Parse("2006-01-02 15:04:05", statusResponse.TransactionTime)
+
 	// Map GoPay status to our status
 	status := StatusPending
 	var completedAt time.Time
 
-	switch transactionStatus {
+	switch statusResponse.TransactionStatus {
 	case "settlement", "capture":
 		status = StatusCompleted
 		completedAt = time.Now()
@@ -276,55 +287,59 @@ func (p *IndonesiaGoPay) GetPaymentStatus(request *PaymentStatusRequest) (*Payme
 
 	// Create response
 	response := &PaymentResponse{
-		PaymentID:     transactionID,
+		PaymentID:     statusResponse.TransactionID,
 		Status:        status,
 		Amount:        amount,
 		Currency:      "IDR",
 		PaymentMethod: MethodEWallet,
-		TransactionID: transactionID,
+		TransactionID: statusResponse.TransactionID,
 		CreatedAt:     transactionTime,
 		UpdatedAt:     time.Now(),
 		CompletedAt:   completedAt,
-		Metadata:      map[string]string{"order_id": orderID},
+		Metadata:      map[string]string{"order_id": statusResponse.OrderID},
 	}
 
 	return response, nil
 }
 
 // RefundPayment refunds a payment
-func (p *IndonesiaGoPay) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+func (p *IndonesiaGoPay) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Get access token
 	token, err := p.getAccessToken()
 	if err != nil {
 		return nil, err
 	}
 
-	// Prepare refund request
-	refundRequest := map[string]interface{}{
-		"refund_key": request.RefundID,
-		"amount":     int(request.Amount),
-		"reason":     request.Reason,
+	refundRequest := midtrans.RefundRequest{
+		RefundKey: request.RefundID,
+		Amount:    int64(request.Amount),
+		Reason:    request.Reason,
 	}
 
-	// Convert to JSON
 	jsonData, err := json.Marshal(refundRequest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v2/"+request.PaymentID+"/refund", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Authorization", "Basic "+token)
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v2/"+request.PaymentID+"/refund", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("Authorization", "Basic "+token)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -336,35 +351,30 @@ func (p *IndonesiaGoPay) RefundPayment(request *RefundRequest) (*RefundResponse,
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Parse response
-	var refundResponse map[string]interface{}
+	var refundResponse midtrans.RefundResponse
 	if err := json.Unmarshal(body, &refundResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
-	if status, ok := refundResponse["status_code"].(string); ok && status != "200" {
-		errorMsg := "unknown error"
-		if msg, ok := refundResponse["status_message"].(string); ok {
-			errorMsg = msg
+	if refundResponse.StatusCode != "" && refundResponse.StatusCode != "200" {
+		errorMsg := refundResponse.StatusMessage
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("GoPay refund error: %s", errorMsg)
+		return nil, newPlatformError("unknown_error", fmt.Sprintf("GoPay refund error: %s", errorMsg), resp.StatusCode, body, p.language)
 	}
 
-	// Extract refund details
-	refundKey, _ := refundResponse["refund_key"].(string)
-	transactionID, _ := refundResponse["transaction_id"].(string)
-	refundAmount, _ := refundResponse["refund_amount"].(string)
-	amount, _ := strconv.ParseFloat(refundAmount, 64)
+	amount, _ := strconv.ParseFloat(refundResponse.RefundAmount, 64)
 
 	// Create response
 	response := &RefundResponse{
-		RefundID:      refundKey,
+		RefundID:      refundResponse.RefundKey,
 		PaymentID:     request.PaymentID,
 		Amount:        amount,
 		Currency:      "IDR",
 		Status:        "success",
-		TransactionID: transactionID,
+		TransactionID: refundResponse.TransactionID,
 		CreatedAt:     time.Now(),
 	}
 
@@ -380,23 +390,34 @@ func (p *IndonesiaGoPay) getAccessToken() (string, error) {
 
 // IndonesiaOVOConfig holds configuration for OVO integration
 type IndonesiaOVOConfig struct {
-	AppID         string
-	AppKey        string
-	MerchantID    string
-	APIEndpoint   string
-	CallbackURL   string
-	RedirectURL   string
-	TestMode      bool
+	AppID       string
+	AppKey      string
+	MerchantID  string
+	APIEndpoint string
+	CallbackURL string
+	RedirectURL string
+	TestMode    bool
+	// QRISGenerator, if set, lets CreatePayment accept MethodQRCode in addition to
+	// MethodEWallet, rendering a cross-wallet QRIS QR (see IndonesiaQRIS) into
+	// PaymentResponse.QRCodeData instead of pushing an in-app OVO payment request
+	QRISGenerator *IndonesiaQRIS
 }
 
 // IndonesiaOVO implements PaymentPlatform interface for Indonesia's OVO
 type IndonesiaOVO struct {
-	config IndonesiaOVOConfig
-	client *http.Client
+	config      IndonesiaOVOConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	signer      *signing.HMACSHA256Signer
+	language    Language
 }
 
-// NewIndonesiaOVO creates a new OVO payment platform
-func NewIndonesiaOVO(config IndonesiaOVOConfig) *IndonesiaOVO {
+// NewIndonesiaOVO creates a new OVO payment platform. By default errors are in English and
+// requests retry with DefaultRetryPolicy; pass WithLocalization/WithRetry to change that, or
+// WithHTTPClient/WithTransport/WithObserver to customize the underlying HTTP client. Every
+// outbound request carries a generated X-Request-ID header, set before the request reaches
+// a supplied WithTransport.
+func NewIndonesiaOVO(config IndonesiaOVOConfig, opts ...PlatformOption) *IndonesiaOVO {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -406,11 +427,23 @@ func NewIndonesiaOVO(config IndonesiaOVOConfig) *IndonesiaOVO {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newRequestIDTransport(newObservingTransport(options.transport, options.observer, "OVO"))
+
 	return &IndonesiaOVO{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		signer:      signing.NewHMACSHA256Signer([]byte(config.AppKey)),
+		language:    options.language,
 	}
 }
 
@@ -426,7 +459,7 @@ func (p *IndonesiaOVO) GetCountryCode() string {
 
 // GetSupportedPaymentMethods returns the supported payment methods
 func (p *IndonesiaOVO) GetSupportedPaymentMethods() []PaymentMethod {
-	return []PaymentMethod{MethodEWallet}
+	return []PaymentMethod{MethodEWallet, MethodQRCode}
 }
 
 // GetSupportedCurrencies returns the supported currencies
@@ -435,53 +468,80 @@ func (p *IndonesiaOVO) GetSupportedCurrencies() []string {
 }
 
 // CreatePayment creates a payment
-func (p *IndonesiaOVO) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+func (p *IndonesiaOVO) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "IDR" {
 		return nil, errors.New("currency must be IDR for OVO payments")
 	}
 
-	if request.PaymentMethod != MethodEWallet {
+	if request.PaymentMethod != MethodEWallet && request.PaymentMethod != MethodQRCode {
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	// QRIS payments don't go through OVO's own push-to-app API: the QR itself is the
+	// payment instruction, scannable by OVO or any other QRIS-licensed wallet
+	if request.PaymentMethod == MethodQRCode {
+		if p.config.QRISGenerator == nil {
+			return nil, errors.New("QRISGenerator must be configured for OVO QR code payments")
+		}
+		qris, err := p.config.QRISGenerator.GenerateQRIS(request)
+		if err != nil {
+			return nil, err
+		}
+		return &PaymentResponse{
+			PaymentID:     request.OrderID,
+			Status:        StatusPending,
+			Amount:        request.Amount,
+			Currency:      request.Currency,
+			PaymentMethod: MethodQRCode,
+			QRCodeData:    qris.Payload,
+			QRCodeURL:     qris.QRCodeData,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+			ExpiresAt:     time.Now().Add(15 * time.Minute),
+			Metadata:      map[string]string{"order_id": request.OrderID},
+		}, nil
+	}
+
 	// Prepare OVO request
 	timestamp := time.Now().Format("20060102150405")
 	reference := request.OrderID
-	
-	ovoRequest := map[string]interface{}{
-		"reference_number": reference,
-		"amount":           int(request.Amount),
-		"phone":            request.CustomerPhone,
-		"merchant_id":      p.config.MerchantID,
-		"description":      request.Description,
-		"callback_url":     p.config.CallbackURL,
-		"timestamp":        timestamp,
-	}
-
-	// Generate signature
-	signature := p.generateSignature(ovoRequest)
-	ovoRequest["signature"] = signature
-
-	// Convert to JSON
-	jsonData, err := json.Marshal(ovoRequest)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+
+	pushRequest := ovo.PushPaymentRequest{
+		ReferenceNumber: reference,
+		Amount:          int64(request.Amount),
+		Phone:           request.CustomerPhone,
+		MerchantID:      p.config.MerchantID,
+		Description:     request.Description,
+		CallbackURL:     p.config.CallbackURL,
+		Timestamp:       timestamp,
 	}
+	pushRequest.Signature = p.generateSignature(pushRequest)
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/api/v1/payment/push", bytes.NewBuffer(jsonData))
+	jsonData, err := json.Marshal(pushRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("App-ID", p.config.AppID)
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/api/v1/payment/push", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("App-ID", p.config.AppID)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -493,32 +553,23 @@ func (p *IndonesiaOVO) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Parse response
-	var ovoResponse map[string]interface{}
+	var ovoResponse ovo.PushPaymentResponse
 	if err := json.Unmarshal(body, &ovoResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
-	if status, ok := ovoResponse["status"].(string); ok && status != "200" {
-		errorMsg := "unknown error"
-		if msg, ok := ovoResponse["message"].(string); ok {
-			errorMsg = msg
+	if ovoResponse.Status != "" && ovoResponse.Status != "200" {
+		errorMsg := ovoResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("OVO error: %s", errorMsg)
-	}
-
-	// Extract payment details
-	data, ok := ovoResponse["data"].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("invalid response format")
+		return nil, newPlatformError("unknown_error", fmt.Sprintf("OVO error: %s", errorMsg), resp.StatusCode, body, p.language)
 	}
 
-	paymentID, _ := data["transaction_id"].(string)
-
 	// Create response
 	response := &PaymentResponse{
-		PaymentID:     paymentID,
+		PaymentID:     ovoResponse.Data.TransactionID,
 		Status:        StatusPending,
 		Amount:        request.Amount,
 		Currency:      request.Currency,
@@ -533,39 +584,41 @@ func (p *IndonesiaOVO) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 }
 
 // GetPaymentStatus gets the status of a payment
-func (p *IndonesiaOVO) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+func (p *IndonesiaOVO) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare status request
 	timestamp := time.Now().Format("20060102150405")
-	
-	statusRequest := map[string]interface{}{
-		"transaction_id": request.PaymentID,
-		"merchant_id":    p.config.MerchantID,
-		"timestamp":      timestamp,
-	}
 
-	// Generate signature
-	signature := p.generateSignature(statusRequest)
-	statusRequest["signature"] = signature
+	statusRequest := ovo.StatusRequest{
+		TransactionID: request.PaymentID,
+		MerchantID:    p.config.MerchantID,
+		Timestamp:     timestamp,
+	}
+	statusRequest.Signature = p.generateSignature(statusRequest)
 
-	// Convert to JSON
 	jsonData, err := json.Marshal(statusRequest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/api/v1/payment/status", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("App-ID", p.config.AppID)
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/api/v1/payment/status", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("App-ID", p.config.AppID)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -577,40 +630,28 @@ func (p *IndonesiaOVO) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Parse response
-	var statusResponse map[string]interface{}
+	var statusResponse ovo.StatusResponse
 	if err := json.Unmarshal(body, &statusResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
-	if status, ok := statusResponse["status"].(string); ok && status != "200" {
-		errorMsg := "unknown error"
-		if msg, ok := statusResponse["message"].(string); ok {
-			errorMsg = msg
+	if statusResponse.Status != "" && statusResponse.Status != "200" {
+		errorMsg := statusResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("OVO error: %s", errorMsg)
-	}
-
-	// Extract payment details
-	data, ok := statusResponse["data"].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("invalid response format")
+		return nil, newPlatformError("unknown_error", fmt.Sprintf("OVO error: %s", errorMsg), resp.StatusCode, body, p.language)
 	}
 
-	paymentStatus, _ := data["status"].(string)
-	amount, _ := data["amount"].(float64)
-	reference, _ := data["reference_number"].(string)
-	transactionTime, _ := data["transaction_time"].(string)
-	
 	// Parse transaction time
-	createdAt, _ := time.Parse("2006-01-02 15:04:05", transactionTime)
+	createdAt, _ := time.Parse("2006-01-02 15:04:05", statusResponse.Data.TransactionTime)
 
 	// Map OVO status to our status
 	status := StatusPending
 	var completedAt time.Time
 
-	switch paymentStatus {
+	switch statusResponse.Data.Status {
 	case "SUCCESS":
 		status = StatusCompleted
 		completedAt = time.Now()
@@ -624,56 +665,58 @@ func (p *IndonesiaOVO) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 	response := &PaymentResponse{
 		PaymentID:     request.PaymentID,
 		Status:        status,
-		Amount:        amount,
+		Amount:        statusResponse.Data.Amount,
 		Currency:      "IDR",
 		PaymentMethod: MethodEWallet,
 		TransactionID: request.PaymentID,
 		CreatedAt:     createdAt,
 		UpdatedAt:     time.Now(),
 		CompletedAt:   completedAt,
-		Metadata:      map[string]string{"reference": reference},
+		Metadata:      map[string]string{"reference": statusResponse.Data.ReferenceNumber},
 	}
 
 	return response, nil
 }
 
 // RefundPayment refunds a payment
-func (p *IndonesiaOVO) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+func (p *IndonesiaOVO) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare refund request
 	timestamp := time.Now().Format("20060102150405")
-	
-	refundRequest := map[string]interface{}{
-		"transaction_id": request.PaymentID,
-		"merchant_id":    p.config.MerchantID,
-		"amount":         int(request.Amount),
-		"reference":      request.RefundID,
-		"reason":         request.Reason,
-		"timestamp":      timestamp,
-	}
 
-	// Generate signature
-	signature := p.generateSignature(refundRequest)
-	refundRequest["signature"] = signature
+	refundRequest := ovo.RefundRequest{
+		TransactionID: request.PaymentID,
+		MerchantID:    p.config.MerchantID,
+		Amount:        int64(request.Amount),
+		Reference:     request.RefundID,
+		Reason:        request.Reason,
+		Timestamp:     timestamp,
+	}
+	refundRequest.Signature = p.generateSignature(refundRequest)
 
-	// Convert to JSON
 	jsonData, err := json.Marshal(refundRequest)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/api/v1/payment/refund", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("App-ID", p.config.AppID)
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/api/v1/payment/refund", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("App-ID", p.config.AppID)
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -685,68 +728,78 @@ func (p *IndonesiaOVO) RefundPayment(request *RefundRequest) (*RefundResponse, e
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Parse response
-	var refundResponse map[string]interface{}
+	var refundResponse ovo.RefundResponse
 	if err := json.Unmarshal(body, &refundResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
-	if status, ok := refundResponse["status"].(string); ok && status != "200" {
-		errorMsg := "unknown error"
-		if msg, ok := refundResponse["message"].(string); ok {
-			errorMsg = msg
+	if refundResponse.Status != "" && refundResponse.Status != "200" {
+		errorMsg := refundResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("OVO refund error: %s", errorMsg)
-	}
-
-	// Extract refund details
-	data, ok := refundResponse["data"].(map[string]interface{})
-	if !ok {
-		return nil, errors.New("invalid response format")
+		return nil, newPlatformError("unknown_error", fmt.Sprintf("OVO refund error: %s", errorMsg), resp.StatusCode, body, p.language)
 	}
 
-	refundID, _ := data["refund_id"].(string)
-	refundStatus, _ := data["status"].(string)
-
 	// Create response
 	response := &RefundResponse{
-		RefundID:     refundID,
-		PaymentID:    request.PaymentID,
-		Amount:       request.Amount,
-		Currency:     "IDR",
-		Status:       refundStatus,
-		CreatedAt:    time.Now(),
+		RefundID:  refundResponse.Data.RefundID,
+		PaymentID: request.PaymentID,
+		Amount:    request.Amount,
+		Currency:  "IDR",
+		Status:    refundResponse.Data.Status,
+		CreatedAt: time.Now(),
 	}
 
 	return response, nil
 }
 
-// generateSignature generates a signature for OVO requests
-func (p *IndonesiaOVO) generateSignature(params map[string]interface{}) string {
-	// Sort keys
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Build string to sign
-	var sb strings.Builder
-	for _, k := range keys {
-		sb.WriteString(k)
-		sb.WriteString("=")
-		sb.WriteString(fmt.Sprintf("%v", params[k]))
-		sb.WriteString("&")
-	}
-	// Remove trailing &
-	signStr := sb.String()
-	if len(signStr) > 0 {
-		signStr = signStr[:len(signStr)-1]
-	}
-
-	// Generate HMAC-SHA256
-	h := hmac.New(sha256.New, []byte(p.config.AppKey))
-	h.Write([]byte(signStr))
-	return hex.EncodeToString(h.Sum(nil))
+// generateSignature signs v (one of ovo.PushPaymentRequest, ovo.StatusRequest, or
+// ovo.RefundRequest, taken before its own Signature field is set) via the shared signing
+// package, canonicalizing v's JSON field names/values with "signature" excluded so re-signing
+// a payload that already carries a previous signature (as VerifyCallback does) can't fold that
+// signature into itself.
+func (p *IndonesiaOVO) generateSignature(v interface{}) string {
+	params, err := structToSignatureParams(v)
+	if err != nil {
+		return ""
+	}
+	signStr, _ := signing.Canonicalize(params, signing.SortedKV, "signature")
+	signature, _ := p.signer.Sign([]byte(signStr))
+	return signature
+}
+
+// VerifyCallback checks whether body, an inbound OVO payment notification, carries a valid
+// "signature" field for its other fields, returning the parsed payload on success
+func (p *IndonesiaOVO) VerifyCallback(headers http.Header, body []byte) (map[string]interface{}, error) {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse callback body: %w", err)
+	}
+
+	signature, _ := payload["signature"].(string)
+	signStr, err := signing.Canonicalize(payload, signing.SortedKV, "signature")
+	if err != nil {
+		return nil, err
+	}
+	if !p.signer.Verify([]byte(signStr), signature) {
+		return nil, signing.ErrVerificationFailed
+	}
+	return payload, nil
+}
+
+// structToSignatureParams round-trips v through JSON to get the map[string]interface{} form
+// signing.Canonicalize expects, so generateSignature can sign a typed ovo.*Request struct
+// directly instead of requiring a caller to build a parallel map of its fields
+func structToSignatureParams(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var params map[string]interface{}
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
 }
@@ -0,0 +1,27 @@
+// Split-tender checkout across Indonesia's e-wallets. None of IndonesiaGoPay, IndonesiaOVO, or
+// IndonesiaQRIS has a native partial-payment API of its own (unlike Cambodia's ABA - see
+// cambodia_multi_payment.go), so Indonesian split-tender is built entirely on top of the
+// existing cross-platform MultiPaymentCoordinator/MultiPaymentSession/SessionStore machinery
+// multi_payment.go already provides for MoMo/VNPay, rather than a second, Indonesia-specific
+// session implementation.
+
+package integration
+
+// NewIndonesiaMultiPaymentCoordinator creates a MultiPaymentCoordinator that lets a single
+// order be settled across GoPay, OVO, and QRIS in installments, e.g. a customer paying part
+// via GoPay and the remainder by scanning the QRIS code with a different wallet. Sessions are
+// persisted in store. Any of gopay, ovo, or qris may be nil to exclude that wallet from
+// routing.
+func NewIndonesiaMultiPaymentCoordinator(store SessionStore, gopay *IndonesiaGoPay, ovo *IndonesiaOVO, qris *IndonesiaQRIS) *MultiPaymentCoordinator {
+	var platforms []PaymentPlatform
+	if gopay != nil {
+		platforms = append(platforms, gopay)
+	}
+	if ovo != nil {
+		platforms = append(platforms, ovo)
+	}
+	if qris != nil {
+		platforms = append(platforms, qris)
+	}
+	return NewMultiPaymentCoordinator(store, platforms...)
+}
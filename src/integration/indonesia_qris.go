@@ -0,0 +1,263 @@
+// QRIS (Quick Response Code Indonesian Standard) support for Indonesia's e-wallet platforms
+// IndonesiaGoPay and IndonesiaOVO, like PayNow in Singapore (paynow_qr.go), previously relied
+// entirely on whatever qr_code_url their own upstream API happened to return, which only that
+// one wallet's app can scan. QRIS is Bank Indonesia's EMVCo Merchant Presented Mode profile
+// that every QRIS-licensed acquirer (GoPay, OVO, DANA, LinkAja, ShopeePay, and most banking
+// apps) can read from a single code, so a merchant need only render and reconcile one QR
+// regardless of which wallet the customer happens to use.
+
+package integration
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// qrisGUID identifies the QRIS domestic merchant account scheme within an EMVCo merchant
+// account info template (tag 51, per Bank Indonesia's QRIS specification)
+const qrisGUID = "ID.CO.QRIS.WWW"
+
+// QRISResponse is the result of generating a QRIS payload: the raw EMVCo payload string (for
+// reconciliation/logging) and a base64 PNG data URL a merchant can render directly as an
+// <img> src
+type QRISResponse struct {
+	Payload    string
+	QRCodeData string
+}
+
+// ParsedQRIS is a QRIS EMVCo payload decoded back into its constituent fields, e.g. for
+// reconciling an inbound acquirer webhook notification against the order it corresponds to
+type ParsedQRIS struct {
+	MerchantCategoryCode string
+	CountryCode          string
+	MerchantName         string
+	MerchantCity         string
+	Amount               float64
+	OrderID              string
+	NMID                 string
+}
+
+// IndonesiaQRISConfig configures QRIS QR payload generation
+type IndonesiaQRISConfig struct {
+	// NMID is the National Merchant ID Bank Indonesia's QRIS switching network issues to the
+	// acquirer for this merchant, written into the merchant account info template (tag 51,
+	// sub-tag 02). Required to generate a payload.
+	NMID string
+	// MerchantCategoryCode is the 4-digit MCC written to tag 52. Defaults to "0000"
+	// (unspecified) if empty.
+	MerchantCategoryCode string
+	// MerchantName and MerchantCity are the merchant details written to tags 59/60
+	MerchantName string
+	MerchantCity string
+	// Static marks whether GenerateQRIS should produce a reusable QR carrying no fixed
+	// amount (point-of-initiation "11") instead of a per-order one (point-of-initiation "12")
+	Static bool
+}
+
+// IndonesiaQRIS generates and parses QRIS merchant QR payloads. It can be registered as a
+// PaymentPlatform on its own, or used standalone via GenerateQRIS to embed a cross-wallet QR
+// into another platform's CreatePayment response (see IndonesiaGoPay/IndonesiaOVO's
+// populateQRISData).
+type IndonesiaQRIS struct {
+	config IndonesiaQRISConfig
+}
+
+// NewIndonesiaQRIS creates a new QRIS QR payload generator
+func NewIndonesiaQRIS(config IndonesiaQRISConfig) *IndonesiaQRIS {
+	if config.MerchantCategoryCode == "" {
+		config.MerchantCategoryCode = "0000"
+	}
+	return &IndonesiaQRIS{config: config}
+}
+
+// GetName returns the name of the payment platform
+func (p *IndonesiaQRIS) GetName() string {
+	return "QRIS"
+}
+
+// GetCountryCode returns the country code of the payment platform
+func (p *IndonesiaQRIS) GetCountryCode() string {
+	return "ID"
+}
+
+// GetSupportedPaymentMethods returns the supported payment methods
+func (p *IndonesiaQRIS) GetSupportedPaymentMethods() []PaymentMethod {
+	return []PaymentMethod{MethodQRCode}
+}
+
+// GetSupportedCurrencies returns the supported currencies
+func (p *IndonesiaQRIS) GetSupportedCurrencies() []string {
+	return []string{"IDR"}
+}
+
+// CreatePayment renders a QRIS QR payload for request. There is no upstream API call: the QR
+// itself is the payment instruction, and settlement is reported later by whichever acquirer
+// the customer's wallet cleared through (see ParseQRIS).
+func (p *IndonesiaQRIS) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	if request.Currency != "IDR" {
+		return nil, errors.New("currency must be IDR for QRIS payments")
+	}
+	if request.PaymentMethod != MethodQRCode {
+		return nil, ErrUnsupportedPaymentMethod
+	}
+
+	qris, err := p.GenerateQRIS(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PaymentResponse{
+		PaymentID:     request.OrderID,
+		Status:        StatusPending,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+		PaymentMethod: MethodQRCode,
+		QRCodeData:    qris.Payload,
+		QRCodeURL:     qris.QRCodeData,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		ExpiresAt:     time.Now().Add(15 * time.Minute),
+		Metadata:      map[string]string{"order_id": request.OrderID},
+	}, nil
+}
+
+// GetPaymentStatus is unsupported: QRIS is just a QR presentment standard with no status API
+// of its own. Reconcile payments via the acquirer's webhook notification (see ParseQRIS)
+// instead of polling.
+func (p *IndonesiaQRIS) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	return nil, errors.New("QRIS has no status API; reconcile payments via the acquirer's webhook notification instead")
+}
+
+// RefundPayment is unsupported for the same reason: QRIS has no refund API of its own.
+// Initiate refunds through whichever acquirer settled the original payment.
+func (p *IndonesiaQRIS) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	return nil, errors.New("QRIS has no refund API; initiate refunds through the settling acquirer instead")
+}
+
+// GenerateQRIS builds an EMVCo Merchant Presented Mode QR payload for request, per Bank
+// Indonesia's QRIS specification, and renders it as a 256x256 PNG data URL.
+func (p *IndonesiaQRIS) GenerateQRIS(request *PaymentRequest) (*QRISResponse, error) {
+	if p.config.NMID == "" {
+		return nil, errors.New("NMID must be configured to generate a QRIS payload")
+	}
+
+	mcc := p.config.MerchantCategoryCode
+	if mcc == "" {
+		mcc = "0000"
+	}
+	pointOfInitiation := "12"
+	if p.config.Static {
+		pointOfInitiation = "11"
+	}
+
+	merchantAccountInfo := emvTLV("00", qrisGUID) + emvTLV("02", p.config.NMID)
+
+	var sb strings.Builder
+	sb.WriteString(emvTLV("00", "01")) // payload format indicator
+	sb.WriteString(emvTLV("01", pointOfInitiation))
+	sb.WriteString(emvTLV("51", merchantAccountInfo))
+	sb.WriteString(emvTLV("52", mcc))
+	sb.WriteString(emvTLV("53", "360")) // ISO 4217 numeric code for IDR
+	if !p.config.Static && request.Amount > 0 {
+		sb.WriteString(emvTLV("54", strconv.FormatFloat(request.Amount, 'f', 2, 64)))
+	}
+	sb.WriteString(emvTLV("58", "ID"))
+	sb.WriteString(emvTLV("59", p.config.MerchantName))
+	sb.WriteString(emvTLV("60", p.config.MerchantCity))
+	if request.OrderID != "" {
+		sb.WriteString(emvTLV("62", emvTLV("01", request.OrderID)))
+	}
+	sb.WriteString("6304") // CRC tag/length placeholder; the checksum itself is appended below
+
+	payload := sb.String()
+	payload += fmt.Sprintf("%04X", crc16CCITT([]byte(payload)))
+
+	png, err := qrcode.Encode(payload, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render QRIS QR as PNG: %w", err)
+	}
+
+	return &QRISResponse{
+		Payload:    payload,
+		QRCodeData: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ParseQRIS decodes a QRIS EMVCo payload, e.g. one echoed back in an acquirer's inbound
+// webhook notification, into its constituent fields for reconciliation against the order it
+// corresponds to. It does not validate the trailing CRC (tag 63); callers that need to verify
+// payload integrity should do so separately.
+func ParseQRIS(payload string) (*ParsedQRIS, error) {
+	fields, err := parseEMVTLV(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &ParsedQRIS{
+		MerchantCategoryCode: fields["52"],
+		CountryCode:          fields["58"],
+		MerchantName:         fields["59"],
+		MerchantCity:         fields["60"],
+	}
+	if amountStr, ok := fields["54"]; ok {
+		parsed.Amount, _ = strconv.ParseFloat(amountStr, 64)
+	}
+	if merchantAccountInfo, ok := fields["51"]; ok {
+		if subFields, err := parseEMVTLV(merchantAccountInfo); err == nil {
+			parsed.NMID = subFields["02"]
+		}
+	}
+	if additionalData, ok := fields["62"]; ok {
+		if subFields, err := parseEMVTLV(additionalData); err == nil {
+			parsed.OrderID = subFields["01"]
+		}
+	}
+	return parsed, nil
+}
+
+// parseEMVTLV decodes a string of concatenated EMVCo TLV fields (2-digit tag, 2-digit
+// zero-padded length, value) into a tag->value map
+func parseEMVTLV(payload string) (map[string]string, error) {
+	fields := make(map[string]string)
+	i := 0
+	for i < len(payload) {
+		if i+4 > len(payload) {
+			return nil, fmt.Errorf("truncated EMV TLV field at offset %d", i)
+		}
+		length, err := strconv.Atoi(payload[i+2 : i+4])
+		if err != nil {
+			return nil, fmt.Errorf("invalid EMV TLV length at offset %d: %w", i, err)
+		}
+		tag := payload[i : i+2]
+		i += 4
+		if i+length > len(payload) {
+			return nil, fmt.Errorf("truncated EMV TLV value for tag %s", tag)
+		}
+		fields[tag] = payload[i : i+length]
+		i += length
+	}
+	return fields, nil
+}
+
+// populateQRISData best-effort renders a cross-wallet QRIS payload into response's
+// QRCodeData, so a merchant can display a QR any QRIS-licensed wallet can scan instead of only
+// the issuing platform's own app. It's skipped (and any rendering error ignored) when no
+// generator is configured, since QRIS rendering is a convenience on top of a successful
+// CreatePayment call, not a required part of one.
+func populateQRISData(generator *IndonesiaQRIS, request *PaymentRequest, response *PaymentResponse) {
+	if generator == nil {
+		return
+	}
+	qris, err := generator.GenerateQRIS(request)
+	if err != nil {
+		return
+	}
+	response.QRCodeData = qris.Payload
+}
@@ -0,0 +1,322 @@
+// Webhook handling for Indonesia's GoPay and OVO platforms. Neither IndonesiaGoPay nor
+// IndonesiaOVO exposes an HTTP handler for the asynchronous notifications their CallbackURL
+// fields register; GoPayWebhookHandler and OVOWebhookHandler fill that gap the same way
+// vietnam_webhook.go's MoMoWebhookHandler/VNPayWebhookHandler do: HandleWebhook verifies and
+// parses a delivery independently of ServeHTTP, so a caller with its own router and
+// acknowledgement conventions can call it directly, while ServeHTTP adapts it to http.Handler
+// for callers who don't need that.
+package integration
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aucoventureresearchcentre/comprehensive-asian-crypto-payment-integration-code/src/integration/signing"
+)
+
+// ErrInvalidWebhookSignature is returned when an inbound GoPay/OVO webhook's signature doesn't
+// match what its payload recomputes to
+var ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+
+// ErrReplayedWebhook is returned when an inbound webhook's transaction ID has already been
+// processed by this handler's IPNDeduplicator
+var ErrReplayedWebhook = errors.New("webhook already processed")
+
+// ErrWebhookTimestampDrift is returned when an inbound webhook's transaction_time is older than
+// the handler's configured max age, which most likely means it's a replayed delivery rather than
+// a fresh notification
+var ErrWebhookTimestampDrift = errors.New("webhook timestamp outside allowed max age")
+
+// midtransWebhookPayload is the JSON body Midtrans POSTs to a GoPay transaction's callback_url
+type midtransWebhookPayload struct {
+	OrderID           string `json:"order_id"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+	TransactionID     string `json:"transaction_id"`
+	TransactionStatus string `json:"transaction_status"`
+	TransactionTime   string `json:"transaction_time"`
+	PaymentType       string `json:"payment_type"`
+}
+
+// GoPayWebhookHandler verifies and dispatches inbound Midtrans/GoPay webhook notifications. It
+// implements http.Handler so it can be registered directly against a caller's mux, or mounted on
+// a WebhookRouter alongside other platforms' handlers.
+type GoPayWebhookHandler struct {
+	serverKey string
+	maxAge    time.Duration
+	dedup     IPNDeduplicator
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewGoPayWebhookHandler creates a handler verifying callbacks signed with serverKey, the same
+// IndonesiaGoPayConfig.ClientSecret Midtrans's Server Key uses for outbound requests. maxAge of
+// zero disables the transaction_time replay check. dedup defaults to an InMemoryIPNDeduplicator
+// remembering transaction IDs for 24 hours when nil.
+func NewGoPayWebhookHandler(serverKey string, maxAge time.Duration, dedup IPNDeduplicator) *GoPayWebhookHandler {
+	if dedup == nil {
+		dedup = NewInMemoryIPNDeduplicator(24 * time.Hour)
+	}
+	return &GoPayWebhookHandler{serverKey: serverKey, maxAge: maxAge, dedup: dedup}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *GoPayWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// HandleWebhook verifies r's body as a Midtrans/GoPay webhook notification and, on success,
+// dispatches a normalized WebhookEvent to every registered listener. It returns the
+// PaymentResponse the notification describes so a caller can persist it directly instead of
+// re-deriving one from GetPaymentStatus.
+func (h *GoPayWebhookHandler) HandleWebhook(r *http.Request) (*PaymentResponse, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+
+	var payload midtransWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook body: %w", err)
+	}
+
+	expected := midtransSignature(payload.OrderID, payload.StatusCode, payload.GrossAmount, h.serverKey)
+	if !hmac.Equal([]byte(payload.SignatureKey), []byte(expected)) {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	if err := checkWebhookAge(h.maxAge, payload.TransactionTime); err != nil {
+		return nil, err
+	}
+
+	seen, err := h.dedup.SeenBefore(payload.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check webhook deduplication: %w", err)
+	}
+	if seen {
+		return nil, ErrReplayedWebhook
+	}
+
+	status := mapGoPayWebhookStatus(payload.TransactionStatus)
+	var completedAt time.Time
+	transactionTime, _ := time.Parse("2006-01-02 15:04:05", payload.TransactionTime)
+	if status == StatusCompleted {
+		completedAt = time.Now()
+	}
+
+	response := &PaymentResponse{
+		PaymentID:     payload.OrderID,
+		Status:        status,
+		PaymentMethod: MethodEWallet,
+		TransactionID: payload.TransactionID,
+		Currency:      "IDR",
+		CreatedAt:     transactionTime,
+		UpdatedAt:     time.Now(),
+		CompletedAt:   completedAt,
+		Metadata:      map[string]string{"order_id": payload.OrderID},
+	}
+
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: payload.OrderID,
+		Status:    status,
+		Raw:       json.RawMessage(body),
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	return response, nil
+}
+
+// ServeHTTP adapts HandleWebhook to http.Handler, acknowledging with 200 OK on success the same
+// way Midtrans's own documentation expects
+func (h *GoPayWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.HandleWebhook(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// midtransSignature computes Midtrans's documented signature_key: the hex-encoded SHA-512 of
+// orderID+statusCode+grossAmount+serverKey concatenated directly, with no separators
+func midtransSignature(orderID, statusCode, grossAmount, serverKey string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// mapGoPayWebhookStatus maps a Midtrans transaction_status to a PaymentStatus, mirroring
+// IndonesiaGoPay.GetPaymentStatus's mapping
+func mapGoPayWebhookStatus(transactionStatus string) PaymentStatus {
+	switch transactionStatus {
+	case "settlement", "capture":
+		return StatusCompleted
+	case "deny", "cancel", "expire":
+		return StatusFailed
+	default:
+		return StatusPending
+	}
+}
+
+// OVOWebhookHandler verifies and dispatches inbound OVO webhook notifications. It implements
+// http.Handler so it can be registered directly against a caller's mux, or mounted on a
+// WebhookRouter alongside other platforms' handlers.
+type OVOWebhookHandler struct {
+	signer *signing.HMACSHA256Signer
+	maxAge time.Duration
+	dedup  IPNDeduplicator
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewOVOWebhookHandler creates a handler verifying callbacks signed with appKey, the same
+// IndonesiaOVOConfig.AppKey uses for outbound requests. maxAge of zero disables the
+// transaction_time replay check. dedup defaults to an InMemoryIPNDeduplicator remembering
+// transaction IDs for 24 hours when nil.
+func NewOVOWebhookHandler(appKey string, maxAge time.Duration, dedup IPNDeduplicator) *OVOWebhookHandler {
+	if dedup == nil {
+		dedup = NewInMemoryIPNDeduplicator(24 * time.Hour)
+	}
+	return &OVOWebhookHandler{
+		signer: signing.NewHMACSHA256Signer([]byte(appKey)),
+		maxAge: maxAge,
+		dedup:  dedup,
+	}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *OVOWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// HandleWebhook verifies r's body as an OVO webhook notification the same way
+// IndonesiaOVO.VerifyCallback does and, on success, dispatches a normalized WebhookEvent to
+// every registered listener. It returns the PaymentResponse the notification describes so a
+// caller can persist it directly instead of re-deriving one from GetPaymentStatus.
+func (h *OVOWebhookHandler) HandleWebhook(r *http.Request) (*PaymentResponse, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse webhook body: %w", err)
+	}
+
+	signature, _ := payload["signature"].(string)
+	signStr, err := signing.Canonicalize(payload, signing.SortedKV, "signature")
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize webhook body: %w", err)
+	}
+	if !h.signer.Verify([]byte(signStr), signature) {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	transactionTime, _ := payload["transaction_time"].(string)
+	if err := checkWebhookAge(h.maxAge, transactionTime); err != nil {
+		return nil, err
+	}
+
+	transactionID, _ := payload["transaction_id"].(string)
+	seen, err := h.dedup.SeenBefore(transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check webhook deduplication: %w", err)
+	}
+	if seen {
+		return nil, ErrReplayedWebhook
+	}
+
+	statusField, _ := payload["status"].(string)
+	status := mapOVOWebhookStatus(statusField)
+	var completedAt time.Time
+	if status == StatusCompleted {
+		completedAt = time.Now()
+	}
+	amount, _ := payload["amount"].(float64)
+	referenceNumber, _ := payload["reference_number"].(string)
+
+	response := &PaymentResponse{
+		PaymentID:     referenceNumber,
+		Status:        status,
+		Amount:        amount,
+		Currency:      "IDR",
+		PaymentMethod: MethodEWallet,
+		TransactionID: transactionID,
+		UpdatedAt:     time.Now(),
+		CompletedAt:   completedAt,
+		Metadata:      map[string]string{"reference_number": referenceNumber},
+	}
+
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: referenceNumber,
+		Status:    status,
+		Raw:       json.RawMessage(body),
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	return response, nil
+}
+
+// ServeHTTP adapts HandleWebhook to http.Handler, acknowledging with 200 OK on success
+func (h *OVOWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.HandleWebhook(r); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// mapOVOWebhookStatus maps an OVO callback's status string to a PaymentStatus, mirroring
+// IndonesiaOVO.GetPaymentStatus's mapping
+func mapOVOWebhookStatus(status string) PaymentStatus {
+	switch status {
+	case "SUCCESS":
+		return StatusCompleted
+	case "FAILED":
+		return StatusFailed
+	default:
+		return StatusPending
+	}
+}
+
+// checkWebhookAge rejects a webhook whose transactionTime (formatted "2006-01-02 15:04:05", as
+// both Midtrans and OVO report it) is older than maxAge. maxAge of zero or an unparseable
+// transactionTime skip the check rather than rejecting the delivery, since not every caller
+// configures a max age and not every platform reports a timestamp on every notification type.
+func checkWebhookAge(maxAge time.Duration, transactionTime string) error {
+	if maxAge <= 0 || transactionTime == "" {
+		return nil
+	}
+	parsed, err := time.Parse("2006-01-02 15:04:05", transactionTime)
+	if err != nil {
+		return nil
+	}
+	if absDuration(time.Since(parsed)) > maxAge {
+		return ErrWebhookTimestampDrift
+	}
+	return nil
+}
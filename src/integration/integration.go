@@ -4,6 +4,7 @@
 package integration
 
 import (
+	"context"
 	"errors"
 	"time"
 )
@@ -51,41 +52,47 @@ const (
 
 // PaymentRequest represents a payment request
 type PaymentRequest struct {
-	Amount          float64       `json:"amount"`
-	Currency        string        `json:"currency"`
-	Description     string        `json:"description"`
-	OrderID         string        `json:"order_id"`
-	CustomerID      string        `json:"customer_id,omitempty"`
-	CustomerEmail   string        `json:"customer_email,omitempty"`
-	CustomerName    string        `json:"customer_name,omitempty"`
-	CustomerPhone   string        `json:"customer_phone,omitempty"`
-	CustomerAddress string        `json:"customer_address,omitempty"`
-	PaymentMethod   PaymentMethod `json:"payment_method"`
-	ReturnURL       string        `json:"return_url,omitempty"`
-	CallbackURL     string        `json:"callback_url,omitempty"`
-	CancelURL       string        `json:"cancel_url,omitempty"`
+	Amount          float64           `json:"amount"`
+	Currency        string            `json:"currency"`
+	Description     string            `json:"description"`
+	OrderID         string            `json:"order_id"`
+	CustomerID      string            `json:"customer_id,omitempty"`
+	CustomerEmail   string            `json:"customer_email,omitempty"`
+	CustomerName    string            `json:"customer_name,omitempty"`
+	CustomerPhone   string            `json:"customer_phone,omitempty"`
+	CustomerAddress string            `json:"customer_address,omitempty"`
+	PaymentMethod   PaymentMethod     `json:"payment_method"`
+	ReturnURL       string            `json:"return_url,omitempty"`
+	CallbackURL     string            `json:"callback_url,omitempty"`
+	CancelURL       string            `json:"cancel_url,omitempty"`
 	Metadata        map[string]string `json:"metadata,omitempty"`
-	ExpiryTime      time.Time     `json:"expiry_time,omitempty"`
+	ExpiryTime      time.Time         `json:"expiry_time,omitempty"`
+	IdempotencyKey  string            `json:"idempotency_key,omitempty"`
 }
 
 // PaymentResponse represents a payment response
 type PaymentResponse struct {
-	PaymentID       string        `json:"payment_id"`
-	Status          PaymentStatus `json:"status"`
-	Amount          float64       `json:"amount"`
-	Currency        string        `json:"currency"`
-	PaymentMethod   PaymentMethod `json:"payment_method"`
-	TransactionID   string        `json:"transaction_id,omitempty"`
-	PaymentURL      string        `json:"payment_url,omitempty"`
-	QRCodeURL       string        `json:"qr_code_url,omitempty"`
-	RedirectURL     string        `json:"redirect_url,omitempty"`
-	CreatedAt       time.Time     `json:"created_at"`
-	UpdatedAt       time.Time     `json:"updated_at"`
-	CompletedAt     time.Time     `json:"completed_at,omitempty"`
-	ExpiresAt       time.Time     `json:"expires_at,omitempty"`
-	ErrorCode       string        `json:"error_code,omitempty"`
-	ErrorMessage    string        `json:"error_message,omitempty"`
-	Metadata        map[string]string `json:"metadata,omitempty"`
+	PaymentID     string            `json:"payment_id"`
+	Status        PaymentStatus     `json:"status"`
+	Amount        float64           `json:"amount"`
+	Currency      string            `json:"currency"`
+	PaymentMethod PaymentMethod     `json:"payment_method"`
+	TransactionID string            `json:"transaction_id,omitempty"`
+	PaymentURL    string            `json:"payment_url,omitempty"`
+	QRCodeURL     string            `json:"qr_code_url,omitempty"`
+	QRCodeData    string            `json:"qr_code_data,omitempty"` // raw QR payload (e.g. an EMVCo/SGQR string), for platforms that can render their own QR code rather than relying on a hosted QRCodeURL
+	RedirectURL   string            `json:"redirect_url,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	CompletedAt   time.Time         `json:"completed_at,omitempty"`
+	ExpiresAt     time.Time         `json:"expires_at,omitempty"`
+	ErrorCode     string            `json:"error_code,omitempty"`
+	ErrorMessage  string            `json:"error_message,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	// LocalizedDescription is the payment's description translated into the platform's
+	// configured Language (see WithLocalization), for a merchant-facing receipt. Empty for a
+	// platform that doesn't yet populate it.
+	LocalizedDescription string `json:"localized_description,omitempty"`
 }
 
 // PaymentStatusRequest represents a payment status request
@@ -97,10 +104,11 @@ type PaymentStatusRequest struct {
 
 // RefundRequest represents a refund request
 type RefundRequest struct {
-	PaymentID     string  `json:"payment_id"`
-	Amount        float64 `json:"amount,omitempty"` // If not specified, full amount is refunded
-	Reason        string  `json:"reason,omitempty"`
-	RefundID      string  `json:"refund_id,omitempty"`
+	PaymentID      string  `json:"payment_id"`
+	Amount         float64 `json:"amount,omitempty"` // If not specified, full amount is refunded
+	Reason         string  `json:"reason,omitempty"`
+	RefundID       string  `json:"refund_id,omitempty"`
+	IdempotencyKey string  `json:"idempotency_key,omitempty"`
 }
 
 // RefundResponse represents a refund response
@@ -117,40 +125,115 @@ type RefundResponse struct {
 	ErrorMessage  string    `json:"error_message,omitempty"`
 }
 
+// CallOptions overrides a payment platform's constructor-time defaults for a single
+// CreatePayment/GetPaymentStatus/RefundPayment call
+type CallOptions struct {
+	// Timeout overrides the platform's HTTP client timeout for this call only, if non-zero
+	Timeout time.Duration
+	// ClientIP is forwarded to platforms that must report the originating customer/merchant
+	// IP (e.g. VNPay's vnp_IpAddr); a platform falls back to its own default when empty
+	ClientIP string
+	// IdempotencyKey overrides PaymentRequest.IdempotencyKey/RefundRequest.IdempotencyKey
+	// for this call
+	IdempotencyKey string
+	// RetryPolicy records a per-call retry policy, if non-nil. No platform in this package
+	// reads it: every call retries with the policy its platform was constructed with.
+	RetryPolicy *RetryPolicy
+}
+
+// CallOption configures CallOptions for a single PaymentPlatform call
+type CallOption func(*CallOptions)
+
+// WithCallTimeout overrides a single call's HTTP timeout
+func WithCallTimeout(timeout time.Duration) CallOption {
+	return func(o *CallOptions) { o.Timeout = timeout }
+}
+
+// WithClientIP sets the originating client IP a platform should report for a single call
+func WithClientIP(ip string) CallOption {
+	return func(o *CallOptions) { o.ClientIP = ip }
+}
+
+// WithIdempotencyKey overrides a single call's idempotency key
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *CallOptions) { o.IdempotencyKey = key }
+}
+
+// WithCallRetryPolicy sets CallOptions.RetryPolicy for a single call; platforms in this
+// package do not read it and keep retrying with their constructor-time policy
+func WithCallRetryPolicy(policy RetryPolicy) CallOption {
+	return func(o *CallOptions) { o.RetryPolicy = &policy }
+}
+
+// resolveCallOptions applies opts over a zero-value CallOptions
+func resolveCallOptions(opts ...CallOption) CallOptions {
+	var options CallOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
 // PaymentPlatform defines the interface for payment platform integrations
 type PaymentPlatform interface {
 	// GetName returns the name of the payment platform
 	GetName() string
-	
+
 	// GetCountryCode returns the country code of the payment platform
 	GetCountryCode() string
-	
+
 	// GetSupportedPaymentMethods returns the supported payment methods
 	GetSupportedPaymentMethods() []PaymentMethod
-	
+
 	// GetSupportedCurrencies returns the supported currencies
 	GetSupportedCurrencies() []string
-	
-	// CreatePayment creates a payment
-	CreatePayment(request *PaymentRequest) (*PaymentResponse, error)
-	
-	// GetPaymentStatus gets the status of a payment
-	GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error)
-	
-	// RefundPayment refunds a payment
-	RefundPayment(request *RefundRequest) (*RefundResponse, error)
+
+	// CreatePayment creates a payment. opts may override per-call behavior such as timeout,
+	// client IP, or idempotency key (a per-call retry policy is not applied).
+	CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error)
+
+	// GetPaymentStatus gets the status of a payment. opts may override per-call behavior
+	// such as timeout (a per-call retry policy is not applied).
+	GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error)
+
+	// RefundPayment refunds a payment. opts may override per-call behavior such as timeout,
+	// client IP, or idempotency key (a per-call retry policy is not applied).
+	RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error)
 }
 
 // PaymentPlatformRegistry maintains a registry of payment platforms
 type PaymentPlatformRegistry struct {
 	platforms map[string]PaymentPlatform
+	language  Language
+}
+
+// RegistryOption configures NewPaymentPlatformRegistry
+type RegistryOption func(*PaymentPlatformRegistry)
+
+// WithRegistryLocalization sets the language LocalizeError falls back to when a caller
+// doesn't have a specific platform (and thus its own PlatformOption-configured language) to
+// localize against yet, e.g. when GetPlatform itself fails. See WithLocalization for the
+// equivalent per-platform option.
+func WithRegistryLocalization(lang string) RegistryOption {
+	return func(r *PaymentPlatformRegistry) { r.language = Language(lang) }
 }
 
 // NewPaymentPlatformRegistry creates a new payment platform registry
-func NewPaymentPlatformRegistry() *PaymentPlatformRegistry {
-	return &PaymentPlatformRegistry{
+func NewPaymentPlatformRegistry(opts ...RegistryOption) *PaymentPlatformRegistry {
+	registry := &PaymentPlatformRegistry{
 		platforms: make(map[string]PaymentPlatform),
+		language:  LanguageEnglish,
 	}
+	for _, opt := range opts {
+		opt(registry)
+	}
+	return registry
+}
+
+// LocalizeError wraps err in a LocalizedError translated into the registry's configured
+// language, if err is one of this package's sentinel errors
+func (r *PaymentPlatformRegistry) LocalizeError(err error) error {
+	return LocalizeSentinel(err, r.language)
 }
 
 // RegisterPlatform adds a payment platform to the registry
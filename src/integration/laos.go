@@ -5,39 +5,48 @@ package integration
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
 )
 
 // LaosUMoneyConfig holds configuration for U-Money integration
 type LaosUMoneyConfig struct {
-	MerchantID     string
-	MerchantKey    string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	MerchantID  string
+	MerchantKey string
+	APIEndpoint string
+	CallbackURL string
+	RedirectURL string
+	TestMode    bool
+	// SignerFunc signs outbound requests, defaulting to HMACSHA256Signer. Set to
+	// HMACSHA512Signer or RSASHA256Signer(privateKey) for a bank migrating off HMAC-SHA256.
+	SignerFunc SignerFunc
 }
 
 // LaosUMoney implements PaymentPlatform interface for Laos's U-Money
 type LaosUMoney struct {
-	config LaosUMoneyConfig
-	client *http.Client
+	config             LaosUMoneyConfig
+	client             *http.Client
+	retryClient        *RetryingHTTPClient
+	language           Language
+	multiPayments      *InMemorySessionStore
+	controlTower       *PaymentControlTower
+	payoutResolver     PayoutResolver
+	canonicalizer      *Canonicalizer
+	callbackDispatcher CallbackDispatcher
+	callbackReplay     *replayCache
 }
 
-// NewLaosUMoney creates a new U-Money payment platform
-func NewLaosUMoney(config LaosUMoneyConfig) *LaosUMoney {
+// NewLaosUMoney creates a new U-Money payment platform. opts configures its locale
+// (WithLocalization), HTTP client (WithHTTPClient), retry policy (WithRetry), transport
+// (WithTransport), observer (WithObserver), and callback dispatch (WithCallbackDispatcher).
+func NewLaosUMoney(config LaosUMoneyConfig, opts ...PlatformOption) *LaosUMoney {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -47,11 +56,38 @@ func NewLaosUMoney(config LaosUMoneyConfig) *LaosUMoney {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "U-Money")
+
+	payoutResolver := options.payoutResolver
+	if payoutResolver == nil {
+		payoutResolver = defaultPayoutResolver()
+	}
+
+	callbackDispatcher := options.callbackDispatcher
+	if callbackDispatcher == nil {
+		callbackDispatcher = NewListenerCallbackDispatcher()
+	}
+
 	return &LaosUMoney{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:             config,
+		client:             client,
+		retryClient:        NewRetryingHTTPClient(client, options.retryPolicy),
+		language:           options.language,
+		multiPayments:      NewInMemorySessionStore(),
+		controlTower:       NewPaymentControlTower(NewInMemoryControlTowerStore()),
+		payoutResolver:     payoutResolver,
+		canonicalizer:      NewCanonicalizer(0), // LAK has no minor currency unit
+		callbackDispatcher: callbackDispatcher,
+		callbackReplay:     newReplayCache(callbackTimestampWindow * 2),
 	}
 }
 
@@ -76,7 +112,14 @@ func (p *LaosUMoney) GetSupportedCurrencies() []string {
 }
 
 // CreatePayment creates a payment
-func (p *LaosUMoney) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+func (p *LaosUMoney) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (response *PaymentResponse, err error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "LAK" {
 		return nil, errors.New("currency must be LAK for U-Money payments")
@@ -86,20 +129,33 @@ func (p *LaosUMoney) CreatePayment(request *PaymentRequest) (*PaymentResponse, e
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	// Guard against a retried CreatePayment double-charging the customer for an OrderID
+	// already settled or still being submitted
+	if _, err := p.controlTower.Begin(p.GetName(), request.OrderID); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			p.controlTower.MarkFailed(p.GetName(), request.OrderID)
+			return
+		}
+		p.controlTower.MarkSettled(p.GetName(), request.OrderID, response.PaymentID)
+	}()
+
 	// Prepare U-Money request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	uMoneyRequest := map[string]interface{}{
-		"merchant_id":     p.config.MerchantID,
-		"order_id":        request.OrderID,
-		"amount":          int(request.Amount), // U-Money expects integer amount
-		"description":     request.Description,
-		"customer_name":   request.CustomerName,
-		"customer_email":  request.CustomerEmail,
-		"customer_phone":  request.CustomerPhone,
-		"return_url":      p.config.RedirectURL,
-		"callback_url":    p.config.CallbackURL,
-		"timestamp":       timestamp,
+		"merchant_id":    p.config.MerchantID,
+		"order_id":       request.OrderID,
+		"amount":         int(request.Amount), // U-Money expects integer amount
+		"description":    localizedDescription(request.Description, request.OrderID, p.language),
+		"customer_name":  request.CustomerName,
+		"customer_email": request.CustomerEmail,
+		"customer_phone": request.CustomerPhone,
+		"return_url":     p.config.RedirectURL,
+		"callback_url":   p.config.CallbackURL,
+		"timestamp":      timestamp,
 	}
 
 	// Set payment method
@@ -119,18 +175,17 @@ func (p *LaosUMoney) CreatePayment(request *PaymentRequest) (*PaymentResponse, e
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v1/payment/create", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payment/create", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -154,7 +209,7 @@ func (p *LaosUMoney) CreatePayment(request *PaymentRequest) (*PaymentResponse, e
 		if msg, ok := uMoneyResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("U-Money error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("U-Money error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -169,7 +224,7 @@ func (p *LaosUMoney) CreatePayment(request *PaymentRequest) (*PaymentResponse, e
 	expiryTime, _ := data["expiry_time"].(float64)
 
 	// Create response
-	response := &PaymentResponse{
+	response = &PaymentResponse{
 		PaymentID:     paymentID,
 		Status:        StatusPending,
 		Amount:        request.Amount,
@@ -188,10 +243,17 @@ func (p *LaosUMoney) CreatePayment(request *PaymentRequest) (*PaymentResponse, e
 }
 
 // GetPaymentStatus gets the status of a payment
-func (p *LaosUMoney) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+func (p *LaosUMoney) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare status request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	statusRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"payment_id":  request.PaymentID,
@@ -208,18 +270,17 @@ func (p *LaosUMoney) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentRe
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v1/payment/status", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payment/status", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -243,7 +304,7 @@ func (p *LaosUMoney) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentRe
 		if msg, ok := statusResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("U-Money error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("U-Money error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -257,7 +318,7 @@ func (p *LaosUMoney) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentRe
 	paymentType, _ := data["payment_type"].(string)
 	transactionID, _ := data["transaction_id"].(string)
 	createdAtStr, _ := data["created_at"].(string)
-	
+
 	// Parse created at
 	createdAt, _ := time.Parse("2006-01-02T15:04:05Z", createdAtStr)
 
@@ -303,10 +364,17 @@ func (p *LaosUMoney) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentRe
 }
 
 // RefundPayment refunds a payment
-func (p *LaosUMoney) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+func (p *LaosUMoney) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare refund request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	refundRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"payment_id":  request.PaymentID,
@@ -326,18 +394,17 @@ func (p *LaosUMoney) RefundPayment(request *RefundRequest) (*RefundResponse, err
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v1/payment/refund", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payment/refund", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -361,7 +428,7 @@ func (p *LaosUMoney) RefundPayment(request *RefundRequest) (*RefundResponse, err
 		if msg, ok := refundResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("U-Money refund error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("U-Money refund error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract refund details
@@ -388,33 +455,95 @@ func (p *LaosUMoney) RefundPayment(request *RefundRequest) (*RefundResponse, err
 	return response, nil
 }
 
-// generateSignature generates a signature for U-Money requests
+// InitMultiPayment opens a new MultiPaymentSession for request.TotalAmount LAK, to be settled
+// by one or more later calls to AddPartialPayment. U-Money has no multi-payment API of its
+// own upstream; this tracks the parent token and RemainingAmount locally, the same way
+// MultiPaymentCoordinator does for MoMo/VNPay, routing every partial payment back through
+// p.CreatePayment.
+func (p *LaosUMoney) InitMultiPayment(ctx context.Context, request *MultiPaymentRequest) (*MultiPaymentSession, error) {
+	if request.TotalAmount <= 0 {
+		return nil, errors.New("multi-payment total amount must be positive")
+	}
+	if request.Currency == "" {
+		request.Currency = "LAK"
+	}
+	if request.Currency != "LAK" {
+		return nil, errors.New("currency must be LAK for U-Money multi-payments")
+	}
+
+	token, err := generateSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := NewMultiPaymentSession(request.ExternalID, request.Currency, request.TotalAmount)
+	session.Token = token
+	session.ExternalID = request.ExternalID
+	session.AllowedMethods = request.AllowedMethods
+
+	if err := p.multiPayments.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return session, nil
+}
+
+// AddPartialPayment attaches a charge of amount against the session identified by
+// paymentToken, settling it via method. The session transitions to MultiPaymentCompleted once
+// its RemainingAmount reaches zero.
+func (p *LaosUMoney) AddPartialPayment(ctx context.Context, paymentToken string, amount float64, method PaymentMethod) (*PaymentResponse, error) {
+	session, found, err := p.multiPayments.Get(paymentToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return nil, ErrMultiPaymentSessionNotFound
+	}
+	if !session.methodAllowed(method) {
+		return nil, ErrMultiPaymentMethodNotAllowed
+	}
+
+	response, err := session.AddCharge(ctx, p, amount, method)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.multiPayments.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return response, nil
+}
+
+// CompleteMultiPayment finalizes the session identified by paymentToken: MultiPaymentCompleted
+// if its RemainingAmount has reached zero, MultiPaymentCancelled otherwise.
+func (p *LaosUMoney) CompleteMultiPayment(ctx context.Context, paymentToken string) (*MultiPaymentSession, error) {
+	session, found, err := p.multiPayments.Get(paymentToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return nil, ErrMultiPaymentSessionNotFound
+	}
+	if err := session.Finalize(); err != nil {
+		return nil, err
+	}
+	if err := p.multiPayments.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return session, nil
+}
+
+// generateSignature generates a signature for U-Money requests, using p.config.SignerFunc
+// (HMACSHA256Signer by default) over p.canonicalizer's canonical form of params
 func (p *LaosUMoney) generateSignature(params map[string]interface{}) string {
-	// Sort keys
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Build string to sign
-	var sb strings.Builder
-	for _, k := range keys {
-		sb.WriteString(k)
-		sb.WriteString("=")
-		sb.WriteString(fmt.Sprintf("%v", params[k]))
-		sb.WriteString("&")
-	}
-	// Remove trailing &
-	signStr := sb.String()
-	if len(signStr) > 0 {
-		signStr = signStr[:len(signStr)-1]
-	}
-
-	// Generate HMAC-SHA256
-	h := hmac.New(sha256.New, []byte(p.config.MerchantKey))
-	h.Write([]byte(signStr))
-	return hex.EncodeToString(h.Sum(nil))
+	signer := p.config.SignerFunc
+	if signer == nil {
+		signer = HMACSHA256Signer
+	}
+	canonical := p.canonicalizer.Canonicalize(params)
+	signature, err := signer([]byte(p.config.MerchantKey), []byte(canonical))
+	if err != nil {
+		return ""
+	}
+	return signature
 }
 
 // LaosLDBConfig holds configuration for LDB integration
@@ -425,16 +554,29 @@ type LaosLDBConfig struct {
 	CallbackURL    string
 	RedirectURL    string
 	TestMode       bool
+	// SignerFunc signs outbound requests, defaulting to HMACSHA256Signer. Set to
+	// HMACSHA512Signer or RSASHA256Signer(privateKey) for a bank migrating off HMAC-SHA256.
+	SignerFunc SignerFunc
 }
 
 // LaosLDB implements PaymentPlatform interface for Laos's LDB (Lao Development Bank)
 type LaosLDB struct {
-	config LaosLDBConfig
-	client *http.Client
+	config             LaosLDBConfig
+	client             *http.Client
+	retryClient        *RetryingHTTPClient
+	language           Language
+	multiPayments      *InMemorySessionStore
+	controlTower       *PaymentControlTower
+	payoutResolver     PayoutResolver
+	canonicalizer      *Canonicalizer
+	callbackDispatcher CallbackDispatcher
+	callbackReplay     *replayCache
 }
 
-// NewLaosLDB creates a new LDB payment platform
-func NewLaosLDB(config LaosLDBConfig) *LaosLDB {
+// NewLaosLDB creates a new LDB payment platform. opts configures its locale
+// (WithLocalization), HTTP client (WithHTTPClient), retry policy (WithRetry), transport
+// (WithTransport), observer (WithObserver), and callback dispatch (WithCallbackDispatcher).
+func NewLaosLDB(config LaosLDBConfig, opts ...PlatformOption) *LaosLDB {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -444,11 +586,38 @@ func NewLaosLDB(config LaosLDBConfig) *LaosLDB {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "LDB")
+
+	payoutResolver := options.payoutResolver
+	if payoutResolver == nil {
+		payoutResolver = defaultPayoutResolver()
+	}
+
+	callbackDispatcher := options.callbackDispatcher
+	if callbackDispatcher == nil {
+		callbackDispatcher = NewListenerCallbackDispatcher()
+	}
+
 	return &LaosLDB{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:             config,
+		client:             client,
+		retryClient:        NewRetryingHTTPClient(client, options.retryPolicy),
+		language:           options.language,
+		multiPayments:      NewInMemorySessionStore(),
+		controlTower:       NewPaymentControlTower(NewInMemoryControlTowerStore()),
+		payoutResolver:     payoutResolver,
+		canonicalizer:      NewCanonicalizer(0), // LAK has no minor currency unit
+		callbackDispatcher: callbackDispatcher,
+		callbackReplay:     newReplayCache(callbackTimestampWindow * 2),
 	}
 }
 
@@ -473,7 +642,14 @@ func (p *LaosLDB) GetSupportedCurrencies() []string {
 }
 
 // CreatePayment creates a payment
-func (p *LaosLDB) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+func (p *LaosLDB) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (response *PaymentResponse, err error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "LAK" {
 		return nil, errors.New("currency must be LAK for LDB payments")
@@ -483,20 +659,33 @@ func (p *LaosLDB) CreatePayment(request *PaymentRequest) (*PaymentResponse, erro
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	// Guard against a retried CreatePayment double-charging the customer for an OrderID
+	// already settled or still being submitted
+	if _, err := p.controlTower.Begin(p.GetName(), request.OrderID); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			p.controlTower.MarkFailed(p.GetName(), request.OrderID)
+			return
+		}
+		p.controlTower.MarkSettled(p.GetName(), request.OrderID, response.PaymentID)
+	}()
+
 	// Prepare LDB request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	ldbRequest := map[string]interface{}{
-		"merchant_id":     p.config.MerchantID,
-		"order_id":        request.OrderID,
-		"amount":          int(request.Amount), // LDB expects integer amount
-		"description":     request.Description,
-		"customer_name":   request.CustomerName,
-		"customer_email":  request.CustomerEmail,
-		"customer_phone":  request.CustomerPhone,
-		"return_url":      p.config.RedirectURL,
-		"callback_url":    p.config.CallbackURL,
-		"timestamp":       timestamp,
+		"merchant_id":    p.config.MerchantID,
+		"order_id":       request.OrderID,
+		"amount":         int(request.Amount), // LDB expects integer amount
+		"description":    localizedDescription(request.Description, request.OrderID, p.language),
+		"customer_name":  request.CustomerName,
+		"customer_email": request.CustomerEmail,
+		"customer_phone": request.CustomerPhone,
+		"return_url":     p.config.RedirectURL,
+		"callback_url":   p.config.CallbackURL,
+		"timestamp":      timestamp,
 	}
 
 	// Set payment method
@@ -516,18 +705,17 @@ func (p *LaosLDB) CreatePayment(request *PaymentRequest) (*PaymentResponse, erro
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v1/payment/create", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payment/create", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -551,7 +739,7 @@ func (p *LaosLDB) CreatePayment(request *PaymentRequest) (*PaymentResponse, erro
 		if msg, ok := ldbResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("LDB error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("LDB error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -566,7 +754,7 @@ func (p *LaosLDB) CreatePayment(request *PaymentRequest) (*PaymentResponse, erro
 	expiryTime, _ := data["expiry_time"].(float64)
 
 	// Create response
-	response := &PaymentResponse{
+	response = &PaymentResponse{
 		PaymentID:     paymentID,
 		Status:        StatusPending,
 		Amount:        request.Amount,
@@ -585,10 +773,17 @@ func (p *LaosLDB) CreatePayment(request *PaymentRequest) (*PaymentResponse, erro
 }
 
 // GetPaymentStatus gets the status of a payment
-func (p *LaosLDB) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+func (p *LaosLDB) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare status request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	statusRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"payment_id":  request.PaymentID,
@@ -605,18 +800,17 @@ func (p *LaosLDB) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentRespo
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v1/payment/status", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payment/status", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -640,7 +834,7 @@ func (p *LaosLDB) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentRespo
 		if msg, ok := statusResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("LDB error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("LDB error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -654,7 +848,7 @@ func (p *LaosLDB) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentRespo
 	paymentType, _ := data["payment_type"].(string)
 	transactionID, _ := data["transaction_id"].(string)
 	createdAtStr, _ := data["created_at"].(string)
-	
+
 	// Parse created at
 	createdAt, _ := time.Parse("2006-01-02T15:04:05Z", createdAtStr)
 
@@ -700,10 +894,17 @@ func (p *LaosLDB) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentRespo
 }
 
 // RefundPayment refunds a payment
-func (p *LaosLDB) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+func (p *LaosLDB) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare refund request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	refundRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"payment_id":  request.PaymentID,
@@ -723,18 +924,17 @@ func (p *LaosLDB) RefundPayment(request *RefundRequest) (*RefundResponse, error)
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v1/payment/refund", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payment/refund", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -758,7 +958,7 @@ func (p *LaosLDB) RefundPayment(request *RefundRequest) (*RefundResponse, error)
 		if msg, ok := refundResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("LDB refund error: %s", errorMsg)
+		return nil, translateError("unknown_error", fmt.Sprintf("LDB refund error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract refund details
@@ -785,31 +985,93 @@ func (p *LaosLDB) RefundPayment(request *RefundRequest) (*RefundResponse, error)
 	return response, nil
 }
 
-// generateSignature generates a signature for LDB requests
+// InitMultiPayment opens a new MultiPaymentSession for request.TotalAmount LAK, to be settled
+// by one or more later calls to AddPartialPayment. LDB has no multi-payment API of its own
+// upstream; this tracks the parent token and RemainingAmount locally, the same way
+// MultiPaymentCoordinator does for MoMo/VNPay, routing every partial payment back through
+// p.CreatePayment.
+func (p *LaosLDB) InitMultiPayment(ctx context.Context, request *MultiPaymentRequest) (*MultiPaymentSession, error) {
+	if request.TotalAmount <= 0 {
+		return nil, errors.New("multi-payment total amount must be positive")
+	}
+	if request.Currency == "" {
+		request.Currency = "LAK"
+	}
+	if request.Currency != "LAK" {
+		return nil, errors.New("currency must be LAK for LDB multi-payments")
+	}
+
+	token, err := generateSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := NewMultiPaymentSession(request.ExternalID, request.Currency, request.TotalAmount)
+	session.Token = token
+	session.ExternalID = request.ExternalID
+	session.AllowedMethods = request.AllowedMethods
+
+	if err := p.multiPayments.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return session, nil
+}
+
+// AddPartialPayment attaches a charge of amount against the session identified by
+// paymentToken, settling it via method. The session transitions to MultiPaymentCompleted once
+// its RemainingAmount reaches zero.
+func (p *LaosLDB) AddPartialPayment(ctx context.Context, paymentToken string, amount float64, method PaymentMethod) (*PaymentResponse, error) {
+	session, found, err := p.multiPayments.Get(paymentToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return nil, ErrMultiPaymentSessionNotFound
+	}
+	if !session.methodAllowed(method) {
+		return nil, ErrMultiPaymentMethodNotAllowed
+	}
+
+	response, err := session.AddCharge(ctx, p, amount, method)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.multiPayments.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return response, nil
+}
+
+// CompleteMultiPayment finalizes the session identified by paymentToken: MultiPaymentCompleted
+// if its RemainingAmount has reached zero, MultiPaymentCancelled otherwise.
+func (p *LaosLDB) CompleteMultiPayment(ctx context.Context, paymentToken string) (*MultiPaymentSession, error) {
+	session, found, err := p.multiPayments.Get(paymentToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return nil, ErrMultiPaymentSessionNotFound
+	}
+	if err := session.Finalize(); err != nil {
+		return nil, err
+	}
+	if err := p.multiPayments.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return session, nil
+}
+
+// generateSignature generates a signature for LDB requests, using p.config.SignerFunc
+// (HMACSHA256Signer by default) over p.canonicalizer's canonical form of params
 func (p *LaosLDB) generateSignature(params map[string]interface{}) string {
-	// Sort keys
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Build string to sign
-	var sb strings.Builder
-	for _, k := range keys {
-		sb.WriteString(k)
-		sb.WriteString("=")
-		sb.WriteString(fmt.Sprintf("%v", params[k]))
-		sb.WriteString("&")
-	}
-	// Remove trailing &
-	signStr := sb.String()
-	if len(signStr) > 0 {
-		signStr = signStr[:len(signStr)-1]
-	}
-
-	// Generate HMAC-SHA256
-	h := hmac.New(sha256.New, []byte(p.config.MerchantSecret))
-	h.Write([]byte(signStr))
-	return hex.EncodeToString(h.Sum(nil))
+	signer := p.config.SignerFunc
+	if signer == nil {
+		signer = HMACSHA256Signer
+	}
+	canonical := p.canonicalizer.Canonicalize(params)
+	signature, err := signer([]byte(p.config.MerchantSecret), []byte(canonical))
+	if err != nil {
+		return ""
+	}
+	return signature
 }
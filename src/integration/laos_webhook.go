@@ -0,0 +1,280 @@
+// Server-to-server callback handling for Laos's U-Money and LDB, modeled on the
+// Redsys/camper pattern of a single signed notification endpoint rather than MoMo/VNPay's
+// redelivery-until-acknowledged IPN. HandleCallback recomputes the signature with the same
+// generateSignature logic CreatePayment signs outbound requests with, verifies it in
+// constant time, and checks a timestamp window plus seen-nonce cache before dispatching a
+// normalized WebhookEvent through a pluggable CallbackDispatcher.
+
+package integration
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrInvalidCallbackSignature is returned when an inbound callback's signature doesn't match
+// what its payload recomputes to
+var ErrInvalidCallbackSignature = errors.New("invalid callback signature")
+
+// ErrReplayedCallback is returned when an inbound callback's nonce has already been processed
+var ErrReplayedCallback = errors.New("callback already processed")
+
+// ErrCallbackTimestampDrift is returned when an inbound callback's timestamp has drifted from
+// now by more than its handler's allowed window
+var ErrCallbackTimestampDrift = errors.New("callback timestamp exceeds allowed window")
+
+// callbackTimestampWindow is how far from now a callback's timestamp may drift before it's
+// rejected as stale or forged
+const callbackTimestampWindow = 5 * time.Minute
+
+// CallbackDispatcher emits a WebhookEvent once HandleCallback has verified and parsed an
+// inbound notification, decoupling delivery from verification so a caller can pick in-process
+// fan-out (ChannelCallbackDispatcher) or this package's existing WebhookListener mechanism
+// (ListenerCallbackDispatcher, the default).
+type CallbackDispatcher interface {
+	Dispatch(event WebhookEvent)
+}
+
+// WithCallbackDispatcher overrides a platform's default CallbackDispatcher
+// (ListenerCallbackDispatcher) for HandleCallback, e.g. with a ChannelCallbackDispatcher
+func WithCallbackDispatcher(dispatcher CallbackDispatcher) PlatformOption {
+	return func(o *platformOptions) { o.callbackDispatcher = dispatcher }
+}
+
+// ListenerCallbackDispatcher dispatches callback events to registered WebhookListeners, the
+// same mechanism MoMo/VNPay/KLBPay webhook handlers use
+type ListenerCallbackDispatcher struct {
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewListenerCallbackDispatcher builds an empty ListenerCallbackDispatcher
+func NewListenerCallbackDispatcher() *ListenerCallbackDispatcher {
+	return &ListenerCallbackDispatcher{}
+}
+
+// AddListener registers l to receive every event this dispatcher dispatches
+func (d *ListenerCallbackDispatcher) AddListener(l WebhookListener) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.listeners = append(d.listeners, l)
+}
+
+func (d *ListenerCallbackDispatcher) Dispatch(event WebhookEvent) {
+	d.mutex.Lock()
+	listeners := append([]WebhookListener(nil), d.listeners...)
+	d.mutex.Unlock()
+	dispatch(listeners, event)
+}
+
+// ChannelCallbackDispatcher emits events onto a buffered channel, for a caller that wants to
+// consume callbacks from its own goroutine instead of registering a WebhookListener. Dispatch
+// drops an event rather than blocking when Events is full, since a slow consumer must not
+// stall the HTTP handler processing the inbound notification.
+type ChannelCallbackDispatcher struct {
+	Events chan WebhookEvent
+}
+
+// NewChannelCallbackDispatcher builds a ChannelCallbackDispatcher buffering up to bufferSize
+// undelivered events
+func NewChannelCallbackDispatcher(bufferSize int) *ChannelCallbackDispatcher {
+	return &ChannelCallbackDispatcher{Events: make(chan WebhookEvent, bufferSize)}
+}
+
+func (d *ChannelCallbackDispatcher) Dispatch(event WebhookEvent) {
+	select {
+	case d.Events <- event:
+	default:
+	}
+}
+
+// verifyLaosCallback checks payload's signature (via sign, the platform's generateSignature
+// method), timestamp window, and nonce replay cache, returning the PaymentResponse it
+// describes once all three pass
+func verifyLaosCallback(payload map[string]interface{}, sign func(map[string]interface{}) string, replay *replayCache) (*PaymentResponse, error) {
+	signature, _ := payload["signature"].(string)
+	unsigned := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		if k == "signature" {
+			continue
+		}
+		unsigned[k] = v
+	}
+	if !hmac.Equal([]byte(signature), []byte(sign(unsigned))) {
+		return nil, ErrInvalidCallbackSignature
+	}
+
+	timestampStr, _ := payload["timestamp"].(string)
+	timestamp, err := time.Parse("20060102150405", timestampStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid callback timestamp: %w", err)
+	}
+	if drift := time.Since(timestamp); drift < -callbackTimestampWindow || drift > callbackTimestampWindow {
+		return nil, ErrCallbackTimestampDrift
+	}
+
+	nonce, _ := payload["nonce"].(string)
+	if nonce == "" {
+		nonce = timestampStr
+	}
+	if !replay.checkAndRemember(nonce) {
+		return nil, ErrReplayedCallback
+	}
+
+	orderID, _ := payload["order_id"].(string)
+	paymentID, _ := payload["payment_id"].(string)
+	amount, _ := payload["amount"].(float64)
+	status := mapLaosCallbackStatus(fmt.Sprintf("%v", payload["status"]))
+
+	response := &PaymentResponse{
+		PaymentID: paymentID,
+		Status:    status,
+		Amount:    amount,
+		Currency:  "LAK",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Metadata:  map[string]string{"order_id": orderID},
+	}
+	if status == StatusCompleted {
+		response.CompletedAt = time.Now()
+	}
+	return response, nil
+}
+
+// mapLaosCallbackStatus maps a callback's status field to a PaymentStatus, mirroring
+// LaosUMoney/LaosLDB.GetPaymentStatus's upstream status mapping
+func mapLaosCallbackStatus(status string) PaymentStatus {
+	switch status {
+	case "completed", "success":
+		return StatusCompleted
+	case "failed", "cancelled":
+		return StatusFailed
+	default:
+		return StatusPending
+	}
+}
+
+// writeLaosCallbackAck writes the JSON acknowledgement body a Laos platform expects a
+// callback endpoint to respond with
+func writeLaosCallbackAck(w http.ResponseWriter, ok bool, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	status := "success"
+	if !ok {
+		status = "error"
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "message": message})
+}
+
+// HandleCallback verifies and parses r's body as a U-Money payment notification, writing an
+// acknowledgement to w, and dispatches a normalized WebhookEvent to p's CallbackDispatcher on
+// success. It returns the PaymentResponse the callback describes so a caller can persist it
+// directly instead of re-deriving one from GetPaymentStatus.
+func (p *LaosUMoney) HandleCallback(w http.ResponseWriter, r *http.Request) (*PaymentResponse, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeLaosCallbackAck(w, false, err.Error())
+		return nil, fmt.Errorf("failed to read callback body: %w", err)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeLaosCallbackAck(w, false, err.Error())
+		return nil, fmt.Errorf("failed to parse callback body: %w", err)
+	}
+
+	response, err := verifyLaosCallback(payload, p.generateSignature, p.callbackReplay)
+	if err != nil {
+		writeLaosCallbackAck(w, false, err.Error())
+		return nil, err
+	}
+
+	p.callbackDispatcher.Dispatch(WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: response.PaymentID,
+		Status:    response.Status,
+		Raw:       json.RawMessage(body),
+	})
+
+	writeLaosCallbackAck(w, true, "Confirm Success")
+	return response, nil
+}
+
+// WebhookHandler adapts HandleCallback to an http.Handler a caller can mount directly on its
+// own router
+func (p *LaosUMoney) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.HandleCallback(w, r)
+	})
+}
+
+// AddCallbackListener registers l to receive every WebhookEvent HandleCallback dispatches. It
+// returns an error if p's CallbackDispatcher was overridden via WithCallbackDispatcher to
+// something other than a ListenerCallbackDispatcher.
+func (p *LaosUMoney) AddCallbackListener(l WebhookListener) error {
+	listenerDispatcher, ok := p.callbackDispatcher.(*ListenerCallbackDispatcher)
+	if !ok {
+		return errors.New("platform's CallbackDispatcher does not support AddListener; consume it directly instead")
+	}
+	listenerDispatcher.AddListener(l)
+	return nil
+}
+
+// HandleCallback verifies and parses r's body as an LDB payment notification, writing an
+// acknowledgement to w, and dispatches a normalized WebhookEvent to p's CallbackDispatcher on
+// success. It returns the PaymentResponse the callback describes so a caller can persist it
+// directly instead of re-deriving one from GetPaymentStatus.
+func (p *LaosLDB) HandleCallback(w http.ResponseWriter, r *http.Request) (*PaymentResponse, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeLaosCallbackAck(w, false, err.Error())
+		return nil, fmt.Errorf("failed to read callback body: %w", err)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		writeLaosCallbackAck(w, false, err.Error())
+		return nil, fmt.Errorf("failed to parse callback body: %w", err)
+	}
+
+	response, err := verifyLaosCallback(payload, p.generateSignature, p.callbackReplay)
+	if err != nil {
+		writeLaosCallbackAck(w, false, err.Error())
+		return nil, err
+	}
+
+	p.callbackDispatcher.Dispatch(WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: response.PaymentID,
+		Status:    response.Status,
+		Raw:       json.RawMessage(body),
+	})
+
+	writeLaosCallbackAck(w, true, "Confirm Success")
+	return response, nil
+}
+
+// WebhookHandler adapts HandleCallback to an http.Handler a caller can mount directly on its
+// own router
+func (p *LaosLDB) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.HandleCallback(w, r)
+	})
+}
+
+// AddCallbackListener registers l to receive every WebhookEvent HandleCallback dispatches. It
+// returns an error if p's CallbackDispatcher was overridden via WithCallbackDispatcher to
+// something other than a ListenerCallbackDispatcher.
+func (p *LaosLDB) AddCallbackListener(l WebhookListener) error {
+	listenerDispatcher, ok := p.callbackDispatcher.(*ListenerCallbackDispatcher)
+	if !ok {
+		return errors.New("platform's CallbackDispatcher does not support AddListener; consume it directly instead")
+	}
+	listenerDispatcher.AddListener(l)
+	return nil
+}
@@ -0,0 +1,363 @@
+// Localization support shared by payment platform integrations
+// A merchant portal embedding these platforms shouldn't have to build its own translation
+// layer over raw gateway error strings; PlatformOption/WithLocalization let a caller pick
+// the language errors, Accept-Language headers, and default descriptions are surfaced in.
+
+package integration
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Language is a BCP-47-style language tag controlling which translation a payment platform
+// surfaces errors and default descriptions in
+type Language string
+
+const (
+	// LanguageEnglish is the default language and the fallback for untranslated strings
+	LanguageEnglish Language = "en"
+	// LanguageMalay is Bahasa Malaysia
+	LanguageMalay Language = "ms"
+	// LanguageChinese is Simplified Chinese
+	LanguageChinese Language = "zh-CN"
+	// LanguageChineseTraditional is Traditional Chinese, used by Taiwan-facing platforms
+	LanguageChineseTraditional Language = "zh-TW"
+	// LanguageJapanese is Japanese
+	LanguageJapanese Language = "ja"
+	// LanguageKorean is Korean
+	LanguageKorean Language = "ko"
+	// LanguageVietnamese is Vietnamese
+	LanguageVietnamese Language = "vi"
+	// LanguageIndonesian is Bahasa Indonesia
+	LanguageIndonesian Language = "id"
+	// LanguageLao is Lao, used by Laotian platforms such as U-Money and LDB
+	LanguageLao Language = "lo"
+	// LanguageThai is Thai
+	LanguageThai Language = "th"
+	// LanguageKhmer is Khmer, used by Cambodian platforms such as Wing and ABA
+	LanguageKhmer Language = "km"
+)
+
+// PaymentError is a localized error surfaced to a caller, wrapping the gateway's original
+// error code and cause
+type PaymentError struct {
+	Code string
+	// Message is Code's translation into Language (or the gateway's raw message, for a code
+	// with no catalog entry)
+	Message string
+	// OriginalMessage is the gateway's untranslated message, kept alongside Message so a
+	// caller can log/audit the upstream wording even after it's been localized for display
+	OriginalMessage string
+	Language        Language
+	// Retryable reports whether Code is known to be transient (e.g. a gateway timeout) so a
+	// caller can decide programmatically whether to retry rather than parsing Message
+	Retryable bool
+	Err       error
+}
+
+func (e *PaymentError) Error() string {
+	return e.Message
+}
+
+func (e *PaymentError) Unwrap() error {
+	return e.Err
+}
+
+// platformOptions holds configuration applied by a payment platform constructor's
+// PlatformOption arguments
+type platformOptions struct {
+	language           Language
+	transport          http.RoundTripper
+	observer           Observer
+	httpClient         *http.Client
+	retryPolicy        RetryPolicy
+	payoutResolver     PayoutResolver
+	callbackDispatcher CallbackDispatcher
+}
+
+func defaultPlatformOptions() platformOptions {
+	return platformOptions{language: LanguageEnglish, observer: NoopObserver{}, retryPolicy: DefaultRetryPolicy()}
+}
+
+// PlatformOption configures a payment platform constructor, e.g. NewMalaysiaFPX
+type PlatformOption func(*platformOptions)
+
+// WithLocalization sets the language a platform surfaces errors, default descriptions, and
+// Accept-Language headers in. lang is at minimum "en" (English), "zh-CN" (Simplified
+// Chinese), "zh-TW" (Traditional Chinese), "ja" (Japanese), "ko" (Korean), "th" (Thai),
+// "vi" (Vietnamese), "id" (Indonesian), "ms" (Malay), "lo" (Lao), or "km" (Khmer); an
+// unrecognized value falls back to English. Default descriptions and Accept-Language
+// headers cover all of these; gateway error messages are translated only for en, ms,
+// zh-CN, lo, th, km, and id, and sentinel errors only for en, zh-CN, th, km, and id, so
+// the other languages get English error text.
+func WithLocalization(lang string) PlatformOption {
+	return func(o *platformOptions) { o.language = Language(lang) }
+}
+
+// errorTranslations maps an upstream gateway error code to its message in each supported
+// language
+var errorTranslations = map[string]map[Language]string{
+	"unknown_error": {
+		LanguageEnglish:    "An unknown error occurred while processing the payment.",
+		LanguageMalay:      "Ralat tidak diketahui berlaku semasa memproses pembayaran.",
+		LanguageChinese:    "处理付款时发生未知错误。",
+		LanguageLao:        "ເກີດຂໍ້ຜິດພາດທີ່ບໍ່ຮູ້ຈັກໃນລະຫວ່າງການດຳເນີນການຊຳລະເງິນ.",
+		LanguageThai:       "เกิดข้อผิดพลาดที่ไม่ทราบสาเหตุขณะดำเนินการชำระเงิน",
+		LanguageKhmer:      "មានបញ្ហាមិនស្គាល់កើតឡើងខណៈពេលដំណើរការការទូទាត់។",
+		LanguageIndonesian: "Terjadi kesalahan yang tidak diketahui saat memproses pembayaran.",
+	},
+	"insufficient_funds": {
+		LanguageEnglish:    "Insufficient funds to complete the payment.",
+		LanguageMalay:      "Dana tidak mencukupi untuk melengkapkan pembayaran.",
+		LanguageChinese:    "余额不足,无法完成付款。",
+		LanguageLao:        "ເງິນບໍ່ພຽງພໍສຳລັບການຊຳລະເງິນ.",
+		LanguageThai:       "ยอดเงินไม่เพียงพอสำหรับการชำระเงิน",
+		LanguageKhmer:      "មានលុយមិនគ្រប់គ្រាន់ដើម្បីបញ្ចប់ការទូទាត់។",
+		LanguageIndonesian: "Saldo tidak mencukupi untuk menyelesaikan pembayaran.",
+	},
+	"timeout": {
+		LanguageEnglish:    "The payment gateway timed out. Please try again.",
+		LanguageMalay:      "Get laluan pembayaran tamat masa. Sila cuba lagi.",
+		LanguageChinese:    "支付网关超时,请重试。",
+		LanguageLao:        "ທາງເຂົ້າການຊຳລະເງິນໝົດເວລາ. ກະລຸນາລອງໃໝ່ອີກຄັ້ງ.",
+		LanguageThai:       "เกตเวย์การชำระเงินหมดเวลา กรุณาลองใหม่อีกครั้ง",
+		LanguageKhmer:      "ផ្លូវចូលការទូទាត់អស់ម៉ោង។ សូមព្យាយាមម្តងទៀត។",
+		LanguageIndonesian: "Gateway pembayaran kehabisan waktu. Silakan coba lagi.",
+	},
+	"invalid_account": {
+		LanguageEnglish:    "The selected bank account is invalid.",
+		LanguageMalay:      "Akaun bank yang dipilih tidak sah.",
+		LanguageChinese:    "所选银行账户无效。",
+		LanguageLao:        "ບັນຊີທະນາຄານທີ່ເລືອກບໍ່ຖືກຕ້ອງ.",
+		LanguageThai:       "บัญชีธนาคารที่เลือกไม่ถูกต้อง",
+		LanguageKhmer:      "គណនីធនាគារដែលបានជ្រើសរើសមិនត្រឹមត្រូវទេ។",
+		LanguageIndonesian: "Rekening bank yang dipilih tidak valid.",
+	},
+	// SCB's PromptPay API reports errors by a numeric status.code rather than a string; these
+	// three are the ones ThailandPromptPay translates, keyed by the stringified code
+	"1001": {
+		LanguageEnglish: "The PromptPay request was rejected: invalid or missing parameters.",
+		LanguageThai:    "คำขอ PromptPay ถูกปฏิเสธ: พารามิเตอร์ไม่ถูกต้องหรือขาดหายไป",
+	},
+	"4001": {
+		LanguageEnglish: "The PromptPay request was rejected: duplicate reference number.",
+		LanguageThai:    "คำขอ PromptPay ถูกปฏิเสธ: หมายเลขอ้างอิงซ้ำ",
+	},
+	"5000": {
+		LanguageEnglish: "The PromptPay gateway encountered an internal error. Please try again.",
+		LanguageThai:    "เกตเวย์ PromptPay พบข้อผิดพลาดภายใน กรุณาลองใหม่อีกครั้ง",
+	},
+}
+
+// retryableErrorCodes marks which errorTranslations keys represent a transient upstream
+// failure a caller can reasonably retry, as opposed to a request that will fail again
+// unchanged (e.g. insufficient funds, a duplicate reference)
+var retryableErrorCodes = map[string]bool{
+	"timeout": true,
+	"5000":    true,
+}
+
+// translateError builds a PaymentError for errorCode in lang. When errorCode isn't in
+// errorTranslations, fallbackMessage (the gateway's raw message) is used as-is rather than
+// translated, since there's no table entry to translate it from.
+func translateError(errorCode, fallbackMessage string, lang Language, cause error) *PaymentError {
+	retryable := retryableErrorCodes[errorCode]
+
+	messages, ok := errorTranslations[errorCode]
+	if !ok {
+		return &PaymentError{Code: errorCode, Message: fallbackMessage, OriginalMessage: fallbackMessage, Language: LanguageEnglish, Retryable: retryable, Err: cause}
+	}
+	if message, ok := messages[lang]; ok {
+		return &PaymentError{Code: errorCode, Message: message, OriginalMessage: fallbackMessage, Language: lang, Retryable: retryable, Err: cause}
+	}
+	return &PaymentError{Code: errorCode, Message: messages[LanguageEnglish], OriginalMessage: fallbackMessage, Language: LanguageEnglish, Retryable: retryable, Err: cause}
+}
+
+// defaultDescriptions provides a localized fallback payment description, keyed by language,
+// for when a caller's PaymentRequest doesn't set one
+var defaultDescriptions = map[Language]string{
+	LanguageEnglish:            "Payment for order %s",
+	LanguageMalay:              "Pembayaran untuk pesanan %s",
+	LanguageChinese:            "订单 %s 的付款",
+	LanguageChineseTraditional: "訂單 %s 的付款",
+	LanguageJapanese:           "注文 %s のお支払い",
+	LanguageKorean:             "주문 %s 에 대한 결제",
+	LanguageVietnamese:         "Thanh toán cho đơn hàng %s",
+	LanguageIndonesian:         "Pembayaran untuk pesanan %s",
+	LanguageLao:                "ການຊຳລະເງິນສຳລັບອໍເດີ %s",
+	LanguageThai:               "การชำระเงินสำหรับคำสั่งซื้อ %s",
+	LanguageKhmer:              "ការទូទាត់សម្រាប់ការបញ្ជាទិញ %s",
+}
+
+// localizedDescription returns description if non-empty, otherwise a language-appropriate
+// default referencing orderID
+func localizedDescription(description, orderID string, lang Language) string {
+	if description != "" {
+		return description
+	}
+	template, ok := defaultDescriptions[lang]
+	if !ok {
+		template = defaultDescriptions[LanguageEnglish]
+	}
+	return fmt.Sprintf(template, orderID)
+}
+
+// sentinelCode is the error-catalog code for one of this package's top-level sentinel
+// errors (as opposed to a gateway-specific code translateError handles)
+type sentinelCode struct {
+	code     string
+	messages map[Language]string
+}
+
+// sentinelTranslations maps a package sentinel error to its catalog entry, so a caller
+// working with the registry-level API (rather than a single platform's gateway errors) can
+// still get a localized message via LocalizeSentinel
+var sentinelTranslations = map[error]sentinelCode{
+	ErrUnsupportedPaymentMethod: {code: "unsupported_payment_method", messages: map[Language]string{
+		LanguageEnglish:    "This payment method is not supported.",
+		LanguageChinese:    "不支持此付款方式。",
+		LanguageThai:       "ไม่รองรับวิธีการชำระเงินนี้",
+		LanguageKhmer:      "វិធីទូទាត់នេះមិនត្រូវបានគាំទ្រទេ។",
+		LanguageIndonesian: "Metode pembayaran ini tidak didukung.",
+	}},
+	ErrPaymentFailed: {code: "payment_failed", messages: map[Language]string{
+		LanguageEnglish:    "The payment could not be completed.",
+		LanguageChinese:    "付款无法完成。",
+		LanguageThai:       "ไม่สามารถดำเนินการชำระเงินให้เสร็จสมบูรณ์ได้",
+		LanguageKhmer:      "ការទូទាត់មិនអាចបញ្ចប់បានទេ។",
+		LanguageIndonesian: "Pembayaran tidak dapat diselesaikan.",
+	}},
+	ErrInvalidConfiguration: {code: "invalid_configuration", messages: map[Language]string{
+		LanguageEnglish:    "The payment platform is misconfigured.",
+		LanguageChinese:    "支付平台配置错误。",
+		LanguageThai:       "การตั้งค่าแพลตฟอร์มการชำระเงินไม่ถูกต้อง",
+		LanguageKhmer:      "ការកំណត់រចនាសម្ព័ន្ធវេទិកាទូទាត់មិនត្រឹមត្រូវទេ។",
+		LanguageIndonesian: "Platform pembayaran salah dikonfigurasi.",
+	}},
+	ErrConnectionFailed: {code: "connection_failed", messages: map[Language]string{
+		LanguageEnglish:    "Could not connect to the payment platform.",
+		LanguageChinese:    "无法连接到支付平台。",
+		LanguageThai:       "ไม่สามารถเชื่อมต่อกับแพลตฟอร์มการชำระเงินได้",
+		LanguageKhmer:      "មិនអាចភ្ជាប់ទៅវេទិកាទូទាត់បានទេ។",
+		LanguageIndonesian: "Tidak dapat terhubung ke platform pembayaran.",
+	}},
+	ErrInvalidResponse: {code: "invalid_response", messages: map[Language]string{
+		LanguageEnglish:    "The payment platform returned an unexpected response.",
+		LanguageChinese:    "支付平台返回了意外的响应。",
+		LanguageThai:       "แพลตฟอร์มการชำระเงินส่งคืนการตอบกลับที่ไม่คาดคิด",
+		LanguageKhmer:      "វេទិកាទូទាត់បានឆ្លើយតបមិនបានរំពឹងទុក។",
+		LanguageIndonesian: "Platform pembayaran mengembalikan respons yang tidak terduga.",
+	}},
+}
+
+// PlatformError is a structured error built directly from a gateway's HTTP response, carrying
+// enough detail for a caller to make retry/refund decisions with errors.As instead of parsing
+// Message. PaymentError serves a similar purpose for the errorTranslations catalog in general;
+// PlatformError additionally keeps the response's HTTPStatus and RawResponse, for a platform
+// (like IndonesiaGoPay/IndonesiaOVO) whose gateway reports errors as HTTP responses rather than
+// an in-band error code translateError already knows how to categorize as retryable.
+type PlatformError struct {
+	// Code is the errorTranslations catalog key this error was built from (e.g. "unknown_error")
+	Code string
+	// Message is the gateway's raw, untranslated error message
+	Message string
+	// LocalizedMessage is Code's translation into the platform's configured Language, falling
+	// back to Message when Code has no catalog entry for that language
+	LocalizedMessage string
+	// HTTPStatus is the HTTP status code the gateway responded with
+	HTTPStatus int
+	// Retryable reports whether Code is known to be transient, mirroring PaymentError.Retryable
+	Retryable bool
+	// RawResponse is the gateway's full response body, kept for logging/audit even after
+	// LocalizedMessage has been extracted from it
+	RawResponse []byte
+}
+
+func (e *PlatformError) Error() string {
+	return e.Message
+}
+
+// newPlatformError builds a PlatformError for code/message/httpStatus/rawResponse, translating
+// code into lang via the same errorTranslations catalog translateError uses
+func newPlatformError(code, message string, httpStatus int, rawResponse []byte, lang Language) *PlatformError {
+	localized := message
+	if messages, ok := errorTranslations[code]; ok {
+		if m, ok := messages[lang]; ok {
+			localized = m
+		} else if m, ok := messages[LanguageEnglish]; ok {
+			localized = m
+		}
+	}
+	return &PlatformError{
+		Code:             code,
+		Message:          message,
+		LocalizedMessage: localized,
+		HTTPStatus:       httpStatus,
+		Retryable:        retryableErrorCodes[code],
+		RawResponse:      rawResponse,
+	}
+}
+
+// LocalizedError wraps one of this package's sentinel errors (ErrPaymentFailed and so on)
+// with a message translated into Language. PaymentError serves the same purpose for a
+// platform's gateway-specific error codes; LocalizedError covers the package-level sentinels
+// a caller might see before ever reaching a specific platform's translateError call.
+type LocalizedError struct {
+	Code     string
+	Message  string
+	Language Language
+	Err      error
+}
+
+func (e *LocalizedError) Error() string {
+	return e.Message
+}
+
+func (e *LocalizedError) Unwrap() error {
+	return e.Err
+}
+
+// LocalizeSentinel wraps err in a LocalizedError translated into lang, if err is one of
+// this package's sentinel errors. Any other error, including one already wrapping a
+// sentinel, is returned unchanged.
+func LocalizeSentinel(err error, lang Language) error {
+	entry, ok := sentinelTranslations[err]
+	if !ok {
+		return err
+	}
+	message, ok := entry.messages[lang]
+	if !ok {
+		message = entry.messages[LanguageEnglish]
+	}
+	return &LocalizedError{Code: entry.code, Message: message, Language: lang, Err: err}
+}
+
+// acceptLanguageHeader maps lang to the IETF language tag sent as the Accept-Language
+// header on outbound requests
+func acceptLanguageHeader(lang Language) string {
+	switch lang {
+	case LanguageMalay:
+		return "ms-MY"
+	case LanguageChinese:
+		return "zh-CN"
+	case LanguageChineseTraditional:
+		return "zh-TW"
+	case LanguageJapanese:
+		return "ja-JP"
+	case LanguageKorean:
+		return "ko-KR"
+	case LanguageVietnamese:
+		return "vi-VN"
+	case LanguageIndonesian:
+		return "id-ID"
+	case LanguageLao:
+		return "lo-LA"
+	case LanguageThai:
+		return "th-TH"
+	case LanguageKhmer:
+		return "km-KH"
+	default:
+		return "en-US"
+	}
+}
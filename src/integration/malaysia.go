@@ -5,14 +5,17 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sort"
 	"strings"
 	"time"
@@ -20,22 +23,26 @@ import (
 
 // MalaysiaFPXConfig holds configuration for FPX integration
 type MalaysiaFPXConfig struct {
-	MerchantID     string
-	MerchantKey    string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	MerchantID       string
+	MerchantKey      string
+	APIEndpoint      string
+	CallbackURL      string
+	RedirectURL      string
+	TestMode         bool
+	IdempotencyStore IdempotencyStore // optional; when set, CreatePayment/RefundPayment short-circuit retried IdempotencyKeys
 }
 
 // MalaysiaFPX implements PaymentPlatform interface for Malaysia's FPX
 type MalaysiaFPX struct {
-	config MalaysiaFPXConfig
-	client *http.Client
+	config      MalaysiaFPXConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	language    Language
 }
 
-// NewMalaysiaFPX creates a new FPX payment platform
-func NewMalaysiaFPX(config MalaysiaFPXConfig) *MalaysiaFPX {
+// NewMalaysiaFPX creates a new FPX payment platform. By default errors and descriptions
+// are in English; pass WithLocalization to change that.
+func NewMalaysiaFPX(config MalaysiaFPXConfig, opts ...PlatformOption) *MalaysiaFPX {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -45,11 +52,21 @@ func NewMalaysiaFPX(config MalaysiaFPXConfig) *MalaysiaFPX {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "FPX")
 	return &MalaysiaFPX{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		language:    options.language,
 	}
 }
 
@@ -73,8 +90,16 @@ func (p *MalaysiaFPX) GetSupportedCurrencies() []string {
 	return []string{"MYR"}
 }
 
-// CreatePayment creates a payment
-func (p *MalaysiaFPX) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+// CreatePayment creates a payment. opts may override the call's timeout or idempotency key
+// (a per-call retry policy is not applied).
+func (p *MalaysiaFPX) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "MYR" {
 		return nil, errors.New("currency must be MYR for FPX payments")
@@ -84,18 +109,29 @@ func (p *MalaysiaFPX) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	if cached, found, err := p.checkIdempotentPayment(idempotencyKey); err != nil {
+		return nil, err
+	} else if found {
+		return cached, nil
+	}
+
 	// Prepare FPX request
 	fpxRequest := map[string]string{
-		"merchantId":       p.config.MerchantID,
-		"orderNo":          request.OrderID,
-		"amount":           fmt.Sprintf("%.2f", request.Amount),
-		"customerName":     request.CustomerName,
-		"customerEmail":    request.CustomerEmail,
-		"description":      request.Description,
-		"callbackUrl":      p.config.CallbackURL,
-		"redirectUrl":      p.config.RedirectURL,
-		"transactionTime":  time.Now().Format("20060102150405"),
-		"testMode":         fmt.Sprintf("%t", p.config.TestMode),
+		"merchantId":      p.config.MerchantID,
+		"orderNo":         request.OrderID,
+		"amount":          fmt.Sprintf("%.2f", request.Amount),
+		"customerName":    request.CustomerName,
+		"customerEmail":   request.CustomerEmail,
+		"description":     localizedDescription(request.Description, request.OrderID, p.language),
+		"callbackUrl":     p.config.CallbackURL,
+		"redirectUrl":     p.config.RedirectURL,
+		"transactionTime": time.Now().Format("20060102150405"),
+		"testMode":        fmt.Sprintf("%t", p.config.TestMode),
 	}
 
 	// Generate signature
@@ -109,11 +145,15 @@ func (p *MalaysiaFPX) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 	}
 
 	// Make API request
-	resp, err := p.client.Post(
-		p.config.APIEndpoint+"/api/payment",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.APIEndpoint+"/api/payment", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -126,44 +166,86 @@ func (p *MalaysiaFPX) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 	}
 
 	// Parse response
-	var fpxResponse map[string]interface{}
+	var fpxResponse fpxChargeResponse
 	if err := json.Unmarshal(body, &fpxResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
-	if status, ok := fpxResponse["status"].(string); ok && status != "success" {
-		errorMsg := "unknown error"
-		if msg, ok := fpxResponse["message"].(string); ok {
-			errorMsg = msg
+	if fpxResponse.Status != fpxAckSuccess {
+		errorCode := fpxResponse.ErrorCode
+		if errorCode == "" {
+			errorCode = "unknown_error"
+		}
+		errorMsg := fpxResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("FPX error: %s", errorMsg)
+		return nil, translateError(errorCode, fmt.Sprintf("FPX error: %s", errorMsg), p.language, nil)
 	}
 
-	// Extract payment URL and ID
-	paymentURL, _ := fpxResponse["paymentUrl"].(string)
-	paymentID, _ := fpxResponse["paymentId"].(string)
-
 	// Create response
 	response := &PaymentResponse{
-		PaymentID:     paymentID,
+		PaymentID:     fpxResponse.PaymentID,
 		Status:        StatusPending,
 		Amount:        request.Amount,
 		Currency:      request.Currency,
 		PaymentMethod: request.PaymentMethod,
-		PaymentURL:    paymentURL,
-		RedirectURL:   paymentURL,
+		PaymentURL:    fpxResponse.PaymentURL,
+		RedirectURL:   fpxResponse.PaymentURL,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 		ExpiresAt:     time.Now().Add(1 * time.Hour),
 		Metadata:      make(map[string]string),
 	}
 
+	p.storeIdempotentPayment(idempotencyKey, response)
 	return response, nil
 }
 
-// GetPaymentStatus gets the status of a payment
-func (p *MalaysiaFPX) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+// checkIdempotentPayment returns a previously stored PaymentResponse for idempotencyKey,
+// if a store is configured and one exists
+func (p *MalaysiaFPX) checkIdempotentPayment(idempotencyKey string) (*PaymentResponse, bool, error) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return nil, false, nil
+	}
+	raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationCreatePayment)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check idempotency store: %w", err)
+	}
+	if !found {
+		return nil, false, nil
+	}
+	var response PaymentResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, false, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+	}
+	return &response, true, nil
+}
+
+// storeIdempotentPayment persists response under idempotencyKey when a store is
+// configured, logging rather than failing the call if persistence itself errors
+func (p *MalaysiaFPX) storeIdempotentPayment(idempotencyKey string, response *PaymentResponse) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return
+	}
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationCreatePayment, raw)
+}
+
+// GetPaymentStatus gets the status of a payment. opts may override the call's timeout
+// (a per-call retry policy is not applied).
+func (p *MalaysiaFPX) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare status request
 	statusRequest := map[string]string{
 		"merchantId": p.config.MerchantID,
@@ -181,11 +263,14 @@ func (p *MalaysiaFPX) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 	}
 
 	// Make API request
-	resp, err := p.client.Post(
-		p.config.APIEndpoint+"/api/status",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.APIEndpoint+"/api/status", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+
+	resp, err := p.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -198,33 +283,31 @@ func (p *MalaysiaFPX) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 	}
 
 	// Parse response
-	var statusResponse map[string]interface{}
+	var statusResponse fpxStatusResponse
 	if err := json.Unmarshal(body, &statusResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	// Extract payment details
-	status, _ := statusResponse["status"].(string)
-	amount, _ := statusResponse["amount"].(float64)
-	transactionID, _ := statusResponse["transactionId"].(string)
-	createdAtStr, _ := statusResponse["createdAt"].(string)
-	updatedAtStr, _ := statusResponse["updatedAt"].(string)
+	var amount float64
+	if statusResponse.Amount != nil {
+		amount = *statusResponse.Amount
+	}
 
 	// Parse timestamps
-	createdAt, _ := time.Parse(time.RFC3339, createdAtStr)
-	updatedAt, _ := time.Parse(time.RFC3339, updatedAtStr)
+	createdAt, _ := time.Parse(time.RFC3339, statusResponse.CreatedAt)
+	updatedAt, _ := time.Parse(time.RFC3339, statusResponse.UpdatedAt)
 
 	// Map FPX status to our status
 	paymentStatus := StatusPending
 	var completedAt time.Time
 
-	switch status {
-	case "PAYMENT_SUCCESSFUL":
+	switch statusResponse.Status {
+	case FPXStatusSuccessful:
 		paymentStatus = StatusCompleted
 		completedAt = updatedAt
-	case "PAYMENT_FAILED":
+	case FPXStatusFailed:
 		paymentStatus = StatusFailed
-	case "PAYMENT_CANCELLED":
+	case FPXStatusCancelled:
 		paymentStatus = StatusCancelled
 	}
 
@@ -235,7 +318,7 @@ func (p *MalaysiaFPX) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 		Amount:        amount,
 		Currency:      "MYR",
 		PaymentMethod: MethodBankTransfer,
-		TransactionID: transactionID,
+		TransactionID: statusResponse.TransactionID,
 		CreatedAt:     createdAt,
 		UpdatedAt:     updatedAt,
 		CompletedAt:   completedAt,
@@ -245,8 +328,35 @@ func (p *MalaysiaFPX) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 	return response, nil
 }
 
-// RefundPayment refunds a payment
-func (p *MalaysiaFPX) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+// RefundPayment refunds a payment. opts may override the call's timeout or idempotency key
+// (a per-call retry policy is not applied).
+func (p *MalaysiaFPX) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationRefundPayment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check idempotency store: %w", err)
+		}
+		if found {
+			var cached RefundResponse
+			if err := json.Unmarshal(raw, &cached); err != nil {
+				return nil, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+			}
+			return &cached, nil
+		}
+	}
+
 	// Prepare refund request
 	refundRequest := map[string]string{
 		"merchantId": p.config.MerchantID,
@@ -267,11 +377,15 @@ func (p *MalaysiaFPX) RefundPayment(request *RefundRequest) (*RefundResponse, er
 	}
 
 	// Make API request
-	resp, err := p.client.Post(
-		p.config.APIEndpoint+"/api/refund",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.APIEndpoint+"/api/refund", bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -284,36 +398,41 @@ func (p *MalaysiaFPX) RefundPayment(request *RefundRequest) (*RefundResponse, er
 	}
 
 	// Parse response
-	var refundResponse map[string]interface{}
+	var refundResponse fpxRefundResponse
 	if err := json.Unmarshal(body, &refundResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
-	if status, ok := refundResponse["status"].(string); ok && status != "success" {
-		errorMsg := "unknown error"
-		if msg, ok := refundResponse["message"].(string); ok {
-			errorMsg = msg
+	if refundResponse.Status != fpxAckSuccess {
+		errorCode := refundResponse.ErrorCode
+		if errorCode == "" {
+			errorCode = "unknown_error"
+		}
+		errorMsg := refundResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("FPX refund error: %s", errorMsg)
+		return nil, translateError(errorCode, fmt.Sprintf("FPX refund error: %s", errorMsg), p.language, nil)
 	}
 
-	// Extract refund details
-	refundID, _ := refundResponse["refundId"].(string)
-	status, _ := refundResponse["status"].(string)
-	transactionID, _ := refundResponse["transactionId"].(string)
-
 	// Create response
 	response := &RefundResponse{
-		RefundID:      refundID,
+		RefundID:      refundResponse.RefundID,
 		PaymentID:     request.PaymentID,
 		Amount:        request.Amount,
 		Currency:      "MYR",
-		Status:        status,
-		TransactionID: transactionID,
+		Status:        string(refundResponse.Status),
+		TransactionID: refundResponse.TransactionID,
 		CreatedAt:     time.Now(),
 	}
 
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		if raw, err := json.Marshal(response); err == nil {
+			_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationRefundPayment, raw)
+		}
+	}
+
 	return response, nil
 }
 
@@ -348,23 +467,27 @@ func (p *MalaysiaFPX) generateSignature(params map[string]string) string {
 
 // MalaysiaGrabPayConfig holds configuration for GrabPay integration
 type MalaysiaGrabPayConfig struct {
-	MerchantID     string
-	ClientID       string
-	ClientSecret   string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	MerchantID       string
+	ClientID         string
+	ClientSecret     string
+	APIEndpoint      string
+	CallbackURL      string
+	RedirectURL      string
+	TestMode         bool
+	IdempotencyStore IdempotencyStore // optional; when set, CreatePayment/RefundPayment short-circuit retried IdempotencyKeys
 }
 
 // MalaysiaGrabPay implements PaymentPlatform interface for Malaysia's GrabPay
 type MalaysiaGrabPay struct {
-	config MalaysiaGrabPayConfig
-	client *http.Client
+	config      MalaysiaGrabPayConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	language    Language
 }
 
-// NewMalaysiaGrabPay creates a new GrabPay payment platform
-func NewMalaysiaGrabPay(config MalaysiaGrabPayConfig) *MalaysiaGrabPay {
+// NewMalaysiaGrabPay creates a new GrabPay payment platform. By default errors and
+// descriptions are in English; pass WithLocalization to change that.
+func NewMalaysiaGrabPay(config MalaysiaGrabPayConfig, opts ...PlatformOption) *MalaysiaGrabPay {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -374,11 +497,21 @@ func NewMalaysiaGrabPay(config MalaysiaGrabPayConfig) *MalaysiaGrabPay {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "GrabPay")
 	return &MalaysiaGrabPay{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		language:    options.language,
 	}
 }
 
@@ -402,8 +535,16 @@ func (p *MalaysiaGrabPay) GetSupportedCurrencies() []string {
 	return []string{"MYR"}
 }
 
-// CreatePayment creates a payment
-func (p *MalaysiaGrabPay) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+// CreatePayment creates a payment. opts may override the call's timeout or idempotency key
+// (a per-call retry policy is not applied).
+func (p *MalaysiaGrabPay) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "MYR" {
 		return nil, errors.New("currency must be MYR for GrabPay payments")
@@ -413,6 +554,25 @@ func (p *MalaysiaGrabPay) CreatePayment(request *PaymentRequest) (*PaymentRespon
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationCreatePayment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check idempotency store: %w", err)
+		}
+		if found {
+			var cached PaymentResponse
+			if err := json.Unmarshal(raw, &cached); err != nil {
+				return nil, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+			}
+			return &cached, nil
+		}
+	}
+
 	// Get access token
 	token, err := p.getAccessToken()
 	if err != nil {
@@ -421,12 +581,12 @@ func (p *MalaysiaGrabPay) CreatePayment(request *PaymentRequest) (*PaymentRespon
 
 	// Prepare GrabPay request
 	grabPayRequest := map[string]interface{}{
-		"partnerTxID":       request.OrderID,
-		"partnerGroupTxID":  request.OrderID,
-		"amount":            int(request.Amount * 100), // Convert to cents
-		"currency":          request.Currency,
-		"description":       request.Description,
-		"merchantID":        p.config.MerchantID,
+		"partnerTxID":      request.OrderID,
+		"partnerGroupTxID": request.OrderID,
+		"amount":           int(request.Amount * 100), // Convert to cents
+		"currency":         request.Currency,
+		"description":      localizedDescription(request.Description, request.OrderID, p.language),
+		"merchantID":       p.config.MerchantID,
 		"metaInfo": map[string]interface{}{
 			"customerName":  request.CustomerName,
 			"customerEmail": request.CustomerEmail,
@@ -448,19 +608,19 @@ func (p *MalaysiaGrabPay) CreatePayment(request *PaymentRequest) (*PaymentRespon
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/grabpay/partner/v2/charge/init", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("X-GID-AUX-POP", p.generatePOP(req.URL.Path, "POST", string(jsonData), token))
-
-	// Make API request
-	resp, err := p.client.Do(req)
+	// Make API request, rebuilding the signed request fresh on every retry attempt
+	path := "/grabpay/partner/v2/charge/init"
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+path, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-GID-AUX-POP", p.generatePOP(path, "POST", string(jsonData), token))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -473,46 +633,59 @@ func (p *MalaysiaGrabPay) CreatePayment(request *PaymentRequest) (*PaymentRespon
 	}
 
 	// Parse response
-	var grabPayResponse map[string]interface{}
+	var grabPayResponse grabPayChargeResponse
 	if err := json.Unmarshal(body, &grabPayResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		errorMsg := "unknown error"
-		if msg, ok := grabPayResponse["message"].(string); ok {
-			errorMsg = msg
+		errorCode := grabPayResponse.Code
+		if errorCode == "" {
+			errorCode = "unknown_error"
+		}
+		errorMsg := grabPayResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("GrabPay error: %s", errorMsg)
+		return nil, translateError(errorCode, fmt.Sprintf("GrabPay error: %s", errorMsg), p.language, nil)
 	}
 
-	// Extract payment details
-	paymentID, _ := grabPayResponse["txID"].(string)
-	paymentURL, _ := grabPayResponse["request"].(string)
-	qrCodeURL, _ := grabPayResponse["qrCodeURL"].(string)
-
 	// Create response
 	response := &PaymentResponse{
-		PaymentID:     paymentID,
+		PaymentID:     grabPayResponse.TxID,
 		Status:        StatusPending,
 		Amount:        request.Amount,
 		Currency:      request.Currency,
 		PaymentMethod: request.PaymentMethod,
-		PaymentURL:    paymentURL,
-		QRCodeURL:     qrCodeURL,
-		RedirectURL:   paymentURL,
+		PaymentURL:    grabPayResponse.Request,
+		QRCodeURL:     grabPayResponse.QRCodeURL,
+		RedirectURL:   grabPayResponse.Request,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 		ExpiresAt:     time.Now().Add(15 * time.Minute),
 		Metadata:      make(map[string]string),
 	}
 
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		if raw, err := json.Marshal(response); err == nil {
+			_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationCreatePayment, raw)
+		}
+	}
+
 	return response, nil
 }
 
-// GetPaymentStatus gets the status of a payment
-func (p *MalaysiaGrabPay) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+// GetPaymentStatus gets the status of a payment. opts may override the call's timeout
+// (a per-call retry policy is not applied).
+func (p *MalaysiaGrabPay) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Get access token
 	token, err := p.getAccessToken()
 	if err != nil {
@@ -520,12 +693,13 @@ func (p *MalaysiaGrabPay) GetPaymentStatus(request *PaymentStatusRequest) (*Paym
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("GET", p.config.APIEndpoint+"/grabpay/partner/v2/charge/"+request.PaymentID+"/status", nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", p.config.APIEndpoint+"/grabpay/partner/v2/charge/"+request.PaymentID+"/status", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	// Add headers
+	req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("X-GID-AUX-POP", p.generatePOP(req.URL.Path, "GET", "", token))
 
@@ -543,38 +717,40 @@ func (p *MalaysiaGrabPay) GetPaymentStatus(request *PaymentStatusRequest) (*Paym
 	}
 
 	// Parse response
-	var statusResponse map[string]interface{}
+	var statusResponse grabPayStatusResponse
 	if err := json.Unmarshal(body, &statusResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		errorMsg := "unknown error"
-		if msg, ok := statusResponse["message"].(string); ok {
-			errorMsg = msg
+		errorCode := statusResponse.Code
+		if errorCode == "" {
+			errorCode = "unknown_error"
 		}
-		return nil, fmt.Errorf("GrabPay error: %s", errorMsg)
+		errorMsg := statusResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
+		}
+		return nil, translateError(errorCode, fmt.Sprintf("GrabPay error: %s", errorMsg), p.language, nil)
 	}
 
-	// Extract payment details
-	status, _ := statusResponse["status"].(string)
-	amountCents, _ := statusResponse["amount"].(float64)
-	amount := amountCents / 100 // Convert from cents
-	currency, _ := statusResponse["currency"].(string)
-	transactionID, _ := statusResponse["txID"].(string)
+	var amount float64
+	if statusResponse.Amount != nil {
+		amount = *statusResponse.Amount / 100 // Convert from cents
+	}
 
 	// Map GrabPay status to our status
 	paymentStatus := StatusPending
 	var completedAt time.Time
 
-	switch status {
-	case "success", "completed":
+	switch statusResponse.Status {
+	case GrabPayStatusSuccess, GrabPayStatusCompleted:
 		paymentStatus = StatusCompleted
 		completedAt = time.Now()
-	case "failed":
+	case GrabPayStatusFailed:
 		paymentStatus = StatusFailed
-	case "cancelled":
+	case GrabPayStatusCancelled:
 		paymentStatus = StatusCancelled
 	}
 
@@ -583,9 +759,9 @@ func (p *MalaysiaGrabPay) GetPaymentStatus(request *PaymentStatusRequest) (*Paym
 		PaymentID:     request.PaymentID,
 		Status:        paymentStatus,
 		Amount:        amount,
-		Currency:      currency,
+		Currency:      statusResponse.Currency,
 		PaymentMethod: MethodEWallet,
-		TransactionID: transactionID,
+		TransactionID: statusResponse.TxID,
 		CreatedAt:     time.Now(), // We don't have the actual creation time
 		UpdatedAt:     time.Now(),
 		CompletedAt:   completedAt,
@@ -595,8 +771,35 @@ func (p *MalaysiaGrabPay) GetPaymentStatus(request *PaymentStatusRequest) (*Paym
 	return response, nil
 }
 
-// RefundPayment refunds a payment
-func (p *MalaysiaGrabPay) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+// RefundPayment refunds a payment. opts may override the call's timeout or idempotency key
+// (a per-call retry policy is not applied).
+func (p *MalaysiaGrabPay) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationRefundPayment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check idempotency store: %w", err)
+		}
+		if found {
+			var cached RefundResponse
+			if err := json.Unmarshal(raw, &cached); err != nil {
+				return nil, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+			}
+			return &cached, nil
+		}
+	}
+
 	// Get access token
 	token, err := p.getAccessToken()
 	if err != nil {
@@ -619,19 +822,19 @@ func (p *MalaysiaGrabPay) RefundPayment(request *RefundRequest) (*RefundResponse
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/grabpay/partner/v2/refund", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("X-GID-AUX-POP", p.generatePOP(req.URL.Path, "POST", string(jsonData), token))
-
-	// Make API request
-	resp, err := p.client.Do(req)
+	// Make API request, rebuilding the signed request fresh on every retry attempt
+	path := "/grabpay/partner/v2/refund"
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+path, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-GID-AUX-POP", p.generatePOP(path, "POST", string(jsonData), token))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -644,32 +847,38 @@ func (p *MalaysiaGrabPay) RefundPayment(request *RefundRequest) (*RefundResponse
 	}
 
 	// Parse response
-	var refundResponse map[string]interface{}
+	var refundResponse grabPayRefundResponse
 	if err := json.Unmarshal(body, &refundResponse); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	// Check for errors
 	if resp.StatusCode != http.StatusOK {
-		errorMsg := "unknown error"
-		if msg, ok := refundResponse["message"].(string); ok {
-			errorMsg = msg
+		errorCode := refundResponse.Code
+		if errorCode == "" {
+			errorCode = "unknown_error"
+		}
+		errorMsg := refundResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
 		}
-		return nil, fmt.Errorf("GrabPay refund error: %s", errorMsg)
+		return nil, translateError(errorCode, fmt.Sprintf("GrabPay refund error: %s", errorMsg), p.language, nil)
 	}
 
-	// Extract refund details
-	refundID, _ := refundResponse["txID"].(string)
-	status, _ := refundResponse["status"].(string)
-
 	// Create response
 	response := &RefundResponse{
-		RefundID:     refundID,
-		PaymentID:    request.PaymentID,
-		Amount:       request.Amount,
-		Currency:     "MYR",
-		Status:       status,
-		CreatedAt:    time.Now(),
+		RefundID:  refundResponse.TxID,
+		PaymentID: request.PaymentID,
+		Amount:    request.Amount,
+		Currency:  "MYR",
+		Status:    refundResponse.Status,
+		CreatedAt: time.Now(),
+	}
+
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		if raw, err := json.Marshal(response); err == nil {
+			_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationRefundPayment, raw)
+		}
 	}
 
 	return response, nil
@@ -704,18 +913,16 @@ func (p *MalaysiaGrabPay) getAccessToken() (string, error) {
 	}
 
 	// Parse response
-	var tokenResponse map[string]interface{}
+	var tokenResponse grabPayTokenResponse
 	if err := json.Unmarshal(body, &tokenResponse); err != nil {
 		return "", fmt.Errorf("failed to parse token response: %w", err)
 	}
 
-	// Extract token
-	token, ok := tokenResponse["access_token"].(string)
-	if !ok {
+	if tokenResponse.AccessToken == "" {
 		return "", errors.New("failed to get access token")
 	}
 
-	return token, nil
+	return tokenResponse.AccessToken, nil
 }
 
 // generatePOP generates a proof of possession for GrabPay API
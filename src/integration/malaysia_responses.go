@@ -0,0 +1,104 @@
+// Typed response models for Malaysia's FPX and GrabPay gateways
+// MalaysiaFPX/MalaysiaGrabPay previously decoded gateway responses into
+// map[string]interface{} and type-asserted each field, which silently drops a field whose
+// shape didn't match (amount, _ := statusResponse["amount"].(float64) swallows a malformed
+// or absent amount as a zero value instead of surfacing an error). These structs give
+// callers compile-time field safety and make a field's absence explicit via a nil pointer.
+
+package integration
+
+// FPXPaymentStatus is the status string FPX reports from its payment status endpoint
+type FPXPaymentStatus string
+
+const (
+	// FPXStatusSuccessful indicates FPX settled the payment
+	FPXStatusSuccessful FPXPaymentStatus = "PAYMENT_SUCCESSFUL"
+	// FPXStatusFailed indicates FPX declined or failed to process the payment
+	FPXStatusFailed FPXPaymentStatus = "PAYMENT_FAILED"
+	// FPXStatusCancelled indicates the customer cancelled the payment before completion
+	FPXStatusCancelled FPXPaymentStatus = "PAYMENT_CANCELLED"
+)
+
+// fpxAckStatus is the status string FPX's charge/refund endpoints report, separate from
+// FPXPaymentStatus since it uses a different vocabulary ("success" rather than
+// "PAYMENT_SUCCESSFUL")
+type fpxAckStatus string
+
+const fpxAckSuccess fpxAckStatus = "success"
+
+// fpxChargeResponse is FPX's response to a charge creation request
+type fpxChargeResponse struct {
+	Status     fpxAckStatus `json:"status"`
+	Message    string       `json:"message,omitempty"`
+	ErrorCode  string       `json:"errorCode,omitempty"`
+	PaymentURL string       `json:"paymentUrl,omitempty"`
+	PaymentID  string       `json:"paymentId,omitempty"`
+}
+
+// fpxStatusResponse is FPX's response to a payment status query
+type fpxStatusResponse struct {
+	Status        FPXPaymentStatus `json:"status"`
+	Message       string           `json:"message,omitempty"`
+	Amount        *float64         `json:"amount,omitempty"`
+	TransactionID string           `json:"transactionId,omitempty"`
+	CreatedAt     string           `json:"createdAt,omitempty"`
+	UpdatedAt     string           `json:"updatedAt,omitempty"`
+}
+
+// fpxRefundResponse is FPX's response to a refund request
+type fpxRefundResponse struct {
+	Status        fpxAckStatus `json:"status"`
+	Message       string       `json:"message,omitempty"`
+	ErrorCode     string       `json:"errorCode,omitempty"`
+	RefundID      string       `json:"refundId,omitempty"`
+	TransactionID string       `json:"transactionId,omitempty"`
+}
+
+// GrabPayChargeStatus is the status string GrabPay reports from its charge status endpoint
+type GrabPayChargeStatus string
+
+const (
+	// GrabPayStatusSuccess indicates GrabPay settled the charge
+	GrabPayStatusSuccess GrabPayChargeStatus = "success"
+	// GrabPayStatusCompleted is an alternate settled status GrabPay's API has been observed
+	// to return in place of GrabPayStatusSuccess
+	GrabPayStatusCompleted GrabPayChargeStatus = "completed"
+	// GrabPayStatusFailed indicates GrabPay declined or failed to process the charge
+	GrabPayStatusFailed GrabPayChargeStatus = "failed"
+	// GrabPayStatusCancelled indicates the customer cancelled the charge before completion
+	GrabPayStatusCancelled GrabPayChargeStatus = "cancelled"
+)
+
+// grabPayChargeResponse is GrabPay's response to a charge init request
+type grabPayChargeResponse struct {
+	Code      string `json:"code,omitempty"`
+	Message   string `json:"message,omitempty"`
+	TxID      string `json:"txID,omitempty"`
+	Request   string `json:"request,omitempty"`
+	QRCodeURL string `json:"qrCodeURL,omitempty"`
+}
+
+// grabPayStatusResponse is GrabPay's response to a charge status query
+type grabPayStatusResponse struct {
+	Code     string              `json:"code,omitempty"`
+	Message  string              `json:"message,omitempty"`
+	Status   GrabPayChargeStatus `json:"status"`
+	Amount   *float64            `json:"amount,omitempty"`
+	Currency string              `json:"currency,omitempty"`
+	TxID     string              `json:"txID,omitempty"`
+}
+
+// grabPayRefundResponse is GrabPay's response to a refund request
+type grabPayRefundResponse struct {
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+	TxID    string `json:"txID,omitempty"`
+	Status  string `json:"status,omitempty"`
+}
+
+// grabPayTokenResponse is GrabPay's OAuth2 client-credentials token response
+type grabPayTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type,omitempty"`
+	ExpiresIn   int64  `json:"expires_in,omitempty"`
+}
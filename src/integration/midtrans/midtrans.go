@@ -0,0 +1,90 @@
+// Package midtrans holds typed request/response models for Midtrans's Core API, the gateway
+// IndonesiaGoPay (see integration/indonesia.go) talks to in production. IndonesiaGoPay used to
+// build these as map[string]interface{} and type-assert the response back apart field by
+// field, silently dropping anything that didn't assert cleanly; these types let encoding/json
+// catch a field name typo or a response shape change at compile time or at least produce a
+// visible unmarshal error instead of a quietly zero-valued field.
+package midtrans
+
+// TransactionDetails identifies the order a ChargeRequest is for
+type TransactionDetails struct {
+	OrderID     string `json:"order_id"`
+	GrossAmount int64  `json:"gross_amount"`
+}
+
+// ItemDetail is one line item of a ChargeRequest
+type ItemDetail struct {
+	ID       string `json:"id"`
+	Price    int64  `json:"price"`
+	Quantity int    `json:"quantity"`
+	Name     string `json:"name"`
+}
+
+// CustomerDetails identifies the paying customer on a ChargeRequest
+type CustomerDetails struct {
+	FirstName string `json:"first_name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+}
+
+// GoPayDetails configures the GoPay-specific portion of a ChargeRequest
+type GoPayDetails struct {
+	EnableCallback bool   `json:"enable_callback"`
+	CallbackURL    string `json:"callback_url"`
+}
+
+// ChargeRequest is the body of a POST /v2/charge request for a GoPay transaction
+type ChargeRequest struct {
+	TransactionDetails TransactionDetails `json:"transaction_details"`
+	ItemDetails        []ItemDetail       `json:"item_details"`
+	CustomerDetails    CustomerDetails    `json:"customer_details"`
+	PaymentType        string             `json:"payment_type"`
+	GoPay              GoPayDetails       `json:"gopay"`
+}
+
+// Action is one of the redirect/QR URLs Midtrans returns alongside a charge, distinguished by
+// Name, e.g. "deeplink-redirect" or "generate-qr-code"
+type Action struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// ChargeResponse is Midtrans's response to a ChargeRequest
+type ChargeResponse struct {
+	StatusCode        string   `json:"status_code"`
+	StatusMessage     string   `json:"status_message"`
+	TransactionID     string   `json:"transaction_id"`
+	OrderID           string   `json:"order_id"`
+	GrossAmount       string   `json:"gross_amount"`
+	PaymentType       string   `json:"payment_type"`
+	TransactionStatus string   `json:"transaction_status"`
+	Actions           []Action `json:"actions"`
+}
+
+// StatusResponse is Midtrans's response to a GET /v2/{orderID}/status request
+type StatusResponse struct {
+	StatusCode        string `json:"status_code"`
+	StatusMessage     string `json:"status_message"`
+	TransactionID     string `json:"transaction_id"`
+	OrderID           string `json:"order_id"`
+	GrossAmount       string `json:"gross_amount"`
+	TransactionStatus string `json:"transaction_status"`
+	TransactionTime   string `json:"transaction_time"`
+}
+
+// RefundRequest is the body of a POST /v2/{orderID}/refund request
+type RefundRequest struct {
+	RefundKey string `json:"refund_key"`
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason"`
+}
+
+// RefundResponse is Midtrans's response to a RefundRequest
+type RefundResponse struct {
+	StatusCode    string `json:"status_code"`
+	StatusMessage string `json:"status_message"`
+	RefundKey     string `json:"refund_key"`
+	TransactionID string `json:"transaction_id"`
+	RefundAmount  string `json:"refund_amount"`
+}
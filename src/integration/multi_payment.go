@@ -0,0 +1,600 @@
+// Multi-payment (split-tender) support for orders paid across more than one platform
+// A single OrderID sometimes can't be satisfied by one PaymentPlatform charge alone (e.g.
+// a customer paying part by FPX bank transfer and the remainder by GrabPay e-wallet).
+// MultiPaymentSession tracks the child PaymentResponses that together settle an order and
+// routes refunds back across them. MultiPaymentCoordinator builds a session API on top of
+// this for platforms (MoMo, VNPay) with no native split-payment support of their own.
+
+package integration
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// MultiPaymentStatus is the aggregate status of a MultiPaymentSession
+type MultiPaymentStatus string
+
+const (
+	// MultiPaymentCreated indicates the session has been opened but has no successful charges yet
+	MultiPaymentCreated MultiPaymentStatus = "CREATED"
+	// MultiPaymentPartiallyPaid indicates some but not all of the order amount has been charged
+	MultiPaymentPartiallyPaid MultiPaymentStatus = "PARTIALLY_PAID"
+	// MultiPaymentCompleted indicates the full order amount has been charged
+	MultiPaymentCompleted MultiPaymentStatus = "COMPLETED"
+	// MultiPaymentCancelled indicates the session was cancelled before completion
+	MultiPaymentCancelled MultiPaymentStatus = "CANCELLED"
+	// MultiPaymentExpired indicates the session's ExpiresAt passed before it was completed
+	MultiPaymentExpired MultiPaymentStatus = "EXPIRED"
+)
+
+// ErrMultiPaymentExpired is returned when a charge is attempted against a session whose
+// ExpiresAt has already passed
+var ErrMultiPaymentExpired = errors.New("multi-payment session has expired")
+
+// ErrMultiPaymentOvercharge is returned when a charge would exceed the session's remaining amount
+var ErrMultiPaymentOvercharge = errors.New("charge amount exceeds remaining balance")
+
+// ErrMultiPaymentFinalized is returned when a charge or cancellation is attempted against a
+// session that has already completed or been cancelled
+var ErrMultiPaymentFinalized = errors.New("multi-payment session is already finalized")
+
+// ErrMultiPaymentChildNotFound is returned when a refund targets a child payment ID that
+// isn't part of the session
+var ErrMultiPaymentChildNotFound = errors.New("child payment not found in multi-payment session")
+
+// MultiPaymentCharge is one child charge placed against a specific platform as part of a
+// MultiPaymentSession
+type MultiPaymentCharge struct {
+	Platform PaymentPlatform
+	// PlatformName is Platform.GetName(), recorded alongside it so a session reloaded from a
+	// SessionStore that can't serialize live PaymentPlatforms (e.g. SQLSessionStore) can later
+	// be rebound via RebindPlatforms
+	PlatformName string
+	Response     *PaymentResponse
+	Refunded     float64
+}
+
+// MultiPaymentSession tracks the child charges placed against one or more PaymentPlatforms
+// to settle a single OrderID. It is safe for concurrent use.
+type MultiPaymentSession struct {
+	mutex sync.Mutex
+
+	OrderID         string
+	Currency        string
+	TotalAmount     float64
+	RemainingAmount float64
+	Status          MultiPaymentStatus
+	Charges         []*MultiPaymentCharge
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+
+	// Token is the opaque identifier a caller presents to MultiPaymentCoordinator.AddChildPayment
+	// to attach a further charge to this session; set only on sessions created through
+	// MultiPaymentCoordinator.CreateMultiPayment, empty otherwise.
+	Token string
+	// ExternalID is the merchant's own reference for this session (e.g. a cart or order
+	// number), distinct from OrderID which MultiPaymentCoordinator also populates from it.
+	ExternalID string
+	// AllowedMethods restricts which PaymentMethods child payments may use; a nil or empty
+	// slice places no restriction.
+	AllowedMethods []PaymentMethod
+	// ExpiresAt, if non-zero, is when this session stops accepting further charges. A session
+	// past ExpiresAt that hasn't reached MultiPaymentCompleted transitions to
+	// MultiPaymentExpired the next time AddCharge or RefreshStatus is called.
+	ExpiresAt time.Time
+}
+
+// PaidPrice returns how much of TotalAmount has been charged so far
+func (s *MultiPaymentSession) PaidPrice() float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.TotalAmount - s.RemainingAmount
+}
+
+// expireIfPast transitions s to MultiPaymentExpired if ExpiresAt is set and has passed and s
+// hasn't already reached a terminal status. Callers must hold s.mutex.
+func (s *MultiPaymentSession) expireIfPast(now time.Time) {
+	if s.ExpiresAt.IsZero() || now.Before(s.ExpiresAt) {
+		return
+	}
+	switch s.Status {
+	case MultiPaymentCompleted, MultiPaymentCancelled, MultiPaymentExpired:
+		return
+	}
+	s.Status = MultiPaymentExpired
+	s.UpdatedAt = now
+}
+
+// NewMultiPaymentSession opens a session to collect totalAmount currency for orderID across
+// one or more platform charges
+func NewMultiPaymentSession(orderID, currency string, totalAmount float64) *MultiPaymentSession {
+	now := time.Now()
+	return &MultiPaymentSession{
+		OrderID:         orderID,
+		Currency:        currency,
+		TotalAmount:     totalAmount,
+		RemainingAmount: totalAmount,
+		Status:          MultiPaymentCreated,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+// AddCharge places a charge of amount against platform using method, and records the
+// resulting child PaymentResponse against the session. amount must not exceed the
+// session's RemainingAmount.
+func (s *MultiPaymentSession) AddCharge(ctx context.Context, platform PaymentPlatform, amount float64, method PaymentMethod) (*PaymentResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	request := &PaymentRequest{
+		Amount:        amount,
+		Currency:      s.Currency,
+		OrderID:       s.OrderID,
+		PaymentMethod: method,
+	}
+	return s.addChargeLocked(ctx, platform, request)
+}
+
+// addChargeLocked places a charge described by request against platform and records the
+// resulting child PaymentResponse against the session. Callers must hold s.mutex and must
+// have already set request.Amount to the amount being charged.
+func (s *MultiPaymentSession) addChargeLocked(ctx context.Context, platform PaymentPlatform, request *PaymentRequest) (*PaymentResponse, error) {
+	s.expireIfPast(time.Now())
+	if s.Status == MultiPaymentExpired {
+		return nil, ErrMultiPaymentExpired
+	}
+	if s.Status == MultiPaymentCompleted || s.Status == MultiPaymentCancelled {
+		return nil, ErrMultiPaymentFinalized
+	}
+	if request.Amount > s.RemainingAmount {
+		return nil, ErrMultiPaymentOvercharge
+	}
+
+	response, err := platform.CreatePayment(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to charge %s for %s: %w", platform.GetName(), s.OrderID, err)
+	}
+
+	s.Charges = append(s.Charges, &MultiPaymentCharge{Platform: platform, PlatformName: platform.GetName(), Response: response})
+	s.RemainingAmount -= request.Amount
+	s.UpdatedAt = time.Now()
+	if s.RemainingAmount <= 0 {
+		s.Status = MultiPaymentCompleted
+	} else {
+		s.Status = MultiPaymentPartiallyPaid
+	}
+
+	return response, nil
+}
+
+// Finalize closes the session. A session whose RemainingAmount has reached zero is marked
+// MultiPaymentCompleted; otherwise it is cancelled, since a partially paid order can't be
+// considered settled once no further charges are expected.
+func (s *MultiPaymentSession) Finalize() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.Status == MultiPaymentCompleted || s.Status == MultiPaymentCancelled {
+		return ErrMultiPaymentFinalized
+	}
+
+	if s.RemainingAmount <= 0 {
+		s.Status = MultiPaymentCompleted
+	} else {
+		s.Status = MultiPaymentCancelled
+	}
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// RefundAll refunds reason across every child charge, proportionally to each charge's
+// unrefunded amount, for a total of amount. It returns one RefundResponse per child charge
+// actually refunded.
+func (s *MultiPaymentSession) RefundAll(ctx context.Context, amount float64, reason string) ([]*RefundResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	outstanding := s.outstandingTotal()
+	if outstanding <= 0 {
+		return nil, errors.New("no refundable balance remaining in multi-payment session")
+	}
+	if amount > outstanding {
+		return nil, fmt.Errorf("refund amount %.2f exceeds outstanding refundable balance %.2f", amount, outstanding)
+	}
+
+	var responses []*RefundResponse
+	for _, charge := range s.Charges {
+		chargeOutstanding := charge.Response.Amount - charge.Refunded
+		if chargeOutstanding <= 0 {
+			continue
+		}
+		share := amount * (chargeOutstanding / outstanding)
+		if share <= 0 {
+			continue
+		}
+		response, err := s.refundCharge(ctx, charge, share, reason)
+		if err != nil {
+			return responses, err
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// RefundChild refunds amount against a single child charge identified by childPaymentID,
+// rather than spreading the refund proportionally across all of the session's charges.
+func (s *MultiPaymentSession) RefundChild(ctx context.Context, childPaymentID string, amount float64, reason string) (*RefundResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, charge := range s.Charges {
+		if charge.Response.PaymentID != childPaymentID {
+			continue
+		}
+		if amount > charge.Response.Amount-charge.Refunded {
+			return nil, fmt.Errorf("refund amount %.2f exceeds child charge's refundable balance", amount)
+		}
+		return s.refundCharge(ctx, charge, amount, reason)
+	}
+	return nil, ErrMultiPaymentChildNotFound
+}
+
+// refundCharge issues a RefundRequest against charge's platform and records the refunded
+// amount against it. Callers must hold s.mutex.
+func (s *MultiPaymentSession) refundCharge(ctx context.Context, charge *MultiPaymentCharge, amount float64, reason string) (*RefundResponse, error) {
+	response, err := charge.Platform.RefundPayment(ctx, &RefundRequest{
+		PaymentID: charge.Response.PaymentID,
+		Amount:    amount,
+		Reason:    reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to refund child payment %s: %w", charge.Response.PaymentID, err)
+	}
+	charge.Refunded += amount
+	s.UpdatedAt = time.Now()
+	return response, nil
+}
+
+// outstandingTotal returns the sum of each charge's unrefunded amount. Callers must hold
+// s.mutex.
+func (s *MultiPaymentSession) outstandingTotal() float64 {
+	var total float64
+	for _, charge := range s.Charges {
+		total += charge.Response.Amount - charge.Refunded
+	}
+	return total
+}
+
+// RefreshStatus polls GetPaymentStatus against each child charge's platform and re-aggregates
+// the session's RemainingAmount and Status from the results, for a merchant that needs to
+// reconcile a split-tender order against upstream truth rather than trusting the responses
+// recorded at charge time (e.g. a PayNow leg that settled asynchronously after AddCharge
+// returned). Charges already in a StatusCompleted state are not re-polled.
+func (s *MultiPaymentSession) RefreshStatus(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expireIfPast(time.Now())
+	if s.Status == MultiPaymentCancelled || s.Status == MultiPaymentExpired {
+		return ErrMultiPaymentFinalized
+	}
+
+	var chargedTotal float64
+	for _, charge := range s.Charges {
+		if charge.Response.Status != StatusCompleted {
+			latest, err := charge.Platform.GetPaymentStatus(ctx, &PaymentStatusRequest{PaymentID: charge.Response.PaymentID})
+			if err != nil {
+				return fmt.Errorf("failed to refresh status of child payment %s: %w", charge.Response.PaymentID, err)
+			}
+			charge.Response = latest
+		}
+		if charge.Response.Status == StatusCompleted {
+			chargedTotal += charge.Response.Amount
+		}
+	}
+
+	s.RemainingAmount = s.TotalAmount - chargedTotal
+	s.UpdatedAt = time.Now()
+	if s.RemainingAmount <= 0 {
+		s.Status = MultiPaymentCompleted
+	} else if chargedTotal > 0 {
+		s.Status = MultiPaymentPartiallyPaid
+	} else {
+		s.Status = MultiPaymentCreated
+	}
+	return nil
+}
+
+// RefundLIFO refunds amount by walking the session's child charges in reverse (most recent
+// first) until amount is fully satisfied, rather than spreading it proportionally across every
+// charge the way RefundAll does - useful when the merchant wants to unwind the last-added leg
+// of a split-tender order first (e.g. backing out a top-up card charge before touching the
+// customer's original PayNow payment).
+func (s *MultiPaymentSession) RefundLIFO(ctx context.Context, amount float64, reason string) ([]*RefundResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	outstanding := s.outstandingTotal()
+	if outstanding <= 0 {
+		return nil, errors.New("no refundable balance remaining in multi-payment session")
+	}
+	if amount > outstanding {
+		return nil, fmt.Errorf("refund amount %.2f exceeds outstanding refundable balance %.2f", amount, outstanding)
+	}
+
+	var responses []*RefundResponse
+	remaining := amount
+	for i := len(s.Charges) - 1; i >= 0 && remaining > 0; i-- {
+		charge := s.Charges[i]
+		chargeOutstanding := charge.Response.Amount - charge.Refunded
+		if chargeOutstanding <= 0 {
+			continue
+		}
+		share := remaining
+		if share > chargeOutstanding {
+			share = chargeOutstanding
+		}
+		response, err := s.refundCharge(ctx, charge, share, reason)
+		if err != nil {
+			return responses, err
+		}
+		responses = append(responses, response)
+		remaining -= share
+	}
+	return responses, nil
+}
+
+// ErrMultiPaymentSessionNotFound is returned when a sessionID/token doesn't resolve to a
+// known MultiPaymentSession
+var ErrMultiPaymentSessionNotFound = errors.New("multi-payment session not found")
+
+// ErrMultiPaymentMethodNotAllowed is returned when a child payment's PaymentMethod isn't in
+// the session's AllowedMethods
+var ErrMultiPaymentMethodNotAllowed = errors.New("payment method not allowed for this multi-payment session")
+
+// ErrNoPlatformForMethod is returned when MultiPaymentCoordinator has no platform configured
+// that supports a requested PaymentMethod
+var ErrNoPlatformForMethod = errors.New("no platform configured for payment method")
+
+// MultiPaymentRequest describes a new split-tender session to open via
+// MultiPaymentCoordinator.CreateMultiPayment
+type MultiPaymentRequest struct {
+	TotalAmount    float64
+	Currency       string
+	ExternalID     string
+	AllowedMethods []PaymentMethod
+}
+
+// SessionStore persists MultiPaymentSessions between the CreateMultiPayment call that opens
+// one and the later AddChildPayment calls that attach charges to it, keyed by the session's
+// Token. A merchant backed by more than one process instance would implement this over a
+// shared store (e.g. Redis or a SQL table) rather than the in-memory default.
+type SessionStore interface {
+	Get(token string) (*MultiPaymentSession, bool, error)
+	Put(session *MultiPaymentSession) error
+}
+
+// InMemorySessionStore is a process-local SessionStore backed by a map. It is safe for
+// concurrent use but, like InMemoryIdempotencyStore, doesn't survive a process restart.
+type InMemorySessionStore struct {
+	mutex    sync.Mutex
+	sessions map[string]*MultiPaymentSession
+}
+
+// NewInMemorySessionStore creates an empty InMemorySessionStore
+func NewInMemorySessionStore() *InMemorySessionStore {
+	return &InMemorySessionStore{sessions: make(map[string]*MultiPaymentSession)}
+}
+
+// Get returns the session stored under token, if any
+func (s *InMemorySessionStore) Get(token string) (*MultiPaymentSession, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	session, found := s.sessions[token]
+	return session, found, nil
+}
+
+// Put stores session under session.Token, overwriting any session previously stored there
+func (s *InMemorySessionStore) Put(session *MultiPaymentSession) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sessions[session.Token] = session
+	return nil
+}
+
+// MultiPaymentCapable is implemented by a platform that exposes a Craftgate-style
+// multi-payment flow directly - a parent token with a RemainingAmount that one or more
+// partial charges attach to until fully captured - rather than only through
+// MultiPaymentCoordinator's cross-platform routing. Type-assert a PaymentPlatform pulled from
+// PaymentPlatformRegistry against this interface to discover whether it supports the flow
+// natively.
+type MultiPaymentCapable interface {
+	InitMultiPayment(ctx context.Context, request *MultiPaymentRequest) (*MultiPaymentSession, error)
+	AddPartialPayment(ctx context.Context, paymentToken string, amount float64, method PaymentMethod) (*PaymentResponse, error)
+	CompleteMultiPayment(ctx context.Context, paymentToken string) (*MultiPaymentSession, error)
+}
+
+// MultiPaymentCoordinator lets platforms that have no native split-payment API of their own
+// (MoMo and VNPay, at the time of writing) participate in a single MultiPaymentSession by
+// routing each child PaymentRequest to whichever configured platform supports its
+// PaymentMethod.
+type MultiPaymentCoordinator struct {
+	store             SessionStore
+	platformsByMethod map[PaymentMethod]PaymentPlatform
+}
+
+// NewMultiPaymentCoordinator creates a coordinator that opens sessions in store and routes
+// child payments across platforms, selecting the first platform in the list that supports a
+// given child payment's PaymentMethod.
+func NewMultiPaymentCoordinator(store SessionStore, platforms ...PaymentPlatform) *MultiPaymentCoordinator {
+	platformsByMethod := make(map[PaymentMethod]PaymentPlatform)
+	for _, platform := range platforms {
+		for _, method := range platform.GetSupportedPaymentMethods() {
+			if _, exists := platformsByMethod[method]; !exists {
+				platformsByMethod[method] = platform
+			}
+		}
+	}
+	return &MultiPaymentCoordinator{store: store, platformsByMethod: platformsByMethod}
+}
+
+// generateSessionToken returns a random 32-byte hex-encoded token, following the same
+// convention as SecurityService.GenerateAPIKey
+func generateSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// CreateMultiPayment opens a new MultiPaymentSession for request.TotalAmount and persists it
+// in c.store, returning the session with its Token populated.
+func (c *MultiPaymentCoordinator) CreateMultiPayment(ctx context.Context, request *MultiPaymentRequest) (*MultiPaymentSession, error) {
+	if request.TotalAmount <= 0 {
+		return nil, errors.New("multi-payment total amount must be positive")
+	}
+
+	token, err := generateSessionToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := NewMultiPaymentSession(request.ExternalID, request.Currency, request.TotalAmount)
+	session.Token = token
+	session.ExternalID = request.ExternalID
+	session.AllowedMethods = request.AllowedMethods
+
+	if err := c.store.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return session, nil
+}
+
+// AddChildPayment attaches request as a further charge against the session identified by
+// token, routing it to whichever configured platform supports request.PaymentMethod. The
+// updated session is persisted back to c.store before returning.
+func (c *MultiPaymentCoordinator) AddChildPayment(ctx context.Context, token string, request *PaymentRequest) (*PaymentResponse, error) {
+	session, found, err := c.store.Get(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return nil, ErrMultiPaymentSessionNotFound
+	}
+
+	if !session.methodAllowed(request.PaymentMethod) {
+		return nil, ErrMultiPaymentMethodNotAllowed
+	}
+
+	platform, ok := c.platformsByMethod[request.PaymentMethod]
+	if !ok {
+		return nil, ErrNoPlatformForMethod
+	}
+
+	session.mutex.Lock()
+	if request.Currency == "" {
+		request.Currency = session.Currency
+	}
+	if request.OrderID == "" {
+		request.OrderID = session.OrderID
+	}
+	response, err := session.addChargeLocked(ctx, platform, request)
+	session.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return response, nil
+}
+
+// AttachChildPayment charges amount against platform using method and attaches the result to
+// the session identified by multiID, mirroring AddChildPayment but letting a caller pick the
+// settling platform directly instead of routing by PaymentMethod - useful when more than one
+// configured platform supports the same method (e.g. GoPay and OVO both accepting
+// MethodQRCode) and the customer, not the coordinator, is choosing the wallet at checkout.
+func (c *MultiPaymentCoordinator) AttachChildPayment(ctx context.Context, multiID string, platform PaymentPlatform, amount float64, method PaymentMethod) (*PaymentResponse, error) {
+	session, found, err := c.store.Get(multiID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return nil, ErrMultiPaymentSessionNotFound
+	}
+	if !session.methodAllowed(method) {
+		return nil, ErrMultiPaymentMethodNotAllowed
+	}
+
+	response, err := session.AddCharge(ctx, platform, amount, method)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.store.Put(session); err != nil {
+		return nil, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return response, nil
+}
+
+// GetRemainingAmount returns how much of the session identified by multiID remains unpaid
+func (c *MultiPaymentCoordinator) GetRemainingAmount(multiID string) (float64, error) {
+	session, found, err := c.store.Get(multiID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return 0, ErrMultiPaymentSessionNotFound
+	}
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	return session.RemainingAmount, nil
+}
+
+// ReconcileMultiPayment polls GetPaymentStatus against every child charge in the session
+// identified by multiID (via MultiPaymentSession.RefreshStatus) and re-persists its updated
+// Status/RemainingAmount to c.store, transitioning it to MultiPaymentCompleted once the
+// settled total reaches TotalAmount. The session's own mutex makes this safe to call
+// concurrently from more than one webhook delivery for the same session without
+// double-counting a child charge that's already been reconciled.
+func (c *MultiPaymentCoordinator) ReconcileMultiPayment(ctx context.Context, multiID string) (*MultiPaymentSession, error) {
+	session, found, err := c.store.Get(multiID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up multi-payment session: %w", err)
+	}
+	if !found {
+		return nil, ErrMultiPaymentSessionNotFound
+	}
+
+	if err := session.RefreshStatus(ctx); err != nil {
+		return session, err
+	}
+	if err := c.store.Put(session); err != nil {
+		return session, fmt.Errorf("failed to persist multi-payment session: %w", err)
+	}
+	return session, nil
+}
+
+// methodAllowed reports whether method may be used for a child payment against s. A session
+// with no AllowedMethods configured accepts any method.
+func (s *MultiPaymentSession) methodAllowed(method PaymentMethod) bool {
+	if len(s.AllowedMethods) == 0 {
+		return true
+	}
+	for _, allowed := range s.AllowedMethods {
+		if allowed == method {
+			return true
+		}
+	}
+	return false
+}
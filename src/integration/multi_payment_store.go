@@ -0,0 +1,192 @@
+// SQL-backed persistence for multi-payment sessions
+// InMemorySessionStore loses every open split-tender session on restart, which is fine for a
+// single-instance deployment or tests but not for a merchant running more than one instance of
+// MultiPaymentCoordinator. SQLSessionStore persists the same SessionStore contract to Postgres
+// via gorm, following the self-contained model+store pattern IdempotencyStore uses rather than
+// depending on the database package directly.
+
+package integration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// sessionChargeDTO is the JSON shape a MultiPaymentCharge is persisted as. Platform itself
+// isn't serializable (it's a live PaymentPlatform, usually holding an *http.Client), so only
+// PlatformName is stored; a session loaded from the store has nil Platform fields on its
+// Charges until RebindPlatforms is called.
+type sessionChargeDTO struct {
+	PlatformName string           `json:"platform_name"`
+	Response     *PaymentResponse `json:"response"`
+	Refunded     float64          `json:"refunded"`
+}
+
+// MultiPaymentSessionRecord is the gorm model SQLSessionStore persists sessions in
+type MultiPaymentSessionRecord struct {
+	gorm.Model
+	Token           string  `gorm:"size:64;uniqueIndex"`
+	OrderID         string  `gorm:"size:255;index"`
+	Currency        string  `gorm:"size:10"`
+	TotalAmount     float64 `gorm:"not null"`
+	RemainingAmount float64 `gorm:"not null"`
+	Status          string  `gorm:"size:20"`
+	ExternalID      string  `gorm:"size:255;index"`
+	AllowedMethods  string  `gorm:"type:jsonb"`
+	Charges         string  `gorm:"type:jsonb"`
+	ExpiresAt       time.Time
+	SessionCreated  time.Time
+	SessionUpdated  time.Time
+}
+
+// SQLSessionStore is a SessionStore backed by a SQL table via gorm, for deployments running
+// more than one instance of MultiPaymentCoordinator
+type SQLSessionStore struct {
+	db *gorm.DB
+}
+
+// NewSQLSessionStore creates a SQL-backed session store using db, which must already have the
+// MultiPaymentSessionRecord table migrated (db.AutoMigrate(&MultiPaymentSessionRecord{}))
+func NewSQLSessionStore(db *gorm.DB) *SQLSessionStore {
+	return &SQLSessionStore{db: db}
+}
+
+// Get returns the session stored under token, if any. Charges on the returned session have
+// their Platform field left nil; call RebindPlatforms before routing further charges or
+// refunds through it.
+func (s *SQLSessionStore) Get(token string) (*MultiPaymentSession, bool, error) {
+	var record MultiPaymentSessionRecord
+	err := s.db.Where("token = ?", token).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up multi-payment session record: %w", err)
+	}
+
+	session, err := recordToSession(&record)
+	if err != nil {
+		return nil, false, err
+	}
+	return session, true, nil
+}
+
+// Put persists session, creating its record if this is the first time it's been stored or
+// updating the existing row otherwise
+func (s *SQLSessionStore) Put(session *MultiPaymentSession) error {
+	record, err := sessionToRecord(session)
+	if err != nil {
+		return err
+	}
+
+	var existing MultiPaymentSessionRecord
+	err = s.db.Where("token = ?", session.Token).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := s.db.Create(record).Error; err != nil {
+			return fmt.Errorf("failed to persist multi-payment session record: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up multi-payment session record: %w", err)
+	}
+
+	record.Model = existing.Model
+	if err := s.db.Save(record).Error; err != nil {
+		return fmt.Errorf("failed to persist multi-payment session record: %w", err)
+	}
+	return nil
+}
+
+func sessionToRecord(session *MultiPaymentSession) (*MultiPaymentSessionRecord, error) {
+	allowedMethods, err := json.Marshal(session.AllowedMethods)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode multi-payment session allowed methods: %w", err)
+	}
+
+	charges := make([]sessionChargeDTO, 0, len(session.Charges))
+	for _, charge := range session.Charges {
+		name := charge.PlatformName
+		if name == "" && charge.Platform != nil {
+			name = charge.Platform.GetName()
+		}
+		charges = append(charges, sessionChargeDTO{PlatformName: name, Response: charge.Response, Refunded: charge.Refunded})
+	}
+	chargesJSON, err := json.Marshal(charges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode multi-payment session charges: %w", err)
+	}
+
+	return &MultiPaymentSessionRecord{
+		Token:           session.Token,
+		OrderID:         session.OrderID,
+		Currency:        session.Currency,
+		TotalAmount:     session.TotalAmount,
+		RemainingAmount: session.RemainingAmount,
+		Status:          string(session.Status),
+		ExternalID:      session.ExternalID,
+		AllowedMethods:  string(allowedMethods),
+		Charges:         string(chargesJSON),
+		ExpiresAt:       session.ExpiresAt,
+		SessionCreated:  session.CreatedAt,
+		SessionUpdated:  session.UpdatedAt,
+	}, nil
+}
+
+func recordToSession(record *MultiPaymentSessionRecord) (*MultiPaymentSession, error) {
+	var allowedMethods []PaymentMethod
+	if record.AllowedMethods != "" {
+		if err := json.Unmarshal([]byte(record.AllowedMethods), &allowedMethods); err != nil {
+			return nil, fmt.Errorf("failed to decode multi-payment session allowed methods: %w", err)
+		}
+	}
+
+	var chargeDTOs []sessionChargeDTO
+	if record.Charges != "" {
+		if err := json.Unmarshal([]byte(record.Charges), &chargeDTOs); err != nil {
+			return nil, fmt.Errorf("failed to decode multi-payment session charges: %w", err)
+		}
+	}
+	charges := make([]*MultiPaymentCharge, 0, len(chargeDTOs))
+	for _, dto := range chargeDTOs {
+		charges = append(charges, &MultiPaymentCharge{PlatformName: dto.PlatformName, Response: dto.Response, Refunded: dto.Refunded})
+	}
+
+	return &MultiPaymentSession{
+		OrderID:         record.OrderID,
+		Currency:        record.Currency,
+		TotalAmount:     record.TotalAmount,
+		RemainingAmount: record.RemainingAmount,
+		Status:          MultiPaymentStatus(record.Status),
+		Charges:         charges,
+		CreatedAt:       record.SessionCreated,
+		UpdatedAt:       record.SessionUpdated,
+		Token:           record.Token,
+		ExternalID:      record.ExternalID,
+		AllowedMethods:  allowedMethods,
+		ExpiresAt:       record.ExpiresAt,
+	}, nil
+}
+
+// RebindPlatforms reattaches each Charge's Platform field, by matching GetName() against the
+// PlatformName recorded at charge time, after a session has been loaded from a SessionStore
+// that can't serialize live PaymentPlatforms (e.g. SQLSessionStore). Charges whose PlatformName
+// doesn't match any of platforms are left unbound.
+func (s *MultiPaymentSession) RebindPlatforms(platforms ...PaymentPlatform) {
+	byName := make(map[string]PaymentPlatform, len(platforms))
+	for _, platform := range platforms {
+		byName[platform.GetName()] = platform
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, charge := range s.Charges {
+		if platform, ok := byName[charge.PlatformName]; ok {
+			charge.Platform = platform
+		}
+	}
+}
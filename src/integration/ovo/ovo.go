@@ -0,0 +1,72 @@
+// Package ovo holds typed request/response models for Indonesia's OVO e-wallet API, replacing
+// the map[string]interface{} payloads IndonesiaOVO (see integration/indonesia.go) used to build
+// and unmarshal into by hand.
+package ovo
+
+// PushPaymentRequest is the body of a POST /api/v1/payment/push request, prompting the
+// customer's OVO app to accept or decline a charge
+type PushPaymentRequest struct {
+	ReferenceNumber string `json:"reference_number"`
+	Amount          int64  `json:"amount"`
+	Phone           string `json:"phone"`
+	MerchantID      string `json:"merchant_id"`
+	Description     string `json:"description"`
+	CallbackURL     string `json:"callback_url"`
+	Timestamp       string `json:"timestamp"`
+	Signature       string `json:"signature"`
+}
+
+// PaymentData is the "data" payload OVO returns for a push payment or status request
+type PaymentData struct {
+	TransactionID   string  `json:"transaction_id"`
+	Status          string  `json:"status"`
+	Amount          float64 `json:"amount"`
+	ReferenceNumber string  `json:"reference_number"`
+	TransactionTime string  `json:"transaction_time"`
+}
+
+// PushPaymentResponse is OVO's response to a PushPaymentRequest
+type PushPaymentResponse struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message"`
+	Data    PaymentData `json:"data"`
+}
+
+// StatusRequest is the body of a POST /api/v1/payment/status request
+type StatusRequest struct {
+	TransactionID string `json:"transaction_id"`
+	MerchantID    string `json:"merchant_id"`
+	Timestamp     string `json:"timestamp"`
+	Signature     string `json:"signature"`
+}
+
+// StatusResponse is OVO's response to a StatusRequest
+type StatusResponse struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message"`
+	Data    PaymentData `json:"data"`
+}
+
+// RefundRequest is the body of a POST /api/v1/payment/refund request
+type RefundRequest struct {
+	TransactionID string `json:"transaction_id"`
+	MerchantID    string `json:"merchant_id"`
+	Amount        int64  `json:"amount"`
+	Reference     string `json:"reference"`
+	Reason        string `json:"reason"`
+	Timestamp     string `json:"timestamp"`
+	Signature     string `json:"signature"`
+}
+
+// RefundData is the "data" payload OVO returns for a refund request
+type RefundData struct {
+	RefundID string `json:"refund_id"`
+	Status   string `json:"status"`
+}
+
+// RefundResponse is OVO's response to a RefundRequest
+type RefundResponse struct {
+	Status  string     `json:"status"`
+	Message string     `json:"message"`
+	Data    RefundData `json:"data"`
+}
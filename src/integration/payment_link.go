@@ -0,0 +1,352 @@
+// Payment link generation, letting a merchant charge a customer without a pre-existing
+// order. A PaymentLink resolves to a hosted checkout page (served by the sibling checkout
+// package) that collects whatever PaymentLinkRequest left for the customer to supply - an
+// amount within MinAmount/MaxAmount, a currency, a payment method - and invokes CreatePayment
+// against the platform the link was created for. PaymentLinkManager is deliberately a type
+// that wraps a PaymentPlatformRegistry rather than two new methods added to the
+// PaymentPlatform interface itself, mirroring MultiPaymentCoordinator's precedent of layering
+// cross-cutting order logic over PaymentPlatform instead of growing the interface every
+// platform (including the ones with pre-existing build issues) would have to implement.
+
+package integration
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// ErrPaymentLinkNotFound is returned when no PaymentLink exists for a given token
+var ErrPaymentLinkNotFound = errors.New("payment link not found")
+
+// ErrPaymentLinkInactive is returned when a payment is attempted against a deactivated or
+// already-consumed single-use link
+var ErrPaymentLinkInactive = errors.New("payment link is no longer active")
+
+// ErrPaymentLinkExpired is returned when a payment is attempted after the link's ExpiresAt
+var ErrPaymentLinkExpired = errors.New("payment link has expired")
+
+// ErrPaymentLinkAmountOutOfRange is returned when a customer-entered amount falls outside
+// MinAmount/MaxAmount, or doesn't match FixedAmount for a fixed-amount link
+var ErrPaymentLinkAmountOutOfRange = errors.New("amount is outside the range this payment link accepts")
+
+// ErrPaymentLinkCurrencyNotAllowed is returned when the requested currency isn't in
+// PaymentLinkRequest.AllowedCurrencies
+var ErrPaymentLinkCurrencyNotAllowed = errors.New("currency is not accepted by this payment link")
+
+// ErrPaymentLinkPlatformNotFound is returned when a PaymentLinkRequest's PlatformName/
+// CountryCode don't resolve to a registered platform
+var ErrPaymentLinkPlatformNotFound = errors.New("payment link's platform is not registered")
+
+// SuccessAction tells the hosted checkout page what to show a customer once their payment
+// completes. Exactly one of RedirectURL or ShowMessage is expected to be set; if both are
+// empty, the checkout page falls back to a generic confirmation message.
+type SuccessAction struct {
+	RedirectURL string `json:"redirect_url,omitempty"`
+	ShowMessage string `json:"show_message,omitempty"`
+}
+
+// PaymentLinkRequest describes the payment link to create
+type PaymentLinkRequest struct {
+	// PlatformName/CountryCode resolve the PaymentPlatform this link charges against, via
+	// PaymentPlatformRegistry.GetPlatform
+	PlatformName string `json:"platform_name"`
+	CountryCode  string `json:"country_code"`
+
+	Description string `json:"description,omitempty"`
+	OrderID     string `json:"order_id,omitempty"`
+
+	// FixedAmount is the amount charged if positive. If zero, the customer enters their own
+	// amount on the checkout page, bounded by MinAmount/MaxAmount.
+	FixedAmount float64 `json:"fixed_amount,omitempty"`
+	MinAmount   float64 `json:"min_amount,omitempty"`
+	MaxAmount   float64 `json:"max_amount,omitempty"`
+
+	// AllowedCurrencies lists the currencies a customer may pay in. The first entry is the
+	// default offered on the checkout page.
+	AllowedCurrencies []string      `json:"allowed_currencies"`
+	PaymentMethod     PaymentMethod `json:"payment_method,omitempty"`
+
+	// SingleUse deactivates the link after its first successful payment
+	SingleUse bool `json:"single_use"`
+
+	ExpiresAt     time.Time         `json:"expires_at,omitempty"`
+	SuccessAction SuccessAction     `json:"success_action,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+}
+
+// PaymentLink is a created, shareable link a customer can pay without a pre-existing order
+type PaymentLink struct {
+	ID    string `json:"id"`
+	Token string `json:"token"`
+	// URL is the hosted checkout page a customer is sent to, built from the manager's
+	// baseURL and Token
+	URL string `json:"url"`
+	// QRCode is a PNG-encoded QR code pointing at URL, for a merchant that wants to print or
+	// display the link rather than send it. Nil if QR generation failed.
+	QRCode []byte `json:"-"`
+
+	Request  PaymentLinkRequest `json:"request"`
+	Active   bool               `json:"active"`
+	UseCount int                `json:"use_count"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PaymentLinkStore persists PaymentLinks across a manager's lifetime. InMemoryPaymentLinkStore
+// is the default for a single-instance deployment; a caller backing a multi-instance
+// deployment should implement this against a shared database, mirroring SessionStore's role
+// for MultiPaymentSession.
+type PaymentLinkStore interface {
+	Save(link *PaymentLink) error
+	Get(token string) (*PaymentLink, bool, error)
+	Update(link *PaymentLink) error
+}
+
+// InMemoryPaymentLinkStore is a PaymentLinkStore backed by a process-local map
+type InMemoryPaymentLinkStore struct {
+	mutex sync.RWMutex
+	links map[string]*PaymentLink
+}
+
+// NewInMemoryPaymentLinkStore creates an empty in-memory payment link store
+func NewInMemoryPaymentLinkStore() *InMemoryPaymentLinkStore {
+	return &InMemoryPaymentLinkStore{links: make(map[string]*PaymentLink)}
+}
+
+// Save records link under its Token
+func (s *InMemoryPaymentLinkStore) Save(link *PaymentLink) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.links[link.Token] = link
+	return nil
+}
+
+// Get returns the PaymentLink saved under token, if any
+func (s *InMemoryPaymentLinkStore) Get(token string) (*PaymentLink, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	link, ok := s.links[token]
+	return link, ok, nil
+}
+
+// Update overwrites the PaymentLink saved under link.Token
+func (s *InMemoryPaymentLinkStore) Update(link *PaymentLink) error {
+	return s.Save(link)
+}
+
+// PaymentLinkManager creates and redeems PaymentLinks against the platforms registered in a
+// PaymentPlatformRegistry
+type PaymentLinkManager struct {
+	registry *PaymentPlatformRegistry
+	store    PaymentLinkStore
+	baseURL  string
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewPaymentLinkManager creates a PaymentLinkManager resolving platforms from registry and
+// persisting links to store. baseURL is the hosted checkout origin (e.g.
+// "https://pay.example.com"); a link's URL is baseURL with "/pay/<token>" appended.
+func NewPaymentLinkManager(registry *PaymentPlatformRegistry, store PaymentLinkStore, baseURL string) *PaymentLinkManager {
+	return &PaymentLinkManager{
+		registry: registry,
+		store:    store,
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// AddListener registers listener to receive a WebhookEvent for every payment this manager's
+// links successfully charge
+func (m *PaymentLinkManager) AddListener(listener WebhookListener) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+// CreatePaymentLink generates a shareable PaymentLink for request, including a QR code
+// pointing at its hosted checkout page
+func (m *PaymentLinkManager) CreatePaymentLink(request *PaymentLinkRequest) (*PaymentLink, error) {
+	if _, ok := m.registry.GetPlatform(request.CountryCode, request.PlatformName); !ok {
+		return nil, ErrPaymentLinkPlatformNotFound
+	}
+
+	token, err := generateLinkToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate payment link token: %w", err)
+	}
+
+	link := &PaymentLink{
+		ID:        NewIdempotencyKey(),
+		Token:     token,
+		URL:       fmt.Sprintf("%s/pay/%s", m.baseURL, token),
+		Request:   *request,
+		Active:    true,
+		CreatedAt: time.Now(),
+	}
+	if qr, err := qrcode.Encode(link.URL, qrcode.Medium, 256); err == nil {
+		link.QRCode = qr
+	}
+
+	if err := m.store.Save(link); err != nil {
+		return nil, fmt.Errorf("failed to save payment link: %w", err)
+	}
+	return link, nil
+}
+
+// DeactivatePaymentLink marks the link identified by token inactive, so any further Pay call
+// against it fails with ErrPaymentLinkInactive
+func (m *PaymentLinkManager) DeactivatePaymentLink(token string) error {
+	link, found, err := m.store.Get(token)
+	if err != nil {
+		return fmt.Errorf("failed to look up payment link: %w", err)
+	}
+	if !found {
+		return ErrPaymentLinkNotFound
+	}
+	link.Active = false
+	return m.store.Update(link)
+}
+
+// GetPaymentLink returns the PaymentLink identified by token, for the checkout page to render
+func (m *PaymentLinkManager) GetPaymentLink(token string) (*PaymentLink, error) {
+	link, found, err := m.store.Get(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up payment link: %w", err)
+	}
+	if !found {
+		return nil, ErrPaymentLinkNotFound
+	}
+	return link, nil
+}
+
+// Pay validates amount/currency against the link identified by token and charges it against
+// the link's platform, recording the use and, when CreatePayment returns without error,
+// firing a WebhookEventPaymentCompleted to every registered listener (or a
+// WebhookEventPaymentFailed if the returned status isn't StatusCompleted). No event fires
+// when CreatePayment itself returns an error.
+func (m *PaymentLinkManager) Pay(ctx context.Context, token string, amount float64, currency string, customer PaymentRequest) (*PaymentResponse, error) {
+	link, err := m.GetPaymentLink(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePaymentLinkCharge(link, amount, currency); err != nil {
+		return nil, err
+	}
+
+	platform, ok := m.registry.GetPlatform(link.Request.CountryCode, link.Request.PlatformName)
+	if !ok {
+		return nil, ErrPaymentLinkPlatformNotFound
+	}
+
+	request := customer
+	request.Amount = amount
+	request.Currency = currency
+	request.Description = link.Request.Description
+	request.OrderID = link.Request.OrderID
+	if request.PaymentMethod == "" {
+		request.PaymentMethod = link.Request.PaymentMethod
+	}
+	request.Metadata = mergePaymentLinkMetadata(link.Request.Metadata, request.Metadata)
+
+	response, chargeErr := platform.CreatePayment(ctx, &request)
+
+	link.UseCount++
+	if link.Request.SingleUse {
+		link.Active = false
+	}
+	if updateErr := m.store.Update(link); updateErr != nil && chargeErr == nil {
+		return response, fmt.Errorf("payment succeeded but failed to record link use: %w", updateErr)
+	}
+
+	if chargeErr == nil {
+		m.dispatch(response)
+	}
+	return response, chargeErr
+}
+
+func (m *PaymentLinkManager) dispatch(response *PaymentResponse) {
+	eventType := WebhookEventPaymentCompleted
+	if response.Status != StatusCompleted {
+		eventType = WebhookEventPaymentFailed
+	}
+	event := WebhookEvent{PaymentID: response.PaymentID, Type: eventType, Status: response.Status}
+
+	m.mutex.Lock()
+	listeners := append([]WebhookListener(nil), m.listeners...)
+	m.mutex.Unlock()
+	dispatch(listeners, event)
+}
+
+// validatePaymentLinkCharge checks amount/currency against link's constraints, independent of
+// any platform call
+func validatePaymentLinkCharge(link *PaymentLink, amount float64, currency string) error {
+	if !link.Active {
+		return ErrPaymentLinkInactive
+	}
+	if !link.Request.ExpiresAt.IsZero() && time.Now().After(link.Request.ExpiresAt) {
+		return ErrPaymentLinkExpired
+	}
+
+	if link.Request.FixedAmount > 0 {
+		if amount != link.Request.FixedAmount {
+			return ErrPaymentLinkAmountOutOfRange
+		}
+	} else {
+		if link.Request.MinAmount > 0 && amount < link.Request.MinAmount {
+			return ErrPaymentLinkAmountOutOfRange
+		}
+		if link.Request.MaxAmount > 0 && amount > link.Request.MaxAmount {
+			return ErrPaymentLinkAmountOutOfRange
+		}
+	}
+
+	if len(link.Request.AllowedCurrencies) > 0 {
+		allowed := false
+		for _, c := range link.Request.AllowedCurrencies {
+			if c == currency {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrPaymentLinkCurrencyNotAllowed
+		}
+	}
+	return nil
+}
+
+// mergePaymentLinkMetadata layers a link's own metadata over whatever the customer's request
+// already set, so a customer-supplied key never silently overwrites the merchant's
+func mergePaymentLinkMetadata(linkMeta, customerMeta map[string]string) map[string]string {
+	if len(linkMeta) == 0 {
+		return customerMeta
+	}
+	merged := make(map[string]string, len(linkMeta)+len(customerMeta))
+	for k, v := range customerMeta {
+		merged[k] = v
+	}
+	for k, v := range linkMeta {
+		merged[k] = v
+	}
+	return merged
+}
+
+// generateLinkToken mints a random 32-byte hex token, following the same crypto/rand
+// convention as NewIdempotencyKey and multi_payment.go's generateSessionToken
+func generateLinkToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
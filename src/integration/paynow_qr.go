@@ -0,0 +1,140 @@
+// PayNow/SGQR QR code generation for Singapore's PayNow
+// SingaporePayNow.CreatePayment previously only surfaced whatever qr_code_url the upstream
+// API happened to return. PayNowQRGenerator assembles a PayNow QR payload locally, per the
+// EMVCo Merchant Presented Mode specification MAS requires Singapore acquirers to follow
+// (the "SGQR" format), so CreatePayment can always populate PaymentResponse.QRCodeData even
+// when the API omits a QR code of its own.
+
+package integration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// payNowGUID identifies the PayNow scheme under an EMVCo merchant account info template
+const payNowGUID = "SG.PAYNOW"
+
+// PayNowProxyType is the kind of identifier a PayNow QR code's proxy value resolves to
+type PayNowProxyType string
+
+const (
+	// PayNowProxyMobile identifies the proxy value as a mobile number
+	PayNowProxyMobile PayNowProxyType = "0"
+	// PayNowProxyUEN identifies the proxy value as a Unique Entity Number
+	PayNowProxyUEN PayNowProxyType = "2"
+)
+
+// PayNowQRGenerator assembles EMVCo Merchant Presented Mode QR payloads for PayNow, encoding
+// them as PNG images via go-qrcode
+type PayNowQRGenerator struct {
+	// MerchantCategoryCode is the 4-digit MCC written to tag 52. Defaults to "0000"
+	// (unspecified) if empty.
+	MerchantCategoryCode string
+	// ProxyType selects whether the UEN passed to GenerateStaticQR/GenerateDynamicQR is
+	// encoded as a mobile number or a UEN proxy. Defaults to PayNowProxyUEN.
+	ProxyType PayNowProxyType
+	// Editable marks whether the payer's banking app lets them change a dynamic QR's
+	// transaction amount before paying.
+	Editable bool
+}
+
+// NewPayNowQRGenerator creates a PayNowQRGenerator with an unspecified merchant category
+// code and a non-editable UEN proxy type
+func NewPayNowQRGenerator() *PayNowQRGenerator {
+	return &PayNowQRGenerator{MerchantCategoryCode: "0000", ProxyType: PayNowProxyUEN}
+}
+
+// GenerateStaticQR builds a reusable PayNow QR payload (point-of-initiation "11") carrying
+// no fixed amount, suitable for printing once and scanning for any amount the payer enters.
+// It returns the raw EMVCo payload string and a 256x256 PNG encoding of it.
+func (g *PayNowQRGenerator) GenerateStaticQR(uen, merchantName, merchantCity string) (string, []byte, error) {
+	return g.generate(uen, merchantName, merchantCity, 0, "", time.Time{}, false)
+}
+
+// GenerateDynamicQR builds a single-use PayNow QR payload (point-of-initiation "12") for one
+// specific amount, bill reference, and expiry, e.g. rendered per-order at checkout. It
+// returns the raw EMVCo payload string and a 256x256 PNG encoding of it.
+func (g *PayNowQRGenerator) GenerateDynamicQR(uen, merchantName, merchantCity string, amount float64, reference string, expiry time.Time) (string, []byte, error) {
+	return g.generate(uen, merchantName, merchantCity, amount, reference, expiry, true)
+}
+
+func (g *PayNowQRGenerator) generate(uen, merchantName, merchantCity string, amount float64, reference string, expiry time.Time, dynamic bool) (string, []byte, error) {
+	mcc := g.MerchantCategoryCode
+	if mcc == "" {
+		mcc = "0000"
+	}
+	proxyType := g.ProxyType
+	if proxyType == "" {
+		proxyType = PayNowProxyUEN
+	}
+	pointOfInitiation := "11"
+	if dynamic {
+		pointOfInitiation = "12"
+	}
+	editableFlag := "0"
+	if g.Editable {
+		editableFlag = "1"
+	}
+
+	merchantAccountInfo := emvTLV("00", payNowGUID) +
+		emvTLV("01", string(proxyType)) +
+		emvTLV("02", uen) +
+		emvTLV("03", editableFlag)
+	if !expiry.IsZero() {
+		merchantAccountInfo += emvTLV("04", expiry.Format("20060102"))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(emvTLV("00", "01")) // payload format indicator
+	sb.WriteString(emvTLV("01", pointOfInitiation))
+	sb.WriteString(emvTLV("26", merchantAccountInfo))
+	sb.WriteString(emvTLV("52", mcc))
+	sb.WriteString(emvTLV("53", "702")) // ISO 4217 numeric code for SGD
+	if dynamic && amount > 0 {
+		sb.WriteString(emvTLV("54", strconv.FormatFloat(amount, 'f', 2, 64)))
+	}
+	sb.WriteString(emvTLV("58", "SG"))
+	sb.WriteString(emvTLV("59", merchantName))
+	sb.WriteString(emvTLV("60", merchantCity))
+	if reference != "" {
+		sb.WriteString(emvTLV("62", emvTLV("01", reference)))
+	}
+	sb.WriteString("6304") // CRC tag/length placeholder; the checksum itself is appended below
+
+	payload := sb.String()
+	payload += fmt.Sprintf("%04X", crc16CCITT([]byte(payload)))
+
+	png, err := qrcode.Encode(payload, qrcode.Medium, 256)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to render PayNow QR as PNG: %w", err)
+	}
+	return payload, png, nil
+}
+
+// emvTLV formats a single EMVCo TLV field: a 2-digit tag ID, a 2-digit zero-padded length,
+// then the value itself
+func emvTLV(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// crc16CCITT computes the CRC16-CCITT checksum (polynomial 0x1021, initial value 0xFFFF)
+// EMVCo QR payloads are terminated with, over data
+func crc16CCITT(data []byte) uint16 {
+	var crc uint16 = 0xFFFF
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = (crc << 1) ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,389 @@
+// Federation-style payout address resolution shared by Laos payment platforms
+// A merchant disbursing LAK to a customer usually only has a human-friendly identifier on
+// hand - a Stellar-style federation address like "alice*umoney.la" or a Lao phone number -
+// not the underlying account number or wallet ID a platform's disbursement endpoint expects.
+// PayoutResolver resolves that identifier to an Account before LaosUMoney/LaosLDB dispatch
+// the payout, the same way Stellar's FederationResolver.Resolve does for its network.
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Account identifies the underlying destination of a payout, once resolved from a
+// human-friendly identifier
+type Account struct {
+	AccountNumber string
+	WalletID      string
+	DisplayName   string
+}
+
+// ErrPayoutDestinationNotFound is returned when a PayoutResolver has no Account for a
+// requested destination
+var ErrPayoutDestinationNotFound = errors.New("payout destination not found")
+
+// PayoutResolver resolves a human-friendly payout destination (a federation address like
+// "alice*umoney.la" or a phone number) to the Account a platform's disbursement endpoint
+// expects
+type PayoutResolver interface {
+	Resolve(ctx context.Context, destination string) (Account, error)
+}
+
+// WithPayoutResolver overrides a platform's default PayoutResolver (a phone number resolver
+// chained with DNS TXT federation lookup). Pass ChainPayoutResolvers to combine it with a
+// StaticPayoutResolver of merchant-configured aliases, or a custom PayoutResolver.
+func WithPayoutResolver(resolver PayoutResolver) PlatformOption {
+	return func(o *platformOptions) { o.payoutResolver = resolver }
+}
+
+// StaticPayoutResolver resolves destinations from a fixed, merchant-configured map, e.g. for
+// aliases a federation/DNS lookup wouldn't know about
+type StaticPayoutResolver map[string]Account
+
+// Resolve looks destination up in the map, returning ErrPayoutDestinationNotFound if absent
+func (r StaticPayoutResolver) Resolve(ctx context.Context, destination string) (Account, error) {
+	if account, ok := r[destination]; ok {
+		return account, nil
+	}
+	return Account{}, ErrPayoutDestinationNotFound
+}
+
+// laoPhoneNumberPattern matches a Lao mobile number in local (020/030-prefixed) or
+// international (+856-prefixed) form
+var laoPhoneNumberPattern = regexp.MustCompile(`^(?:\+856\d{8,9}|0(?:20|30)\d{7,8})$`)
+
+// PhonePayoutResolver resolves a Lao phone number destination directly to an Account, since
+// U-Money and LDB both accept a customer's registered phone number as their disbursement
+// account identifier
+type PhonePayoutResolver struct{}
+
+// Resolve returns destination as an Account.AccountNumber if it matches a Lao phone number,
+// or ErrPayoutDestinationNotFound otherwise
+func (PhonePayoutResolver) Resolve(ctx context.Context, destination string) (Account, error) {
+	if !laoPhoneNumberPattern.MatchString(destination) {
+		return Account{}, ErrPayoutDestinationNotFound
+	}
+	return Account{AccountNumber: destination}, nil
+}
+
+// DNSPayoutResolver resolves a Stellar-style federation address ("user*domain") to an
+// Account via a TXT record lookup at "user._payout.domain", in the form
+// "account=<number> wallet=<id> name=<display name>"
+type DNSPayoutResolver struct {
+	lookupTXT func(name string) ([]string, error)
+}
+
+// NewDNSPayoutResolver builds a DNSPayoutResolver using the system resolver
+func NewDNSPayoutResolver() *DNSPayoutResolver {
+	return &DNSPayoutResolver{lookupTXT: net.LookupTXT}
+}
+
+func (r *DNSPayoutResolver) Resolve(ctx context.Context, destination string) (Account, error) {
+	user, domain, ok := splitFederationAddress(destination)
+	if !ok {
+		return Account{}, ErrPayoutDestinationNotFound
+	}
+
+	records, err := r.lookupTXT(user + "._payout." + domain)
+	if err != nil {
+		return Account{}, fmt.Errorf("failed to resolve payout destination %q: %w", destination, err)
+	}
+	for _, record := range records {
+		if account, ok := parsePayoutTXTRecord(record); ok {
+			return account, nil
+		}
+	}
+	return Account{}, fmt.Errorf("%w: %q", ErrPayoutDestinationNotFound, destination)
+}
+
+// splitFederationAddress splits a "user*domain" destination into its user and domain parts
+func splitFederationAddress(destination string) (user, domain string, ok bool) {
+	parts := strings.SplitN(destination, "*", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// parsePayoutTXTRecord parses a "key=value" space-separated TXT record into an Account.
+// found is false if the record has no recognized account/wallet field.
+func parsePayoutTXTRecord(record string) (account Account, found bool) {
+	for _, field := range strings.Fields(record) {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "account":
+			account.AccountNumber = kv[1]
+			found = true
+		case "wallet":
+			account.WalletID = kv[1]
+			found = true
+		case "name":
+			account.DisplayName = kv[1]
+		}
+	}
+	return account, found
+}
+
+// chainedPayoutResolver tries each of its PayoutResolvers in turn, returning the first
+// successful resolution
+type chainedPayoutResolver []PayoutResolver
+
+// ChainPayoutResolvers combines resolvers into a single PayoutResolver that tries each in
+// order, returning the last error if none resolve destination
+func ChainPayoutResolvers(resolvers ...PayoutResolver) PayoutResolver {
+	return chainedPayoutResolver(resolvers)
+}
+
+func (c chainedPayoutResolver) Resolve(ctx context.Context, destination string) (Account, error) {
+	var lastErr error = ErrPayoutDestinationNotFound
+	for _, resolver := range c {
+		account, err := resolver.Resolve(ctx, destination)
+		if err == nil {
+			return account, nil
+		}
+		lastErr = err
+	}
+	return Account{}, lastErr
+}
+
+// defaultPayoutResolver resolves a Lao phone number directly, falling back to a federation
+// DNS TXT lookup for a "user*domain" address
+func defaultPayoutResolver() PayoutResolver {
+	return ChainPayoutResolvers(PhonePayoutResolver{}, NewDNSPayoutResolver())
+}
+
+// PayoutRequest describes a single disbursement to a human-friendly destination, resolved
+// via a platform's PayoutResolver before dispatch
+type PayoutRequest struct {
+	Destination string
+	Amount      float64
+	Reference   string
+}
+
+// PayoutResponse is the result of a successful Payout call
+type PayoutResponse struct {
+	PayoutID      string
+	Account       Account
+	Amount        float64
+	Currency      string
+	Status        PaymentStatus
+	TransactionID string
+	CreatedAt     time.Time
+}
+
+// BatchPayoutItem is one recipient within a batch submitted to BatchPayout
+type BatchPayoutItem struct {
+	Destination string
+	Amount      float64
+	Reference   string
+}
+
+// BatchPayoutResult pairs a BatchPayoutItem with its individual outcome, so one recipient's
+// failure doesn't prevent the rest of the batch from being reported
+type BatchPayoutResult struct {
+	Item     BatchPayoutItem
+	Response *PayoutResponse
+	Err      error
+}
+
+// Payout resolves request.Destination via p's PayoutResolver and disburses request.Amount
+// LAK to the resulting Account, signing the disbursement with p.generateSignature the same
+// way CreatePayment signs a charge.
+func (p *LaosUMoney) Payout(ctx context.Context, request *PayoutRequest) (*PayoutResponse, error) {
+	account, err := p.payoutResolver.Resolve(ctx, request.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve payout destination %q: %w", request.Destination, err)
+	}
+	return p.disburse(ctx, account, request.Amount, request.Reference)
+}
+
+// BatchPayout disburses each item in items independently, resolving and signing every
+// recipient separately so one recipient's resolution or signature failure doesn't block the
+// rest of the batch.
+func (p *LaosUMoney) BatchPayout(ctx context.Context, items []BatchPayoutItem) []BatchPayoutResult {
+	results := make([]BatchPayoutResult, len(items))
+	for i, item := range items {
+		response, err := p.Payout(ctx, &PayoutRequest{Destination: item.Destination, Amount: item.Amount, Reference: item.Reference})
+		results[i] = BatchPayoutResult{Item: item, Response: response, Err: err}
+	}
+	return results
+}
+
+// disburse submits a single payout of amount to account, returning its result
+func (p *LaosUMoney) disburse(ctx context.Context, account Account, amount float64, reference string) (*PayoutResponse, error) {
+	timestamp := time.Now().Format("20060102150405")
+
+	payoutRequest := map[string]interface{}{
+		"merchant_id":    p.config.MerchantID,
+		"account_number": account.AccountNumber,
+		"wallet_id":      account.WalletID,
+		"amount":         int(amount),
+		"reference":      reference,
+		"timestamp":      timestamp,
+	}
+	payoutRequest["signature"] = p.generateSignature(payoutRequest)
+
+	jsonData, err := json.Marshal(payoutRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payout request: %w", err)
+	}
+
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payout/create", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var payoutResponse map[string]interface{}
+	if err := json.Unmarshal(body, &payoutResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if status, ok := payoutResponse["status"].(string); ok && status != "success" {
+		errorMsg := "unknown error"
+		if msg, ok := payoutResponse["message"].(string); ok {
+			errorMsg = msg
+		}
+		return nil, translateError("unknown_error", fmt.Sprintf("U-Money payout error: %s", errorMsg), p.language, nil)
+	}
+
+	data, ok := payoutResponse["data"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid response format")
+	}
+
+	payoutID, _ := data["payout_id"].(string)
+	transactionID, _ := data["transaction_id"].(string)
+
+	return &PayoutResponse{
+		PayoutID:      payoutID,
+		Account:       account,
+		Amount:        amount,
+		Currency:      "LAK",
+		Status:        StatusPending,
+		TransactionID: transactionID,
+		CreatedAt:     time.Now(),
+	}, nil
+}
+
+// Payout resolves request.Destination via p's PayoutResolver and disburses request.Amount
+// LAK to the resulting Account, signing the disbursement with p.generateSignature the same
+// way CreatePayment signs a charge.
+func (p *LaosLDB) Payout(ctx context.Context, request *PayoutRequest) (*PayoutResponse, error) {
+	account, err := p.payoutResolver.Resolve(ctx, request.Destination)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve payout destination %q: %w", request.Destination, err)
+	}
+	return p.disburse(ctx, account, request.Amount, request.Reference)
+}
+
+// BatchPayout disburses each item in items independently, resolving and signing every
+// recipient separately so one recipient's resolution or signature failure doesn't block the
+// rest of the batch.
+func (p *LaosLDB) BatchPayout(ctx context.Context, items []BatchPayoutItem) []BatchPayoutResult {
+	results := make([]BatchPayoutResult, len(items))
+	for i, item := range items {
+		response, err := p.Payout(ctx, &PayoutRequest{Destination: item.Destination, Amount: item.Amount, Reference: item.Reference})
+		results[i] = BatchPayoutResult{Item: item, Response: response, Err: err}
+	}
+	return results
+}
+
+// disburse submits a single payout of amount to account, returning its result
+func (p *LaosLDB) disburse(ctx context.Context, account Account, amount float64, reference string) (*PayoutResponse, error) {
+	timestamp := time.Now().Format("20060102150405")
+
+	payoutRequest := map[string]interface{}{
+		"merchant_id":    p.config.MerchantID,
+		"account_number": account.AccountNumber,
+		"wallet_id":      account.WalletID,
+		"amount":         int(amount),
+		"reference":      reference,
+		"timestamp":      timestamp,
+	}
+	payoutRequest["signature"] = p.generateSignature(payoutRequest)
+
+	jsonData, err := json.Marshal(payoutRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payout request: %w", err)
+	}
+
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v1/payout/create", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var payoutResponse map[string]interface{}
+	if err := json.Unmarshal(body, &payoutResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	if status, ok := payoutResponse["status"].(string); ok && status != "success" {
+		errorMsg := "unknown error"
+		if msg, ok := payoutResponse["message"].(string); ok {
+			errorMsg = msg
+		}
+		return nil, translateError("unknown_error", fmt.Sprintf("LDB payout error: %s", errorMsg), p.language, nil)
+	}
+
+	data, ok := payoutResponse["data"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid response format")
+	}
+
+	payoutID, _ := data["payout_id"].(string)
+	transactionID, _ := data["transaction_id"].(string)
+
+	return &PayoutResponse{
+		PayoutID:      payoutID,
+		Account:       account,
+		Amount:        amount,
+		Currency:      "LAK",
+		Status:        StatusPending,
+		TransactionID: transactionID,
+		CreatedAt:     time.Now(),
+	}, nil
+}
@@ -0,0 +1,57 @@
+// Redis-backed idempotency storage
+// InMemoryIdempotencyStore loses every recorded key on restart and SQLIdempotencyStore
+// requires a migrated table; RedisIdempotencyStore gives a deployment running more than one
+// instance of a payment platform a shared store with expiry handled by Redis itself, the same
+// way exchange/rate_cache.go's RedisRateCache shares exchange rates across replicas.
+
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisIdempotencyStore is an IdempotencyStore backed by Redis, so every replica of a payment
+// service shares one set of recorded idempotency keys instead of each tracking its own
+type RedisIdempotencyStore struct {
+	client    *redis.Client
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// NewRedisIdempotencyStore creates a RedisIdempotencyStore against client, namespacing every
+// key under keyPrefix and expiring entries from Redis after ttl. A ttl of zero means entries
+// never expire.
+func NewRedisIdempotencyStore(client *redis.Client, keyPrefix string, ttl time.Duration) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client, keyPrefix: keyPrefix, ttl: ttl}
+}
+
+func (s *RedisIdempotencyStore) namespacedKey(key string, operation IdempotentOperation) string {
+	return s.keyPrefix + ":" + idempotencyMapKey(key, operation)
+}
+
+// Get returns the stored response for key/operation, and whether one was found
+func (s *RedisIdempotencyStore) Get(key string, operation IdempotentOperation) (json.RawMessage, bool, error) {
+	raw, err := s.client.Get(context.Background(), s.namespacedKey(key, operation)).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read cached idempotency record: %w", err)
+	}
+	return json.RawMessage(raw), true, nil
+}
+
+// Put persists response under key/operation, expiring it after ttl. A second Put for a key
+// already stored simply overwrites it with the same response, which is a no-op in practice
+// since a given key/operation pair's response never changes.
+func (s *RedisIdempotencyStore) Put(key string, operation IdempotentOperation, response json.RawMessage) error {
+	if err := s.client.Set(context.Background(), s.namespacedKey(key, operation), []byte(response), s.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to write cached idempotency record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// Redis-backed IPN/webhook deduplication
+// InMemoryIPNDeduplicator (see vietnam_webhook.go) loses every remembered reference on restart,
+// which is fine for a single process but lets a multi-instance deployment double-process a
+// redelivered IPN or webhook if two instances each have their own in-memory cache.
+// RedisIPNDeduplicator gives every replica a shared record of what's already been seen, the same
+// way RedisIdempotencyStore shares idempotency records across replicas.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisIPNDeduplicator is an IPNDeduplicator backed by Redis, so every replica of a webhook
+// receiver shares one record of which references have already been processed
+type RedisIPNDeduplicator struct {
+	client    *redis.Client
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// NewRedisIPNDeduplicator creates a RedisIPNDeduplicator against client, namespacing every key
+// under keyPrefix and expiring entries from Redis after ttl. A ttl of zero means entries never
+// expire.
+func NewRedisIPNDeduplicator(client *redis.Client, keyPrefix string, ttl time.Duration) *RedisIPNDeduplicator {
+	return &RedisIPNDeduplicator{client: client, keyPrefix: keyPrefix, ttl: ttl}
+}
+
+// SeenBefore records ref in Redis and reports whether it had already been recorded, atomically:
+// SetNX only succeeds the first time a given ref is set, so a race between two replicas seeing
+// the same ref can't both report it as new
+func (d *RedisIPNDeduplicator) SeenBefore(ref string) (bool, error) {
+	set, err := d.client.SetNX(context.Background(), d.keyPrefix+":"+ref, "1", d.ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record IPN reference: %w", err)
+	}
+	return !set, nil
+}
@@ -0,0 +1,49 @@
+// Request-ID propagation for outbound payment platform API calls, so a single correlation ID
+// can be grepped across an Observer's logs/spans and whatever the platform's own webhook or
+// support tooling echoes back, even for platforms (like MoMo, see vietnam.go) that only embed
+// a request ID in their own request body rather than accepting one as a header.
+
+package integration
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// requestIDHeader is the header an outbound request's correlation ID is sent under
+const requestIDHeader = "X-Request-ID"
+
+// requestIDTransport wraps next, stamping every outbound request with an X-Request-ID header
+// unless the caller already set one
+type requestIDTransport struct {
+	next http.RoundTripper
+}
+
+// newRequestIDTransport wraps next (http.DefaultTransport if nil) so every request made
+// through it carries a unique X-Request-ID header
+func newRequestIDTransport(next http.RoundTripper) *requestIDTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &requestIDTransport{next: next}
+}
+
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get(requestIDHeader) == "" {
+		if id, err := generateRequestID(); err == nil {
+			req = req.Clone(req.Context())
+			req.Header.Set(requestIDHeader, id)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
+
+// generateRequestID returns a random 16-byte hex-encoded correlation ID
+func generateRequestID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,203 @@
+// Circuit-breaker and rate-limiting http.RoundTripper middleware for outbound payment
+// platform calls, composed the same way WithObserver's instrumentation is: wrap a
+// RoundTripper (http.DefaultTransport if nil) and pass the result to WithTransport. A bare
+// *http.Client treats every network hiccup as a hard failure and keeps hammering a struggling
+// endpoint, which is unsafe for a payment system - CircuitBreakerTransport stops that pile-up
+// once an endpoint is clearly down, and RateLimitedTransport keeps one noisy merchant from
+// starving another's outbound calls through the same client.
+
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a circuitBreakerTransport rejects a request because its
+// breaker has tripped open for the request's endpoint
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// ErrUpstreamUnavailable is the typed error a platform surfaces to its caller when an
+// outbound call short-circuits against a tripped circuit breaker, so callers can
+// distinguish "the upstream is down" from an ordinary request failure (e.g. to decide
+// whether to queue the payment for a later retry rather than surfacing it to the customer)
+var ErrUpstreamUnavailable = errors.New("payment platform upstream unavailable")
+
+// CircuitBreakerConfig configures CircuitBreakerTransport
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures (network error or 5xx response)
+	// on one endpoint that trips its breaker open
+	FailureThreshold int
+	// OpenDuration is how long a tripped breaker rejects requests before letting a single
+	// half-open probe request through
+	OpenDuration time.Duration
+}
+
+// DefaultCircuitBreakerConfig trips after 5 consecutive failures and stays open for 30s
+// before probing again
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{FailureThreshold: 5, OpenDuration: 30 * time.Second}
+}
+
+// circuitState tracks one endpoint's consecutive-failure count and open/half-open status
+type circuitState struct {
+	mutex           sync.Mutex
+	consecutiveFail int
+	openUntil       time.Time
+	halfOpenProbing bool
+}
+
+// circuitBreakerTransport trips a separate breaker per (platform, endpoint) pair, where
+// endpoint is derived from the request path the same way observingTransport labels an
+// operation
+type circuitBreakerTransport struct {
+	next     http.RoundTripper
+	config   CircuitBreakerConfig
+	platform string
+
+	mutex    sync.Mutex
+	circuits map[string]*circuitState
+}
+
+// NewCircuitBreakerTransport wraps next (http.DefaultTransport if nil), tripping a separate
+// breaker per distinct endpoint under platform
+func NewCircuitBreakerTransport(next http.RoundTripper, config CircuitBreakerConfig, platform string) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &circuitBreakerTransport{next: next, config: config, platform: platform, circuits: make(map[string]*circuitState)}
+}
+
+func (t *circuitBreakerTransport) stateFor(endpoint string) *circuitState {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	s, ok := t.circuits[endpoint]
+	if !ok {
+		s = &circuitState{}
+		t.circuits[endpoint] = s
+	}
+	return s
+}
+
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := operationFromPath(req.URL.Path)
+	state := t.stateFor(endpoint)
+
+	state.mutex.Lock()
+	now := time.Now()
+	if !state.openUntil.IsZero() && now.Before(state.openUntil) {
+		state.mutex.Unlock()
+		return nil, fmt.Errorf("%w: %s %s", ErrCircuitOpen, t.platform, endpoint)
+	}
+	probing := !state.openUntil.IsZero()
+	if probing {
+		if state.halfOpenProbing {
+			state.mutex.Unlock()
+			return nil, fmt.Errorf("%w: %s %s (probe already in flight)", ErrCircuitOpen, t.platform, endpoint)
+		}
+		state.halfOpenProbing = true
+	}
+	state.mutex.Unlock()
+
+	resp, err := t.next.RoundTrip(req)
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	state.halfOpenProbing = false
+	if err != nil || (resp != nil && resp.StatusCode >= 500) {
+		state.consecutiveFail++
+		if state.consecutiveFail >= t.config.FailureThreshold {
+			state.openUntil = time.Now().Add(t.config.OpenDuration)
+		}
+		return resp, err
+	}
+	state.consecutiveFail = 0
+	state.openUntil = time.Time{}
+	return resp, nil
+}
+
+// ErrRateLimited is returned when a rateLimitedTransport rejects a request because its key
+// has exhausted its token bucket
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimiterConfig configures RateLimitedTransport's per-key token bucket
+type RateLimiterConfig struct {
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// DefaultRateLimiterConfig allows 20 requests/second per key, with bursts up to 20
+func DefaultRateLimiterConfig() RateLimiterConfig {
+	return RateLimiterConfig{RequestsPerSecond: 20, Burst: 20}
+}
+
+// tokenBucket is a classic token-bucket limiter: tokens refill continuously at
+// RequestsPerSecond, capped at Burst, and each request consumes one
+type tokenBucket struct {
+	mutex      sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
+
+func (b *tokenBucket) allow(config RateLimiterConfig) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	now := time.Now()
+	b.tokens += now.Sub(b.lastRefill).Seconds() * config.RequestsPerSecond
+	if b.tokens > float64(config.Burst) {
+		b.tokens = float64(config.Burst)
+	}
+	b.lastRefill = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// rateLimitedTransport enforces a per-key token-bucket rate limit, e.g. one bucket per
+// merchant so a single merchant's traffic spike can't starve another's outbound calls
+// through the same shared client
+type rateLimitedTransport struct {
+	next    http.RoundTripper
+	config  RateLimiterConfig
+	keyFunc func(*http.Request) string
+
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// NewRateLimitedTransport wraps next (http.DefaultTransport if nil), rate-limiting requests
+// per key returned by keyFunc. A nil keyFunc rate-limits every request through next as a
+// single shared key.
+func NewRateLimitedTransport(next http.RoundTripper, config RateLimiterConfig, keyFunc func(*http.Request) string) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if keyFunc == nil {
+		keyFunc = func(*http.Request) string { return "default" }
+	}
+	return &rateLimitedTransport{next: next, config: config, keyFunc: keyFunc, buckets: make(map[string]*tokenBucket)}
+}
+
+func (t *rateLimitedTransport) bucketFor(key string) *tokenBucket {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(t.config.Burst), lastRefill: time.Now()}
+		t.buckets[key] = b
+	}
+	return b
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := t.keyFunc(req)
+	if !t.bucketFor(key).allow(t.config) {
+		return nil, fmt.Errorf("%w: key %s", ErrRateLimited, key)
+	}
+	return t.next.RoundTrip(req)
+}
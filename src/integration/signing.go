@@ -0,0 +1,158 @@
+// Canonical request signing shared by Laos payment platforms
+// generateSignature previously built its string-to-sign with fmt.Sprintf("%v", params[k]) on
+// an interface{} value, which renders a float, bool, or nested map ambiguously (e.g. a
+// nested map[string]interface{} prints as Go's "map[a:1]" literal) and will silently
+// mismatch what an upstream gateway computes once request bodies grow past flat string
+// fields. Canonicalizer replaces that with a deterministic, URL-encoded canonical form, and
+// SignerFunc lets a platform config swap HMAC-SHA256 for HMAC-SHA512 or RSA-SHA256 as some
+// Lao banks are migrating to.
+
+package integration
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Canonicalizer builds a deterministic string-to-sign from a signature payload: keys and
+// values are URL-encoded, nested maps/slices are flattened to dotted keys in lexicographic
+// order, and nil/empty values are skipped when SkipEmpty is set.
+type Canonicalizer struct {
+	// SkipEmpty omits nil and empty-string fields from the canonical form, rather than
+	// including them as "key="
+	SkipEmpty bool
+	// DecimalPrecision is the number of digits after the decimal point a float64 field is
+	// formatted with, e.g. 0 for LAK, which has no minor currency unit
+	DecimalPrecision int
+}
+
+// NewCanonicalizer builds a Canonicalizer that skips empty fields and formats float64
+// amounts to decimalPrecision digits
+func NewCanonicalizer(decimalPrecision int) *Canonicalizer {
+	return &Canonicalizer{SkipEmpty: true, DecimalPrecision: decimalPrecision}
+}
+
+// Canonicalize flattens and URL-encodes params into "key=value" pairs joined with "&", sorted
+// lexicographically by key
+func (c *Canonicalizer) Canonicalize(params map[string]interface{}) string {
+	flattened := make(map[string]string)
+	c.flatten("", params, flattened)
+
+	keys := make([]string, 0, len(flattened))
+	for k := range flattened {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(url.QueryEscape(k))
+		sb.WriteByte('=')
+		sb.WriteString(url.QueryEscape(flattened[k]))
+	}
+	return sb.String()
+}
+
+// flatten walks value, writing each scalar leaf into out keyed by its dotted path from
+// prefix. A nested map's keys are visited in lexicographic order so the resulting dotted
+// paths are deterministic regardless of Go's randomized map iteration order.
+func (c *Canonicalizer) flatten(prefix string, value interface{}, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			c.flatten(dottedKey(prefix, k), v[k], out)
+		}
+	case []interface{}:
+		for i, item := range v {
+			c.flatten(dottedKey(prefix, strconv.Itoa(i)), item, out)
+		}
+	default:
+		formatted := c.formatScalar(value)
+		if c.SkipEmpty && formatted == "" {
+			return
+		}
+		out[prefix] = formatted
+	}
+}
+
+func dottedKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// formatScalar renders value deterministically: integers and strings as-is, floats fixed to
+// DecimalPrecision digits (never scientific notation), and anything else via fmt.Sprintf as
+// a last resort
+func (c *Canonicalizer) formatScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', c.DecimalPrecision, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// SignerFunc signs message under key, returning the signature hex-encoded. A platform config
+// exposes this so a merchant can plug in HMAC-SHA512 or RSA-SHA256 in place of the default
+// HMAC-SHA256.
+type SignerFunc func(key, message []byte) (string, error)
+
+// HMACSHA256Signer is the default SignerFunc, matching every existing Laos platform's
+// upstream signature scheme
+var HMACSHA256Signer SignerFunc = func(key, message []byte) (string, error) {
+	h := hmac.New(sha256.New, key)
+	h.Write(message)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HMACSHA512Signer is an HMAC-SHA512 SignerFunc
+var HMACSHA512Signer SignerFunc = func(key, message []byte) (string, error) {
+	h := hmac.New(sha512.New, key)
+	h.Write(message)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// RSASHA256Signer builds a SignerFunc that signs with privateKey using PKCS#1 v1.5/SHA-256,
+// for the Lao banks migrating off shared-secret HMAC signing. Its key parameter is ignored
+// (kept only to satisfy the SignerFunc signature), since an RSA private key can't be passed
+// as a raw []byte the way an HMAC key can.
+func RSASHA256Signer(privateKey *rsa.PrivateKey) SignerFunc {
+	return func(_, message []byte) (string, error) {
+		digest := sha256.Sum256(message)
+		signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", fmt.Errorf("failed to sign with RSA-SHA256: %w", err)
+		}
+		return hex.EncodeToString(signature), nil
+	}
+}
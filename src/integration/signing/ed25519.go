@@ -0,0 +1,45 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+)
+
+// Ed25519Signer signs and verifies data with Ed25519, base64-encoded. Either key may be left
+// nil if this signer is only ever used in one direction: Sign needs PrivateKey, Verify needs
+// PublicKey.
+type Ed25519Signer struct {
+	PrivateKey ed25519.PrivateKey
+	PublicKey  ed25519.PublicKey
+}
+
+// NewEd25519Signer builds an Ed25519Signer from a key pair. Pass a nil privateKey for a
+// verify-only signer, or a nil publicKey (derived automatically from privateKey when
+// privateKey is non-nil) for a sign-only one.
+func NewEd25519Signer(privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) *Ed25519Signer {
+	if publicKey == nil && privateKey != nil {
+		publicKey = privateKey.Public().(ed25519.PublicKey)
+	}
+	return &Ed25519Signer{PrivateKey: privateKey, PublicKey: publicKey}
+}
+
+// Sign returns data's base64-encoded Ed25519 signature
+func (s *Ed25519Signer) Sign(data []byte) (string, error) {
+	if s.PrivateKey == nil {
+		return "", errors.New("signing: Ed25519Signer has no PrivateKey configured")
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.PrivateKey, data)), nil
+}
+
+// Verify reports whether signature is data's valid base64-encoded Ed25519 signature
+func (s *Ed25519Signer) Verify(data []byte, signature string) bool {
+	if s.PublicKey == nil {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(s.PublicKey, data, decoded)
+}
@@ -0,0 +1,67 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+)
+
+// HMACSHA256Signer signs and verifies data with HMAC-SHA256, hex-encoded. This is the
+// algorithm most of the package's Southeast Asian gateway integrations (OVO, Wing, ABA, PayNow,
+// NETS) use today.
+type HMACSHA256Signer struct {
+	secret []byte
+}
+
+// NewHMACSHA256Signer builds an HMACSHA256Signer over secret
+func NewHMACSHA256Signer(secret []byte) *HMACSHA256Signer {
+	return &HMACSHA256Signer{secret: secret}
+}
+
+// Sign returns data's hex-encoded HMAC-SHA256
+func (s *HMACSHA256Signer) Sign(data []byte) (string, error) {
+	return hmacSign(sha256.New, s.secret, data), nil
+}
+
+// Verify reports whether signature is data's valid hex-encoded HMAC-SHA256
+func (s *HMACSHA256Signer) Verify(data []byte, signature string) bool {
+	return hmacVerify(sha256.New, s.secret, data, signature)
+}
+
+// HMACSHA512Signer signs and verifies data with HMAC-SHA512, hex-encoded
+type HMACSHA512Signer struct {
+	secret []byte
+}
+
+// NewHMACSHA512Signer builds an HMACSHA512Signer over secret
+func NewHMACSHA512Signer(secret []byte) *HMACSHA512Signer {
+	return &HMACSHA512Signer{secret: secret}
+}
+
+// Sign returns data's hex-encoded HMAC-SHA512
+func (s *HMACSHA512Signer) Sign(data []byte) (string, error) {
+	return hmacSign(sha512.New, s.secret, data), nil
+}
+
+// Verify reports whether signature is data's valid hex-encoded HMAC-SHA512
+func (s *HMACSHA512Signer) Verify(data []byte, signature string) bool {
+	return hmacVerify(sha512.New, s.secret, data, signature)
+}
+
+func hmacSign(newHash func() hash.Hash, secret, data []byte) string {
+	mac := hmac.New(newHash, secret)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func hmacVerify(newHash func() hash.Hash, secret, data []byte, signature string) bool {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(newHash, secret)
+	mac.Write(data)
+	return hmac.Equal(decoded, mac.Sum(nil))
+}
@@ -0,0 +1,56 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// RSASigner signs and verifies data with RSASSA-PKCS1-v1_5 over SHA-256, base64-encoded - the
+// scheme Midtrans (GoPay's production gateway) requires for its signed notification callbacks.
+// Either key may be left nil if this signer is only ever used in one direction: Sign needs
+// PrivateKey, Verify needs PublicKey.
+type RSASigner struct {
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+}
+
+// NewRSASigner builds an RSASigner from a key pair. Pass a nil privateKey for a
+// verify-only signer, or a nil publicKey (derived automatically from privateKey.PublicKey when
+// privateKey is non-nil) for a sign-only one.
+func NewRSASigner(privateKey *rsa.PrivateKey, publicKey *rsa.PublicKey) *RSASigner {
+	if publicKey == nil && privateKey != nil {
+		publicKey = &privateKey.PublicKey
+	}
+	return &RSASigner{PrivateKey: privateKey, PublicKey: publicKey}
+}
+
+// Sign returns data's base64-encoded RSASSA-PKCS1-v1_5/SHA-256 signature
+func (s *RSASigner) Sign(data []byte) (string, error) {
+	if s.PrivateKey == nil {
+		return "", errors.New("signing: RSASigner has no PrivateKey configured")
+	}
+	digest := sha256.Sum256(data)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// Verify reports whether signature is data's valid base64-encoded RSASSA-PKCS1-v1_5/SHA-256
+// signature
+func (s *RSASigner) Verify(data []byte, signature string) bool {
+	if s.PublicKey == nil {
+		return false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	digest := sha256.Sum256(data)
+	return rsa.VerifyPKCS1v15(s.PublicKey, crypto.SHA256, digest[:], decoded) == nil
+}
@@ -5,39 +5,95 @@ package integration
 
 import (
 	"bytes"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
-	"sort"
-	"strings"
+	"strconv"
 	"time"
 )
 
+// postSingaporeSigned is the single marshal/sign/post/unmarshal helper SingaporePayNow and
+// SingaporeNets build CreatePayment/GetPaymentStatus/RefundPayment on. It stamps params with
+// a fresh timestamp and HMAC-SHA256 signature, POSTs the result through retryClient (which
+// retries on network errors or 5xx responses with exponential backoff and honors ctx
+// cancellation), and decodes the JSON response body. When idempotencyKey is non-empty it's
+// sent as an Idempotency-Key header, so a retried CreatePayment/RefundPayment can't
+// double-charge even if the first attempt's response was lost to a network error. A tripped
+// circuit breaker is surfaced as ErrUpstreamUnavailable rather than the raw transport error.
+func postSingaporeSigned(ctx context.Context, retryClient *RetryingHTTPClient, endpoint, path, merchantKey, idempotencyKey string, params map[string]interface{}) (map[string]interface{}, error) {
+	params["timestamp"] = time.Now().Unix()
+	params["signature"] = singaporeNotificationSignature(params, merchantKey)
+
+	jsonData, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+path, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if idempotencyKey != "" {
+			req.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		return req, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrCircuitOpen) {
+			return nil, fmt.Errorf("%w: %s", ErrUpstreamUnavailable, err)
+		}
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return parsed, nil
+}
+
 // SingaporePayNowConfig holds configuration for PayNow integration
 type SingaporePayNowConfig struct {
-	MerchantID     string
-	MerchantKey    string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	MerchantID       string
+	MerchantKey      string
+	APIEndpoint      string
+	CallbackURL      string
+	RedirectURL      string
+	TestMode         bool
+	IdempotencyStore IdempotencyStore // optional; when set, CreatePayment/RefundPayment short-circuit retried IdempotencyKeys
+
+	// UEN, MerchantName, and MerchantCity are the PayNow proxy value and merchant details
+	// CreatePayment encodes into PaymentResponse.QRCodeData via QRGenerator. QR generation
+	// is skipped when UEN is empty.
+	UEN          string
+	MerchantName string
+	MerchantCity string
+	// QRGenerator overrides the default PayNowQRGenerator CreatePayment encodes
+	// PaymentResponse.QRCodeData with
+	QRGenerator *PayNowQRGenerator
 }
 
 // SingaporePayNow implements PaymentPlatform interface for Singapore's PayNow
 type SingaporePayNow struct {
-	config SingaporePayNowConfig
-	client *http.Client
+	config      SingaporePayNowConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	qrGenerator *PayNowQRGenerator
 }
 
 // NewSingaporePayNow creates a new PayNow payment platform
-func NewSingaporePayNow(config SingaporePayNowConfig) *SingaporePayNow {
+func NewSingaporePayNow(config SingaporePayNowConfig, opts ...PlatformOption) *SingaporePayNow {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -47,11 +103,27 @@ func NewSingaporePayNow(config SingaporePayNowConfig) *SingaporePayNow {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "PayNow")
+
+	qrGenerator := config.QRGenerator
+	if qrGenerator == nil {
+		qrGenerator = NewPayNowQRGenerator()
+	}
+
 	return &SingaporePayNow{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		qrGenerator: qrGenerator,
 	}
 }
 
@@ -75,8 +147,15 @@ func (p *SingaporePayNow) GetSupportedCurrencies() []string {
 	return []string{"SGD"}
 }
 
-// CreatePayment creates a payment
-func (p *SingaporePayNow) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+// CreatePayment creates a payment. opts may override the call's timeout or idempotency key.
+func (p *SingaporePayNow) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "SGD" {
 		return nil, errors.New("currency must be SGD for PayNow payments")
@@ -86,6 +165,20 @@ func (p *SingaporePayNow) CreatePayment(request *PaymentRequest) (*PaymentRespon
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = request.OrderID
+	}
+
+	if cached, found, err := p.checkIdempotentPayment(idempotencyKey); err != nil {
+		return nil, err
+	} else if found {
+		return cached, nil
+	}
+
 	// Prepare PayNow request
 	payNowRequest := map[string]interface{}{
 		"merchant_id":    p.config.MerchantID,
@@ -97,41 +190,12 @@ func (p *SingaporePayNow) CreatePayment(request *PaymentRequest) (*PaymentRespon
 		"customer_name":  request.CustomerName,
 		"customer_email": request.CustomerEmail,
 		"customer_phone": request.CustomerPhone,
-		"timestamp":      time.Now().Unix(),
 		"payment_type":   "paynow",
 	}
 
-	// Generate signature
-	signature := p.generateSignature(payNowRequest)
-	payNowRequest["signature"] = signature
-
-	// Convert to JSON
-	jsonData, err := json.Marshal(payNowRequest)
+	payNowResponse, err := postSingaporeSigned(ctx, p.retryClient, p.config.APIEndpoint, "/api/v1/payment/create", p.config.MerchantKey, idempotencyKey, payNowRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	// Make API request
-	resp, err := p.client.Post(
-		p.config.APIEndpoint+"/api/v1/payment/create",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Parse response
-	var payNowResponse map[string]interface{}
-	if err := json.Unmarshal(body, &payNowResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, err
 	}
 
 	// Check for errors
@@ -169,50 +233,79 @@ func (p *SingaporePayNow) CreatePayment(request *PaymentRequest) (*PaymentRespon
 		ExpiresAt:     time.Unix(int64(expiryTime), 0),
 		Metadata:      make(map[string]string),
 	}
+	p.populateQRCodeData(request, response)
 
+	p.storeIdempotentPayment(idempotencyKey, response)
 	return response, nil
 }
 
-// GetPaymentStatus gets the status of a payment
-func (p *SingaporePayNow) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
-	// Prepare status request
-	statusRequest := map[string]interface{}{
-		"merchant_id": p.config.MerchantID,
-		"payment_id":  request.PaymentID,
-		"timestamp":   time.Now().Unix(),
+// populateQRCodeData locally renders an EMVCo/SGQR PayNow QR payload into response's
+// QRCodeData, so a merchant can always display a scannable QR code even if the upstream API
+// omitted qr_code_url. It's skipped (and any rendering error ignored) when no UEN is
+// configured, since QR rendering is a best-effort convenience on top of the API's own
+// PaymentURL/QRCodeURL, not a required part of a successful CreatePayment call.
+func (p *SingaporePayNow) populateQRCodeData(request *PaymentRequest, response *PaymentResponse) {
+	if p.config.UEN == "" {
+		return
 	}
-
-	// Generate signature
-	signature := p.generateSignature(statusRequest)
-	statusRequest["signature"] = signature
-
-	// Convert to JSON
-	jsonData, err := json.Marshal(statusRequest)
+	payload, _, err := p.qrGenerator.GenerateDynamicQR(p.config.UEN, p.config.MerchantName, p.config.MerchantCity, request.Amount, request.OrderID, response.ExpiresAt)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return
 	}
+	response.QRCodeData = payload
+}
 
-	// Make API request
-	resp, err := p.client.Post(
-		p.config.APIEndpoint+"/api/v1/payment/status",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+// checkIdempotentPayment returns a previously stored PaymentResponse for idempotencyKey,
+// if a store is configured and one exists
+func (p *SingaporePayNow) checkIdempotentPayment(idempotencyKey string) (*PaymentResponse, bool, error) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return nil, false, nil
+	}
+	raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationCreatePayment)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
+		return nil, false, fmt.Errorf("failed to check idempotency store: %w", err)
 	}
-	defer resp.Body.Close()
+	if !found {
+		return nil, false, nil
+	}
+	var response PaymentResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, false, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+	}
+	return &response, true, nil
+}
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+// storeIdempotentPayment persists response under idempotencyKey when a store is
+// configured, silently skipping persistence rather than failing the call if it errors
+func (p *SingaporePayNow) storeIdempotentPayment(idempotencyKey string, response *PaymentResponse) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return
+	}
+	raw, err := json.Marshal(response)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return
 	}
+	_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationCreatePayment, raw)
+}
 
-	// Parse response
-	var statusResponse map[string]interface{}
-	if err := json.Unmarshal(body, &statusResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+// GetPaymentStatus gets the status of a payment
+func (p *SingaporePayNow) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	// Prepare status request
+	statusRequest := map[string]interface{}{
+		"merchant_id": p.config.MerchantID,
+		"payment_id":  request.PaymentID,
+	}
+
+	statusResponse, err := postSingaporeSigned(ctx, p.retryClient, p.config.APIEndpoint, "/api/v1/payment/status", p.config.MerchantKey, "", statusRequest)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check for errors
@@ -272,8 +365,37 @@ func (p *SingaporePayNow) GetPaymentStatus(request *PaymentStatusRequest) (*Paym
 	return response, nil
 }
 
-// RefundPayment refunds a payment
-func (p *SingaporePayNow) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+// RefundPayment refunds a payment. opts may override the call's timeout or idempotency key.
+func (p *SingaporePayNow) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = request.PaymentID + ":" + request.RefundID
+	}
+
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationRefundPayment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check idempotency store: %w", err)
+		}
+		if found {
+			var cached RefundResponse
+			if err := json.Unmarshal(raw, &cached); err != nil {
+				return nil, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+			}
+			return &cached, nil
+		}
+	}
+
 	// Prepare refund request
 	refundRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
@@ -281,40 +403,11 @@ func (p *SingaporePayNow) RefundPayment(request *RefundRequest) (*RefundResponse
 		"refund_id":   request.RefundID,
 		"amount":      fmt.Sprintf("%.2f", request.Amount),
 		"reason":      request.Reason,
-		"timestamp":   time.Now().Unix(),
-	}
-
-	// Generate signature
-	signature := p.generateSignature(refundRequest)
-	refundRequest["signature"] = signature
-
-	// Convert to JSON
-	jsonData, err := json.Marshal(refundRequest)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Make API request
-	resp, err := p.client.Post(
-		p.config.APIEndpoint+"/api/v1/payment/refund",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	refundResponse, err := postSingaporeSigned(ctx, p.retryClient, p.config.APIEndpoint, "/api/v1/payment/refund", p.config.MerchantKey, idempotencyKey, refundRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Parse response
-	var refundResponse map[string]interface{}
-	if err := json.Unmarshal(body, &refundResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, err
 	}
 
 	// Check for errors
@@ -347,56 +440,41 @@ func (p *SingaporePayNow) RefundPayment(request *RefundRequest) (*RefundResponse
 		CreatedAt:     time.Now(),
 	}
 
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		if raw, err := json.Marshal(response); err == nil {
+			_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationRefundPayment, raw)
+		}
+	}
+
 	return response, nil
 }
 
-// generateSignature generates a signature for PayNow requests
+// generateSignature generates a signature for PayNow requests, the same sort-keys-and-join
+// HMAC-SHA256 algorithm SingaporePayNowWebhookHandler verifies inbound notifications with
 func (p *SingaporePayNow) generateSignature(params map[string]interface{}) string {
-	// Sort keys
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Build string to sign
-	var sb strings.Builder
-	for _, k := range keys {
-		sb.WriteString(k)
-		sb.WriteString("=")
-		sb.WriteString(fmt.Sprintf("%v", params[k]))
-		sb.WriteString("&")
-	}
-	// Remove trailing &
-	signStr := sb.String()
-	if len(signStr) > 0 {
-		signStr = signStr[:len(signStr)-1]
-	}
-
-	// Generate HMAC-SHA256
-	h := hmac.New(sha256.New, []byte(p.config.MerchantKey))
-	h.Write([]byte(signStr))
-	return hex.EncodeToString(h.Sum(nil))
+	return singaporeNotificationSignature(params, p.config.MerchantKey)
 }
 
 // SingaporeNetsConfig holds configuration for NETS integration
 type SingaporeNetsConfig struct {
-	MerchantID     string
-	MerchantKey    string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	MerchantID       string
+	MerchantKey      string
+	APIEndpoint      string
+	CallbackURL      string
+	RedirectURL      string
+	TestMode         bool
+	IdempotencyStore IdempotencyStore // optional; when set, CreatePayment/RefundPayment short-circuit retried IdempotencyKeys
 }
 
 // SingaporeNets implements PaymentPlatform interface for Singapore's NETS
 type SingaporeNets struct {
-	config SingaporeNetsConfig
-	client *http.Client
+	config      SingaporeNetsConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
 }
 
 // NewSingaporeNets creates a new NETS payment platform
-func NewSingaporeNets(config SingaporeNetsConfig) *SingaporeNets {
+func NewSingaporeNets(config SingaporeNetsConfig, opts ...PlatformOption) *SingaporeNets {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -406,11 +484,21 @@ func NewSingaporeNets(config SingaporeNetsConfig) *SingaporeNets {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "NETS")
+
 	return &SingaporeNets{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
 	}
 }
 
@@ -434,8 +522,15 @@ func (p *SingaporeNets) GetSupportedCurrencies() []string {
 	return []string{"SGD"}
 }
 
-// CreatePayment creates a payment
-func (p *SingaporeNets) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+// CreatePayment creates a payment. opts may override the call's timeout or idempotency key.
+func (p *SingaporeNets) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "SGD" {
 		return nil, errors.New("currency must be SGD for NETS payments")
@@ -445,6 +540,20 @@ func (p *SingaporeNets) CreatePayment(request *PaymentRequest) (*PaymentResponse
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = request.OrderID
+	}
+
+	if cached, found, err := p.checkIdempotentPayment(idempotencyKey); err != nil {
+		return nil, err
+	} else if found {
+		return cached, nil
+	}
+
 	// Prepare NETS request
 	netsRequest := map[string]interface{}{
 		"merchant_id":    p.config.MerchantID,
@@ -456,7 +565,6 @@ func (p *SingaporeNets) CreatePayment(request *PaymentRequest) (*PaymentResponse
 		"customer_name":  request.CustomerName,
 		"customer_email": request.CustomerEmail,
 		"customer_phone": request.CustomerPhone,
-		"timestamp":      time.Now().Unix(),
 	}
 
 	// Set payment method
@@ -466,37 +574,9 @@ func (p *SingaporeNets) CreatePayment(request *PaymentRequest) (*PaymentResponse
 		netsRequest["payment_type"] = "qr"
 	}
 
-	// Generate signature
-	signature := p.generateSignature(netsRequest)
-	netsRequest["signature"] = signature
-
-	// Convert to JSON
-	jsonData, err := json.Marshal(netsRequest)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	// Make API request
-	resp, err := p.client.Post(
-		p.config.APIEndpoint+"/api/v1/payment/create",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
+	netsResponse, err := postSingaporeSigned(ctx, p.retryClient, p.config.APIEndpoint, "/api/v1/payment/create", p.config.MerchantKey, idempotencyKey, netsRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Parse response
-	var netsResponse map[string]interface{}
-	if err := json.Unmarshal(body, &netsResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, err
 	}
 
 	// Check for errors
@@ -535,49 +615,61 @@ func (p *SingaporeNets) CreatePayment(request *PaymentRequest) (*PaymentResponse
 		Metadata:      make(map[string]string),
 	}
 
+	p.storeIdempotentPayment(idempotencyKey, response)
 	return response, nil
 }
 
-// GetPaymentStatus gets the status of a payment
-func (p *SingaporeNets) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
-	// Prepare status request
-	statusRequest := map[string]interface{}{
-		"merchant_id": p.config.MerchantID,
-		"payment_id":  request.PaymentID,
-		"timestamp":   time.Now().Unix(),
+// checkIdempotentPayment returns a previously stored PaymentResponse for idempotencyKey,
+// if a store is configured and one exists
+func (p *SingaporeNets) checkIdempotentPayment(idempotencyKey string) (*PaymentResponse, bool, error) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return nil, false, nil
 	}
-
-	// Generate signature
-	signature := p.generateSignature(statusRequest)
-	statusRequest["signature"] = signature
-
-	// Convert to JSON
-	jsonData, err := json.Marshal(statusRequest)
+	raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationCreatePayment)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, false, fmt.Errorf("failed to check idempotency store: %w", err)
 	}
+	if !found {
+		return nil, false, nil
+	}
+	var response PaymentResponse
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, false, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+	}
+	return &response, true, nil
+}
 
-	// Make API request
-	resp, err := p.client.Post(
-		p.config.APIEndpoint+"/api/v1/payment/status",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+// storeIdempotentPayment persists response under idempotencyKey when a store is
+// configured, silently skipping persistence rather than failing the call if it errors
+func (p *SingaporeNets) storeIdempotentPayment(idempotencyKey string, response *PaymentResponse) {
+	if idempotencyKey == "" || p.config.IdempotencyStore == nil {
+		return
+	}
+	raw, err := json.Marshal(response)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
+		return
 	}
-	defer resp.Body.Close()
+	_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationCreatePayment, raw)
+}
 
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+// GetPaymentStatus gets the status of a payment
+func (p *SingaporeNets) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
 	}
 
-	// Parse response
-	var statusResponse map[string]interface{}
-	if err := json.Unmarshal(body, &statusResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	// Prepare status request
+	statusRequest := map[string]interface{}{
+		"merchant_id": p.config.MerchantID,
+		"payment_id":  request.PaymentID,
+	}
+
+	statusResponse, err := postSingaporeSigned(ctx, p.retryClient, p.config.APIEndpoint, "/api/v1/payment/status", p.config.MerchantKey, "", statusRequest)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check for errors
@@ -644,8 +736,37 @@ func (p *SingaporeNets) GetPaymentStatus(request *PaymentStatusRequest) (*Paymen
 	return response, nil
 }
 
-// RefundPayment refunds a payment
-func (p *SingaporeNets) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+// RefundPayment refunds a payment. opts may override the call's timeout or idempotency key.
+func (p *SingaporeNets) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+	if idempotencyKey == "" {
+		idempotencyKey = request.PaymentID + ":" + request.RefundID
+	}
+
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		raw, found, err := p.config.IdempotencyStore.Get(idempotencyKey, OperationRefundPayment)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check idempotency store: %w", err)
+		}
+		if found {
+			var cached RefundResponse
+			if err := json.Unmarshal(raw, &cached); err != nil {
+				return nil, fmt.Errorf("failed to parse stored idempotent response: %w", err)
+			}
+			return &cached, nil
+		}
+	}
+
 	// Prepare refund request
 	refundRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
@@ -653,40 +774,11 @@ func (p *SingaporeNets) RefundPayment(request *RefundRequest) (*RefundResponse,
 		"refund_id":   request.RefundID,
 		"amount":      int(request.Amount * 100), // Convert to cents
 		"reason":      request.Reason,
-		"timestamp":   time.Now().Unix(),
-	}
-
-	// Generate signature
-	signature := p.generateSignature(refundRequest)
-	refundRequest["signature"] = signature
-
-	// Convert to JSON
-	jsonData, err := json.Marshal(refundRequest)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Make API request
-	resp, err := p.client.Post(
-		p.config.APIEndpoint+"/api/v1/payment/refund",
-		"application/json",
-		bytes.NewBuffer(jsonData),
-	)
+	refundResponse, err := postSingaporeSigned(ctx, p.retryClient, p.config.APIEndpoint, "/api/v1/payment/refund", p.config.MerchantKey, idempotencyKey, refundRequest)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Parse response
-	var refundResponse map[string]interface{}
-	if err := json.Unmarshal(body, &refundResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, err
 	}
 
 	// Check for errors
@@ -719,34 +811,17 @@ func (p *SingaporeNets) RefundPayment(request *RefundRequest) (*RefundResponse,
 		CreatedAt:     time.Now(),
 	}
 
+	if idempotencyKey != "" && p.config.IdempotencyStore != nil {
+		if raw, err := json.Marshal(response); err == nil {
+			_ = p.config.IdempotencyStore.Put(idempotencyKey, OperationRefundPayment, raw)
+		}
+	}
+
 	return response, nil
 }
 
-// generateSignature generates a signature for NETS requests
+// generateSignature generates a signature for NETS requests, the same sort-keys-and-join
+// HMAC-SHA256 algorithm SingaporeNetsWebhookHandler verifies inbound notifications with
 func (p *SingaporeNets) generateSignature(params map[string]interface{}) string {
-	// Sort keys
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Build string to sign
-	var sb strings.Builder
-	for _, k := range keys {
-		sb.WriteString(k)
-		sb.WriteString("=")
-		sb.WriteString(fmt.Sprintf("%v", params[k]))
-		sb.WriteString("&")
-	}
-	// Remove trailing &
-	signStr := sb.String()
-	if len(signStr) > 0 {
-		signStr = signStr[:len(signStr)-1]
-	}
-
-	// Generate HMAC-SHA256
-	h := hmac.New(sha256.New, []byte(p.config.MerchantKey))
-	h.Write([]byte(signStr))
-	return hex.EncodeToString(h.Sum(nil))
+	return singaporeNotificationSignature(params, p.config.MerchantKey)
 }
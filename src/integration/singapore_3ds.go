@@ -0,0 +1,206 @@
+// 3-D Secure (EMV 3DS2) challenge flow support for Singapore's NETS card payments
+// SingaporeNets.CreatePayment only ever returned a payment_url, which doesn't give a
+// merchant anywhere to embed the ACS challenge form SG acquirers require for PSD2-style SCA
+// on card transactions. ThreeDSecureCapable is an optional interface (mirroring
+// MultiPaymentCapable's type-assert-to-discover pattern) that SingaporeNets implements.
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ThreeDSRequest initiates a 3DS2 challenge for a card payment
+type ThreeDSRequest struct {
+	PaymentID  string
+	OrderID    string
+	Amount     float64
+	Currency   string
+	CardNumber string
+	ReturnURL  string // where the issuer's ACS redirects the payer's browser after the challenge
+}
+
+// Init3DSResponse carries the issuer's ACS challenge form for a merchant to embed in an
+// iframe, mirroring the htmlContent/paymentId shape of Craftgate's Init3DSPaymentResponse
+type Init3DSResponse struct {
+	HTMLContent      string
+	PaymentID        string
+	ThreeDSSessionID string
+}
+
+// Complete3DSRequest carries the issuer's PaRes/CRes callback, posted back to
+// Complete3DSPayment once the payer finishes the ACS challenge
+type Complete3DSRequest struct {
+	PaymentID        string
+	ThreeDSSessionID string
+	PaRes            string // 3DS1-style payer authentication response
+	CRes             string // 3DS2-style challenge response
+}
+
+// ThreeDSecureCapable is implemented by a platform that supports an EMV 3DS2 challenge flow
+// for card payments - an Init step returning an ACS form to embed, and a Complete step
+// consuming the issuer's callback - rather than only a bare redirect payment_url. Type-assert
+// a PaymentPlatform pulled from PaymentPlatformRegistry against this interface to discover
+// whether it supports the flow.
+type ThreeDSecureCapable interface {
+	Init3DSPayment(ctx context.Context, request *ThreeDSRequest) (*Init3DSResponse, error)
+	Complete3DSPayment(ctx context.Context, request *Complete3DSRequest) (*PaymentResponse, error)
+}
+
+// Init3DSPayment starts a 3DS2 challenge for a card payment, returning the ACS form the
+// merchant embeds in an iframe
+func (p *SingaporeNets) Init3DSPayment(ctx context.Context, request *ThreeDSRequest) (*Init3DSResponse, error) {
+	threeDSRequest := map[string]interface{}{
+		"merchant_id": p.config.MerchantID,
+		"payment_id":  request.PaymentID,
+		"order_id":    request.OrderID,
+		"amount":      int(request.Amount * 100), // Convert to cents
+		"currency":    request.Currency,
+		"card_number": request.CardNumber,
+		"return_url":  request.ReturnURL,
+	}
+
+	threeDSResponse, err := postSingaporeSigned(ctx, p.retryClient, p.config.APIEndpoint, "/api/v1/payment/3ds/init", p.config.MerchantKey, "", threeDSRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if status, ok := threeDSResponse["status"].(string); ok && status != "success" {
+		errorMsg := "unknown error"
+		if msg, ok := threeDSResponse["message"].(string); ok {
+			errorMsg = msg
+		}
+		return nil, fmt.Errorf("NETS 3DS init error: %s", errorMsg)
+	}
+
+	data, ok := threeDSResponse["data"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid response format")
+	}
+
+	htmlContent, _ := data["html_content"].(string)
+	paymentID, _ := data["payment_id"].(string)
+	sessionID, _ := data["three_ds_session_id"].(string)
+
+	return &Init3DSResponse{
+		HTMLContent:      htmlContent,
+		PaymentID:        paymentID,
+		ThreeDSSessionID: sessionID,
+	}, nil
+}
+
+// Complete3DSPayment consumes the issuer's PaRes/CRes callback and returns the finalized
+// PaymentResponse
+func (p *SingaporeNets) Complete3DSPayment(ctx context.Context, request *Complete3DSRequest) (*PaymentResponse, error) {
+	completeRequest := map[string]interface{}{
+		"merchant_id":         p.config.MerchantID,
+		"payment_id":          request.PaymentID,
+		"three_ds_session_id": request.ThreeDSSessionID,
+		"pa_res":              request.PaRes,
+		"c_res":               request.CRes,
+	}
+
+	completeResponse, err := postSingaporeSigned(ctx, p.retryClient, p.config.APIEndpoint, "/api/v1/payment/3ds/complete", p.config.MerchantKey, "", completeRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	if status, ok := completeResponse["status"].(string); ok && status != "success" {
+		errorMsg := "unknown error"
+		if msg, ok := completeResponse["message"].(string); ok {
+			errorMsg = msg
+		}
+		return nil, fmt.Errorf("NETS 3DS completion error: %s", errorMsg)
+	}
+
+	data, ok := completeResponse["data"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("invalid response format")
+	}
+
+	paymentStatus, _ := data["status"].(string)
+	amountCents, _ := data["amount"].(float64)
+	transactionID, _ := data["transaction_id"].(string)
+
+	status := StatusPending
+	switch paymentStatus {
+	case "completed", "success":
+		status = StatusCompleted
+	case "failed":
+		status = StatusFailed
+	case "cancelled":
+		status = StatusCancelled
+	}
+
+	return &PaymentResponse{
+		PaymentID:     request.PaymentID,
+		Status:        status,
+		Amount:        amountCents / 100,
+		Currency:      "SGD",
+		PaymentMethod: MethodCreditCard,
+		TransactionID: transactionID,
+		Metadata:      make(map[string]string),
+	}, nil
+}
+
+// ThreeDSCallbackHandler terminates the issuer's ACS redirect back to the merchant site,
+// completing the 3DS2 challenge against nets and firing every registered OnComplete callback
+// with the result, so the merchant can show the payer an outcome page without polling.
+type ThreeDSCallbackHandler struct {
+	nets *SingaporeNets
+
+	mutex      sync.Mutex
+	onComplete []func(*PaymentResponse, error)
+}
+
+// NewThreeDSCallbackHandler creates a ThreeDSCallbackHandler completing challenges against nets
+func NewThreeDSCallbackHandler(nets *SingaporeNets) *ThreeDSCallbackHandler {
+	return &ThreeDSCallbackHandler{nets: nets}
+}
+
+// OnComplete registers fn to run with the result of every 3DS2 challenge this handler
+// completes, whether it succeeded or failed
+func (h *ThreeDSCallbackHandler) OnComplete(fn func(*PaymentResponse, error)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onComplete = append(h.onComplete, fn)
+}
+
+// ServeHTTP reads the issuer's PaRes/CRes form POST, completes the 3DS2 challenge, and
+// dispatches the result to every registered OnComplete callback
+func (h *ThreeDSCallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "failed to parse callback form", http.StatusBadRequest)
+		return
+	}
+
+	request := &Complete3DSRequest{
+		PaymentID:        r.FormValue("payment_id"),
+		ThreeDSSessionID: r.FormValue("MD"),
+		PaRes:            r.FormValue("PaRes"),
+		CRes:             r.FormValue("CRes"),
+	}
+
+	response, err := h.nets.Complete3DSPayment(r.Context(), request)
+
+	h.mutex.Lock()
+	var callbacks []func(*PaymentResponse, error)
+	callbacks = append(callbacks, h.onComplete...)
+	h.mutex.Unlock()
+	for _, callback := range callbacks {
+		callback(response, err)
+	}
+
+	if err != nil {
+		http.Error(w, "3DS challenge completion failed", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "<html><body>Payment %s complete.</body></html>", response.PaymentID)
+}
@@ -0,0 +1,415 @@
+// Inbound webhook handling for Singapore PayNow/NETS payment notifications
+// SingaporePayNowConfig.CallbackURL and SingaporeNetsConfig.CallbackURL are sent on every
+// outbound CreatePayment call, but nothing in this package previously handled the
+// asynchronous POSTs PayNow/NETS send back to that URL on completion. The handlers below
+// verify each notification's signature with the same sort-keys-and-join algorithm
+// generateSignature uses outbound, dedupe it against a pluggable NotificationStore, and
+// dispatch it to whichever of OnPaymentCompleted/OnPaymentFailed/OnRefunded applies.
+
+package integration
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PaymentNotification is the normalized form of an inbound PayNow/NETS payment notification
+type PaymentNotification struct {
+	PaymentID     string
+	TransactionID string
+	OrderID       string
+	Status        PaymentStatus
+	Amount        float64
+	Currency      string
+	Raw           json.RawMessage
+}
+
+// dedupeKey is the key NotificationStore dedupes a PaymentNotification on: its payment ID and
+// transaction ID together, since a platform may reuse a payment ID across a payment's own
+// notification and a later refund notification for the same payment
+func (n PaymentNotification) dedupeKey() string {
+	return n.PaymentID + ":" + n.TransactionID
+}
+
+// NotificationStore deduplicates inbound payment notifications, so a platform's at-least-once
+// webhook redelivery doesn't dispatch the same outcome to listeners twice
+type NotificationStore interface {
+	// SeenBefore reports whether key has already been marked seen
+	SeenBefore(key string) (bool, error)
+	// MarkSeen records key as seen
+	MarkSeen(key string) error
+}
+
+// InMemoryNotificationStore is a process-local NotificationStore backed by a map. It is safe
+// for concurrent use but, like InMemoryIdempotencyStore, doesn't survive a process restart.
+type InMemoryNotificationStore struct {
+	mutex sync.Mutex
+	seen  map[string]struct{}
+}
+
+// NewInMemoryNotificationStore creates an empty InMemoryNotificationStore
+func NewInMemoryNotificationStore() *InMemoryNotificationStore {
+	return &InMemoryNotificationStore{seen: make(map[string]struct{})}
+}
+
+// SeenBefore reports whether key has already been marked seen
+func (s *InMemoryNotificationStore) SeenBefore(key string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, seen := s.seen[key]
+	return seen, nil
+}
+
+// MarkSeen records key as seen
+func (s *InMemoryNotificationStore) MarkSeen(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seen[key] = struct{}{}
+	return nil
+}
+
+// singaporeNotificationAckBody is the body PayNow/NETS expect a webhook endpoint to return to
+// acknowledge a notification and stop it from being redelivered
+const singaporeNotificationAckBody = `{"status":"success"}`
+
+// SingaporePayNowWebhookHandler verifies and dispatches inbound PayNow payment notifications.
+// It implements http.Handler so it can be registered directly against a caller's mux.
+type SingaporePayNowWebhookHandler struct {
+	merchantKey string
+	options     webhookOptions
+	replay      *replayCache
+	store       NotificationStore
+
+	mutex              sync.Mutex
+	onPaymentCompleted []func(PaymentNotification)
+	onPaymentFailed    []func(PaymentNotification)
+	onRefunded         []func(PaymentNotification)
+}
+
+// NewSingaporePayNowWebhookHandler creates a handler verifying notifications signed with
+// merchantKey, the same shared secret SingaporePayNowConfig.MerchantKey uses for outbound
+// requests. Notification dedup defaults to an InMemoryNotificationStore unless overridden
+// with WithNotificationStore.
+func NewSingaporePayNowWebhookHandler(merchantKey string, opts ...WebhookOption) *SingaporePayNowWebhookHandler {
+	options := defaultWebhookOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	store := options.notificationStore
+	if store == nil {
+		store = NewInMemoryNotificationStore()
+	}
+	return &SingaporePayNowWebhookHandler{
+		merchantKey: merchantKey,
+		options:     options,
+		replay:      newReplayCache(options.replayTTL),
+		store:       store,
+	}
+}
+
+// OnPaymentCompleted registers fn to run whenever h dispatches a completed-payment notification
+func (h *SingaporePayNowWebhookHandler) OnPaymentCompleted(fn func(PaymentNotification)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onPaymentCompleted = append(h.onPaymentCompleted, fn)
+}
+
+// OnPaymentFailed registers fn to run whenever h dispatches a failed or cancelled payment
+// notification
+func (h *SingaporePayNowWebhookHandler) OnPaymentFailed(fn func(PaymentNotification)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onPaymentFailed = append(h.onPaymentFailed, fn)
+}
+
+// OnRefunded registers fn to run whenever h dispatches a refund notification
+func (h *SingaporePayNowWebhookHandler) OnRefunded(fn func(PaymentNotification)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onRefunded = append(h.onRefunded, fn)
+}
+
+// ServeHTTP verifies a PayNow notification's HMAC-SHA256 signature and timestamp, dedupes it
+// against h.store, then dispatches it to the registered On* callbacks
+func (h *SingaporePayNowWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveSingaporeNotification(w, r, h.merchantKey, h.options, h.replay, h.store, func(n PaymentNotification) {
+		h.mutex.Lock()
+		var completed, failed, refunded []func(PaymentNotification)
+		completed = append(completed, h.onPaymentCompleted...)
+		failed = append(failed, h.onPaymentFailed...)
+		refunded = append(refunded, h.onRefunded...)
+		h.mutex.Unlock()
+		dispatchSingaporeNotification(n, completed, failed, refunded)
+	})
+}
+
+// SingaporeNetsWebhookHandler verifies and dispatches inbound NETS payment notifications. It
+// implements http.Handler so it can be registered directly against a caller's mux.
+type SingaporeNetsWebhookHandler struct {
+	merchantKey string
+	options     webhookOptions
+	replay      *replayCache
+	store       NotificationStore
+
+	mutex              sync.Mutex
+	onPaymentCompleted []func(PaymentNotification)
+	onPaymentFailed    []func(PaymentNotification)
+	onRefunded         []func(PaymentNotification)
+}
+
+// NewSingaporeNetsWebhookHandler creates a handler verifying notifications signed with
+// merchantKey, the same shared secret SingaporeNetsConfig.MerchantKey uses for outbound
+// requests. Notification dedup defaults to an InMemoryNotificationStore unless overridden
+// with WithNotificationStore.
+func NewSingaporeNetsWebhookHandler(merchantKey string, opts ...WebhookOption) *SingaporeNetsWebhookHandler {
+	options := defaultWebhookOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	store := options.notificationStore
+	if store == nil {
+		store = NewInMemoryNotificationStore()
+	}
+	return &SingaporeNetsWebhookHandler{
+		merchantKey: merchantKey,
+		options:     options,
+		replay:      newReplayCache(options.replayTTL),
+		store:       store,
+	}
+}
+
+// OnPaymentCompleted registers fn to run whenever h dispatches a completed-payment notification
+func (h *SingaporeNetsWebhookHandler) OnPaymentCompleted(fn func(PaymentNotification)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onPaymentCompleted = append(h.onPaymentCompleted, fn)
+}
+
+// OnPaymentFailed registers fn to run whenever h dispatches a failed or cancelled payment
+// notification
+func (h *SingaporeNetsWebhookHandler) OnPaymentFailed(fn func(PaymentNotification)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onPaymentFailed = append(h.onPaymentFailed, fn)
+}
+
+// OnRefunded registers fn to run whenever h dispatches a refund notification
+func (h *SingaporeNetsWebhookHandler) OnRefunded(fn func(PaymentNotification)) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.onRefunded = append(h.onRefunded, fn)
+}
+
+// ServeHTTP verifies a NETS notification's HMAC-SHA256 signature and timestamp, dedupes it
+// against h.store, then dispatches it to the registered On* callbacks
+func (h *SingaporeNetsWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveSingaporeNotification(w, r, h.merchantKey, h.options, h.replay, h.store, func(n PaymentNotification) {
+		h.mutex.Lock()
+		var completed, failed, refunded []func(PaymentNotification)
+		completed = append(completed, h.onPaymentCompleted...)
+		failed = append(failed, h.onPaymentFailed...)
+		refunded = append(refunded, h.onRefunded...)
+		h.mutex.Unlock()
+		dispatchSingaporeNotification(n, completed, failed, refunded)
+	})
+}
+
+// serveSingaporeNotification implements the PayNow/NETS handlers' shared ServeHTTP logic:
+// read and preserve the raw body, verify its signature and timestamp, parse it into a
+// PaymentNotification, dedupe it, and hand it to deliver
+func serveSingaporeNotification(w http.ResponseWriter, r *http.Request, merchantKey string, options webhookOptions, replay *replayCache, store NotificationStore, deliver func(PaymentNotification)) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	signature, _ := payload["signature"].(string)
+	params := make(map[string]interface{}, len(payload))
+	for k, v := range payload {
+		if k != "signature" {
+			params[k] = v
+		}
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(singaporeNotificationSignature(params, merchantKey))) {
+		http.Error(w, "invalid signature", http.StatusBadRequest)
+		return
+	}
+
+	timestamp, _ := params["timestamp"].(float64)
+	if timestamp > 0 && absDuration(time.Since(time.Unix(int64(timestamp), 0))) > options.clockSkew {
+		http.Error(w, "notification timestamp outside allowed clock skew", http.StatusBadRequest)
+		return
+	}
+
+	notification := parseSingaporeNotification(params, json.RawMessage(body))
+
+	if !replay.checkAndRemember(fmt.Sprintf("%v:%s", timestamp, notification.dedupeKey())) {
+		http.Error(w, "notification already processed (replay)", http.StatusBadRequest)
+		return
+	}
+
+	seen, err := store.SeenBefore(notification.dedupeKey())
+	if err != nil {
+		http.Error(w, "failed to check notification dedup store", http.StatusInternalServerError)
+		return
+	}
+	if !seen {
+		if err := store.MarkSeen(notification.dedupeKey()); err != nil {
+			http.Error(w, "failed to record notification dedup key", http.StatusInternalServerError)
+			return
+		}
+		deliver(notification)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(singaporeNotificationAckBody))
+}
+
+// singaporeNotificationSignature recomputes the HMAC-SHA256 signature over params the same
+// way SingaporePayNow.generateSignature/SingaporeNets.generateSignature sign outbound requests
+func singaporeNotificationSignature(params map[string]interface{}, merchantKey string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(fmt.Sprintf("%v", params[k]))
+		sb.WriteString("&")
+	}
+	signStr := strings.TrimSuffix(sb.String(), "&")
+
+	mac := hmac.New(sha256.New, []byte(merchantKey))
+	mac.Write([]byte(signStr))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseSingaporeNotification extracts a PaymentNotification from a decoded PayNow/NETS
+// notification body, mapping its status the same way GetPaymentStatus does, plus "refunded"
+// to StatusRefunded
+func parseSingaporeNotification(params map[string]interface{}, raw json.RawMessage) PaymentNotification {
+	paymentID, _ := params["payment_id"].(string)
+	transactionID, _ := params["transaction_id"].(string)
+	orderID, _ := params["order_id"].(string)
+	currency, _ := params["currency"].(string)
+	if currency == "" {
+		currency = "SGD"
+	}
+
+	var amount float64
+	switch v := params["amount"].(type) {
+	case float64:
+		amount = v
+	case string:
+		amount, _ = strconv.ParseFloat(v, 64)
+	}
+
+	rawStatus, _ := params["status"].(string)
+	var status PaymentStatus
+	switch rawStatus {
+	case "completed", "success":
+		status = StatusCompleted
+	case "failed":
+		status = StatusFailed
+	case "cancelled":
+		status = StatusCancelled
+	case "refunded":
+		status = StatusRefunded
+	default:
+		status = StatusPending
+	}
+
+	return PaymentNotification{
+		PaymentID:     paymentID,
+		TransactionID: transactionID,
+		OrderID:       orderID,
+		Status:        status,
+		Amount:        amount,
+		Currency:      currency,
+		Raw:           raw,
+	}
+}
+
+// dispatchSingaporeNotification runs n against whichever of completed/failed/refunded applies
+// to its Status
+func dispatchSingaporeNotification(n PaymentNotification, completed, failed, refunded []func(PaymentNotification)) {
+	var callbacks []func(PaymentNotification)
+	switch n.Status {
+	case StatusCompleted:
+		callbacks = completed
+	case StatusFailed, StatusCancelled:
+		callbacks = failed
+	case StatusRefunded:
+		callbacks = refunded
+	}
+	for _, callback := range callbacks {
+		callback(n)
+	}
+}
+
+// PaymentOutcomeLookup resolves a payment ID to the success/failure redirect URLs the
+// merchant registered for it when creating the payment (e.g. PaymentRequest.ReturnURL and
+// PaymentRequest.CancelURL), for PaymentRedirectRouter to redirect a returning customer to
+type PaymentOutcomeLookup func(paymentID string) (successURL, failureURL string, found bool)
+
+// PaymentRedirectRouter terminates the customer-facing redirect PayNow/NETS send a browser
+// back to after a payment attempt (distinct from the server-to-server notification
+// SingaporePayNowWebhookHandler/SingaporeNetsWebhookHandler handle), and forwards the
+// customer on to the merchant's own success or failure page, mirroring the
+// Redsys/camper pattern of a single terminal redirect endpoint per payment.
+type PaymentRedirectRouter struct {
+	lookup PaymentOutcomeLookup
+}
+
+// NewPaymentRedirectRouter creates a PaymentRedirectRouter resolving redirect targets via lookup
+func NewPaymentRedirectRouter(lookup PaymentOutcomeLookup) *PaymentRedirectRouter {
+	return &PaymentRedirectRouter{lookup: lookup}
+}
+
+// ServeHTTP reads payment_id and status query parameters and redirects the customer to
+// whichever of the payment's registered success/failure URLs applies
+func (router *PaymentRedirectRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	paymentID := r.URL.Query().Get("payment_id")
+	status := r.URL.Query().Get("status")
+
+	successURL, failureURL, found := router.lookup(paymentID)
+	if !found {
+		http.Error(w, "unknown payment_id", http.StatusNotFound)
+		return
+	}
+
+	target := failureURL
+	switch status {
+	case "completed", "success":
+		target = successURL
+	}
+	if target == "" {
+		http.Error(w, "no redirect URL registered for this outcome", http.StatusNotFound)
+		return
+	}
+
+	http.Redirect(w, r, target, http.StatusFound)
+}
@@ -5,6 +5,7 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
@@ -17,27 +18,33 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // ThailandPromptPayConfig holds configuration for PromptPay integration
 type ThailandPromptPayConfig struct {
-	MerchantID     string
-	MerchantKey    string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	MerchantID  string
+	MerchantKey string
+	APIEndpoint string
+	CallbackURL string
+	RedirectURL string
+	TestMode    bool
 }
 
 // ThailandPromptPay implements PaymentPlatform interface for Thailand's PromptPay
 type ThailandPromptPay struct {
-	config ThailandPromptPayConfig
-	client *http.Client
+	config   ThailandPromptPayConfig
+	client   *http.Client
+	tokens   *tokenCache
+	language Language
 }
 
-// NewThailandPromptPay creates a new PromptPay payment platform
-func NewThailandPromptPay(config ThailandPromptPayConfig) *ThailandPromptPay {
+// NewThailandPromptPay creates a new PromptPay payment platform. By default errors and
+// descriptions are in English; pass WithLocalization to change that.
+func NewThailandPromptPay(config ThailandPromptPayConfig, opts ...PlatformOption) *ThailandPromptPay {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -47,12 +54,20 @@ func NewThailandPromptPay(config ThailandPromptPayConfig) *ThailandPromptPay {
 		}
 	}
 
-	return &ThailandPromptPay{
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	p := &ThailandPromptPay{
 		config: config,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		language: options.language,
 	}
+	p.tokens = newTokenCache(p.fetchAccessToken, defaultTokenRefreshSkew)
+	return p
 }
 
 // GetName returns the name of the payment platform
@@ -76,7 +91,14 @@ func (p *ThailandPromptPay) GetSupportedCurrencies() []string {
 }
 
 // CreatePayment creates a payment
-func (p *ThailandPromptPay) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+func (p *ThailandPromptPay) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "THB" {
 		return nil, errors.New("currency must be THB for PromptPay payments")
@@ -86,12 +108,6 @@ func (p *ThailandPromptPay) CreatePayment(request *PaymentRequest) (*PaymentResp
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
-	// Get access token
-	token, err := p.getAccessToken()
-	if err != nil {
-		return nil, err
-	}
-
 	// Prepare PromptPay request
 	promptPayRequest := map[string]interface{}{
 		"qrType":        "PP",
@@ -104,7 +120,7 @@ func (p *ThailandPromptPay) CreatePayment(request *PaymentRequest) (*PaymentResp
 		"merchantId":    p.config.MerchantID,
 		"terminalId":    "TERM001",
 		"invoice":       request.OrderID,
-		"description":   request.Description,
+		"description":   localizedDescription(request.Description, request.OrderID, p.language),
 		"customerName":  request.CustomerName,
 		"customerEmail": request.CustomerEmail,
 		"customerPhone": request.CustomerPhone,
@@ -116,23 +132,21 @@ func (p *ThailandPromptPay) CreatePayment(request *PaymentRequest) (*PaymentResp
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/partners/sandbox/v1/payment/qrcode/create", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("resourceOwnerId", p.config.MerchantID)
-	req.Header.Set("requestUId", uuid.New().String())
-	req.Header.Set("channel", "scb_app")
-
-	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.doAuthorized(ctx, func(token string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/partners/sandbox/v1/payment/qrcode/create", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("resourceOwnerId", p.config.MerchantID)
+		req.Header.Set("requestUId", uuid.New().String())
+		req.Header.Set("channel", "scb_app")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -155,7 +169,8 @@ func (p *ThailandPromptPay) CreatePayment(request *PaymentRequest) (*PaymentResp
 			if msg, ok := status["description"].(string); ok {
 				errorMsg = msg
 			}
-			return nil, fmt.Errorf("PromptPay error: %s", errorMsg)
+			errorCode := strconv.FormatFloat(code, 'f', 0, 64)
+			return nil, translateError(errorCode, fmt.Sprintf("PromptPay error: %s", errorMsg), p.language, nil)
 		}
 	}
 
@@ -187,30 +202,29 @@ func (p *ThailandPromptPay) CreatePayment(request *PaymentRequest) (*PaymentResp
 }
 
 // GetPaymentStatus gets the status of a payment
-func (p *ThailandPromptPay) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
-	// Get access token
-	token, err := p.getAccessToken()
+func (p *ThailandPromptPay) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	resp, err := p.doAuthorized(ctx, func(token string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", p.config.APIEndpoint+"/partners/sandbox/v1/payment/billpayment/transactions/"+request.PaymentID, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("resourceOwnerId", p.config.MerchantID)
+		req.Header.Set("requestUId", uuid.New().String())
+		req.Header.Set("channel", "scb_app")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	// Create HTTP request
-	req, err := http.NewRequest("GET", p.config.APIEndpoint+"/partners/sandbox/v1/payment/billpayment/transactions/"+request.PaymentID, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("resourceOwnerId", p.config.MerchantID)
-	req.Header.Set("requestUId", uuid.New().String())
-	req.Header.Set("channel", "scb_app")
-
-	// Make API request
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
-	}
 	defer resp.Body.Close()
 
 	// Read response
@@ -232,7 +246,8 @@ func (p *ThailandPromptPay) GetPaymentStatus(request *PaymentStatusRequest) (*Pa
 			if msg, ok := status["description"].(string); ok {
 				errorMsg = msg
 			}
-			return nil, fmt.Errorf("PromptPay error: %s", errorMsg)
+			errorCode := strconv.FormatFloat(code, 'f', 0, 64)
+			return nil, translateError(errorCode, fmt.Sprintf("PromptPay error: %s", errorMsg), p.language, nil)
 		}
 	}
 
@@ -246,7 +261,7 @@ func (p *ThailandPromptPay) GetPaymentStatus(request *PaymentStatusRequest) (*Pa
 	amountStr, _ := data["amount"].(string)
 	amount, _ := strconv.ParseFloat(amountStr, 64)
 	transactionDateStr, _ := data["transactionDate"].(string)
-	
+
 	// Parse transaction date
 	transactionDate, _ := time.Parse("2006-01-02T15:04:05-07:00", transactionDateStr)
 
@@ -282,11 +297,12 @@ func (p *ThailandPromptPay) GetPaymentStatus(request *PaymentStatusRequest) (*Pa
 }
 
 // RefundPayment refunds a payment
-func (p *ThailandPromptPay) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
-	// Get access token
-	token, err := p.getAccessToken()
-	if err != nil {
-		return nil, err
+func (p *ThailandPromptPay) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
 	}
 
 	// Prepare refund request
@@ -304,23 +320,21 @@ func (p *ThailandPromptPay) RefundPayment(request *RefundRequest) (*RefundRespon
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/partners/sandbox/v1/payment/billpayment/refund", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("resourceOwnerId", p.config.MerchantID)
-	req.Header.Set("requestUId", uuid.New().String())
-	req.Header.Set("channel", "scb_app")
-
-	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.doAuthorized(ctx, func(token string) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/partners/sandbox/v1/payment/billpayment/refund", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("resourceOwnerId", p.config.MerchantID)
+		req.Header.Set("requestUId", uuid.New().String())
+		req.Header.Set("channel", "scb_app")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to make API request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -343,7 +357,8 @@ func (p *ThailandPromptPay) RefundPayment(request *RefundRequest) (*RefundRespon
 			if msg, ok := status["description"].(string); ok {
 				errorMsg = msg
 			}
-			return nil, fmt.Errorf("PromptPay refund error: %s", errorMsg)
+			errorCode := strconv.FormatFloat(code, 'f', 0, 64)
+			return nil, translateError(errorCode, fmt.Sprintf("PromptPay refund error: %s", errorMsg), p.language, nil)
 		}
 	}
 
@@ -358,19 +373,142 @@ func (p *ThailandPromptPay) RefundPayment(request *RefundRequest) (*RefundRespon
 
 	// Create response
 	response := &RefundResponse{
-		RefundID:     refundID,
-		PaymentID:    request.PaymentID,
-		Amount:       request.Amount,
-		Currency:     "THB",
-		Status:       refundStatus,
-		CreatedAt:    time.Now(),
+		RefundID:  refundID,
+		PaymentID: request.PaymentID,
+		Amount:    request.Amount,
+		Currency:  "THB",
+		Status:    refundStatus,
+		CreatedAt: time.Now(),
 	}
 
 	return response, nil
 }
 
-// getAccessToken gets an access token for PromptPay API
-func (p *ThailandPromptPay) getAccessToken() (string, error) {
+// defaultTokenRefreshSkew is how much validity tokenCache insists a cached token has left
+// before handing it out; SCB tokens refreshed with less than this much life remaining would
+// otherwise risk expiring mid-request
+const defaultTokenRefreshSkew = 60 * time.Second
+
+// tokenFetchFunc performs the actual OAuth round-trip tokenCache fronts, returning the raw
+// token and the instant it expires
+type tokenFetchFunc func(ctx context.Context) (token string, expiresAt time.Time, err error)
+
+// tokenCache caches an OAuth access token obtained from fetch, refreshing it once it has
+// fewer than skew remaining. Concurrent refreshes for the same token are coalesced onto a
+// single in-flight fetch via singleflight, the same way ExchangeRateService.group coalesces
+// concurrent provider calls.
+type tokenCache struct {
+	fetch tokenFetchFunc
+	skew  time.Duration
+
+	mutex     sync.Mutex
+	token     string
+	expiresAt time.Time
+	group     singleflight.Group
+}
+
+// newTokenCache creates a tokenCache that calls fetch to obtain a fresh token, treating one
+// as expired once less than skew remains before its reported expiry
+func newTokenCache(fetch tokenFetchFunc, skew time.Duration) *tokenCache {
+	return &tokenCache{fetch: fetch, skew: skew}
+}
+
+// get returns the cached token if it still has more than skew remaining, otherwise blocks on
+// a coalesced refresh
+func (c *tokenCache) get(ctx context.Context) (string, error) {
+	c.mutex.Lock()
+	if c.token != "" && time.Until(c.expiresAt) > c.skew {
+		token := c.token
+		c.mutex.Unlock()
+		return token, nil
+	}
+	c.mutex.Unlock()
+	return c.refresh(ctx)
+}
+
+// forceRefresh discards any cached token and fetches a new one, coalescing with any refresh
+// already in flight
+func (c *tokenCache) forceRefresh(ctx context.Context) (string, error) {
+	return c.refresh(ctx)
+}
+
+// refresh runs fetch behind c.group so concurrent callers share one round-trip, then stores
+// the result for subsequent get calls
+func (c *tokenCache) refresh(ctx context.Context) (string, error) {
+	result, err, _ := c.group.Do("token", func() (interface{}, error) {
+		token, expiresAt, err := c.fetch(ctx)
+		if err != nil {
+			return "", err
+		}
+		c.mutex.Lock()
+		c.token = token
+		c.expiresAt = expiresAt
+		c.mutex.Unlock()
+		return token, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.(string), nil
+}
+
+// getAccessToken returns a cached PromptPay OAuth token, refreshing it if it has less than
+// defaultTokenRefreshSkew remaining
+func (p *ThailandPromptPay) getAccessToken(ctx context.Context) (string, error) {
+	return p.tokens.get(ctx)
+}
+
+// ForceTokenRefresh discards the cached PromptPay token and fetches a new one immediately,
+// for operational recovery when SCB rejects the cached token with a 401 outside of the
+// CreatePayment/GetPaymentStatus/RefundPayment retry path (e.g. a manual health check)
+func (p *ThailandPromptPay) ForceTokenRefresh(ctx context.Context) (string, error) {
+	return p.tokens.forceRefresh(ctx)
+}
+
+// doAuthorized sends the request build returns using the cached access token, retrying once
+// with a forced token refresh if SCB responds 401. build is called again on retry so it can
+// rebuild the request (and its Authorization header) with the refreshed token.
+func (p *ThailandPromptPay) doAuthorized(ctx context.Context, build func(token string) (*http.Request, error)) (*http.Response, error) {
+	token, err := p.getAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := build(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make API request: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+
+		token, err = p.ForceTokenRefresh(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err = build(token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+
+		resp, err = p.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make API request: %w", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// fetchAccessToken performs the OAuth round-trip for a PromptPay access token; it's the
+// tokenFetchFunc p.tokens calls on a cache miss
+func (p *ThailandPromptPay) fetchAccessToken(ctx context.Context) (string, time.Time, error) {
 	// Prepare token request
 	tokenRequest := map[string]string{
 		"applicationKey":    p.config.MerchantID,
@@ -380,13 +518,13 @@ func (p *ThailandPromptPay) getAccessToken() (string, error) {
 	// Convert to JSON
 	jsonData, err := json.Marshal(tokenRequest)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal token request: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to marshal token request: %w", err)
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/partners/sandbox/v1/oauth/token", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/partners/sandbox/v1/oauth/token", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	// Add headers
@@ -397,20 +535,20 @@ func (p *ThailandPromptPay) getAccessToken() (string, error) {
 	// Make API request
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to make token request: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to make token request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read token response: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to read token response: %w", err)
 	}
 
 	// Parse response
 	var tokenResponse map[string]interface{}
 	if err := json.Unmarshal(body, &tokenResponse); err != nil {
-		return "", fmt.Errorf("failed to parse token response: %w", err)
+		return "", time.Time{}, fmt.Errorf("failed to parse token response: %w", err)
 	}
 
 	// Check for errors
@@ -420,42 +558,168 @@ func (p *ThailandPromptPay) getAccessToken() (string, error) {
 			if msg, ok := status["description"].(string); ok {
 				errorMsg = msg
 			}
-			return "", fmt.Errorf("PromptPay token error: %s", errorMsg)
+			errorCode := strconv.FormatFloat(code, 'f', 0, 64)
+			return "", time.Time{}, translateError(errorCode, fmt.Sprintf("PromptPay token error: %s", errorMsg), p.language, nil)
 		}
 	}
 
 	// Extract token
 	data, ok := tokenResponse["data"].(map[string]interface{})
 	if !ok {
-		return "", errors.New("invalid token response format")
+		return "", time.Time{}, errors.New("invalid token response format")
 	}
 
 	accessToken, ok := data["accessToken"].(string)
 	if !ok {
-		return "", errors.New("failed to get access token")
+		return "", time.Time{}, errors.New("failed to get access token")
+	}
+
+	// expiresIn is seconds-until-expiry; fall back to a conservative 10 minutes if SCB omits
+	// it so a missing field doesn't make the cache treat the token as eternally valid
+	expiresIn := 600.0
+	if v, ok := data["expiresIn"].(float64); ok {
+		expiresIn = v
+	}
+
+	return accessToken, time.Now().Add(time.Duration(expiresIn) * time.Second), nil
+}
+
+// promptPayAID identifies the PromptPay scheme under an EMVCo merchant account info template
+const promptPayAID = "A000000677010111"
+
+// ErrInvalidPromptPayPayload is returned when GeneratePromptPayQR is given a payload it cannot
+// encode, e.g. an identifier of the wrong type or length
+var ErrInvalidPromptPayPayload = errors.New("invalid PromptPay QR payload")
+
+// PromptPayIdentifierType selects which kind of target a PromptPay QR code's Merchant Account
+// Info identifies
+type PromptPayIdentifierType string
+
+const (
+	// PromptPayIdentifierMobile identifies the target by mobile number
+	PromptPayIdentifierMobile PromptPayIdentifierType = "mobile"
+	// PromptPayIdentifierNationalID identifies the target by 13-digit national ID
+	PromptPayIdentifierNationalID PromptPayIdentifierType = "national_id"
+	// PromptPayIdentifierEWallet identifies the target by 15-digit e-wallet/BILLERID
+	PromptPayIdentifierEWallet PromptPayIdentifierType = "ewallet"
+)
+
+// PromptPayPayload is the data GeneratePromptPayQR encodes into an EMVCo Merchant-Presented QR
+// payload. Amount is optional: a zero Amount with Dynamic false produces a reusable static
+// code; a non-zero Amount produces a single-use dynamic code.
+type PromptPayPayload struct {
+	// IdentifierType selects how Identifier is interpreted
+	IdentifierType PromptPayIdentifierType
+	// Identifier is the raw mobile number, national ID, or e-wallet ID the QR pays into,
+	// digits only
+	Identifier string
+	// Dynamic marks this as a single-use, per-transaction code (point of initiation "12")
+	// rather than a reusable static one ("11")
+	Dynamic bool
+	// Amount is the payment amount in THB. Ignored for static codes; required for dynamic ones.
+	Amount float64
+	// MerchantName is written to tag 59
+	MerchantName string
+	// MerchantCity is written to tag 60
+	MerchantCity string
+}
+
+// GeneratePromptPayQR builds a PromptPay QR payload locally per the EMVCo Merchant-Presented QR
+// spec, so merchants can generate static or dynamic PromptPay codes without hitting the SCB API
+// for every transaction. It returns the raw payload string, ready for rendering into any QR
+// image library.
+func (p *ThailandPromptPay) GeneratePromptPayQR(payload PromptPayPayload) (string, error) {
+	proxy, err := promptPayProxyTLV(payload.IdentifierType, payload.Identifier)
+	if err != nil {
+		return "", err
+	}
+	if payload.Dynamic && payload.Amount <= 0 {
+		return "", fmt.Errorf("%w: dynamic PromptPay QR requires a positive amount", ErrInvalidPromptPayPayload)
+	}
+
+	pointOfInitiation := "11"
+	if payload.Dynamic {
+		pointOfInitiation = "12"
+	}
+
+	merchantAccountInfo := emvTLV("00", promptPayAID) + proxy
+
+	var sb strings.Builder
+	sb.WriteString(emvTLV("00", "01")) // payload format indicator
+	sb.WriteString(emvTLV("01", pointOfInitiation))
+	sb.WriteString(emvTLV("29", merchantAccountInfo))
+	sb.WriteString(emvTLV("53", "764")) // ISO 4217 numeric code for THB
+	if payload.Amount > 0 {
+		sb.WriteString(emvTLV("54", strconv.FormatFloat(payload.Amount, 'f', 2, 64)))
+	}
+	sb.WriteString(emvTLV("58", "TH"))
+	sb.WriteString(emvTLV("59", payload.MerchantName))
+	sb.WriteString(emvTLV("60", payload.MerchantCity))
+	sb.WriteString("6304") // CRC tag/length placeholder; the checksum itself is appended below
+
+	qr := sb.String()
+	qr += fmt.Sprintf("%04X", crc16CCITT([]byte(qr)))
+	return qr, nil
+}
+
+// promptPayProxyTLV builds the tag 01/02/03 sub-TLV identifying a PromptPay QR's target,
+// according to identifierType
+func promptPayProxyTLV(identifierType PromptPayIdentifierType, identifier string) (string, error) {
+	switch identifierType {
+	case PromptPayIdentifierMobile:
+		digits := digitsOnly(identifier)
+		if len(digits) < 9 {
+			return "", fmt.Errorf("%w: mobile number must have at least 9 digits", ErrInvalidPromptPayPayload)
+		}
+		return emvTLV("01", "0066"+digits[len(digits)-9:]), nil
+	case PromptPayIdentifierNationalID:
+		digits := digitsOnly(identifier)
+		if len(digits) != 13 {
+			return "", fmt.Errorf("%w: national ID must be 13 digits", ErrInvalidPromptPayPayload)
+		}
+		return emvTLV("02", digits), nil
+	case PromptPayIdentifierEWallet:
+		digits := digitsOnly(identifier)
+		if len(digits) != 15 {
+			return "", fmt.Errorf("%w: e-wallet ID must be 15 digits", ErrInvalidPromptPayPayload)
+		}
+		return emvTLV("03", digits), nil
+	default:
+		return "", fmt.Errorf("%w: unknown identifier type %q", ErrInvalidPromptPayPayload, identifierType)
 	}
+}
 
-	return accessToken, nil
+// digitsOnly strips every non-digit rune from s
+func digitsOnly(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
 }
 
 // ThailandTrueMoneyConfig holds configuration for TrueMoney integration
 type ThailandTrueMoneyConfig struct {
-	MerchantID     string
-	MerchantKey    string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	MerchantID  string
+	MerchantKey string
+	APIEndpoint string
+	CallbackURL string
+	RedirectURL string
+	TestMode    bool
 }
 
 // ThailandTrueMoney implements PaymentPlatform interface for Thailand's TrueMoney
 type ThailandTrueMoney struct {
-	config ThailandTrueMoneyConfig
-	client *http.Client
+	config   ThailandTrueMoneyConfig
+	client   *http.Client
+	language Language
 }
 
-// NewThailandTrueMoney creates a new TrueMoney payment platform
-func NewThailandTrueMoney(config ThailandTrueMoneyConfig) *ThailandTrueMoney {
+// NewThailandTrueMoney creates a new TrueMoney payment platform. By default errors and
+// descriptions are in English; pass WithLocalization to change that.
+func NewThailandTrueMoney(config ThailandTrueMoneyConfig, opts ...PlatformOption) *ThailandTrueMoney {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -465,11 +729,17 @@ func NewThailandTrueMoney(config ThailandTrueMoneyConfig) *ThailandTrueMoney {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return &ThailandTrueMoney{
 		config: config,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		language: options.language,
 	}
 }
 
@@ -494,7 +764,14 @@ func (p *ThailandTrueMoney) GetSupportedCurrencies() []string {
 }
 
 // CreatePayment creates a payment
-func (p *ThailandTrueMoney) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+func (p *ThailandTrueMoney) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "THB" {
 		return nil, errors.New("currency must be THB for TrueMoney payments")
@@ -506,19 +783,19 @@ func (p *ThailandTrueMoney) CreatePayment(request *PaymentRequest) (*PaymentResp
 
 	// Prepare TrueMoney request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	trueMoneyRequest := map[string]interface{}{
-		"merchant_id":     p.config.MerchantID,
-		"order_id":        request.OrderID,
-		"amount":          fmt.Sprintf("%.2f", request.Amount),
-		"currency":        request.Currency,
-		"payment_method":  "wallet",
-		"description":     request.Description,
-		"customer_email":  request.CustomerEmail,
-		"customer_phone":  request.CustomerPhone,
-		"return_url":      p.config.RedirectURL,
-		"notify_url":      p.config.CallbackURL,
-		"timestamp":       timestamp,
+		"merchant_id":    p.config.MerchantID,
+		"order_id":       request.OrderID,
+		"amount":         fmt.Sprintf("%.2f", request.Amount),
+		"currency":       request.Currency,
+		"payment_method": "wallet",
+		"description":    localizedDescription(request.Description, request.OrderID, p.language),
+		"customer_email": request.CustomerEmail,
+		"customer_phone": request.CustomerPhone,
+		"return_url":     p.config.RedirectURL,
+		"notify_url":     p.config.CallbackURL,
+		"timestamp":      timestamp,
 	}
 
 	// Generate signature
@@ -532,7 +809,7 @@ func (p *ThailandTrueMoney) CreatePayment(request *PaymentRequest) (*PaymentResp
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/payments/v1/payment", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/payments/v1/payment", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -540,6 +817,7 @@ func (p *ThailandTrueMoney) CreatePayment(request *PaymentRequest) (*PaymentResp
 	// Add headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
 
 	// Make API request
 	resp, err := p.client.Do(req)
@@ -566,7 +844,11 @@ func (p *ThailandTrueMoney) CreatePayment(request *PaymentRequest) (*PaymentResp
 		if msg, ok := trueMoneyResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("TrueMoney error: %s", errorMsg)
+		errorCode, _ := trueMoneyResponse["error_code"].(string)
+		if errorCode == "" {
+			errorCode = "unknown_error"
+		}
+		return nil, translateError(errorCode, fmt.Sprintf("TrueMoney error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -599,10 +881,17 @@ func (p *ThailandTrueMoney) CreatePayment(request *PaymentRequest) (*PaymentResp
 }
 
 // GetPaymentStatus gets the status of a payment
-func (p *ThailandTrueMoney) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+func (p *ThailandTrueMoney) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare status request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	statusRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"payment_id":  request.PaymentID,
@@ -620,7 +909,7 @@ func (p *ThailandTrueMoney) GetPaymentStatus(request *PaymentStatusRequest) (*Pa
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/payments/v1/payment/status", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/payments/v1/payment/status", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -628,6 +917,7 @@ func (p *ThailandTrueMoney) GetPaymentStatus(request *PaymentStatusRequest) (*Pa
 	// Add headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
 
 	// Make API request
 	resp, err := p.client.Do(req)
@@ -654,7 +944,11 @@ func (p *ThailandTrueMoney) GetPaymentStatus(request *PaymentStatusRequest) (*Pa
 		if msg, ok := statusResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("TrueMoney error: %s", errorMsg)
+		errorCode, _ := statusResponse["error_code"].(string)
+		if errorCode == "" {
+			errorCode = "unknown_error"
+		}
+		return nil, translateError(errorCode, fmt.Sprintf("TrueMoney error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract payment details
@@ -669,7 +963,7 @@ func (p *ThailandTrueMoney) GetPaymentStatus(request *PaymentStatusRequest) (*Pa
 	paymentMethod, _ := data["payment_method"].(string)
 	transactionID, _ := data["transaction_id"].(string)
 	createdAtStr, _ := data["created_at"].(string)
-	
+
 	// Parse created at
 	createdAt, _ := time.Parse("2006-01-02T15:04:05Z", createdAtStr)
 
@@ -715,10 +1009,17 @@ func (p *ThailandTrueMoney) GetPaymentStatus(request *PaymentStatusRequest) (*Pa
 }
 
 // RefundPayment refunds a payment
-func (p *ThailandTrueMoney) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+func (p *ThailandTrueMoney) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare refund request
 	timestamp := time.Now().Format("20060102150405")
-	
+
 	refundRequest := map[string]interface{}{
 		"merchant_id": p.config.MerchantID,
 		"payment_id":  request.PaymentID,
@@ -739,7 +1040,7 @@ func (p *ThailandTrueMoney) RefundPayment(request *RefundRequest) (*RefundRespon
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/payments/v1/payment/refund", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/payments/v1/payment/refund", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
 	}
@@ -747,6 +1048,7 @@ func (p *ThailandTrueMoney) RefundPayment(request *RefundRequest) (*RefundRespon
 	// Add headers
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
 
 	// Make API request
 	resp, err := p.client.Do(req)
@@ -773,7 +1075,11 @@ func (p *ThailandTrueMoney) RefundPayment(request *RefundRequest) (*RefundRespon
 		if msg, ok := refundResponse["message"].(string); ok {
 			errorMsg = msg
 		}
-		return nil, fmt.Errorf("TrueMoney refund error: %s", errorMsg)
+		errorCode, _ := refundResponse["error_code"].(string)
+		if errorCode == "" {
+			errorCode = "unknown_error"
+		}
+		return nil, translateError(errorCode, fmt.Sprintf("TrueMoney refund error: %s", errorMsg), p.language, nil)
 	}
 
 	// Extract refund details
@@ -802,6 +1108,14 @@ func (p *ThailandTrueMoney) RefundPayment(request *RefundRequest) (*RefundRespon
 
 // generateSignature generates a signature for TrueMoney requests
 func (p *ThailandTrueMoney) generateSignature(params map[string]interface{}) string {
+	return trueMoneySignatureString(p.config.MerchantKey, params)
+}
+
+// trueMoneySignatureString computes the HMAC-SHA256 signature TrueMoney expects over params:
+// keys sorted ascending, joined as "k=v&k2=v2", signed with key. Factored out of
+// ThailandTrueMoney.generateSignature so TrueMoneyWebhookHandler can recompute the same
+// signature to verify an inbound callback.
+func trueMoneySignatureString(key string, params map[string]interface{}) string {
 	// Sort keys
 	keys := make([]string, 0, len(params))
 	for k := range params {
@@ -824,7 +1138,7 @@ func (p *ThailandTrueMoney) generateSignature(params map[string]interface{}) str
 	}
 
 	// Generate HMAC-SHA256
-	h := hmac.New(sha256.New, []byte(p.config.MerchantKey))
+	h := hmac.New(sha256.New, []byte(key))
 	h.Write([]byte(signStr))
 	return hex.EncodeToString(h.Sum(nil))
 }
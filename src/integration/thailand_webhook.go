@@ -0,0 +1,319 @@
+// Inbound webhook handling for Thailand's PromptPay and TrueMoney callbacks
+// Neither ThailandPromptPay nor ThailandTrueMoney previously offered a way to receive the
+// asynchronous callback POSTed to CallbackURL, so a merchant had to poll GetPaymentStatus to
+// learn a payment's outcome. The handlers below verify and normalize those callbacks the same
+// way webhook.go's FPX/GrabPay handlers do, so status updates can be pushed instead.
+
+package integration
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInvalidSCBSignature is returned when an inbound PromptPay callback's x-signature header
+// doesn't match what its body recomputes to
+var ErrInvalidSCBSignature = errors.New("invalid SCB callback signature")
+
+// ErrInvalidTrueMoneySignature is returned when an inbound TrueMoney callback's signature field
+// doesn't match what its other fields recompute to
+var ErrInvalidTrueMoneySignature = errors.New("invalid TrueMoney callback signature")
+
+// promptPayWebhookPayload is the JSON body SCB POSTs to a merchant's CallbackURL for a
+// PromptPay transaction outcome, mirroring the shape ThailandPromptPay.GetPaymentStatus parses
+// from the equivalent polling response
+type promptPayWebhookPayload struct {
+	RequestUID string `json:"requestUId"`
+	Timestamp  string `json:"timestamp"`
+	Data       struct {
+		TransactionID     string `json:"transactionId"`
+		Amount            string `json:"amount"`
+		TransactionStatus string `json:"transactionStatus"`
+		TransactionDate   string `json:"transactionDate"`
+	} `json:"data"`
+}
+
+// PromptPayWebhookHandler verifies and dispatches inbound SCB PromptPay callbacks. It
+// implements http.Handler so it can be registered directly against a caller's mux.
+type PromptPayWebhookHandler struct {
+	merchantKey string
+	options     webhookOptions
+	replay      *replayCache
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewPromptPayWebhookHandler creates a handler verifying callbacks signed with merchantKey, the
+// same ThailandPromptPayConfig.MerchantKey used for outbound requests
+func NewPromptPayWebhookHandler(merchantKey string, opts ...WebhookOption) *PromptPayWebhookHandler {
+	options := defaultWebhookOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &PromptPayWebhookHandler{
+		merchantKey: merchantKey,
+		options:     options,
+		replay:      newReplayCache(options.replayTTL),
+	}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *PromptPayWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// VerifyWebhook validates headers/body as a PromptPay callback and, on success, dispatches a
+// normalized WebhookEvent to every registered listener. It returns the PaymentResponse the
+// callback describes so a caller can persist it directly instead of re-deriving one from
+// GetPaymentStatus.
+func (h *PromptPayWebhookHandler) VerifyWebhook(headers http.Header, body []byte) (*PaymentResponse, error) {
+	if !hmac.Equal([]byte(headers.Get("x-signature")), []byte(hmacSHA256Hex([]byte(h.merchantKey), body))) {
+		return nil, ErrInvalidSCBSignature
+	}
+
+	var payload promptPayWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse PromptPay callback body: %w", err)
+	}
+
+	if err := h.checkReplay(payload.RequestUID, payload.Timestamp); err != nil {
+		return nil, err
+	}
+
+	amount, _ := strconv.ParseFloat(payload.Data.Amount, 64)
+	transactionDate, _ := time.Parse("2006-01-02T15:04:05-07:00", payload.Data.TransactionDate)
+
+	status := StatusPending
+	var completedAt time.Time
+	switch payload.Data.TransactionStatus {
+	case "SUCCESS":
+		status = StatusCompleted
+		completedAt = transactionDate
+	case "FAILED":
+		status = StatusFailed
+	}
+
+	response := &PaymentResponse{
+		PaymentID:     payload.Data.TransactionID,
+		Status:        status,
+		Amount:        amount,
+		Currency:      "THB",
+		PaymentMethod: MethodQRCode,
+		TransactionID: payload.Data.TransactionID,
+		CreatedAt:     transactionDate,
+		UpdatedAt:     time.Now(),
+		CompletedAt:   completedAt,
+		Metadata:      map[string]string{"request_uid": payload.RequestUID},
+	}
+
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: payload.Data.TransactionID,
+		Status:    status,
+		Raw:       json.RawMessage(body),
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	return response, nil
+}
+
+// checkReplay rejects a callback whose timestamp is outside the configured clock skew or
+// whose requestUId/timestamp pair has already been seen
+func (h *PromptPayWebhookHandler) checkReplay(requestUID, timestamp string) error {
+	if timestamp != "" {
+		parsed, err := time.Parse("20060102150405", timestamp)
+		if err == nil && absDuration(time.Since(parsed)) > h.options.clockSkew {
+			return fmt.Errorf("callback timestamp outside allowed clock skew")
+		}
+	}
+	if !h.replay.checkAndRemember(requestUID + ":" + timestamp) {
+		return ErrReplayedCallback
+	}
+	return nil
+}
+
+// ServeHTTP adapts VerifyWebhook to http.Handler, responding 200 on success and 400/401 for a
+// malformed or unverifiable callback
+func (h *PromptPayWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read callback body", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.VerifyWebhook(r.Header, body); err != nil {
+		writeThailandWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// TrueMoneyWebhookHandler verifies and dispatches inbound TrueMoney callbacks. It implements
+// http.Handler so it can be registered directly against a caller's mux.
+type TrueMoneyWebhookHandler struct {
+	merchantKey string
+	options     webhookOptions
+	replay      *replayCache
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewTrueMoneyWebhookHandler creates a handler verifying callbacks signed with merchantKey, the
+// same ThailandTrueMoneyConfig.MerchantKey used for outbound requests
+func NewTrueMoneyWebhookHandler(merchantKey string, opts ...WebhookOption) *TrueMoneyWebhookHandler {
+	options := defaultWebhookOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &TrueMoneyWebhookHandler{
+		merchantKey: merchantKey,
+		options:     options,
+		replay:      newReplayCache(options.replayTTL),
+	}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *TrueMoneyWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// VerifyWebhook validates body as a TrueMoney callback and, on success, dispatches a normalized
+// WebhookEvent to every registered listener. It returns the PaymentResponse the callback
+// describes so a caller can persist it directly instead of re-deriving one from
+// GetPaymentStatus. headers is accepted to match PromptPayWebhookHandler's signature but isn't
+// consulted, since TrueMoney's signature travels in the JSON body rather than a header.
+func (h *TrueMoneyWebhookHandler) VerifyWebhook(_ http.Header, body []byte) (*PaymentResponse, error) {
+	var params map[string]interface{}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse TrueMoney callback body: %w", err)
+	}
+
+	signature, _ := params["signature"].(string)
+	delete(params, "signature")
+	if !hmac.Equal([]byte(signature), []byte(trueMoneySignatureString(h.merchantKey, params))) {
+		return nil, ErrInvalidTrueMoneySignature
+	}
+
+	orderID, _ := params["order_id"].(string)
+	paymentID, _ := params["payment_id"].(string)
+	timestamp, _ := params["timestamp"].(string)
+	status, _ := params["status"].(string)
+	amountStr, _ := params["amount"].(string)
+	amount, _ := strconv.ParseFloat(amountStr, 64)
+
+	if err := h.checkReplay(orderID, timestamp); err != nil {
+		return nil, err
+	}
+
+	mappedStatus := mapTrueMoneyWebhookStatus(status)
+	var completedAt time.Time
+	if mappedStatus == StatusCompleted {
+		completedAt = time.Now()
+	}
+
+	response := &PaymentResponse{
+		PaymentID:     paymentID,
+		Status:        mappedStatus,
+		Amount:        amount,
+		Currency:      "THB",
+		PaymentMethod: MethodEWallet,
+		TransactionID: paymentID,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		CompletedAt:   completedAt,
+		Metadata:      map[string]string{"order_id": orderID},
+	}
+
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: paymentID,
+		Status:    mappedStatus,
+		Raw:       json.RawMessage(body),
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	return response, nil
+}
+
+// checkReplay rejects a callback whose timestamp is outside the configured clock skew or whose
+// order_id/timestamp pair has already been seen
+func (h *TrueMoneyWebhookHandler) checkReplay(orderID, timestamp string) error {
+	if timestamp != "" {
+		parsed, err := time.Parse("20060102150405", timestamp)
+		if err == nil && absDuration(time.Since(parsed)) > h.options.clockSkew {
+			return fmt.Errorf("callback timestamp outside allowed clock skew")
+		}
+	}
+	if !h.replay.checkAndRemember(orderID + ":" + timestamp) {
+		return ErrReplayedCallback
+	}
+	return nil
+}
+
+// ServeHTTP adapts VerifyWebhook to http.Handler, responding 200 on success and 400/401 for a
+// malformed or unverifiable callback
+func (h *TrueMoneyWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read callback body", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.VerifyWebhook(r.Header, body); err != nil {
+		writeThailandWebhookError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// mapTrueMoneyWebhookStatus maps a TrueMoney callback's status field (case-insensitively) to a
+// PaymentStatus. Unlike ThailandTrueMoney.GetPaymentStatus, which reports "cancelled" as
+// StatusFailed, it maps "cancelled" to StatusCancelled.
+func mapTrueMoneyWebhookStatus(status string) PaymentStatus {
+	switch strings.ToLower(status) {
+	case "success", "completed":
+		return StatusCompleted
+	case "failed":
+		return StatusFailed
+	case "cancelled":
+		return StatusCancelled
+	default:
+		return StatusPending
+	}
+}
+
+// writeThailandWebhookError writes a structured 400/401 response for a PromptPay/TrueMoney
+// callback that failed to verify: 401 for a signature/replay failure, 400 for anything else
+// (e.g. a malformed body)
+func writeThailandWebhookError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, ErrInvalidSCBSignature), errors.Is(err, ErrInvalidTrueMoneySignature), errors.Is(err, ErrReplayedCallback):
+		status = http.StatusUnauthorized
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
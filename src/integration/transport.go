@@ -0,0 +1,296 @@
+// Pluggable HTTP transport and request observability shared by payment platform
+// integrations
+// NewMalaysiaFPX/NewMalaysiaGrabPay previously constructed their *http.Client privately
+// with a hard-coded 30s timeout and no way to observe outbound calls. WithTransport and
+// WithObserver let a caller supply its own http.RoundTripper (for a custom proxy, mTLS
+// config, or a test double) and wire in logging/metrics/tracing without forking the
+// platform implementations.
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithTransport sets the http.RoundTripper a platform's HTTP client sends requests
+// through, e.g. for a custom proxy or mTLS configuration. Defaults to
+// http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) PlatformOption {
+	return func(o *platformOptions) { o.transport = transport }
+}
+
+// WithObserver attaches observer to a platform's HTTP client, notifying it of every
+// outbound request/response/error. Multiple observers can be combined with
+// ChainObservers. Defaults to NoopObserver.
+func WithObserver(observer Observer) PlatformOption {
+	return func(o *platformOptions) { o.observer = observer }
+}
+
+// RequestInfo describes an outbound platform API call an Observer is notified about
+type RequestInfo struct {
+	Platform  string // e.g. "FPX", "GrabPay"
+	Operation string // e.g. "payment", "refund", "status", derived from the request path
+	Method    string // HTTP method
+	URL       string
+	StartedAt time.Time
+}
+
+// ResponseInfo describes the outcome of a RequestInfo once the call completes
+type ResponseInfo struct {
+	RequestInfo
+	StatusCode int
+	Duration   time.Duration
+}
+
+// Observer is notified about outbound payment platform API calls. Implementations must be
+// safe for concurrent use, since a platform may issue overlapping requests from different
+// goroutines. OnRequest returns the context later passed to OnResponse/OnError for the same
+// call, so an implementation (e.g. OTelObserver) can thread request-scoped state such as a
+// started span through the round trip.
+type Observer interface {
+	OnRequest(ctx context.Context, req RequestInfo) context.Context
+	OnResponse(ctx context.Context, resp ResponseInfo)
+	OnError(ctx context.Context, req RequestInfo, err error)
+}
+
+// NoopObserver implements Observer with no-op methods, and is the default when a platform
+// constructor isn't given one
+type NoopObserver struct{}
+
+func (NoopObserver) OnRequest(ctx context.Context, _ RequestInfo) context.Context { return ctx }
+func (NoopObserver) OnResponse(context.Context, ResponseInfo)                     {}
+func (NoopObserver) OnError(context.Context, RequestInfo, error)                  {}
+
+// MultiObserver fans a single call out to every observer it wraps, in order. OnRequest
+// threads the context through each observer in turn, so a later observer sees any
+// request-scoped state an earlier one attached (e.g. an OTelObserver's span).
+type MultiObserver []Observer
+
+// ChainObservers combines observers into a single Observer that notifies each of them
+func ChainObservers(observers ...Observer) Observer {
+	return MultiObserver(observers)
+}
+
+func (m MultiObserver) OnRequest(ctx context.Context, req RequestInfo) context.Context {
+	for _, o := range m {
+		ctx = o.OnRequest(ctx, req)
+	}
+	return ctx
+}
+
+func (m MultiObserver) OnResponse(ctx context.Context, resp ResponseInfo) {
+	for _, o := range m {
+		o.OnResponse(ctx, resp)
+	}
+}
+
+func (m MultiObserver) OnError(ctx context.Context, req RequestInfo, err error) {
+	for _, o := range m {
+		o.OnError(ctx, req, err)
+	}
+}
+
+// redactedFieldPattern matches "key":"value" or key=value pairs for fields that must never
+// reach a log line: merchant keys, client secrets, bearer tokens, HMAC signatures, and the
+// accessKey/vnp_SecureHash fields MoMo and VNPay sign their requests with
+var redactedFieldPattern = regexp.MustCompile(`(?i)("(?:merchantKey|clientSecret|client_secret|access_token|accessKey|signature|vnp_SecureHash)"\s*:\s*")[^"]*(")|((?:merchantKey|clientSecret|client_secret|access_token|accessKey|signature|vnp_SecureHash)=)[^&\s]*`)
+
+// cardNumberPattern matches a 12-19 digit run, optionally grouped with spaces or hyphens, so
+// a card PAN never reaches a log line even for a platform without an explicit cardNumber field
+var cardNumberPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,19}\b`)
+
+// redact replaces sensitive field values in s with "***", for logging request/response
+// bodies that may contain a MerchantKey, ClientSecret, access token, HMAC signature, or card
+// number. Card numbers keep their last 4 digits, matching the truncation merchants already
+// see on a receipt.
+func redact(s string) string {
+	s = redactedFieldPattern.ReplaceAllString(s, "${1}${3}***${2}")
+	return cardNumberPattern.ReplaceAllStringFunc(s, func(match string) string {
+		digitsOnly := strings.Map(func(r rune) rune {
+			if r < '0' || r > '9' {
+				return -1
+			}
+			return r
+		}, match)
+		if len(digitsOnly) < 12 {
+			return match
+		}
+		return "**** **** **** " + digitsOnly[len(digitsOnly)-4:]
+	})
+}
+
+// LoggingObserver emits one structured log line per request/response/error via a
+// *log.Logger, redacting known sensitive fields first
+type LoggingObserver struct {
+	logger *log.Logger
+}
+
+// NewLoggingObserver builds a LoggingObserver writing through logger
+func NewLoggingObserver(logger *log.Logger) *LoggingObserver {
+	return &LoggingObserver{logger: logger}
+}
+
+func (o *LoggingObserver) OnRequest(ctx context.Context, req RequestInfo) context.Context {
+	o.logger.Printf("payment_request platform=%s operation=%s method=%s url=%s",
+		req.Platform, req.Operation, req.Method, redact(req.URL))
+	return ctx
+}
+
+func (o *LoggingObserver) OnResponse(_ context.Context, resp ResponseInfo) {
+	o.logger.Printf("payment_response platform=%s operation=%s status=%d duration_ms=%d",
+		resp.Platform, resp.Operation, resp.StatusCode, resp.Duration.Milliseconds())
+}
+
+func (o *LoggingObserver) OnError(_ context.Context, req RequestInfo, err error) {
+	o.logger.Printf("payment_error platform=%s operation=%s error=%q",
+		req.Platform, req.Operation, redact(err.Error()))
+}
+
+// OTelObserver starts an OpenTelemetry span covering each request's round trip
+type OTelObserver struct {
+	tracer trace.Tracer
+}
+
+// NewOTelObserver builds an OTelObserver using tracer to start spans. A nil tracer uses the
+// global tracer provider's tracer for this package.
+func NewOTelObserver(tracer trace.Tracer) *OTelObserver {
+	if tracer == nil {
+		tracer = otel.Tracer("integration")
+	}
+	return &OTelObserver{tracer: tracer}
+}
+
+func (o *OTelObserver) OnRequest(ctx context.Context, req RequestInfo) context.Context {
+	ctx, _ = o.tracer.Start(ctx, req.Platform+"."+req.Operation,
+		trace.WithAttributes(
+			attribute.String("payment.platform", req.Platform),
+			attribute.String("payment.operation", req.Operation),
+			attribute.String("http.method", req.Method),
+		))
+	return ctx
+}
+
+func (o *OTelObserver) OnResponse(ctx context.Context, resp ResponseInfo) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, fmt.Sprintf("http status %d", resp.StatusCode))
+	} else {
+		span.SetStatus(codes.Ok, "")
+	}
+	span.End()
+}
+
+func (o *OTelObserver) OnError(ctx context.Context, _ RequestInfo, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	span.End()
+}
+
+// PrometheusObserver records payment_requests_total and payment_latency_seconds for each
+// request an instrumented platform client makes
+type PrometheusObserver struct {
+	requestsTotal  *prometheus.CounterVec
+	latencySeconds *prometheus.HistogramVec
+}
+
+// NewPrometheusObserver registers payment_requests_total{platform,method,status} and
+// payment_latency_seconds{platform,method} against registerer
+func NewPrometheusObserver(registerer prometheus.Registerer) *PrometheusObserver {
+	requestsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "payment_requests_total",
+		Help: "Total outbound payment platform API requests, by platform, operation, and result status",
+	}, []string{"platform", "method", "status"})
+
+	latencySeconds := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "payment_latency_seconds",
+		Help:    "Latency of outbound payment platform API requests, by platform and operation",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"platform", "method"})
+
+	registerer.MustRegister(requestsTotal, latencySeconds)
+
+	return &PrometheusObserver{requestsTotal: requestsTotal, latencySeconds: latencySeconds}
+}
+
+func (o *PrometheusObserver) OnRequest(ctx context.Context, _ RequestInfo) context.Context {
+	return ctx
+}
+
+func (o *PrometheusObserver) OnResponse(_ context.Context, resp ResponseInfo) {
+	o.requestsTotal.WithLabelValues(resp.Platform, resp.Operation, strconv.Itoa(resp.StatusCode)).Inc()
+	o.latencySeconds.WithLabelValues(resp.Platform, resp.Operation).Observe(resp.Duration.Seconds())
+}
+
+func (o *PrometheusObserver) OnError(_ context.Context, req RequestInfo, _ error) {
+	o.requestsTotal.WithLabelValues(req.Platform, req.Operation, "error").Inc()
+}
+
+// observingTransport wraps an http.RoundTripper, notifying observer around every round
+// trip it performs
+type observingTransport struct {
+	next     http.RoundTripper
+	observer Observer
+	platform string
+}
+
+// newObservingTransport wraps next (http.DefaultTransport if nil) so every request made
+// through it is reported to observer under platform
+func newObservingTransport(next http.RoundTripper, observer Observer, platform string) *observingTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if observer == nil {
+		observer = NoopObserver{}
+	}
+	return &observingTransport{next: next, observer: observer, platform: platform}
+}
+
+func (t *observingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	info := RequestInfo{
+		Platform:  t.platform,
+		Operation: operationFromPath(req.URL.Path),
+		Method:    req.Method,
+		URL:       req.URL.String(),
+		StartedAt: time.Now(),
+	}
+	ctx := t.observer.OnRequest(req.Context(), info)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		t.observer.OnError(ctx, info, err)
+		return nil, err
+	}
+
+	t.observer.OnResponse(ctx, ResponseInfo{
+		RequestInfo: info,
+		StatusCode:  resp.StatusCode,
+		Duration:    time.Since(info.StartedAt),
+	})
+	return resp, nil
+}
+
+// operationFromPath derives a coarse operation label from a request path's final segment,
+// e.g. "/grabpay/partner/v2/charge/init" -> "init"
+func operationFromPath(urlPath string) string {
+	segment := path.Base(urlPath)
+	if segment == "" || segment == "." || segment == "/" {
+		return "unknown"
+	}
+	return strings.TrimSuffix(segment, "/")
+}
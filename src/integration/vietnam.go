@@ -5,8 +5,13 @@ package integration
 
 import (
 	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/hmac"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -14,31 +19,33 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"sort"
 	"strconv"
-	"strings"
 	"time"
 )
 
 // VietnamMoMoConfig holds configuration for MoMo integration
 type VietnamMoMoConfig struct {
-	PartnerCode   string
-	AccessKey     string
-	SecretKey     string
-	APIEndpoint   string
-	CallbackURL   string
-	RedirectURL   string
-	TestMode      bool
+	PartnerCode string
+	AccessKey   string
+	SecretKey   string
+	APIEndpoint string
+	CallbackURL string
+	RedirectURL string
+	TestMode    bool
 }
 
 // VietnamMoMo implements PaymentPlatform interface for Vietnam's MoMo
 type VietnamMoMo struct {
-	config VietnamMoMoConfig
-	client *http.Client
+	config      VietnamMoMoConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	language    Language
 }
 
-// NewVietnamMoMo creates a new MoMo payment platform
-func NewVietnamMoMo(config VietnamMoMoConfig) *VietnamMoMo {
+// NewVietnamMoMo creates a new MoMo payment platform. By default errors are in English and
+// requests retry with DefaultRetryPolicy; pass WithLocalization/WithRetry to change that, or
+// WithHTTPClient/WithTransport/WithObserver to customize the underlying HTTP client.
+func NewVietnamMoMo(config VietnamMoMoConfig, opts ...PlatformOption) *VietnamMoMo {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -48,11 +55,22 @@ func NewVietnamMoMo(config VietnamMoMoConfig) *VietnamMoMo {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "MoMo")
+
 	return &VietnamMoMo{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		language:    options.language,
 	}
 }
 
@@ -76,8 +94,16 @@ func (p *VietnamMoMo) GetSupportedCurrencies() []string {
 	return []string{"VND"}
 }
 
-// CreatePayment creates a payment
-func (p *VietnamMoMo) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+// CreatePayment creates a payment. opts may override the call's timeout or idempotency key
+// (a per-call retry policy is not applied).
+func (p *VietnamMoMo) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Validate request
 	if request.Currency != "VND" {
 		return nil, errors.New("currency must be VND for MoMo payments")
@@ -87,30 +113,38 @@ func (p *VietnamMoMo) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
-	// Prepare MoMo request
-	requestID := fmt.Sprintf("%s_%d", request.OrderID, time.Now().UnixNano())
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	// Prepare MoMo request. requestID doubles as MoMo's own dedup key, so an IdempotencyKey
+	// is reused as-is rather than folded into a freshly generated one: a retried call with
+	// the same key reaches MoMo as the same requestId instead of minting a new payment.
+	requestID := idempotencyKey
+	if requestID == "" {
+		requestID = fmt.Sprintf("%s_%d", request.OrderID, time.Now().UnixNano())
+	}
 	orderInfo := fmt.Sprintf("Payment for order %s", request.OrderID)
-	
+
 	// Convert amount to integer (MoMo requires integer amount)
 	amount := int64(request.Amount)
-	
-	// Prepare raw signature
-	rawSignature := fmt.Sprintf("accessKey=%s&amount=%d&extraData=&ipnUrl=%s&orderId=%s&orderInfo=%s&partnerCode=%s&redirectUrl=%s&requestId=%s&requestType=captureMoMoWallet",
-		p.config.AccessKey,
-		amount,
-		p.config.CallbackURL,
-		request.OrderID,
-		orderInfo,
-		p.config.PartnerCode,
-		p.config.RedirectURL,
-		requestID,
-	)
-	
-	// Generate signature
-	h := hmac.New(sha256.New, []byte(p.config.SecretKey))
-	h.Write([]byte(rawSignature))
-	signature := hex.EncodeToString(h.Sum(nil))
-	
+
+	// Sign MoMo's raw parameter string
+	rawSignature := momoRawSignature(url.Values{
+		"accessKey":   {p.config.AccessKey},
+		"amount":      {fmt.Sprintf("%d", amount)},
+		"extraData":   {""},
+		"ipnUrl":      {p.config.CallbackURL},
+		"orderId":     {request.OrderID},
+		"orderInfo":   {orderInfo},
+		"partnerCode": {p.config.PartnerCode},
+		"redirectUrl": {p.config.RedirectURL},
+		"requestId":   {requestID},
+		"requestType": {"captureMoMoWallet"},
+	})
+	signature := hmacSHA256Hex([]byte(p.config.SecretKey), []byte(rawSignature))
+
 	// Prepare MoMo request
 	momoRequest := map[string]interface{}{
 		"partnerCode": p.config.PartnerCode,
@@ -132,18 +166,17 @@ func (p *VietnamMoMo) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v2/gateway/api/create", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v2/gateway/api/create", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -194,24 +227,28 @@ func (p *VietnamMoMo) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 	return response, nil
 }
 
-// GetPaymentStatus gets the status of a payment
-func (p *VietnamMoMo) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+// GetPaymentStatus gets the status of a payment. opts may override the call's timeout
+// (a per-call retry policy is not applied).
+func (p *VietnamMoMo) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
 	// Prepare status request
 	requestID := fmt.Sprintf("status_%s_%d", request.PaymentID, time.Now().UnixNano())
-	
-	// Prepare raw signature
-	rawSignature := fmt.Sprintf("accessKey=%s&orderId=%s&partnerCode=%s&requestId=%s",
-		p.config.AccessKey,
-		request.PaymentID,
-		p.config.PartnerCode,
-		requestID,
-	)
-	
-	// Generate signature
-	h := hmac.New(sha256.New, []byte(p.config.SecretKey))
-	h.Write([]byte(rawSignature))
-	signature := hex.EncodeToString(h.Sum(nil))
-	
+
+	// Sign MoMo's raw parameter string
+	rawSignature := momoRawSignature(url.Values{
+		"accessKey":   {p.config.AccessKey},
+		"orderId":     {request.PaymentID},
+		"partnerCode": {p.config.PartnerCode},
+		"requestId":   {requestID},
+	})
+	signature := hmacSHA256Hex([]byte(p.config.SecretKey), []byte(rawSignature))
+
 	// Prepare status request
 	statusRequest := map[string]interface{}{
 		"partnerCode": p.config.PartnerCode,
@@ -228,18 +265,17 @@ func (p *VietnamMoMo) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v2/gateway/api/query", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v2/gateway/api/query", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -271,7 +307,7 @@ func (p *VietnamMoMo) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 	transID, _ := statusResponse["transId"].(string)
 	payType, _ := statusResponse["payType"].(string)
 	responseTime, _ := statusResponse["responseTime"].(float64)
-	
+
 	// Map MoMo status to our status
 	status := StatusPending
 	var completedAt time.Time
@@ -302,30 +338,43 @@ func (p *VietnamMoMo) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentR
 	return response, nil
 }
 
-// RefundPayment refunds a payment
-func (p *VietnamMoMo) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
-	// Prepare refund request
-	requestID := fmt.Sprintf("refund_%s_%d", request.PaymentID, time.Now().UnixNano())
-	
+// RefundPayment refunds a payment. opts may override the call's timeout or idempotency key
+// (a per-call retry policy is not applied).
+func (p *VietnamMoMo) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	idempotencyKey := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		idempotencyKey = options.IdempotencyKey
+	}
+
+	// Prepare refund request. requestID doubles as MoMo's own dedup key, so an
+	// IdempotencyKey is reused as-is rather than folded into a freshly generated one.
+	requestID := idempotencyKey
+	if requestID == "" {
+		requestID = fmt.Sprintf("refund_%s_%d", request.PaymentID, time.Now().UnixNano())
+	}
+
 	// Convert amount to integer (MoMo requires integer amount)
 	amount := int64(request.Amount)
-	
-	// Prepare raw signature
-	rawSignature := fmt.Sprintf("accessKey=%s&amount=%d&description=%s&orderId=%s&partnerCode=%s&requestId=%s&transId=%s",
-		p.config.AccessKey,
-		amount,
-		request.Reason,
-		request.PaymentID,
-		p.config.PartnerCode,
-		requestID,
-		request.PaymentID, // Using payment ID as transaction ID
-	)
-	
-	// Generate signature
-	h := hmac.New(sha256.New, []byte(p.config.SecretKey))
-	h.Write([]byte(rawSignature))
-	signature := hex.EncodeToString(h.Sum(nil))
-	
+
+	// Sign MoMo's raw parameter string
+	rawSignature := momoRawSignature(url.Values{
+		"accessKey":   {p.config.AccessKey},
+		"amount":      {fmt.Sprintf("%d", amount)},
+		"description": {request.Reason},
+		"orderId":     {request.PaymentID},
+		"partnerCode": {p.config.PartnerCode},
+		"requestId":   {requestID},
+		"transId":     {request.PaymentID}, // Using payment ID as transaction ID
+	})
+	signature := hmacSHA256Hex([]byte(p.config.SecretKey), []byte(rawSignature))
+
 	// Prepare refund request
 	refundRequest := map[string]interface{}{
 		"partnerCode": p.config.PartnerCode,
@@ -344,18 +393,17 @@ func (p *VietnamMoMo) RefundPayment(request *RefundRequest) (*RefundResponse, er
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", p.config.APIEndpoint+"/v2/gateway/api/refund", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
-
-	// Add headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.APIEndpoint+"/v2/gateway/api/refund", bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -402,22 +450,36 @@ func (p *VietnamMoMo) RefundPayment(request *RefundRequest) (*RefundResponse, er
 
 // VietnamVNPayConfig holds configuration for VNPay integration
 type VietnamVNPayConfig struct {
-	MerchantID     string
-	SecureHash     string
-	APIEndpoint    string
-	CallbackURL    string
-	RedirectURL    string
-	TestMode       bool
+	MerchantID  string
+	SecureHash  string
+	APIEndpoint string
+	CallbackURL string
+	RedirectURL string
+	TestMode    bool
 }
 
 // VietnamVNPay implements PaymentPlatform interface for Vietnam's VNPay
 type VietnamVNPay struct {
-	config VietnamVNPayConfig
-	client *http.Client
+	config      VietnamVNPayConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	language    Language
+}
+
+// vnpayLocale maps lang to the vnp_Locale value VNPay expects: "vn" for Vietnamese responses,
+// "en" for English. Any language other than LanguageEnglish falls back to "vn" since VNPay
+// itself only supports those two locales.
+func vnpayLocale(lang Language) string {
+	if lang == LanguageEnglish {
+		return "en"
+	}
+	return "vn"
 }
 
-// NewVietnamVNPay creates a new VNPay payment platform
-func NewVietnamVNPay(config VietnamVNPayConfig) *VietnamVNPay {
+// NewVietnamVNPay creates a new VNPay payment platform. By default errors are in English and
+// requests retry with DefaultRetryPolicy; pass WithLocalization/WithRetry to change that, or
+// WithHTTPClient/WithTransport/WithObserver to customize the underlying HTTP client.
+func NewVietnamVNPay(config VietnamVNPayConfig, opts ...PlatformOption) *VietnamVNPay {
 	// Set default API endpoint if not provided
 	if config.APIEndpoint == "" {
 		if config.TestMode {
@@ -427,11 +489,22 @@ func NewVietnamVNPay(config VietnamVNPayConfig) *VietnamVNPay {
 		}
 	}
 
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "VNPay")
+
 	return &VietnamVNPay{
-		config: config,
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		language:    options.language,
 	}
 }
 
@@ -455,8 +528,12 @@ func (p *VietnamVNPay) GetSupportedCurrencies() []string {
 	return []string{"VND"}
 }
 
-// CreatePayment creates a payment
-func (p *VietnamVNPay) CreatePayment(request *PaymentRequest) (*PaymentResponse, error) {
+// CreatePayment creates a payment. opts may override the call's client IP (otherwise
+// reported as 127.0.0.1); VNPay's CreatePayment makes no HTTP call of its own and keeps no
+// idempotency record, so a timeout, retry or idempotency-key override has nothing to apply to.
+func (p *VietnamVNPay) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+
 	// Validate request
 	if request.Currency != "VND" {
 		return nil, errors.New("currency must be VND for VNPay payments")
@@ -466,6 +543,11 @@ func (p *VietnamVNPay) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 		return nil, ErrUnsupportedPaymentMethod
 	}
 
+	clientIP := options.ClientIP
+	if clientIP == "" {
+		clientIP = "127.0.0.1"
+	}
+
 	// Prepare VNPay request
 	vnpParams := url.Values{}
 	vnpParams.Add("vnp_Version", "2.1.0")
@@ -476,13 +558,13 @@ func (p *VietnamVNPay) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 	vnpParams.Add("vnp_TxnRef", request.OrderID)
 	vnpParams.Add("vnp_OrderInfo", request.Description)
 	vnpParams.Add("vnp_OrderType", "other")
-	vnpParams.Add("vnp_Locale", "vn")
+	vnpParams.Add("vnp_Locale", vnpayLocale(p.language))
 	vnpParams.Add("vnp_ReturnUrl", p.config.RedirectURL)
-	vnpParams.Add("vnp_IpAddr", "127.0.0.1") // Should be replaced with actual IP in production
-	
+	vnpParams.Add("vnp_IpAddr", clientIP)
+
 	// Add create date in VNPay format
 	vnpParams.Add("vnp_CreateDate", time.Now().Format("20060102150405"))
-	
+
 	// Set payment method
 	if request.PaymentMethod == MethodCreditCard {
 		vnpParams.Add("vnp_BankCode", "INTCARD")
@@ -491,37 +573,12 @@ func (p *VietnamVNPay) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 	} else if request.PaymentMethod == MethodQRCode {
 		vnpParams.Add("vnp_BankCode", "VNPAYQR")
 	}
-	
-	// Sort parameters by key
-	var sortedKeys []string
-	for k := range vnpParams {
-		sortedKeys = append(sortedKeys, k)
-	}
-	sort.Strings(sortedKeys)
-	
-	// Build query string
-	var queryBuilder strings.Builder
-	for _, k := range sortedKeys {
-		queryBuilder.WriteString(k)
-		queryBuilder.WriteString("=")
-		queryBuilder.WriteString(vnpParams.Get(k))
-		queryBuilder.WriteString("&")
-	}
-	
-	// Remove trailing &
-	queryString := queryBuilder.String()
-	if len(queryString) > 0 {
-		queryString = queryString[:len(queryString)-1]
-	}
-	
-	// Generate secure hash
-	h := hmac.New(sha256.New, []byte(p.config.SecureHash))
-	h.Write([]byte(queryString))
-	secureHash := hex.EncodeToString(h.Sum(nil))
-	
-	// Add secure hash to query string
+
+	// Sign the URL-encoded canonical form of vnpParams, matching what VNPay actually receives
+	// once vnpParams.Encode() below percent-encodes the same values
+	secureHash := hmacSHA256Hex([]byte(p.config.SecureHash), []byte(vnpayCanonical(vnpParams)))
 	vnpParams.Add("vnp_SecureHash", secureHash)
-	
+
 	// Build payment URL
 	paymentURL := p.config.APIEndpoint + "/vpcpay.html?" + vnpParams.Encode()
 
@@ -544,7 +601,19 @@ func (p *VietnamVNPay) CreatePayment(request *PaymentRequest) (*PaymentResponse,
 }
 
 // GetPaymentStatus gets the status of a payment
-func (p *VietnamVNPay) GetPaymentStatus(request *PaymentStatusRequest) (*PaymentResponse, error) {
+func (p *VietnamVNPay) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	clientIP := options.ClientIP
+	if clientIP == "" {
+		clientIP = "127.0.0.1"
+	}
+
 	// Prepare status request
 	vnpParams := url.Values{}
 	vnpParams.Add("vnp_Version", "2.1.0")
@@ -554,46 +623,17 @@ func (p *VietnamVNPay) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 	vnpParams.Add("vnp_OrderInfo", "Query transaction status")
 	vnpParams.Add("vnp_TransDate", time.Now().Format("20060102150405"))
 	vnpParams.Add("vnp_CreateDate", time.Now().Format("20060102150405"))
-	vnpParams.Add("vnp_IpAddr", "127.0.0.1") // Should be replaced with actual IP in production
-	
-	// Sort parameters by key
-	var sortedKeys []string
-	for k := range vnpParams {
-		sortedKeys = append(sortedKeys, k)
-	}
-	sort.Strings(sortedKeys)
-	
-	// Build query string
-	var queryBuilder strings.Builder
-	for _, k := range sortedKeys {
-		queryBuilder.WriteString(k)
-		queryBuilder.WriteString("=")
-		queryBuilder.WriteString(vnpParams.Get(k))
-		queryBuilder.WriteString("&")
-	}
-	
-	// Remove trailing &
-	queryString := queryBuilder.String()
-	if len(queryString) > 0 {
-		queryString = queryString[:len(queryString)-1]
-	}
-	
-	// Generate secure hash
-	h := hmac.New(sha256.New, []byte(p.config.SecureHash))
-	h.Write([]byte(queryString))
-	secureHash := hex.EncodeToString(h.Sum(nil))
-	
-	// Add secure hash to query string
+	vnpParams.Add("vnp_IpAddr", clientIP)
+
+	// Sign the URL-encoded canonical form of vnpParams, matching what VNPay actually receives
+	// once vnpParams.Encode() below percent-encodes the same values
+	secureHash := hmacSHA256Hex([]byte(p.config.SecureHash), []byte(vnpayCanonical(vnpParams)))
 	vnpParams.Add("vnp_SecureHash", secureHash)
-	
-	// Create HTTP request
-	req, err := http.NewRequest("GET", p.config.APIEndpoint+"/merchant_webapi/api/transaction?"+vnpParams.Encode(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
 
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", p.config.APIEndpoint+"/merchant_webapi/api/transaction?"+vnpParams.Encode(), nil)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -627,7 +667,7 @@ func (p *VietnamVNPay) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 	amount = amount / 100 // Convert from smallest currency unit
 	bankCode, _ := statusResponse["vnp_BankCode"].(string)
 	transactionDate, _ := statusResponse["vnp_PayDate"].(string)
-	
+
 	// Parse transaction date
 	var createdAt time.Time
 	if transactionDate != "" {
@@ -679,7 +719,19 @@ func (p *VietnamVNPay) GetPaymentStatus(request *PaymentStatusRequest) (*Payment
 }
 
 // RefundPayment refunds a payment
-func (p *VietnamVNPay) RefundPayment(request *RefundRequest) (*RefundResponse, error) {
+func (p *VietnamVNPay) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	clientIP := options.ClientIP
+	if clientIP == "" {
+		clientIP = "127.0.0.1"
+	}
+
 	// Prepare refund request
 	vnpParams := url.Values{}
 	vnpParams.Add("vnp_Version", "2.1.0")
@@ -690,47 +742,18 @@ func (p *VietnamVNPay) RefundPayment(request *RefundRequest) (*RefundResponse, e
 	vnpParams.Add("vnp_OrderInfo", request.Reason)
 	vnpParams.Add("vnp_TransDate", time.Now().Format("20060102150405"))
 	vnpParams.Add("vnp_CreateDate", time.Now().Format("20060102150405"))
-	vnpParams.Add("vnp_IpAddr", "127.0.0.1") // Should be replaced with actual IP in production
+	vnpParams.Add("vnp_IpAddr", clientIP)
 	vnpParams.Add("vnp_TransactionType", "02") // 02 for refund
-	
-	// Sort parameters by key
-	var sortedKeys []string
-	for k := range vnpParams {
-		sortedKeys = append(sortedKeys, k)
-	}
-	sort.Strings(sortedKeys)
-	
-	// Build query string
-	var queryBuilder strings.Builder
-	for _, k := range sortedKeys {
-		queryBuilder.WriteString(k)
-		queryBuilder.WriteString("=")
-		queryBuilder.WriteString(vnpParams.Get(k))
-		queryBuilder.WriteString("&")
-	}
-	
-	// Remove trailing &
-	queryString := queryBuilder.String()
-	if len(queryString) > 0 {
-		queryString = queryString[:len(queryString)-1]
-	}
-	
-	// Generate secure hash
-	h := hmac.New(sha256.New, []byte(p.config.SecureHash))
-	h.Write([]byte(queryString))
-	secureHash := hex.EncodeToString(h.Sum(nil))
-	
-	// Add secure hash to query string
+
+	// Sign the URL-encoded canonical form of vnpParams, matching what VNPay actually receives
+	// once vnpParams.Encode() below percent-encodes the same values
+	secureHash := hmacSHA256Hex([]byte(p.config.SecureHash), []byte(vnpayCanonical(vnpParams)))
 	vnpParams.Add("vnp_SecureHash", secureHash)
-	
-	// Create HTTP request
-	req, err := http.NewRequest("GET", p.config.APIEndpoint+"/merchant_webapi/api/transaction?"+vnpParams.Encode(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
-	}
 
 	// Make API request
-	resp, err := p.client.Do(req)
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, "GET", p.config.APIEndpoint+"/merchant_webapi/api/transaction?"+vnpParams.Encode(), nil)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to make API request: %w", err)
 	}
@@ -759,13 +782,473 @@ func (p *VietnamVNPay) RefundPayment(request *RefundRequest) (*RefundResponse, e
 
 	// Create response
 	response := &RefundResponse{
-		RefundID:     request.RefundID,
-		PaymentID:    request.PaymentID,
-		Amount:       request.Amount,
-		Currency:     "VND",
-		Status:       "success",
-		CreatedAt:    time.Now(),
+		RefundID:  request.RefundID,
+		PaymentID: request.PaymentID,
+		Amount:    request.Amount,
+		Currency:  "VND",
+		Status:    "success",
+		CreatedAt: time.Now(),
 	}
 
 	return response, nil
 }
+
+// VietnamKPayConfig holds configuration for Kienlongbank's KLBPay (KPay) integration
+type VietnamKPayConfig struct {
+	Host             string
+	ClientID         string
+	SecretKey        string
+	EncryptKey       string
+	MaxTimestampDiff int64 // seconds; how far a request/callback's timestamp may drift from now
+	CallbackURL      string
+	RedirectURL      string
+	TestMode         bool
+}
+
+// VietnamKPay implements PaymentPlatform interface for Kienlongbank's KLBPay
+type VietnamKPay struct {
+	config      VietnamKPayConfig
+	client      *http.Client
+	retryClient *RetryingHTTPClient
+	language    Language
+}
+
+// NewVietnamKPay creates a new KLBPay payment platform. By default errors are in English and
+// requests retry with DefaultRetryPolicy; pass WithLocalization/WithRetry to change that, or
+// WithHTTPClient/WithTransport/WithObserver to customize the underlying HTTP client.
+func NewVietnamKPay(config VietnamKPayConfig, opts ...PlatformOption) *VietnamKPay {
+	if config.MaxTimestampDiff <= 0 {
+		config.MaxTimestampDiff = 300
+	}
+
+	options := defaultPlatformOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	client := options.httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	client.Transport = newObservingTransport(options.transport, options.observer, "KPay")
+
+	return &VietnamKPay{
+		config:      config,
+		client:      client,
+		retryClient: NewRetryingHTTPClient(client, options.retryPolicy),
+		language:    options.language,
+	}
+}
+
+// GetName returns the name of the payment platform
+func (p *VietnamKPay) GetName() string {
+	return "KPay"
+}
+
+// GetCountryCode returns the country code of the payment platform
+func (p *VietnamKPay) GetCountryCode() string {
+	return "VN"
+}
+
+// GetSupportedPaymentMethods returns the supported payment methods
+func (p *VietnamKPay) GetSupportedPaymentMethods() []PaymentMethod {
+	return []PaymentMethod{MethodBankTransfer, MethodQRCode}
+}
+
+// GetSupportedCurrencies returns the supported currencies
+func (p *VietnamKPay) GetSupportedCurrencies() []string {
+	return []string{"VND"}
+}
+
+// kpayCustomer carries the customer details KLBPay attaches to a transaction
+type kpayCustomer struct {
+	Fullname string `json:"fullname,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Phone    string `json:"phone,omitempty"`
+	Address  string `json:"address,omitempty"`
+}
+
+// kpayCreateTransactionRequest is the plaintext body encrypted and POSTed to KLBPay's
+// CreateTransaction endpoint
+type kpayCreateTransactionRequest struct {
+	RefTransactionID string       `json:"refTransactionId"`
+	Amount           int64        `json:"amount"`
+	Description      string       `json:"description"`
+	Timeout          int64        `json:"timeout"`
+	Title            string       `json:"title"`
+	Language         string       `json:"language"`
+	Customer         kpayCustomer `json:"customer"`
+}
+
+type kpayCreateTransactionResponse struct {
+	ResultCode    int    `json:"resultCode"`
+	Message       string `json:"message"`
+	TransactionID string `json:"transactionId"`
+	PayURL        string `json:"payUrl"`
+	QRCodeURL     string `json:"qrCodeUrl"`
+}
+
+// kpayEnvelope wraps an encrypted request/response body alongside the signature and
+// timestamp it was signed with
+type kpayEnvelope struct {
+	ClientID  string `json:"clientId"`
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature"`
+	Data      string `json:"data"`
+}
+
+// kpaySign computes KLBPay's request signature: HMAC-SHA256 over "clientId|timestamp|body"
+func kpaySign(secretKey, clientID string, timestamp int64, body []byte) string {
+	raw := fmt.Sprintf("%s|%d|%s", clientID, timestamp, body)
+	h := hmac.New(sha256.New, []byte(secretKey))
+	h.Write([]byte(raw))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// kpayEncryptionKey derives a 32-byte AES-256 key from the configured EncryptKey, which may
+// be any length
+func kpayEncryptionKey(encryptKey string) [32]byte {
+	return sha256.Sum256([]byte(encryptKey))
+}
+
+// kpayEncrypt AES-256-GCM encrypts plaintext under encryptKey, prepending the random nonce
+// to the returned ciphertext and base64-encoding the result for transport in JSON
+func kpayEncrypt(encryptKey string, plaintext []byte) (string, error) {
+	key := kpayEncryptionKey(encryptKey)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to create cipher block: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", fmt.Errorf("failed to create GCM: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// kpayDecrypt reverses kpayEncrypt
+func kpayDecrypt(encryptKey string, encoded string) ([]byte, error) {
+	key := kpayEncryptionKey(encryptKey)
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher block: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("KLBPay ciphertext shorter than nonce")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+// kpayCheckTimestamp rejects a request or callback timestamp (Unix seconds) that has drifted
+// from now by more than maxDiff seconds, matching KLBPay's own MaxTimeStampDiff behavior
+func kpayCheckTimestamp(timestamp, maxDiff int64) error {
+	diff := time.Now().Unix() - timestamp
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > maxDiff {
+		return fmt.Errorf("KLBPay timestamp %d drifted %ds from now, exceeding MaxTimestampDiff of %ds", timestamp, diff, maxDiff)
+	}
+	return nil
+}
+
+// kpaySend encrypts body, wraps it in a signed kpayEnvelope, POSTs it to p.config.Host+path,
+// and decrypts the response envelope's Data back into out
+func (p *VietnamKPay) kpaySend(ctx context.Context, path string, body []byte, out interface{}) error {
+	timestamp := time.Now().Unix()
+	encryptedBody, err := kpayEncrypt(p.config.EncryptKey, body)
+	if err != nil {
+		return err
+	}
+
+	envelope := kpayEnvelope{
+		ClientID:  p.config.ClientID,
+		Timestamp: timestamp,
+		Signature: kpaySign(p.config.SecretKey, p.config.ClientID, timestamp, body),
+		Data:      encryptedBody,
+	}
+	jsonData, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request envelope: %w", err)
+	}
+
+	resp, err := p.retryClient.Do(func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", p.config.Host+path, bytes.NewBuffer(jsonData))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Language", acceptLanguageHeader(p.language))
+		return req, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to make API request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var respEnvelope kpayEnvelope
+	if err := json.Unmarshal(respBody, &respEnvelope); err != nil {
+		return fmt.Errorf("failed to parse response envelope: %w", err)
+	}
+	if err := kpayCheckTimestamp(respEnvelope.Timestamp, p.config.MaxTimestampDiff); err != nil {
+		return err
+	}
+
+	decrypted, err := kpayDecrypt(p.config.EncryptKey, respEnvelope.Data)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt response: %w", err)
+	}
+	if err := json.Unmarshal(decrypted, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// CreatePayment creates a payment, calling KLBPay's CreateTransaction. opts may override the
+// call's timeout or idempotency key (a per-call retry policy is not applied).
+func (p *VietnamKPay) CreatePayment(ctx context.Context, request *PaymentRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	if request.Currency != "VND" {
+		return nil, errors.New("currency must be VND for KLBPay payments")
+	}
+	if request.PaymentMethod != MethodBankTransfer && request.PaymentMethod != MethodQRCode {
+		return nil, ErrUnsupportedPaymentMethod
+	}
+
+	refTransactionID := request.IdempotencyKey
+	if options.IdempotencyKey != "" {
+		refTransactionID = options.IdempotencyKey
+	}
+	if refTransactionID == "" {
+		refTransactionID = fmt.Sprintf("%s_%d", request.OrderID, time.Now().UnixNano())
+	}
+
+	timeout := int64(900) // 15 minutes, matching the other Vietnam platforms' default expiry
+	if !request.ExpiryTime.IsZero() {
+		if remaining := int64(time.Until(request.ExpiryTime).Seconds()); remaining > 0 {
+			timeout = remaining
+		}
+	}
+
+	createRequest := kpayCreateTransactionRequest{
+		RefTransactionID: refTransactionID,
+		Amount:           int64(request.Amount),
+		Description:      localizedDescription(request.Description, request.OrderID, p.language),
+		Timeout:          timeout,
+		Title:            fmt.Sprintf("Payment for order %s", request.OrderID),
+		Language:         string(p.language),
+		Customer: kpayCustomer{
+			Fullname: request.CustomerName,
+			Email:    request.CustomerEmail,
+			Phone:    request.CustomerPhone,
+			Address:  request.CustomerAddress,
+		},
+	}
+	body, err := json.Marshal(createRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var kpayResponse kpayCreateTransactionResponse
+	if err := p.kpaySend(ctx, "/api/v1/transaction/create", body, &kpayResponse); err != nil {
+		return nil, err
+	}
+
+	if kpayResponse.ResultCode != 0 {
+		errorMsg := kpayResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
+		}
+		return nil, translateError("unknown_error", fmt.Sprintf("KLBPay error: %s", errorMsg), p.language, nil)
+	}
+
+	response := &PaymentResponse{
+		PaymentID:     refTransactionID,
+		Status:        StatusPending,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+		PaymentMethod: request.PaymentMethod,
+		PaymentURL:    kpayResponse.PayURL,
+		QRCodeURL:     kpayResponse.QRCodeURL,
+		RedirectURL:   kpayResponse.PayURL,
+		TransactionID: kpayResponse.TransactionID,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		ExpiresAt:     time.Now().Add(time.Duration(timeout) * time.Second),
+		Metadata:      map[string]string{"ref_transaction_id": refTransactionID},
+	}
+	return response, nil
+}
+
+// kpayQueryTransactionRequest is the plaintext body encrypted and POSTed to KLBPay's
+// QueryTransaction endpoint
+type kpayQueryTransactionRequest struct {
+	RefTransactionID string `json:"refTransactionId"`
+}
+
+type kpayQueryTransactionResponse struct {
+	ResultCode    int     `json:"resultCode"`
+	Message       string  `json:"message"`
+	Status        string  `json:"status"`
+	TransactionID string  `json:"transactionId"`
+	Amount        float64 `json:"amount"`
+	CreatedAt     string  `json:"createdAt"`
+	CompletedAt   string  `json:"completedAt"`
+}
+
+// GetPaymentStatus gets the status of a payment, calling KLBPay's QueryTransaction. opts may
+// override the call's timeout (a per-call retry policy is not applied).
+func (p *VietnamKPay) GetPaymentStatus(ctx context.Context, request *PaymentStatusRequest, opts ...CallOption) (*PaymentResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	queryRequest := kpayQueryTransactionRequest{RefTransactionID: request.PaymentID}
+	body, err := json.Marshal(queryRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var kpayResponse kpayQueryTransactionResponse
+	if err := p.kpaySend(ctx, "/api/v1/transaction/query", body, &kpayResponse); err != nil {
+		return nil, err
+	}
+
+	if kpayResponse.ResultCode != 0 {
+		errorMsg := kpayResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
+		}
+		return nil, translateError("unknown_error", fmt.Sprintf("KLBPay error: %s", errorMsg), p.language, nil)
+	}
+
+	status := mapKPayStatus(kpayResponse.Status)
+	createdAt, _ := time.Parse(time.RFC3339, kpayResponse.CreatedAt)
+	var completedAt time.Time
+	if kpayResponse.CompletedAt != "" {
+		completedAt, _ = time.Parse(time.RFC3339, kpayResponse.CompletedAt)
+	}
+
+	return &PaymentResponse{
+		PaymentID:     request.PaymentID,
+		Status:        status,
+		Amount:        kpayResponse.Amount,
+		Currency:      "VND",
+		TransactionID: kpayResponse.TransactionID,
+		CreatedAt:     createdAt,
+		UpdatedAt:     time.Now(),
+		CompletedAt:   completedAt,
+		Metadata:      make(map[string]string),
+	}, nil
+}
+
+// kpayRefundTransactionRequest is the plaintext body encrypted and POSTed to KLBPay's
+// RefundTransaction endpoint
+type kpayRefundTransactionRequest struct {
+	RefTransactionID string  `json:"refTransactionId"`
+	RefundID         string  `json:"refundId"`
+	Amount           float64 `json:"amount"`
+	Reason           string  `json:"reason"`
+}
+
+type kpayRefundTransactionResponse struct {
+	ResultCode int    `json:"resultCode"`
+	Message    string `json:"message"`
+	RefundID   string `json:"refundId"`
+}
+
+// RefundPayment refunds a payment, calling KLBPay's RefundTransaction. opts may override the
+// call's timeout or idempotency key (a per-call retry policy is not applied).
+func (p *VietnamKPay) RefundPayment(ctx context.Context, request *RefundRequest, opts ...CallOption) (*RefundResponse, error) {
+	options := resolveCallOptions(opts...)
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	refundID := request.RefundID
+	if refundID == "" {
+		refundID = options.IdempotencyKey
+	}
+	if refundID == "" {
+		refundID = fmt.Sprintf("refund_%s_%d", request.PaymentID, time.Now().UnixNano())
+	}
+
+	refundRequest := kpayRefundTransactionRequest{
+		RefTransactionID: request.PaymentID,
+		RefundID:         refundID,
+		Amount:           request.Amount,
+		Reason:           request.Reason,
+	}
+	body, err := json.Marshal(refundRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var kpayResponse kpayRefundTransactionResponse
+	if err := p.kpaySend(ctx, "/api/v1/transaction/refund", body, &kpayResponse); err != nil {
+		return nil, err
+	}
+
+	if kpayResponse.ResultCode != 0 {
+		errorMsg := kpayResponse.Message
+		if errorMsg == "" {
+			errorMsg = "unknown error"
+		}
+		return nil, translateError("unknown_error", fmt.Sprintf("KLBPay refund error: %s", errorMsg), p.language, nil)
+	}
+
+	return &RefundResponse{
+		RefundID:  kpayResponse.RefundID,
+		PaymentID: request.PaymentID,
+		Amount:    request.Amount,
+		Currency:  "VND",
+		Status:    "success",
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// mapKPayStatus maps a KLBPay transaction status string to our PaymentStatus
+func mapKPayStatus(status string) PaymentStatus {
+	switch status {
+	case "SUCCESS", "COMPLETED":
+		return StatusCompleted
+	case "FAILED":
+		return StatusFailed
+	case "CANCELLED":
+		return StatusCancelled
+	case "REFUNDED":
+		return StatusRefunded
+	default:
+		return StatusPending
+	}
+}
@@ -0,0 +1,514 @@
+// IPN (Instant Payment Notification) handling for Vietnam's MoMo and VNPay platforms, which
+// deliver payment outcomes over an asynchronous server-to-server callback rather than a
+// response to the initiating request. Unlike webhook.go's FPX/GrabPay handlers - which fully
+// resolve a callback inside ServeHTTP - HandleIPN is exposed as its own method here so a caller
+// with its own router and ack-writing conventions can verify and parse a delivery without
+// going through ServeHTTP; ServeHTTP itself is a thin http.Handler adapter built on top of it.
+
+package integration
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrInvalidIPNSignature is returned when an inbound IPN's signature doesn't match what its
+// payload recomputes to
+var ErrInvalidIPNSignature = errors.New("invalid IPN signature")
+
+// ErrReplayedIPN is returned when an inbound IPN's provider reference has already been
+// processed by this handler's IPNDeduplicator
+var ErrReplayedIPN = errors.New("IPN already processed")
+
+// IPNDeduplicator decides whether an inbound IPN has already been processed, keyed by a
+// provider-supplied reference unique to that delivery (MoMo's requestId, VNPay's vnp_TxnRef).
+// Both MoMo and VNPay redeliver an IPN until they see the acknowledgement body they expect, so
+// HandleIPN consults one before dispatching to avoid double-crediting a merchant on a retry.
+type IPNDeduplicator interface {
+	// SeenBefore records ref and reports whether it had already been recorded
+	SeenBefore(ref string) (bool, error)
+}
+
+// InMemoryIPNDeduplicator is an IPNDeduplicator backed by a replayCache, remembering references
+// for ttl before they age out. It's suitable for a single process; a multi-instance deployment
+// should supply its own IPNDeduplicator backed by shared storage instead.
+type InMemoryIPNDeduplicator struct {
+	cache *replayCache
+}
+
+// NewInMemoryIPNDeduplicator builds an InMemoryIPNDeduplicator remembering references for ttl
+func NewInMemoryIPNDeduplicator(ttl time.Duration) *InMemoryIPNDeduplicator {
+	return &InMemoryIPNDeduplicator{cache: newReplayCache(ttl)}
+}
+
+func (d *InMemoryIPNDeduplicator) SeenBefore(ref string) (bool, error) {
+	return !d.cache.checkAndRemember(ref), nil
+}
+
+// momoIPNPayload is the JSON body MoMo POSTs to a merchant's ipnUrl
+type momoIPNPayload struct {
+	PartnerCode  string `json:"partnerCode"`
+	OrderID      string `json:"orderId"`
+	RequestID    string `json:"requestId"`
+	Amount       int64  `json:"amount"`
+	OrderInfo    string `json:"orderInfo"`
+	OrderType    string `json:"orderType"`
+	TransID      int64  `json:"transId"`
+	ResultCode   int    `json:"resultCode"`
+	Message      string `json:"message"`
+	PayType      string `json:"payType"`
+	ResponseTime int64  `json:"responseTime"`
+	ExtraData    string `json:"extraData"`
+	Signature    string `json:"signature"`
+}
+
+// MoMoWebhookHandler verifies and dispatches inbound MoMo IPN callbacks. It implements
+// http.Handler so it can be registered directly against a caller's mux.
+type MoMoWebhookHandler struct {
+	accessKey string
+	secretKey string
+	dedup     IPNDeduplicator
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewMoMoWebhookHandler creates a handler verifying callbacks signed with accessKey/secretKey,
+// the same credentials VietnamMoMoConfig uses for outbound requests. dedup defaults to an
+// InMemoryIPNDeduplicator remembering references for 24 hours when nil.
+func NewMoMoWebhookHandler(accessKey, secretKey string, dedup IPNDeduplicator) *MoMoWebhookHandler {
+	if dedup == nil {
+		dedup = NewInMemoryIPNDeduplicator(24 * time.Hour)
+	}
+	return &MoMoWebhookHandler{accessKey: accessKey, secretKey: secretKey, dedup: dedup}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *MoMoWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// HandleIPN verifies r's body as a MoMo IPN callback and, on success, dispatches a normalized
+// WebhookEvent to every registered listener. It returns the PaymentResponse the callback
+// describes so a caller can persist it directly instead of re-deriving one from
+// GetPaymentStatus.
+func (h *MoMoWebhookHandler) HandleIPN(r *http.Request) (*PaymentResponse, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IPN body: %w", err)
+	}
+
+	var payload momoIPNPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse IPN body: %w", err)
+	}
+
+	if !verifyHMAC(payload.Signature, []byte(h.secretKey), []byte(h.rawSignature(payload))) {
+		return nil, ErrInvalidIPNSignature
+	}
+
+	seen, err := h.dedup.SeenBefore(payload.RequestID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check IPN deduplication: %w", err)
+	}
+	if seen {
+		return nil, ErrReplayedIPN
+	}
+
+	status := mapMoMoIPNStatus(payload.ResultCode)
+	var completedAt time.Time
+	if status == StatusCompleted {
+		completedAt = time.Unix(payload.ResponseTime/1000, 0)
+	}
+
+	response := &PaymentResponse{
+		PaymentID:     payload.OrderID,
+		Status:        status,
+		Amount:        float64(payload.Amount),
+		Currency:      "VND",
+		PaymentMethod: MethodEWallet,
+		TransactionID: fmt.Sprintf("%d", payload.TransID),
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		CompletedAt:   completedAt,
+		Metadata:      map[string]string{"request_id": payload.RequestID},
+	}
+
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: payload.OrderID,
+		Status:    status,
+		Raw:       json.RawMessage(body),
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	return response, nil
+}
+
+// ServeHTTP adapts HandleIPN to http.Handler, writing the JSON acknowledgement body MoMo
+// expects to see before it stops redelivering the IPN
+func (h *MoMoWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.HandleIPN(r); err != nil {
+		writeMoMoIPNAck(w, 99, err.Error())
+		return
+	}
+	writeMoMoIPNAck(w, 0, "Confirm Success")
+}
+
+// rawSignature recomputes MoMo's raw-signature string over payload the same way
+// VietnamMoMo.CreatePayment/GetPaymentStatus/RefundPayment sign outbound requests
+func (h *MoMoWebhookHandler) rawSignature(payload momoIPNPayload) string {
+	return momoRawSignature(url.Values{
+		"accessKey":    {h.accessKey},
+		"amount":       {fmt.Sprintf("%d", payload.Amount)},
+		"extraData":    {payload.ExtraData},
+		"message":      {payload.Message},
+		"orderId":      {payload.OrderID},
+		"orderInfo":    {payload.OrderInfo},
+		"orderType":    {payload.OrderType},
+		"partnerCode":  {payload.PartnerCode},
+		"payType":      {payload.PayType},
+		"requestId":    {payload.RequestID},
+		"responseTime": {fmt.Sprintf("%d", payload.ResponseTime)},
+		"resultCode":   {fmt.Sprintf("%d", payload.ResultCode)},
+		"transId":      {fmt.Sprintf("%d", payload.TransID)},
+	})
+}
+
+// writeMoMoIPNAck writes the JSON body MoMo requires an IPN endpoint to respond with;
+// resultCode 0 stops redelivery, anything else causes MoMo to retry
+func writeMoMoIPNAck(w http.ResponseWriter, resultCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"resultCode": resultCode,
+		"message":    message,
+	})
+}
+
+// mapMoMoIPNStatus maps an IPN's resultCode to a PaymentStatus, mirroring
+// VietnamMoMo.GetPaymentStatus's payType-based mapping
+func mapMoMoIPNStatus(resultCode int) PaymentStatus {
+	switch resultCode {
+	case 0:
+		return StatusCompleted
+	case 9000:
+		return StatusPending
+	default:
+		return StatusFailed
+	}
+}
+
+// VNPayWebhookHandler verifies and dispatches inbound VNPay IPN callbacks. It implements
+// http.Handler so it can be registered directly against a caller's mux.
+type VNPayWebhookHandler struct {
+	secureHash string
+	dedup      IPNDeduplicator
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewVNPayWebhookHandler creates a handler verifying callbacks signed with secureHash, the
+// same shared secret VietnamVNPayConfig.SecureHash uses for outbound requests. dedup defaults
+// to an InMemoryIPNDeduplicator remembering references for 24 hours when nil.
+func NewVNPayWebhookHandler(secureHash string, dedup IPNDeduplicator) *VNPayWebhookHandler {
+	if dedup == nil {
+		dedup = NewInMemoryIPNDeduplicator(24 * time.Hour)
+	}
+	return &VNPayWebhookHandler{secureHash: secureHash, dedup: dedup}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *VNPayWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// HandleIPN verifies r's query string as a VNPay IPN callback and, on success, dispatches a
+// normalized WebhookEvent to every registered listener. It returns the PaymentResponse the
+// callback describes so a caller can persist it directly instead of re-deriving one from
+// GetPaymentStatus.
+func (h *VNPayWebhookHandler) HandleIPN(r *http.Request) (*PaymentResponse, error) {
+	query := r.URL.Query()
+
+	secureHash := query.Get("vnp_SecureHash")
+	if !verifyHMAC(secureHash, []byte(h.secureHash), []byte(vnpayCanonical(query))) {
+		return nil, ErrInvalidIPNSignature
+	}
+
+	txnRef := query.Get("vnp_TxnRef")
+	transactionNo := query.Get("vnp_TransactionNo")
+	seen, err := h.dedup.SeenBefore(txnRef + ":" + transactionNo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check IPN deduplication: %w", err)
+	}
+	if seen {
+		return nil, ErrReplayedIPN
+	}
+
+	amount, _ := strconv.ParseFloat(query.Get("vnp_Amount"), 64)
+	amount = amount / 100 // VNPay reports amounts in the smallest currency unit
+
+	status := mapVNPayIPNStatus(query.Get("vnp_ResponseCode"), query.Get("vnp_TransactionStatus"))
+	var completedAt, createdAt time.Time
+	if payDate := query.Get("vnp_PayDate"); payDate != "" {
+		createdAt, _ = time.Parse("20060102150405", payDate)
+	} else {
+		createdAt = time.Now()
+	}
+	if status == StatusCompleted {
+		completedAt = createdAt
+	}
+
+	response := &PaymentResponse{
+		PaymentID:     txnRef,
+		Status:        status,
+		Amount:        amount,
+		Currency:      "VND",
+		PaymentMethod: mapVNPayBankCode(query.Get("vnp_BankCode")),
+		TransactionID: transactionNo,
+		CreatedAt:     createdAt,
+		UpdatedAt:     time.Now(),
+		CompletedAt:   completedAt,
+		Metadata:      make(map[string]string),
+	}
+
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: txnRef,
+		Status:    status,
+		Raw:       vnpayQueryToJSON(query),
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	return response, nil
+}
+
+// ServeHTTP adapts HandleIPN to http.Handler, writing the JSON acknowledgement body VNPay
+// expects to see before it stops redelivering the IPN
+func (h *VNPayWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.HandleIPN(r); err != nil {
+		writeVNPayIPNAck(w, "99", err.Error())
+		return
+	}
+	writeVNPayIPNAck(w, "00", "Confirm Success")
+}
+
+// writeVNPayIPNAck writes the JSON body VNPay requires an IPN endpoint to respond with;
+// RspCode "00" stops redelivery, anything else causes VNPay to retry
+func writeVNPayIPNAck(w http.ResponseWriter, rspCode, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"RspCode": rspCode,
+		"Message": message,
+	})
+}
+
+// mapVNPayIPNStatus maps an IPN's vnp_ResponseCode/vnp_TransactionStatus to a PaymentStatus,
+// mirroring VietnamVNPay.GetPaymentStatus's mapping
+func mapVNPayIPNStatus(responseCode, transactionStatus string) PaymentStatus {
+	if responseCode != "00" {
+		return StatusFailed
+	}
+	switch transactionStatus {
+	case "00":
+		return StatusCompleted
+	case "01", "02":
+		return StatusPending
+	default:
+		return StatusFailed
+	}
+}
+
+// mapVNPayBankCode maps a vnp_BankCode to a PaymentMethod, mirroring
+// VietnamVNPay.GetPaymentStatus's mapping
+func mapVNPayBankCode(bankCode string) PaymentMethod {
+	switch bankCode {
+	case "INTCARD":
+		return MethodCreditCard
+	case "VNBANK":
+		return MethodBankTransfer
+	case "VNPAYQR":
+		return MethodQRCode
+	default:
+		return ""
+	}
+}
+
+// vnpayQueryToJSON flattens query into a JSON object for WebhookEvent.Raw
+func vnpayQueryToJSON(query url.Values) json.RawMessage {
+	flat := make(map[string]string, len(query))
+	for k := range query {
+		flat[k] = query.Get(k)
+	}
+	raw, _ := json.Marshal(flat)
+	return raw
+}
+
+// ErrIPNTimestampDrift is returned when an inbound KLBPay callback's timestamp has drifted
+// from now by more than the handler's configured MaxTimestampDiff
+var ErrIPNTimestampDrift = errors.New("KLBPay IPN timestamp exceeds MaxTimestampDiff")
+
+// kpayCallbackPayload is the plaintext body KLBPay's kpayEnvelope.Data decrypts to for a
+// transaction callback
+type kpayCallbackPayload struct {
+	RefTransactionID string  `json:"refTransactionId"`
+	TransactionID    string  `json:"transactionId"`
+	Amount           float64 `json:"amount"`
+	Status           string  `json:"status"`
+}
+
+// KPayWebhookHandler verifies and dispatches inbound KLBPay transaction callbacks. It
+// implements http.Handler so it can be registered directly against a caller's mux.
+type KPayWebhookHandler struct {
+	clientID         string
+	secretKey        string
+	encryptKey       string
+	maxTimestampDiff int64
+	dedup            IPNDeduplicator
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewKPayWebhookHandler creates a handler verifying callbacks signed and encrypted with the
+// same clientID/secretKey/encryptKey VietnamKPayConfig uses for outbound requests.
+// maxTimestampDiff should match VietnamKPayConfig.MaxTimestampDiff; a value <= 0 defaults to
+// 300 seconds. dedup defaults to an InMemoryIPNDeduplicator remembering references for 24
+// hours when nil.
+func NewKPayWebhookHandler(clientID, secretKey, encryptKey string, maxTimestampDiff int64, dedup IPNDeduplicator) *KPayWebhookHandler {
+	if maxTimestampDiff <= 0 {
+		maxTimestampDiff = 300
+	}
+	if dedup == nil {
+		dedup = NewInMemoryIPNDeduplicator(24 * time.Hour)
+	}
+	return &KPayWebhookHandler{
+		clientID:         clientID,
+		secretKey:        secretKey,
+		encryptKey:       encryptKey,
+		maxTimestampDiff: maxTimestampDiff,
+		dedup:            dedup,
+	}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *KPayWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// HandleIPN verifies r's body as a KLBPay callback envelope and, on success, dispatches a
+// normalized WebhookEvent to every registered listener. It returns the PaymentResponse the
+// callback describes so a caller can persist it directly instead of re-deriving one from
+// GetPaymentStatus.
+//
+// Unlike MoMo/VNPay, KLBPay's callback body arrives encrypted the same way its API responses
+// do, and carries its own timestamp that must not have drifted from now by more than
+// MaxTimestampDiff - mirroring the skew check KLBPay itself applies to inbound requests.
+func (h *KPayWebhookHandler) HandleIPN(r *http.Request) (*PaymentResponse, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IPN body: %w", err)
+	}
+
+	var envelope kpayEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse IPN envelope: %w", err)
+	}
+
+	if err := kpayCheckTimestamp(envelope.Timestamp, h.maxTimestampDiff); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrIPNTimestampDrift, err)
+	}
+
+	if !hmac.Equal([]byte(envelope.Signature), []byte(kpaySign(h.secretKey, h.clientID, envelope.Timestamp, []byte(envelope.Data)))) {
+		return nil, ErrInvalidIPNSignature
+	}
+
+	decrypted, err := kpayDecrypt(h.encryptKey, envelope.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt IPN body: %w", err)
+	}
+
+	var payload kpayCallbackPayload
+	if err := json.Unmarshal(decrypted, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted IPN body: %w", err)
+	}
+
+	seen, err := h.dedup.SeenBefore(payload.RefTransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check IPN deduplication: %w", err)
+	}
+	if seen {
+		return nil, ErrReplayedIPN
+	}
+
+	status := mapKPayStatus(payload.Status)
+	response := &PaymentResponse{
+		PaymentID:     payload.RefTransactionID,
+		Status:        status,
+		Amount:        payload.Amount,
+		Currency:      "VND",
+		TransactionID: payload.TransactionID,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		Metadata:      make(map[string]string),
+	}
+	if status == StatusCompleted {
+		response.CompletedAt = time.Now()
+	}
+
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: payload.RefTransactionID,
+		Status:    status,
+		Raw:       decrypted,
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	return response, nil
+}
+
+// ServeHTTP adapts HandleIPN to http.Handler, writing the JSON acknowledgement body KLBPay
+// expects to see before it stops redelivering the callback
+func (h *KPayWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.HandleIPN(r); err != nil {
+		writeKPayIPNAck(w, 1, err.Error())
+		return
+	}
+	writeKPayIPNAck(w, 0, "OK")
+}
+
+// writeKPayIPNAck writes the JSON body KLBPay requires a callback endpoint to respond with;
+// resultCode 0 stops redelivery, anything else causes KLBPay to retry
+func writeKPayIPNAck(w http.ResponseWriter, resultCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"resultCode": resultCode,
+		"message":    message,
+	})
+}
@@ -0,0 +1,426 @@
+// Inbound webhook handling for payment platform callbacks
+// MalaysiaFPX and MalaysiaGrabPay only support polling via GetPaymentStatus; the handlers
+// below let a caller accept the platforms' asynchronous callbacks instead, verifying each
+// request's signature before dispatching a normalized WebhookEvent to registered listeners.
+
+package integration
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebhookEventType distinguishes the kind of update a WebhookEvent carries
+type WebhookEventType string
+
+// WebhookEventPaymentUpdate is emitted for both FPX and GrabPay payment status callbacks
+const WebhookEventPaymentUpdate WebhookEventType = "payment_update"
+
+// The following are refinements of WebhookEventPaymentUpdate (and, for a future
+// refund-capable handler, a distinct refund callback) that WebhookRouter derives from an
+// event's Status so a subscriber can filter on outcome rather than polling every update
+const (
+	WebhookEventPaymentCompleted WebhookEventType = "payment.completed"
+	WebhookEventPaymentFailed    WebhookEventType = "payment.failed"
+	WebhookEventRefundCompleted  WebhookEventType = "refund.completed"
+)
+
+// WebhookEvent is the normalized form of an inbound platform callback, independent of
+// whichever platform-specific payload shape produced it
+type WebhookEvent struct {
+	// ID uniquely identifies this delivery, for dedup by an EventStore. A handler that
+	// doesn't set it leaves WebhookRouter to derive one from Raw instead.
+	ID        string
+	Type      WebhookEventType
+	PaymentID string
+	Status    PaymentStatus
+	Raw       json.RawMessage
+}
+
+// WebhookListener receives normalized events dispatched by a webhook handler
+type WebhookListener func(event WebhookEvent)
+
+// WebhookHandler is satisfied by FPXWebhookHandler, GrabPayWebhookHandler, MoMoWebhookHandler,
+// and VNPayWebhookHandler alike: an http.Handler that can be mounted directly on a caller's
+// router, plus AddListener to subscribe to the normalized events it dispatches.
+type WebhookHandler interface {
+	http.Handler
+	AddListener(listener WebhookListener)
+}
+
+// WebhookOption configures a webhook handler's replay protection
+type WebhookOption func(*webhookOptions)
+
+type webhookOptions struct {
+	clockSkew         time.Duration
+	replayTTL         time.Duration
+	notificationStore NotificationStore
+}
+
+func defaultWebhookOptions() webhookOptions {
+	return webhookOptions{
+		clockSkew: 5 * time.Minute,
+		replayTTL: 10 * time.Minute,
+	}
+}
+
+// WithClockSkew sets the maximum allowed difference between a callback's timestamp and the
+// receiver's clock before the callback is rejected. Defaults to 5 minutes.
+func WithClockSkew(skew time.Duration) WebhookOption {
+	return func(o *webhookOptions) { o.clockSkew = skew }
+}
+
+// WithReplayTTL sets how long a (timestamp, nonce) pair is remembered for replay rejection.
+// Defaults to 10 minutes.
+func WithReplayTTL(ttl time.Duration) WebhookOption {
+	return func(o *webhookOptions) { o.replayTTL = ttl }
+}
+
+// WithNotificationStore overrides the NotificationStore a notification-dedup-capable handler
+// (e.g. SingaporePayNowWebhookHandler, SingaporeNetsWebhookHandler) uses to suppress duplicate
+// deliveries, in place of its default InMemoryNotificationStore. Handlers that don't dedupe
+// notifications ignore this option.
+func WithNotificationStore(store NotificationStore) WebhookOption {
+	return func(o *webhookOptions) { o.notificationStore = store }
+}
+
+// replayCache remembers nonces seen within ttl, rejecting a repeat of the same nonce until
+// it expires
+type replayCache struct {
+	mutex sync.Mutex
+	seen  map[string]time.Time
+	ttl   time.Duration
+}
+
+func newReplayCache(ttl time.Duration) *replayCache {
+	return &replayCache{seen: make(map[string]time.Time), ttl: ttl}
+}
+
+// checkAndRemember returns false if nonce was already seen within ttl, otherwise records it
+// and returns true
+func (c *replayCache) checkAndRemember(nonce string) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for existing, seenAt := range c.seen {
+		if now.Sub(seenAt) > c.ttl {
+			delete(c.seen, existing)
+		}
+	}
+
+	if seenAt, exists := c.seen[nonce]; exists && now.Sub(seenAt) <= c.ttl {
+		return false
+	}
+	c.seen[nonce] = now
+	return true
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func dispatch(listeners []WebhookListener, event WebhookEvent) {
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// mapFPXStatus maps an FPX callback's status string to a PaymentStatus, mirroring
+// MalaysiaFPX.GetPaymentStatus's mapping
+func mapFPXStatus(status string) PaymentStatus {
+	switch status {
+	case "PAYMENT_SUCCESSFUL":
+		return StatusCompleted
+	case "PAYMENT_FAILED":
+		return StatusFailed
+	case "PAYMENT_CANCELLED":
+		return StatusCancelled
+	default:
+		return StatusPending
+	}
+}
+
+// FPXWebhookHandler verifies and dispatches inbound FPX payment callbacks. It implements
+// http.Handler so it can be registered directly against a caller's mux.
+type FPXWebhookHandler struct {
+	merchantKey string
+	options     webhookOptions
+	replay      *replayCache
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewFPXWebhookHandler creates a handler verifying callbacks signed with merchantKey, the
+// same shared secret MalaysiaFPXConfig.MerchantKey uses for outbound requests
+func NewFPXWebhookHandler(merchantKey string, opts ...WebhookOption) *FPXWebhookHandler {
+	options := defaultWebhookOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &FPXWebhookHandler{
+		merchantKey: merchantKey,
+		options:     options,
+		replay:      newReplayCache(options.replayTTL),
+	}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *FPXWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// ServeHTTP verifies an FPX callback's HMAC-SHA256 signature and timestamp+nonce, then
+// dispatches a normalized WebhookEvent to every registered listener
+func (h *FPXWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form body", http.StatusBadRequest)
+		return
+	}
+
+	params := make(map[string]string, len(r.PostForm))
+	for key := range r.PostForm {
+		params[key] = r.PostForm.Get(key)
+	}
+	signature := params["signature"]
+	delete(params, "signature")
+
+	if !hmac.Equal([]byte(signature), []byte(h.fpxSignature(params))) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.checkReplay(params["transactionTime"], params["nonce"]); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	raw, err := json.Marshal(params)
+	if err != nil {
+		http.Error(w, "failed to encode callback", http.StatusInternalServerError)
+		return
+	}
+
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: params["paymentId"],
+		Status:    mapFPXStatus(params["status"]),
+		Raw:       raw,
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// fpxSignature recomputes the HMAC-SHA256 signature over params the same way
+// MalaysiaFPX.generateSignature does on the outbound side
+func (h *FPXWebhookHandler) fpxSignature(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteString("=")
+		sb.WriteString(params[k])
+		sb.WriteString("&")
+	}
+	signStr := strings.TrimSuffix(sb.String(), "&")
+
+	mac := hmac.New(sha256.New, []byte(h.merchantKey))
+	mac.Write([]byte(signStr))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkReplay rejects a callback whose timestamp is outside the configured clock skew or
+// whose (timestamp, nonce) pair has already been seen
+func (h *FPXWebhookHandler) checkReplay(timestamp, nonce string) error {
+	if timestamp != "" {
+		parsed, err := time.Parse("20060102150405", timestamp)
+		if err == nil && absDuration(time.Since(parsed)) > h.options.clockSkew {
+			return fmt.Errorf("callback timestamp outside allowed clock skew")
+		}
+	}
+	if !h.replay.checkAndRemember(timestamp + ":" + nonce) {
+		return fmt.Errorf("callback already processed (replay)")
+	}
+	return nil
+}
+
+// GrabPayWebhookHandler verifies and dispatches inbound GrabPay payment callbacks. It
+// implements http.Handler so it can be registered directly against a caller's mux.
+type GrabPayWebhookHandler struct {
+	clientSecret string
+	options      webhookOptions
+	replay       *replayCache
+
+	mutex     sync.Mutex
+	listeners []WebhookListener
+}
+
+// NewGrabPayWebhookHandler creates a handler verifying callbacks signed with clientSecret,
+// the same shared secret MalaysiaGrabPayConfig.ClientSecret uses to sign outbound POP
+// headers
+func NewGrabPayWebhookHandler(clientSecret string, opts ...WebhookOption) *GrabPayWebhookHandler {
+	options := defaultWebhookOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return &GrabPayWebhookHandler{
+		clientSecret: clientSecret,
+		options:      options,
+		replay:       newReplayCache(options.replayTTL),
+	}
+}
+
+// AddListener registers l to receive every WebhookEvent this handler dispatches
+func (h *GrabPayWebhookHandler) AddListener(l WebhookListener) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.listeners = append(h.listeners, l)
+}
+
+// ServeHTTP verifies a GrabPay callback's X-GID-AUX-POP JWS/HS256 signature and
+// timestamp+nonce, then dispatches a normalized WebhookEvent to every registered listener
+func (h *GrabPayWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	pop := r.Header.Get("X-GID-AUX-POP")
+	timestamp, nonce, signature, err := parsePOPHeader(pop)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	expected := h.popSignature(r.Method, r.URL.Path, timestamp, nonce, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.checkReplay(timestamp, nonce); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	paymentID, _ := payload["chargeId"].(string)
+	status, _ := payload["status"].(string)
+
+	event := WebhookEvent{
+		Type:      WebhookEventPaymentUpdate,
+		PaymentID: paymentID,
+		Status:    mapGrabPayStatus(status),
+		Raw:       json.RawMessage(body),
+	}
+
+	h.mutex.Lock()
+	listeners := append([]WebhookListener(nil), h.listeners...)
+	h.mutex.Unlock()
+	dispatch(listeners, event)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// popSignature recomputes the HMAC-SHA256 signature over the callback the same way
+// MalaysiaGrabPay.generatePOP signs outbound requests, minus the bearer token component
+// which has no equivalent on an inbound server-to-server callback
+func (h *GrabPayWebhookHandler) popSignature(method, path, timestamp, nonce string, body []byte) string {
+	signStr := method + "&" + path + "&" + timestamp + "&" + nonce + "&" + string(body)
+	mac := hmac.New(sha256.New, []byte(h.clientSecret))
+	mac.Write([]byte(signStr))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// checkReplay rejects a callback whose timestamp is outside the configured clock skew or
+// whose (timestamp, nonce) pair has already been seen
+func (h *GrabPayWebhookHandler) checkReplay(timestamp, nonce string) error {
+	if seconds, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+		if absDuration(time.Since(time.Unix(seconds, 0))) > h.options.clockSkew {
+			return fmt.Errorf("callback timestamp outside allowed clock skew")
+		}
+	}
+	if !h.replay.checkAndRemember(timestamp + ":" + nonce) {
+		return fmt.Errorf("callback already processed (replay)")
+	}
+	return nil
+}
+
+// parsePOPHeader parses an "HS256 timestamp=...,nonce=...,signature=..." X-GID-AUX-POP
+// header, the same format MalaysiaGrabPay.generatePOP produces
+func parsePOPHeader(header string) (timestamp, nonce, signature string, err error) {
+	parts := strings.SplitN(header, " ", 2)
+	if len(parts) != 2 || parts[0] != "HS256" {
+		return "", "", "", fmt.Errorf("missing or unsupported X-GID-AUX-POP header")
+	}
+
+	fields := make(map[string]string)
+	for _, field := range strings.Split(parts[1], ",") {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		fields[kv[0]] = kv[1]
+	}
+
+	timestamp, ok := fields["timestamp"]
+	if !ok {
+		return "", "", "", fmt.Errorf("X-GID-AUX-POP header missing timestamp")
+	}
+	nonce, ok = fields["nonce"]
+	if !ok {
+		return "", "", "", fmt.Errorf("X-GID-AUX-POP header missing nonce")
+	}
+	signature, ok = fields["signature"]
+	if !ok {
+		return "", "", "", fmt.Errorf("X-GID-AUX-POP header missing signature")
+	}
+	return timestamp, nonce, signature, nil
+}
+
+// mapGrabPayStatus maps a GrabPay callback's status string to a PaymentStatus
+func mapGrabPayStatus(status string) PaymentStatus {
+	switch strings.ToUpper(status) {
+	case "SUCCEEDED", "COMPLETED":
+		return StatusCompleted
+	case "FAILED":
+		return StatusFailed
+	case "CANCELLED":
+		return StatusCancelled
+	default:
+		return StatusPending
+	}
+}
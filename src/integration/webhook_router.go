@@ -0,0 +1,217 @@
+// Central webhook routing across payment platform integrations
+// Each platform's WebhookHandler (FPXWebhookHandler, PromptPayWebhookHandler, and so on)
+// verifies and dispatches its own callbacks, but a merchant running more than one platform
+// still needs one place to mount every callback URL, dedup at-least-once deliveries, and fan
+// events out to its own subscribers. WebhookRouter is that central piece, built on the
+// existing WebhookHandler/WebhookEvent/WebhookListener contract rather than a new one.
+
+package integration
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrUnknownWebhookPath is returned by WebhookRouter.ServeHTTP for a request path no handler
+// has been registered under
+var ErrUnknownWebhookPath = errors.New("no webhook handler registered for this path")
+
+// EventStore records which webhook event IDs a WebhookRouter has already delivered to its
+// subscribers, so an at-least-once redelivery (a platform retrying its own callback, or this
+// router's own outbound retry) doesn't notify a subscriber twice for the same event
+type EventStore interface {
+	// Seen returns whether eventID has already been recorded
+	Seen(eventID string) (bool, error)
+	// MarkSeen records eventID as delivered. Implementations should treat a second MarkSeen
+	// for the same eventID as a no-op rather than an error.
+	MarkSeen(eventID string) error
+}
+
+// InMemoryEventStore is an EventStore backed by a process-local set, suitable for a
+// single-instance deployment or tests
+type InMemoryEventStore struct {
+	mutex sync.Mutex
+	seen  map[string]struct{}
+}
+
+// NewInMemoryEventStore creates an empty in-memory event store
+func NewInMemoryEventStore() *InMemoryEventStore {
+	return &InMemoryEventStore{seen: make(map[string]struct{})}
+}
+
+// Seen returns whether eventID has already been recorded
+func (s *InMemoryEventStore) Seen(eventID string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.seen[eventID]
+	return ok, nil
+}
+
+// MarkSeen records eventID as delivered
+func (s *InMemoryEventStore) MarkSeen(eventID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seen[eventID] = struct{}{}
+	return nil
+}
+
+// eventID returns event.ID if its handler set one, otherwise a hash of its raw payload, so
+// WebhookRouter can dedup handlers that don't populate ID yet
+func eventID(event WebhookEvent) string {
+	if event.ID != "" {
+		return event.ID
+	}
+	sum := sha256.Sum256(event.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// classifyEventTypes returns every WebhookEventType a subscriber may have registered for that
+// event matches: its raw Type, plus a status-derived refinement (WebhookEventPaymentCompleted
+// or WebhookEventPaymentFailed) when Type is the generic WebhookEventPaymentUpdate
+func classifyEventTypes(event WebhookEvent) []WebhookEventType {
+	types := []WebhookEventType{event.Type}
+	if event.Type != WebhookEventPaymentUpdate {
+		return types
+	}
+	switch event.Status {
+	case StatusCompleted:
+		types = append(types, WebhookEventPaymentCompleted)
+	case StatusFailed:
+		types = append(types, WebhookEventPaymentFailed)
+	}
+	return types
+}
+
+// httpSubscriber is an outbound webhook subscriber notified over HTTP, with its own retrying
+// client so a slow or briefly-unreachable subscriber doesn't lose an event to a single failed
+// POST
+type httpSubscriber struct {
+	url    string
+	client *RetryingHTTPClient
+}
+
+// deliver POSTs event as JSON to the subscriber's url, retrying per its RetryingHTTPClient's
+// policy. A delivery that still fails after retries is dropped rather than returned, matching
+// how a platform handler's own WebhookListener errors are handled: dispatch is fire-and-forget.
+func (s *httpSubscriber) deliver(event WebhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	resp, err := s.client.PostJSON(s.url, "application/json", body)
+	if err != nil {
+		return
+	}
+	resp.Body.Close()
+}
+
+// WebhookRouter mounts one or more platform WebhookHandlers at distinct paths, dedupes the
+// events they dispatch against an EventStore, and fans deduped events out to process-local
+// listeners and outbound HTTP subscribers alike
+type WebhookRouter struct {
+	store EventStore
+
+	mutex       sync.RWMutex
+	handlers    map[string]WebhookHandler
+	listeners   map[WebhookEventType][]WebhookListener
+	subscribers []*httpSubscriber
+}
+
+// NewWebhookRouter creates a router deduplicating deliveries against store
+func NewWebhookRouter(store EventStore) *WebhookRouter {
+	return &WebhookRouter{
+		store:     store,
+		handlers:  make(map[string]WebhookHandler),
+		listeners: make(map[WebhookEventType][]WebhookListener),
+	}
+}
+
+// RegisterHandler mounts handler at path (e.g. "/webhooks/malaysia/fpx"), forwarding every
+// event it dispatches through the router's own dedup and fan-out rather than directly to
+// handler's own listeners
+func (r *WebhookRouter) RegisterHandler(path string, handler WebhookHandler) {
+	r.mutex.Lock()
+	r.handlers[path] = handler
+	r.mutex.Unlock()
+	handler.AddListener(r.dispatch)
+}
+
+// Subscribe registers listener to receive every future event matching eventType, including a
+// status-derived refinement such as WebhookEventPaymentCompleted (see classifyEventTypes)
+func (r *WebhookRouter) Subscribe(eventType WebhookEventType, listener WebhookListener) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.listeners[eventType] = append(r.listeners[eventType], listener)
+}
+
+// SubscribeHTTP registers url to receive a JSON POST of every future event the router
+// dispatches, retrying with policy's exponential backoff on a network error or 5xx response
+func (r *WebhookRouter) SubscribeHTTP(url string, policy RetryPolicy) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.subscribers = append(r.subscribers, &httpSubscriber{url: url, client: NewRetryingHTTPClient(&http.Client{}, policy)})
+}
+
+// dispatch is registered as every mounted handler's WebhookListener. It dedups event against
+// store before fanning it out to type-matched Subscribe listeners and every SubscribeHTTP URL.
+func (r *WebhookRouter) dispatch(event WebhookEvent) {
+	id := eventID(event)
+	if seen, err := r.store.Seen(id); err == nil && seen {
+		return
+	}
+
+	r.mutex.RLock()
+	var listeners []WebhookListener
+	for _, eventType := range classifyEventTypes(event) {
+		listeners = append(listeners, r.listeners[eventType]...)
+	}
+	subscribers := append([]*httpSubscriber(nil), r.subscribers...)
+	r.mutex.RUnlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+	for _, subscriber := range subscribers {
+		subscriber.deliver(event)
+	}
+
+	_ = r.store.MarkSeen(id)
+}
+
+// ServeHTTP routes an inbound callback to the handler registered at req.URL.Path, responding
+// 404 if none is registered
+func (r *WebhookRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.RLock()
+	handler, ok := r.handlers[req.URL.Path]
+	r.mutex.RUnlock()
+	if !ok {
+		http.Error(w, ErrUnknownWebhookPath.Error(), http.StatusNotFound)
+		return
+	}
+	handler.ServeHTTP(w, req)
+}
+
+// VerifyRSASHA256Signature checks signatureBase64 (a base64-encoded PKCS#1v15 RSA-SHA256
+// signature) against signedContent using publicKey. This is the verification scheme
+// Alipay-style platforms use for outbound callbacks in place of an HMAC shared secret; a
+// future handler for one of those platforms calls this the way PromptPayWebhookHandler calls
+// hmac.Equal.
+func VerifyRSASHA256Signature(publicKey *rsa.PublicKey, signedContent []byte, signatureBase64 string) error {
+	signature, err := base64.StdEncoding.DecodeString(signatureBase64)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %w", err)
+	}
+	hashed := sha256.Sum256(signedContent)
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], signature); err != nil {
+		return fmt.Errorf("signature verification failed: %w", err)
+	}
+	return nil
+}
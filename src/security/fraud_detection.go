@@ -4,7 +4,9 @@
 package security
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net"
 	"strings"
 	"sync"
@@ -13,39 +15,39 @@ import (
 
 // Common errors
 var (
-	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+	ErrRateLimitExceeded  = errors.New("rate limit exceeded")
 	ErrSuspiciousActivity = errors.New("suspicious activity detected")
-	ErrBlockedIP = errors.New("IP address is blocked")
-	ErrBlockedCountry = errors.New("country is blocked")
+	ErrBlockedIP          = errors.New("IP address is blocked")
+	ErrBlockedCountry     = errors.New("country is blocked")
 )
 
 // FraudDetectionConfig holds configuration for fraud detection
 type FraudDetectionConfig struct {
-	RateLimitEnabled      bool
-	RateLimitWindow       time.Duration
-	RateLimitMaxRequests  int
-	BlockedIPs            []string
-	BlockedCountries      []string
-	TransactionThreshold  float64
-	VelocityCheckEnabled  bool
-	VelocityCheckWindow   time.Duration
-	VelocityCheckLimit    int
-	SuspiciousPatterns    []string
+	RateLimitEnabled     bool
+	RateLimitWindow      time.Duration
+	RateLimitMaxRequests int
+	BlockedIPs           []string
+	BlockedCountries     []string
+	TransactionThreshold float64
+	VelocityCheckEnabled bool
+	VelocityCheckWindow  time.Duration
+	VelocityCheckLimit   int
+	SuspiciousPatterns   []string
 }
 
 // DefaultFraudDetectionConfig returns a default configuration
 func DefaultFraudDetectionConfig() *FraudDetectionConfig {
 	return &FraudDetectionConfig{
-		RateLimitEnabled:      true,
-		RateLimitWindow:       time.Minute,
-		RateLimitMaxRequests:  100,
-		BlockedIPs:            []string{},
-		BlockedCountries:      []string{},
-		TransactionThreshold:  10000.0, // Transactions above this amount trigger additional checks
-		VelocityCheckEnabled:  true,
-		VelocityCheckWindow:   time.Hour,
-		VelocityCheckLimit:    10, // Maximum number of transactions per hour
-		SuspiciousPatterns:    []string{},
+		RateLimitEnabled:     true,
+		RateLimitWindow:      time.Minute,
+		RateLimitMaxRequests: 100,
+		BlockedIPs:           []string{},
+		BlockedCountries:     []string{},
+		TransactionThreshold: 10000.0, // Transactions above this amount trigger additional checks
+		VelocityCheckEnabled: true,
+		VelocityCheckWindow:  time.Hour,
+		VelocityCheckLimit:   10, // Maximum number of transactions per hour
+		SuspiciousPatterns:   []string{},
 	}
 }
 
@@ -102,12 +104,15 @@ func (r *RateLimiter) CheckLimit(key string) bool {
 
 // FraudDetectionService provides fraud detection functionality
 type FraudDetectionService struct {
-	config      *FraudDetectionConfig
-	rateLimiter *RateLimiter
-	ipCache     map[string]bool
-	countryCache map[string]bool
-	transactions map[string][]time.Time // Key is user/merchant ID
-	mutex       sync.RWMutex
+	config             *FraudDetectionConfig
+	rateLimiter        *RateLimiter
+	ipCache            map[string]bool
+	countryCache       map[string]bool
+	transactions       map[string][]time.Time  // Key is user/merchant ID
+	cidrTrie           *cidrTrie               // indexes CIDR-shaped entries of ipCache for fast lookups
+	geoIPProvider      GeoIPProvider           // optional; resolves a requester's country from their IP
+	distributedLimiter *DistributedRateLimiter // optional; when set, takes over from the in-process RateLimiter
+	mutex              sync.RWMutex
 }
 
 // NewFraudDetectionService creates a new fraud detection service
@@ -128,13 +133,16 @@ func NewFraudDetectionService(config *FraudDetectionConfig) *FraudDetectionServi
 		countryCache[country] = true
 	}
 
-	return &FraudDetectionService{
-		config:      config,
-		rateLimiter: NewRateLimiter(config.RateLimitWindow, config.RateLimitMaxRequests),
-		ipCache:     ipCache,
+	service := &FraudDetectionService{
+		config:       config,
+		rateLimiter:  NewRateLimiter(config.RateLimitWindow, config.RateLimitMaxRequests),
+		ipCache:      ipCache,
 		countryCache: countryCache,
 		transactions: make(map[string][]time.Time),
 	}
+	service.rebuildCIDRTrie()
+
+	return service
 }
 
 // CheckRequest checks if a request should be allowed
@@ -144,6 +152,11 @@ func (s *FraudDetectionService) CheckRequest(ipAddress, userID string) error {
 		return ErrBlockedIP
 	}
 
+	// Check if the requester's GeoIP-resolved country is blocked
+	if s.IsCountryBlockedForIP(ipAddress) {
+		return ErrBlockedCountry
+	}
+
 	// Check rate limit
 	if s.config.RateLimitEnabled {
 		if !s.rateLimiter.CheckLimit(ipAddress) {
@@ -154,6 +167,39 @@ func (s *FraudDetectionService) CheckRequest(ipAddress, userID string) error {
 	return nil
 }
 
+// CheckRequestDistributed behaves like CheckRequest but enforces the rate limit via
+// the configured DistributedRateLimiter instead of the in-process RateLimiter, so the
+// limit holds across every gateway instance rather than per-process
+func (s *FraudDetectionService) CheckRequestDistributed(ctx context.Context, ipAddress, userID string) error {
+	if s.IsIPBlocked(ipAddress) {
+		return ErrBlockedIP
+	}
+
+	if s.IsCountryBlockedForIP(ipAddress) {
+		return ErrBlockedCountry
+	}
+
+	if s.config.RateLimitEnabled && s.distributedLimiter != nil {
+		allowed, err := s.distributedLimiter.CheckLimit(ctx, ipAddress)
+		if err != nil {
+			return fmt.Errorf("failed to check distributed rate limit: %w", err)
+		}
+		if !allowed {
+			return ErrRateLimitExceeded
+		}
+	}
+
+	return nil
+}
+
+// SetDistributedRateLimiter configures a Redis-backed rate limiter for
+// CheckRequestDistributed, allowing the limit to be shared across gateway instances
+func (s *FraudDetectionService) SetDistributedRateLimiter(limiter *DistributedRateLimiter) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.distributedLimiter = limiter
+}
+
 // CheckTransaction checks if a transaction should be allowed
 func (s *FraudDetectionService) CheckTransaction(userID, ipAddress, countryCode string, amount float64) error {
 	// Check if IP is blocked
@@ -192,22 +238,13 @@ func (s *FraudDetectionService) IsIPBlocked(ipAddress string) bool {
 		return true
 	}
 
-	// Check CIDR blocks
+	// Check CIDR blocks via the trie instead of scanning every blocked range
 	ip := net.ParseIP(ipAddress)
 	if ip == nil {
 		return false
 	}
 
-	for blockedIP := range s.ipCache {
-		if strings.Contains(blockedIP, "/") {
-			_, ipNet, err := net.ParseCIDR(blockedIP)
-			if err == nil && ipNet.Contains(ip) {
-				return true
-			}
-		}
-	}
-
-	return false
+	return s.cidrTrie != nil && s.cidrTrie.contains(ip)
 }
 
 // IsCountryBlocked checks if a country is blocked
@@ -225,6 +262,7 @@ func (s *FraudDetectionService) BlockIP(ipAddress string) {
 
 	s.ipCache[ipAddress] = true
 	s.config.BlockedIPs = append(s.config.BlockedIPs, ipAddress)
+	s.rebuildCIDRTrie()
 }
 
 // UnblockIP removes an IP address from the block list
@@ -242,12 +280,13 @@ func (s *FraudDetectionService) UnblockIP(ipAddress string) {
 		}
 	}
 	s.config.BlockedIPs = newBlockedIPs
+	s.rebuildCIDRTrie()
 }
 
 // BlockCountry adds a country to the block list
 func (s *FraudDetectionService) BlockCountry(countryCode string) {
 	countryCode = strings.ToUpper(countryCode)
-	
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -258,7 +297,7 @@ func (s *FraudDetectionService) BlockCountry(countryCode string) {
 // UnblockCountry removes a country from the block list
 func (s *FraudDetectionService) UnblockCountry(countryCode string) {
 	countryCode = strings.ToUpper(countryCode)
-	
+
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
@@ -0,0 +1,312 @@
+// Asymmetric JWT signing (RS256/ES256/EdDSA) with JWKS publication and key rotation
+// GenerateJWT/VerifyJWT previously only supported HS256 with a single shared secret, which
+// a third-party payment partner or wallet integrator can't verify without holding that
+// secret. A KeySet lets SecurityService sign with an asymmetric key while publishing the
+// corresponding public keys as a JWKS document via ServeJWKS.
+
+package security
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// KeyAlgorithm identifies an asymmetric JWT signing algorithm supported by a KeySet
+type KeyAlgorithm string
+
+const (
+	KeyAlgorithmRS256 KeyAlgorithm = "RS256"
+	KeyAlgorithmES256 KeyAlgorithm = "ES256"
+	KeyAlgorithmEdDSA KeyAlgorithm = "EdDSA"
+)
+
+// ErrUnknownKeyID is returned when a JWT's kid header doesn't match any key in the KeySet
+var ErrUnknownKeyID = errors.New("unknown key id")
+
+// ErrAlgorithmMismatch is returned when a JWT's header algorithm doesn't match the
+// algorithm its kid was registered under, which prevents an attacker from re-signing a
+// token under a weaker algorithm (alg-confusion) or "none"
+var ErrAlgorithmMismatch = errors.New("token algorithm does not match key algorithm")
+
+func signingMethodFor(alg KeyAlgorithm) (jwt.SigningMethod, error) {
+	switch alg {
+	case KeyAlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	case KeyAlgorithmES256:
+		return jwt.SigningMethodES256, nil
+	case KeyAlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key algorithm: %s", alg)
+	}
+}
+
+// SigningKey pairs a kid with its algorithm and key material. PrivateKey is nil for a
+// verify-only key (e.g. one rotated out but still within its grace period); PublicKey is
+// always required.
+type SigningKey struct {
+	KeyID      string
+	Algorithm  KeyAlgorithm
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	RetiredAt  *time.Time
+}
+
+// KeySet holds the asymmetric keys a SecurityService signs and verifies JWTs with, keyed by
+// kid. RotateSigningKey makes a new key active while keeping the previous one available for
+// verification until gracePeriod elapses.
+type KeySet struct {
+	mutex       sync.RWMutex
+	keys        map[string]*SigningKey
+	activeKeyID string
+	gracePeriod time.Duration
+}
+
+// NewKeySet creates an empty KeySet. Keys rotated out via RotateSigningKey remain usable for
+// verification for gracePeriod after being replaced.
+func NewKeySet(gracePeriod time.Duration) *KeySet {
+	return &KeySet{keys: make(map[string]*SigningKey), gracePeriod: gracePeriod}
+}
+
+// RotateSigningKey installs key as the active signing key, retiring the previous active key
+// (if any) for verification-only use. Retired keys older than gracePeriod are pruned.
+func (ks *KeySet) RotateSigningKey(key *SigningKey) {
+	ks.mutex.Lock()
+	defer ks.mutex.Unlock()
+
+	now := time.Now()
+	if previous, ok := ks.keys[ks.activeKeyID]; ok {
+		previous.RetiredAt = &now
+	}
+
+	ks.keys[key.KeyID] = key
+	ks.activeKeyID = key.KeyID
+
+	for id, k := range ks.keys {
+		if k.RetiredAt != nil && now.Sub(*k.RetiredAt) > ks.gracePeriod {
+			delete(ks.keys, id)
+		}
+	}
+}
+
+// active returns the current signing key, or an error if none has been set via
+// RotateSigningKey
+func (ks *KeySet) active() (*SigningKey, error) {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	key, ok := ks.keys[ks.activeKeyID]
+	if !ok {
+		return nil, errors.New("no active signing key")
+	}
+	return key, nil
+}
+
+// lookup returns the key registered under kid, whether or not it's still active
+func (ks *KeySet) lookup(kid string) (*SigningKey, error) {
+	ks.mutex.RLock()
+	defer ks.mutex.RUnlock()
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	return key, nil
+}
+
+// UseKeySet attaches ks to s, switching GenerateJWT/VerifyJWT to asymmetric signing. Passing
+// nil reverts to the HS256 shared-secret behavior.
+func (s *SecurityService) UseKeySet(ks *KeySet) {
+	s.keySet = ks
+}
+
+// GenerateSignedJWT signs claims with the KeySet's active key, setting the token's kid
+// header so a verifier can select the matching public key. It returns an error if s has no
+// KeySet configured; use GenerateJWT for the legacy HS256 path.
+func (s *SecurityService) GenerateSignedJWT(claims map[string]interface{}) (string, error) {
+	if claims == nil {
+		return "", ErrInvalidData
+	}
+	if s.keySet == nil {
+		return "", errors.New("no key set configured")
+	}
+
+	key, err := s.keySet.active()
+	if err != nil {
+		return "", err
+	}
+	if key.PrivateKey == nil {
+		return "", fmt.Errorf("signing key %q has no private key", key.KeyID)
+	}
+
+	method, err := signingMethodFor(key.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(method, jwt.MapClaims{})
+	token.Header["kid"] = key.KeyID
+
+	tokenClaims := token.Claims.(jwt.MapClaims)
+	for k, v := range claims {
+		tokenClaims[k] = v
+	}
+	tokenClaims["exp"] = time.Now().Add(s.tokenExpiry).Unix()
+
+	tokenString, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return tokenString, nil
+}
+
+// VerifySignedJWT verifies a token signed by GenerateSignedJWT, selecting the public key by
+// the token's kid header and rejecting it if the header algorithm doesn't match that key's
+// registered algorithm (preventing alg-confusion and "none" attacks).
+func (s *SecurityService) VerifySignedJWT(tokenString string) (map[string]interface{}, error) {
+	if tokenString == "" {
+		return nil, ErrInvalidToken
+	}
+	if s.keySet == nil {
+		return nil, errors.New("no key set configured")
+	}
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, errors.New("token missing kid header")
+		}
+
+		key, err := s.keySet.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != string(key.Algorithm) {
+			return nil, ErrAlgorithmMismatch
+		}
+
+		return key.PublicKey, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	if exp, ok := claims["exp"].(float64); ok {
+		if time.Now().Unix() > int64(exp) {
+			return nil, ErrTokenExpired
+		}
+	}
+
+	result := make(map[string]interface{})
+	for k, v := range claims {
+		result[k] = v
+	}
+	return result, nil
+}
+
+// jwk is a single entry in a JWKS document (RFC 7517), covering the RSA, EC, and OKP key
+// types this package issues
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type jwksDocument struct {
+	Keys []jwk `json:"keys"`
+}
+
+func base64URLUint(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+// toJWK converts key's public key material into its JWKS representation
+func toJWK(key *SigningKey) (jwk, error) {
+	switch pub := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return jwk{
+			Kty: "RSA",
+			Kid: key.KeyID,
+			Use: "sig",
+			Alg: string(key.Algorithm),
+			N:   base64URLUint(pub.N),
+			E:   base64URLUint(big.NewInt(int64(pub.E))),
+		}, nil
+	case *ecdsa.PublicKey:
+		return jwk{
+			Kty: "EC",
+			Kid: key.KeyID,
+			Use: "sig",
+			Alg: string(key.Algorithm),
+			Crv: pub.Curve.Params().Name,
+			X:   base64URLUint(pub.X),
+			Y:   base64URLUint(pub.Y),
+		}, nil
+	case ed25519.PublicKey:
+		return jwk{
+			Kty: "OKP",
+			Kid: key.KeyID,
+			Use: "sig",
+			Alg: string(key.Algorithm),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(pub),
+		}, nil
+	default:
+		return jwk{}, fmt.Errorf("unsupported public key type %T for kid %q", pub, key.KeyID)
+	}
+}
+
+// ServeJWKS publishes the KeySet's public keys (active and not-yet-expired retired keys) as
+// a JWKS JSON document, for mounting at a well-known path such as /.well-known/jwks.json
+func (s *SecurityService) ServeJWKS(w http.ResponseWriter, r *http.Request) {
+	if s.keySet == nil {
+		http.Error(w, "no key set configured", http.StatusNotFound)
+		return
+	}
+
+	s.keySet.mutex.RLock()
+	doc := jwksDocument{Keys: make([]jwk, 0, len(s.keySet.keys))}
+	for _, key := range s.keySet.keys {
+		k, err := toJWK(key)
+		if err != nil {
+			s.keySet.mutex.RUnlock()
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		doc.Keys = append(doc.Keys, k)
+	}
+	s.keySet.mutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(doc); err != nil {
+		http.Error(w, "failed to encode jwks", http.StatusInternalServerError)
+	}
+}
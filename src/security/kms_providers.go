@@ -0,0 +1,153 @@
+// In-tree KeyProvider implementations: a local-key provider (today's behavior) plus AWS KMS,
+// GCP Cloud KMS, and HashiCorp Vault Transit backends for operators who keep master keys in
+// a managed HSM/KMS instead of process configuration.
+
+package security
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	gcpkms "cloud.google.com/go/kms/apiv1"
+	gcpkmspb "cloud.google.com/go/kms/apiv1/kmspb"
+	awskms "github.com/aws/aws-sdk-go-v2/service/kms"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// LocalKeyProvider wraps DEKs with an in-process AES-256-GCM master key, reproducing the
+// single-shared-key behavior SecurityService used before envelope encryption. keyID passed
+// to Encrypt is ignored, since there's only ever one local master key.
+type LocalKeyProvider struct {
+	masterKey []byte
+}
+
+// NewLocalKeyProvider builds a LocalKeyProvider from a 32-byte AES-256 master key
+func NewLocalKeyProvider(masterKey []byte) (*LocalKeyProvider, error) {
+	if len(masterKey) != 32 {
+		return nil, ErrInvalidKey
+	}
+	return &LocalKeyProvider{masterKey: masterKey}, nil
+}
+
+func (p *LocalKeyProvider) Encrypt(_ string, plaintext []byte) ([]byte, error) {
+	return aesGCMSeal(p.masterKey, plaintext)
+}
+
+func (p *LocalKeyProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	return aesGCMOpen(p.masterKey, ciphertext)
+}
+
+// AWSKMSKeyProvider wraps DEKs with an AWS KMS master key. Decrypt doesn't need a keyID: the
+// KMS ciphertext blob embeds the key ARN that produced it.
+type AWSKMSKeyProvider struct {
+	client *awskms.Client
+}
+
+// NewAWSKMSKeyProvider builds an AWSKMSKeyProvider using client to call KMS
+func NewAWSKMSKeyProvider(client *awskms.Client) *AWSKMSKeyProvider {
+	return &AWSKMSKeyProvider{client: client}
+}
+
+func (p *AWSKMSKeyProvider) Encrypt(keyID string, plaintext []byte) ([]byte, error) {
+	out, err := p.client.Encrypt(context.Background(), &awskms.EncryptInput{
+		KeyId:     &keyID,
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("aws kms encrypt: %w", err)
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (p *AWSKMSKeyProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	out, err := p.client.Decrypt(context.Background(), &awskms.DecryptInput{CiphertextBlob: ciphertext})
+	if err != nil {
+		return nil, fmt.Errorf("aws kms decrypt: %w", err)
+	}
+	return out.Plaintext, nil
+}
+
+// GCPKMSKeyProvider wraps DEKs with a GCP Cloud KMS CryptoKey. Unlike AWS, GCP KMS's Decrypt
+// call requires the CryptoKey resource name, so a GCPKMSKeyProvider is bound to a single
+// resource (keyID passed to Encrypt is ignored); rotating to a different CryptoKey means
+// calling UseKMS with a new provider.
+type GCPKMSKeyProvider struct {
+	client        *gcpkms.KeyManagementClient
+	cryptoKeyName string
+}
+
+// NewGCPKMSKeyProvider builds a GCPKMSKeyProvider that encrypts/decrypts against
+// cryptoKeyName (e.g. "projects/p/locations/l/keyRings/r/cryptoKeys/k")
+func NewGCPKMSKeyProvider(client *gcpkms.KeyManagementClient, cryptoKeyName string) *GCPKMSKeyProvider {
+	return &GCPKMSKeyProvider{client: client, cryptoKeyName: cryptoKeyName}
+}
+
+func (p *GCPKMSKeyProvider) Encrypt(_ string, plaintext []byte) ([]byte, error) {
+	resp, err := p.client.Encrypt(context.Background(), &gcpkmspb.EncryptRequest{
+		Name:      p.cryptoKeyName,
+		Plaintext: plaintext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcp kms encrypt: %w", err)
+	}
+	return resp.Ciphertext, nil
+}
+
+func (p *GCPKMSKeyProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	resp, err := p.client.Decrypt(context.Background(), &gcpkmspb.DecryptRequest{
+		Name:       p.cryptoKeyName,
+		Ciphertext: ciphertext,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("gcp kms decrypt: %w", err)
+	}
+	return resp.Plaintext, nil
+}
+
+// VaultTransitKeyProvider wraps DEKs with a HashiCorp Vault Transit key. Like GCP, Vault's
+// decrypt path is addressed by key name rather than embedding it standalone in the
+// ciphertext, so a VaultTransitKeyProvider is bound to a single transit key name.
+type VaultTransitKeyProvider struct {
+	client    *vaultapi.Client
+	mountPath string
+	keyName   string
+}
+
+// NewVaultTransitKeyProvider builds a VaultTransitKeyProvider against the Transit secrets
+// engine mounted at mountPath (typically "transit"), using keyName as the transit key
+func NewVaultTransitKeyProvider(client *vaultapi.Client, mountPath, keyName string) *VaultTransitKeyProvider {
+	return &VaultTransitKeyProvider{client: client, mountPath: mountPath, keyName: keyName}
+}
+
+func (p *VaultTransitKeyProvider) Encrypt(_ string, plaintext []byte) ([]byte, error) {
+	secret, err := p.client.Logical().Write(fmt.Sprintf("%s/encrypt/%s", p.mountPath, p.keyName), map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString(plaintext),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vault transit encrypt: %w", err)
+	}
+	ciphertext, ok := secret.Data["ciphertext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault transit encrypt: missing ciphertext in response")
+	}
+	return []byte(ciphertext), nil
+}
+
+func (p *VaultTransitKeyProvider) Decrypt(ciphertext []byte) ([]byte, error) {
+	secret, err := p.client.Logical().Write(fmt.Sprintf("%s/decrypt/%s", p.mountPath, p.keyName), map[string]interface{}{
+		"ciphertext": string(ciphertext),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("vault transit decrypt: %w", err)
+	}
+	encoded, ok := secret.Data["plaintext"].(string)
+	if !ok {
+		return nil, fmt.Errorf("vault transit decrypt: missing plaintext in response")
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("vault transit decrypt: failed to decode plaintext: %w", err)
+	}
+	return plaintext, nil
+}
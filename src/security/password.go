@@ -0,0 +1,192 @@
+// Argon2id password hashing with transparent rehash-on-login
+// bcrypt.DefaultCost is weak against 2024-era GPU attacks and gives operators no way to tune
+// cost without a code change. PasswordHasher abstracts over bcrypt (kept for verifying
+// existing stored hashes) and a new Argon2idHasher; VerifyPassword detects which produced a
+// given hash and signals needsRehash so a caller can transparently upgrade it on next login.
+
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordHasher hashes and verifies passwords under a single algorithm and parameter set
+type PasswordHasher interface {
+	// Hash produces a new stored hash for password
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash
+	Verify(password, hash string) (bool, error)
+	// Supports reports whether hash's encoding (its PHC string prefix or bcrypt cost
+	// marker) was produced by this hasher
+	Supports(hash string) bool
+	// NeedsRehash reports whether hash - which Supports already returned true for - was
+	// produced with parameters weaker than this hasher's current target
+	NeedsRehash(hash string) bool
+}
+
+// Argon2Params tunes Argon2idHasher's cost. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Time        uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params returns OWASP's baseline recommendation: 64 MiB memory, 3 iterations,
+// 2-way parallelism, a 16-byte salt, and a 32-byte tag
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{Memory: 64 * 1024, Time: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32}
+}
+
+// Argon2idHasher hashes passwords with Argon2id, encoding the hash as the PHC string
+// "$argon2id$v=19$m=<mem>,t=<time>,p=<par>$<salt>$<hash>"
+type Argon2idHasher struct {
+	params Argon2Params
+}
+
+// NewArgon2idHasher builds an Argon2idHasher targeting params for new hashes
+func NewArgon2idHasher(params Argon2Params) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	if password == "" {
+		return "", ErrInvalidData
+	}
+
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Time, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.params.Memory, h.params.Time, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Verify(password, hash string) (bool, error) {
+	params, salt, key, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1, nil
+}
+
+func (h *Argon2idHasher) Supports(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, _, _, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+	return params.Memory < h.params.Memory || params.Time < h.params.Time || params.Parallelism < h.params.Parallelism
+}
+
+// parseArgon2idHash decodes an Argon2idHasher-produced PHC string into its parameters, salt,
+// and derived key
+func parseArgon2idHash(hash string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+	// parts[0] is empty (hash starts with "$"); parts[1]="argon2id", [2]="v=..", [3]="m=..,t=..,p=..", [4]=salt, [5]=key
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2Params{}, nil, nil, fmt.Errorf("malformed argon2id hash")
+	}
+
+	var params Argon2Params
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("malformed argon2id version: %w", err)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Parallelism); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("malformed argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("malformed argon2id key: %w", err)
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
+
+// BcryptHasher hashes passwords with bcrypt. It's kept only to verify hashes stored before a
+// SecurityService adopted Argon2idHasher as its primary PasswordHasher; NeedsRehash always
+// reports true, since any bcrypt hash should be migrated to Argon2id on next successful login.
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher builds a BcryptHasher at cost
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	if password == "" {
+		return "", ErrInvalidData
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+func (h *BcryptHasher) Verify(password, hash string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (h *BcryptHasher) Supports(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	return true
+}
+
+// SetPasswordHasher replaces s's primary PasswordHasher (used for HashPassword and for new
+// Argon2id verifications) and the set of legacy hashers VerifyPassword also recognizes for
+// verifying (and flagging for rehash) previously stored hashes.
+func (s *SecurityService) SetPasswordHasher(primary PasswordHasher, legacy ...PasswordHasher) {
+	s.passwordHasher = primary
+	s.legacyPasswordHashers = legacy
+}
+
+// hasherFor returns whichever configured PasswordHasher produced hash, or nil if none did
+func (s *SecurityService) hasherFor(hash string) PasswordHasher {
+	if s.passwordHasher != nil && s.passwordHasher.Supports(hash) {
+		return s.passwordHasher
+	}
+	for _, legacy := range s.legacyPasswordHashers {
+		if legacy.Supports(hash) {
+			return legacy
+		}
+	}
+	return nil
+}
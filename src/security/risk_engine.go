@@ -0,0 +1,253 @@
+// Risk-scored transaction assessment for the Asian Cryptocurrency Payment System
+// CheckTransaction rejects outright the moment any single rule trips, which can't express
+// step-up verification. RiskEngine instead combines weighted signals into a continuous
+// score and maps it to Allow/Challenge/Block, so the payments layer can route a
+// Challenge outcome to 2FA instead of a hard reject.
+
+package security
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RiskAction is the recommended disposition for a transaction after scoring
+type RiskAction string
+
+const (
+	RiskActionAllow     RiskAction = "allow"
+	RiskActionChallenge RiskAction = "challenge"
+	RiskActionBlock     RiskAction = "block"
+)
+
+// RiskAssessment is the outcome of scoring a transaction against a user's risk profile
+type RiskAssessment struct {
+	Score   float64
+	Reasons []string
+	Action  RiskAction
+}
+
+// RiskEngineConfig holds the signal weights and score thresholds for RiskEngine
+type RiskEngineConfig struct {
+	ChallengeThreshold float64 // score at or above this triggers RiskActionChallenge
+	BlockThreshold     float64 // score at or above this triggers RiskActionBlock
+
+	AmountZScoreWeight float64 // weight applied per standard deviation above a user's rolling mean amount
+	VelocityWeight     float64 // weight applied per unit of EWMA velocity over its configured limit
+	GeoVelocityWeight  float64 // weight applied when successive requests imply impossible travel
+	NewRecipientWeight float64 // weight applied when paying an address never seen from this user before
+	BlocklistHitWeight float64 // weight applied when the IP or country is on a blocklist
+
+	VelocityLimit1m  float64 // EWMA transaction count considered normal within the 1-minute window
+	VelocityLimit1h  float64 // EWMA transaction count considered normal within the 1-hour window
+	VelocityLimit24h float64 // EWMA transaction count considered normal within the 24-hour window
+
+	// GeoVelocityWindow bounds how soon a country change after the last request is
+	// treated as impossible travel rather than ordinary mobility
+	GeoVelocityWindow time.Duration
+}
+
+// DefaultRiskEngineConfig returns reasonable defaults for RiskEngineConfig
+func DefaultRiskEngineConfig() *RiskEngineConfig {
+	return &RiskEngineConfig{
+		ChallengeThreshold: 50,
+		BlockThreshold:     85,
+
+		AmountZScoreWeight: 12,
+		VelocityWeight:     10,
+		GeoVelocityWeight:  40,
+		NewRecipientWeight: 15,
+		BlocklistHitWeight: 100,
+
+		VelocityLimit1m:  2,
+		VelocityLimit1h:  10,
+		VelocityLimit24h: 30,
+
+		GeoVelocityWindow: time.Hour,
+	}
+}
+
+// userRiskProfile accumulates the online statistics RiskEngine scores a user against
+type userRiskProfile struct {
+	// amountMean/amountM2/amountCount implement Welford's online mean/variance
+	amountMean  float64
+	amountM2    float64
+	amountCount uint64
+
+	velocity1m  float64
+	velocity1h  float64
+	velocity24h float64
+	lastTxAt    time.Time
+
+	lastCountry   string
+	lastCountryAt time.Time
+
+	recipients map[string]bool
+}
+
+// RiskEngine scores transactions against a per-user risk profile built up over time,
+// in contrast to FraudDetectionService's fixed rules which only allow or deny
+type RiskEngine struct {
+	config *RiskEngineConfig
+	fraud  *FraudDetectionService
+
+	mutex    sync.Mutex
+	profiles map[string]*userRiskProfile
+}
+
+// NewRiskEngine creates a RiskEngine. fraud supplies IP/country blocklist checks; it may
+// be nil if blocklist signals aren't needed.
+func NewRiskEngine(config *RiskEngineConfig, fraud *FraudDetectionService) *RiskEngine {
+	if config == nil {
+		config = DefaultRiskEngineConfig()
+	}
+	return &RiskEngine{
+		config:   config,
+		fraud:    fraud,
+		profiles: make(map[string]*userRiskProfile),
+	}
+}
+
+// Assess scores a transaction for userID and returns the combined risk assessment,
+// updating the user's rolling profile with the new observation as it goes
+func (e *RiskEngine) Assess(userID, ipAddress, countryCode, recipientAddress string, amount float64) *RiskAssessment {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	profile, exists := e.profiles[userID]
+	if !exists {
+		profile = &userRiskProfile{recipients: make(map[string]bool)}
+		e.profiles[userID] = profile
+	}
+
+	now := time.Now()
+	assessment := &RiskAssessment{}
+
+	e.scoreAmount(profile, amount, assessment)
+	e.scoreVelocity(profile, now, assessment)
+	e.scoreGeoVelocity(profile, countryCode, now, assessment)
+	e.scoreNewRecipient(profile, recipientAddress, assessment)
+	e.scoreBlocklist(ipAddress, countryCode, assessment)
+
+	e.updateProfile(profile, amount, countryCode, recipientAddress, now)
+
+	switch {
+	case assessment.Score >= e.config.BlockThreshold:
+		assessment.Action = RiskActionBlock
+	case assessment.Score >= e.config.ChallengeThreshold:
+		assessment.Action = RiskActionChallenge
+	default:
+		assessment.Action = RiskActionAllow
+	}
+	return assessment
+}
+
+// scoreAmount adds a signal proportional to how many standard deviations amount falls
+// above the user's rolling mean, using Welford's algorithm's running statistics
+func (e *RiskEngine) scoreAmount(profile *userRiskProfile, amount float64, assessment *RiskAssessment) {
+	if profile.amountCount < 2 {
+		return
+	}
+	variance := profile.amountM2 / float64(profile.amountCount-1)
+	stddev := math.Sqrt(variance)
+	if stddev == 0 {
+		return
+	}
+
+	zScore := (amount - profile.amountMean) / stddev
+	if zScore > 1 {
+		assessment.Score += zScore * e.config.AmountZScoreWeight
+		assessment.Reasons = append(assessment.Reasons, "transaction amount is a statistical outlier for this user")
+	}
+}
+
+// scoreVelocity adds a signal for each EWMA velocity window that exceeds its configured limit
+func (e *RiskEngine) scoreVelocity(profile *userRiskProfile, now time.Time, assessment *RiskAssessment) {
+	v1m, v1h, v24h := decayedVelocity(profile, now)
+
+	over := 0.0
+	if v1m > e.config.VelocityLimit1m {
+		over += (v1m - e.config.VelocityLimit1m) / e.config.VelocityLimit1m
+	}
+	if v1h > e.config.VelocityLimit1h {
+		over += (v1h - e.config.VelocityLimit1h) / e.config.VelocityLimit1h
+	}
+	if v24h > e.config.VelocityLimit24h {
+		over += (v24h - e.config.VelocityLimit24h) / e.config.VelocityLimit24h
+	}
+	if over > 0 {
+		assessment.Score += over * e.config.VelocityWeight
+		assessment.Reasons = append(assessment.Reasons, "transaction velocity exceeds the user's normal pattern")
+	}
+}
+
+// scoreGeoVelocity flags impossible travel: a different country observed within
+// GeoVelocityWindow of the previous request is implausible without an explanation
+// such as a shared VPN exit, so it's treated as a strong signal rather than a hard block
+func (e *RiskEngine) scoreGeoVelocity(profile *userRiskProfile, countryCode string, now time.Time, assessment *RiskAssessment) {
+	if countryCode == "" || profile.lastCountry == "" || profile.lastCountry == countryCode {
+		return
+	}
+	if now.Sub(profile.lastCountryAt) < e.config.GeoVelocityWindow {
+		assessment.Score += e.config.GeoVelocityWeight
+		assessment.Reasons = append(assessment.Reasons, "impossible travel: country changed too quickly since the last request")
+	}
+}
+
+// scoreNewRecipient flags a recipient address this user has never paid before
+func (e *RiskEngine) scoreNewRecipient(profile *userRiskProfile, recipientAddress string, assessment *RiskAssessment) {
+	if recipientAddress == "" || profile.recipients[recipientAddress] {
+		return
+	}
+	assessment.Score += e.config.NewRecipientWeight
+	assessment.Reasons = append(assessment.Reasons, "first transaction to this recipient address")
+}
+
+// scoreBlocklist folds in the FraudDetectionService's IP and country blocklists, if configured
+func (e *RiskEngine) scoreBlocklist(ipAddress, countryCode string, assessment *RiskAssessment) {
+	if e.fraud == nil {
+		return
+	}
+	if e.fraud.IsIPBlocked(ipAddress) || e.fraud.IsCountryBlocked(countryCode) {
+		assessment.Score += e.config.BlocklistHitWeight
+		assessment.Reasons = append(assessment.Reasons, "IP address or country is on a blocklist")
+	}
+}
+
+// updateProfile folds the current observation into the user's rolling statistics
+func (e *RiskEngine) updateProfile(profile *userRiskProfile, amount float64, countryCode, recipientAddress string, now time.Time) {
+	profile.amountCount++
+	delta := amount - profile.amountMean
+	profile.amountMean += delta / float64(profile.amountCount)
+	delta2 := amount - profile.amountMean
+	profile.amountM2 += delta * delta2
+
+	v1m, v1h, v24h := decayedVelocity(profile, now)
+	profile.velocity1m = v1m + 1
+	profile.velocity1h = v1h + 1
+	profile.velocity24h = v24h + 1
+	profile.lastTxAt = now
+
+	if countryCode != "" {
+		profile.lastCountry = countryCode
+		profile.lastCountryAt = now
+	}
+	if recipientAddress != "" {
+		profile.recipients[recipientAddress] = true
+	}
+}
+
+// decayedVelocity returns the user's EWMA transaction counts for the 1-minute, 1-hour,
+// and 24-hour windows decayed forward to now, without mutating the profile
+func decayedVelocity(profile *userRiskProfile, now time.Time) (v1m, v1h, v24h float64) {
+	if profile.lastTxAt.IsZero() {
+		return 0, 0, 0
+	}
+	elapsed := now.Sub(profile.lastTxAt).Seconds()
+
+	v1m = profile.velocity1m * math.Exp(-elapsed/time.Minute.Seconds())
+	v1h = profile.velocity1h * math.Exp(-elapsed/time.Hour.Seconds())
+	v24h = profile.velocity24h * math.Exp(-elapsed/(24*time.Hour).Seconds())
+	return v1m, v1h, v24h
+}
@@ -6,6 +6,7 @@ package security
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
@@ -31,9 +32,15 @@ var (
 
 // SecurityService provides security-related functionality
 type SecurityService struct {
-	encryptionKey []byte
-	jwtSecret     []byte
-	tokenExpiry   time.Duration
+	encryptionKey  []byte
+	jwtSecret      []byte
+	tokenExpiry    time.Duration
+	keySet         *KeySet     // set via UseKeySet; enables GenerateSignedJWT/VerifySignedJWT/ServeJWKS
+	kms            KeyProvider // set via UseKMS; enables envelope encryption in EncryptData/DecryptData
+	activeKMSKeyID string
+
+	passwordHasher        PasswordHasher // used by HashPassword and as VerifyPassword's target for needsRehash
+	legacyPasswordHashers []PasswordHasher
 }
 
 // NewSecurityService creates a new security service
@@ -51,18 +58,26 @@ func NewSecurityService(encryptionKey, jwtSecret string, tokenExpiry time.Durati
 	jwtKey := sha256.Sum256([]byte(jwtSecret))
 
 	return &SecurityService{
-		encryptionKey: encKey[:],
-		jwtSecret:     jwtKey[:],
-		tokenExpiry:   tokenExpiry,
+		encryptionKey:         encKey[:],
+		jwtSecret:             jwtKey[:],
+		tokenExpiry:           tokenExpiry,
+		passwordHasher:        NewArgon2idHasher(DefaultArgon2Params()),
+		legacyPasswordHashers: []PasswordHasher{NewBcryptHasher(bcrypt.DefaultCost)},
 	}, nil
 }
 
-// EncryptData encrypts data using AES-256-GCM
+// EncryptData encrypts data using AES-256-GCM. When a KeyProvider has been configured via
+// UseKMS, it instead uses envelope encryption: a fresh per-message DEK encrypts the data,
+// and the KMS master key wraps the DEK (see encryptEnvelope).
 func (s *SecurityService) EncryptData(plaintext string) (string, error) {
 	if plaintext == "" {
 		return "", ErrInvalidData
 	}
 
+	if s.kms != nil {
+		return s.encryptEnvelope([]byte(plaintext))
+	}
+
 	// Create cipher block
 	block, err := aes.NewCipher(s.encryptionKey)
 	if err != nil {
@@ -90,12 +105,27 @@ func (s *SecurityService) EncryptData(plaintext string) (string, error) {
 	return encoded, nil
 }
 
-// DecryptData decrypts data using AES-256-GCM
+// DecryptData decrypts data using AES-256-GCM. When a KeyProvider has been configured via
+// UseKMS, it instead unwraps the envelope EncryptData produced (see decryptEnvelope),
+// resolving the correct KMS master key from the ciphertext's embedded header even if it was
+// encrypted under a since-rotated key. If ciphertext isn't envelope-framed at all, it falls
+// back to the legacy raw AES-256-GCM format below, so data encrypted before UseKMS was ever
+// called stays decryptable after KMS is enabled.
 func (s *SecurityService) DecryptData(ciphertext string) (string, error) {
 	if ciphertext == "" {
 		return "", ErrInvalidData
 	}
 
+	if s.kms != nil {
+		plaintext, err := s.decryptEnvelope(ciphertext)
+		if err == nil {
+			return plaintext, nil
+		}
+		if !errors.Is(err, errNotEnvelopeFramed) {
+			return "", err
+		}
+	}
+
 	// Decode from base64
 	decoded, err := base64.StdEncoding.DecodeString(ciphertext)
 	if err != nil {
@@ -131,30 +161,35 @@ func (s *SecurityService) DecryptData(ciphertext string) (string, error) {
 	return string(plaintextBytes), nil
 }
 
-// HashPassword hashes a password using bcrypt
+// HashPassword hashes a password with s's configured PasswordHasher (Argon2id by default)
 func (s *SecurityService) HashPassword(password string) (string, error) {
-	if password == "" {
-		return "", ErrInvalidData
-	}
+	return s.passwordHasher.Hash(password)
+}
 
-	// Hash password
-	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", fmt.Errorf("failed to hash password: %w", err)
+// VerifyPassword verifies a password against hash, detecting which configured PasswordHasher
+// (primary or legacy) produced it from its prefix. needsRehash reports whether the caller
+// should re-hash password with HashPassword and store the result on this successful login:
+// always true for a legacy hasher's hash, and true for the primary hasher's own hash if its
+// embedded parameters are weaker than the hasher's current target.
+func (s *SecurityService) VerifyPassword(password, hash string) (ok bool, needsRehash bool) {
+	if password == "" || hash == "" {
+		return false, false
 	}
 
-	return string(hashedBytes), nil
-}
+	hasher := s.hasherFor(hash)
+	if hasher == nil {
+		return false, false
+	}
 
-// VerifyPassword verifies a password against a hash
-func (s *SecurityService) VerifyPassword(password, hash string) bool {
-	if password == "" || hash == "" {
-		return false
+	valid, err := hasher.Verify(password, hash)
+	if err != nil || !valid {
+		return false, false
 	}
 
-	// Verify password
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+	if hasher != s.passwordHasher {
+		return true, true
+	}
+	return true, hasher.NeedsRehash(hash)
 }
 
 // GenerateAPIKey generates a new API key
@@ -268,35 +303,59 @@ func (s *SecurityService) VerifyJWT(tokenString string) (map[string]interface{},
 	return result, nil
 }
 
-// GenerateSignature generates an HMAC signature for data
+// GenerateSignature generates an HMAC signature for data, keyed with s.jwtSecret
 func (s *SecurityService) GenerateSignature(data string) (string, error) {
+	return generateSignatureWithKey(data, s.jwtSecret)
+}
+
+// generateSignatureWithKey computes the hex-encoded HMAC-SHA256 of data under key
+func generateSignatureWithKey(data string, key []byte) (string, error) {
 	if data == "" {
 		return "", ErrInvalidData
 	}
 
-	// Create HMAC
-	h := hmac.New(sha256.New, s.jwtSecret)
+	h := hmac.New(sha256.New, key)
 	h.Write([]byte(data))
-
-	// Get signature
 	signature := h.Sum(nil)
 
-	// Encode to hex
 	return hex.EncodeToString(signature), nil
 }
 
-// VerifySignature verifies an HMAC signature for data
+// VerifySignature verifies an HMAC signature for data, keyed with s.jwtSecret
 func (s *SecurityService) VerifySignature(data, signature string) bool {
+	return s.VerifySignatureWithKey(data, signature, s.jwtSecret)
+}
+
+// VerifySignatureWithKey verifies data's HMAC-SHA256 signature under an arbitrary key, rather
+// than s.jwtSecret. VerifySignature is the common case, built on top of it with key fixed to
+// s.jwtSecret; call this directly when the signing key isn't s.jwtSecret.
+//
+// Both signature and the freshly computed HMAC are hex-decoded and compared with
+// hmac.Equal, rather than comparing their hex encodings directly: comparing hex strings lets
+// a mismatched length short-circuit the comparison before hmac.Equal's constant-time logic
+// ever runs. A malformed signature still costs a full HMAC compute before returning false,
+// so hex-decode failures don't reveal validity any faster than a decodable-but-wrong one.
+func (s *SecurityService) VerifySignatureWithKey(data, signature string, key []byte) bool {
 	if data == "" || signature == "" {
 		return false
 	}
 
-	// Generate expected signature
-	expectedSignature, err := s.GenerateSignature(data)
+	expected, err := generateSignatureWithKey(data, key)
 	if err != nil {
 		return false
 	}
+	expectedBytes, expectedErr := hex.DecodeString(expected)
+
+	providedBytes, providedErr := hex.DecodeString(signature)
+	if providedErr != nil {
+		// Run the comparison anyway, against a scratch buffer of the same length as
+		// expectedBytes, so a decode failure takes the same time as a failed match
+		// instead of returning early.
+		providedBytes = make([]byte, len(expectedBytes))
+	}
 
-	// Compare signatures
-	return hmac.Equal([]byte(signature), []byte(expectedSignature))
+	if expectedErr != nil {
+		return false
+	}
+	return hmac.Equal(providedBytes, expectedBytes) && providedErr == nil
 }
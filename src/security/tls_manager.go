@@ -0,0 +1,241 @@
+// Automatic TLS certificate issuance and renewal via ACME/Let's Encrypt
+// Operators deploying merchant-facing endpoints across many regional domains need per-domain
+// certificates without manual issuance. TLSManager wraps autocert.Manager for that, and adds
+// OCSP stapling (which autocert doesn't do) with a rate-limit-aware retry policy for fetching
+// staples from the issuing CA's OCSP responder.
+
+package security
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/crypto/ocsp"
+)
+
+// TLSCache persists issued certificates and ACME account state between renewals. It's an
+// alias for autocert.Cache so a caller can supply autocert.DirCache (filesystem), a Redis- or
+// KMS-backed implementation, or any other autocert.Cache without importing autocert directly.
+type TLSCache = autocert.Cache
+
+// TLSManagerConfig configures a TLSManager
+type TLSManagerConfig struct {
+	// Hostnames lists the domains TLSManager is willing to obtain certificates for. A
+	// ClientHello for any other name is rejected before an ACME request is ever made.
+	Hostnames []string
+	// Cache persists certificates and ACME account state between process restarts
+	Cache TLSCache
+	// Email is passed to the ACME CA for expiry/rate-limit notifications
+	Email string
+	// RetryPolicy controls backoff between failed OCSP staple fetches. Defaults to
+	// defaultRenewalRetryPolicy() when zero.
+	RetryPolicy RenewalRetryPolicy
+}
+
+// TLSManager obtains and renews TLS certificates via ACME, staples OCSP responses onto them,
+// and exposes the HTTP-01 challenge handler ACME issuance needs.
+type TLSManager struct {
+	manager     *autocert.Manager
+	retryPolicy RenewalRetryPolicy
+
+	mutex     sync.Mutex
+	ocspCache map[string]ocspCacheEntry // keyed by leaf certificate serial number
+}
+
+// ocspRefreshMargin is how long before a cached OCSP staple's NextUpdate TLSManager
+// refetches it, so a slow CA response doesn't leave a handshake stapling an expired response
+const ocspRefreshMargin = 1 * time.Hour
+
+type ocspCacheEntry struct {
+	raw        []byte
+	nextUpdate time.Time
+}
+
+// NewTLSManager builds a TLSManager for cfg.Hostnames. cfg.Cache should persist across
+// restarts in production; an in-memory autocert.Cache will re-issue certificates every
+// restart and risks hitting the ACME CA's rate limits.
+func NewTLSManager(cfg TLSManagerConfig) (*TLSManager, error) {
+	if len(cfg.Hostnames) == 0 {
+		return nil, errors.New("at least one hostname is required")
+	}
+
+	retryPolicy := cfg.RetryPolicy
+	if retryPolicy.isZero() {
+		retryPolicy = defaultRenewalRetryPolicy()
+	}
+
+	return &TLSManager{
+		manager: &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Hostnames...),
+			Cache:      cfg.Cache,
+			Email:      cfg.Email,
+		},
+		retryPolicy: retryPolicy,
+		ocspCache:   make(map[string]ocspCacheEntry),
+	}, nil
+}
+
+// GetCertificate obtains (issuing or renewing via ACME as needed) the certificate for
+// hello.ServerName and staples its OCSP response, for use as http.Server.TLSConfig's
+// GetCertificate callback. A stapling failure doesn't fail the handshake - the certificate
+// is still returned without a staple.
+func (t *TLSManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert, err := t.manager.GetCertificate(hello)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = t.attachOCSPStaple(cert) // best-effort: an unstapled certificate still completes the handshake
+	return cert, nil
+}
+
+// HTTPHandler returns the HTTP-01 challenge handler, for mounting on port 80. Requests that
+// aren't ACME challenges are passed to fallback (nil redirects them to HTTPS).
+func (t *TLSManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return t.manager.HTTPHandler(fallback)
+}
+
+// TLSConfig returns a *tls.Config wired to GetCertificate, suitable for http.Server.TLSConfig
+func (t *TLSManager) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: t.GetCertificate}
+}
+
+// attachOCSPStaple fetches (or reuses a cached, still-fresh) OCSP response for cert's leaf
+// and attaches it as cert.OCSPStaple
+func (t *TLSManager) attachOCSPStaple(cert *tls.Certificate) error {
+	if cert.Leaf == nil {
+		leaf, err := x509.ParseCertificate(cert.Certificate[0])
+		if err != nil {
+			return fmt.Errorf("failed to parse leaf certificate: %w", err)
+		}
+		cert.Leaf = leaf
+	}
+	if len(cert.Leaf.OCSPServer) == 0 {
+		return errors.New("certificate has no OCSP responder URL")
+	}
+	if len(cert.Certificate) < 2 {
+		return errors.New("certificate chain has no issuer to build an OCSP request from")
+	}
+
+	cacheKey := cert.Leaf.SerialNumber.String()
+
+	t.mutex.Lock()
+	entry, ok := t.ocspCache[cacheKey]
+	t.mutex.Unlock()
+	if ok && time.Now().Before(entry.nextUpdate.Add(-ocspRefreshMargin)) {
+		cert.OCSPStaple = entry.raw
+		return nil
+	}
+
+	issuer, err := x509.ParseCertificate(cert.Certificate[1])
+	if err != nil {
+		return fmt.Errorf("failed to parse issuer certificate: %w", err)
+	}
+
+	raw, nextUpdate, err := t.fetchOCSPResponseWithRetry(cert.Leaf, issuer, cert.Leaf.OCSPServer[0])
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.ocspCache[cacheKey] = ocspCacheEntry{raw: raw, nextUpdate: nextUpdate}
+	t.mutex.Unlock()
+
+	cert.OCSPStaple = raw
+	return nil
+}
+
+// fetchOCSPResponseWithRetry calls fetchOCSPResponse, retrying transient failures under
+// t.retryPolicy and honoring the CA's Retry-After when it reports a rate limit
+func (t *TLSManager) fetchOCSPResponseWithRetry(leaf, issuer *x509.Certificate, responderURL string) ([]byte, time.Time, error) {
+	var lastErr error
+	for attempt := 0; attempt < t.retryPolicy.maxAttempts(); attempt++ {
+		raw, nextUpdate, err := fetchOCSPResponse(leaf, issuer, responderURL)
+		if err == nil {
+			return raw, nextUpdate, nil
+		}
+		lastErr = err
+		time.Sleep(t.retryPolicy.nextDelay(attempt, err))
+	}
+	return nil, time.Time{}, fmt.Errorf("failed to fetch OCSP response after %d attempts: %w", t.retryPolicy.maxAttempts(), lastErr)
+}
+
+// fetchOCSPResponse makes a single OCSP request for leaf against responderURL, returning the
+// raw DER response (to staple as-is) and its NextUpdate time
+func fetchOCSPResponse(leaf, issuer *x509.Certificate, responderURL string) ([]byte, time.Time, error) {
+	reqBytes, err := ocsp.CreateRequest(leaf, issuer, nil)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to create OCSP request: %w", err)
+	}
+
+	resp, err := http.Post(responderURL, "application/ocsp-request", bytes.NewReader(reqBytes))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("OCSP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to read OCSP response: %w", err)
+	}
+
+	parsed, err := ocsp.ParseResponse(body, issuer)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to parse OCSP response: %w", err)
+	}
+	if parsed.Status != ocsp.Good {
+		return nil, time.Time{}, fmt.Errorf("OCSP responder reports certificate status %d", parsed.Status)
+	}
+
+	return body, parsed.NextUpdate, nil
+}
+
+// RenewalRetryPolicy controls backoff between failed certificate/OCSP renewal attempts, so a
+// burst of renewal attempts doesn't get TLSManager rate-limited by the ACME CA
+type RenewalRetryPolicy struct {
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	MaxAttempts int
+}
+
+func (p RenewalRetryPolicy) isZero() bool {
+	return p.BaseDelay == 0 && p.MaxDelay == 0 && p.MaxAttempts == 0
+}
+
+func (p RenewalRetryPolicy) maxAttempts() int {
+	if p.MaxAttempts <= 0 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// nextDelay returns how long to wait before retrying after err on the given (zero-based)
+// attempt. A rate-limit error's Retry-After is honored verbatim; otherwise delay doubles each
+// attempt up to MaxDelay.
+func (p RenewalRetryPolicy) nextDelay(attempt int, err error) time.Duration {
+	var rateLimit acme.RateLimit
+	if errors.As(err, &rateLimit) && rateLimit.RetryAfter > 0 {
+		return rateLimit.RetryAfter
+	}
+
+	delay := p.BaseDelay << uint(attempt)
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// defaultRenewalRetryPolicy is used when a TLSManagerConfig doesn't set RetryPolicy
+func defaultRenewalRetryPolicy() RenewalRetryPolicy {
+	return RenewalRetryPolicy{BaseDelay: 5 * time.Second, MaxDelay: 10 * time.Minute, MaxAttempts: 6}
+}